@@ -0,0 +1,298 @@
+package seata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrAbortQueued is joined to the error of an abort that failed and will be
+// retried in the background (see AbortEscalationConfig)
+var ErrAbortQueued = errors.New("abort queued for background retry")
+
+// abortStatePrefix marks pending aborts among the workflows of a WorkflowStore
+const abortStatePrefix = "abort/"
+
+// AbortEscalationConfig keeps retrying aborts that failed, e.g. while the TC
+// is unreachable, and escalates the ones that keep failing. Only transport
+// errors and server errors are retried; an abort answered with 404 or 409
+// (unknown or already aborted) is done, and one refused otherwise is
+// escalated and given up.
+type AbortEscalationConfig struct {
+	// Store persists pending aborts so a restarted client resumes them; nil
+	// keeps them in memory only
+	Store WorkflowStore
+	// RetryInterval between background attempts; zero means 5s
+	RetryInterval time.Duration
+	// EscalateAfter is the number of failed attempts after which
+	// OnAbortEscalation is called; zero means 5
+	EscalateAfter int
+	// OnAbortEscalation is called once per abort, e.g. to page someone;
+	// retrying continues afterwards
+	OnAbortEscalation func(PendingAbort)
+}
+
+// PendingAbort is an abort that has not succeeded yet
+type PendingAbort struct {
+	GID           string
+	Mode          string
+	SkipBranchIDs []string
+	// Cancel marks a failed CancelSaga, retried as a cancellation
+	Cancel    bool
+	Attempts  int
+	LastError error
+	Since     time.Time
+}
+
+// abortEscalator retries pending aborts in the background
+type abortEscalator struct {
+	client *Client
+	config AbortEscalationConfig
+	stop   chan struct{}
+	done   chan struct{}
+
+	mu      sync.Mutex
+	pending map[string]*pendingAbort
+}
+
+type pendingAbort struct {
+	PendingAbort
+	escalated bool
+}
+
+func newAbortEscalator(client *Client, config *AbortEscalationConfig) *abortEscalator {
+	copied := *config
+	if copied.RetryInterval <= 0 {
+		copied.RetryInterval = 5 * time.Second
+	}
+	if copied.EscalateAfter <= 0 {
+		copied.EscalateAfter = 5
+	}
+	return &abortEscalator{
+		client:  client,
+		config:  copied,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		pending: make(map[string]*pendingAbort),
+	}
+}
+
+// PendingAborts returns the aborts being retried in the background
+func (c *Client) PendingAborts() []PendingAbort {
+	if c.abortEscalator == nil {
+		return nil
+	}
+	e := c.abortEscalator
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	aborts := make([]PendingAbort, 0, len(e.pending))
+	for _, p := range e.pending {
+		aborts = append(aborts, p.PendingAbort)
+	}
+	return aborts
+}
+
+// enqueue records a failed abort of tx and persists it
+func (e *abortEscalator) enqueue(ctx context.Context, tx *Transaction, skipBranchIDs []string, cancel bool, err error) {
+	e.mu.Lock()
+	p, ok := e.pending[tx.gid]
+	if !ok {
+		p = &pendingAbort{PendingAbort: PendingAbort{GID: tx.gid, Mode: tx.mode, SkipBranchIDs: skipBranchIDs, Cancel: cancel, Since: time.Now()}}
+		e.pending[tx.gid] = p
+	}
+	e.mu.Unlock()
+
+	if !ok {
+		e.save(ctx, p.PendingAbort, WorkflowStatusPending)
+	}
+	e.failed(p, err)
+}
+
+// failed counts a failed attempt and escalates once the limit is reached
+func (e *abortEscalator) failed(p *pendingAbort, err error) {
+	e.mu.Lock()
+	p.Attempts++
+	p.LastError = err
+	escalate := !p.escalated && p.Attempts >= e.config.EscalateAfter
+	if escalate {
+		p.escalated = true
+	}
+	snapshot := p.PendingAbort
+	e.mu.Unlock()
+
+	if escalate {
+		fmt.Printf("Warning: abort of transaction %s failed %d times: %v\n", snapshot.GID, snapshot.Attempts, err)
+		if e.config.OnAbortEscalation != nil {
+			e.config.OnAbortEscalation(snapshot)
+		}
+	}
+}
+
+func (e *abortEscalator) run() {
+	defer close(e.done)
+	e.resume(context.Background())
+	ticker := time.NewTicker(e.config.RetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			e.retry(context.Background())
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+func (e *abortEscalator) close() {
+	close(e.stop)
+	<-e.done
+}
+
+// resume loads aborts persisted by an earlier run of the client
+func (e *abortEscalator) resume(ctx context.Context) {
+	if e.config.Store == nil {
+		return
+	}
+	states, err := e.config.Store.ListPending(ctx)
+	if err != nil {
+		fmt.Printf("Warning: failed to load pending aborts: %v\n", err)
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, state := range states {
+		if !strings.HasPrefix(state.ID, abortStatePrefix) || e.pending[state.GID] != nil {
+			continue
+		}
+		var saved savedAbort
+		if len(state.Payload) > 0 && state.Payload[0] == '[' {
+			// Saved before cancellations were retried: the skipped branches
+			_ = json.Unmarshal(state.Payload, &saved.SkipBranchIDs)
+		} else if len(state.Payload) > 0 {
+			_ = json.Unmarshal(state.Payload, &saved)
+		}
+		e.pending[state.GID] = &pendingAbort{PendingAbort: PendingAbort{
+			GID:           state.GID,
+			Mode:          state.Mode,
+			SkipBranchIDs: saved.SkipBranchIDs,
+			Cancel:        saved.Cancel,
+			Since:         time.Unix(state.CreatedUnix, 0),
+		}}
+	}
+}
+
+// retry attempts every pending abort once
+func (e *abortEscalator) retry(ctx context.Context) {
+	e.mu.Lock()
+	due := make([]*pendingAbort, 0, len(e.pending))
+	for _, p := range e.pending {
+		due = append(due, p)
+	}
+	e.mu.Unlock()
+
+	for _, p := range due {
+		tx := &Transaction{client: e.client.ForGID(p.GID), gid: p.GID, mode: p.Mode}
+		retry := func() error { return tx.abort(ctx, p.SkipBranchIDs) }
+		if p.Cancel {
+			retry = func() error { return tx.cancelSaga(ctx) }
+		}
+		err := retry()
+		switch {
+		case err == nil || abortSettled(err):
+			e.remove(ctx, p, WorkflowStatusCompleted)
+		case abortRetryable(err):
+			e.failed(p, err)
+		default:
+			// The TC refused the abort; retrying cannot change its answer
+			e.remove(ctx, p, WorkflowStatusFailed)
+			e.giveUp(p, err)
+		}
+	}
+}
+
+// remove drops a pending abort, persisting its final status
+func (e *abortEscalator) remove(ctx context.Context, p *pendingAbort, status string) {
+	e.mu.Lock()
+	delete(e.pending, p.GID)
+	e.mu.Unlock()
+	e.save(ctx, p.PendingAbort, status)
+}
+
+// giveUp escalates an abort the TC refused, unless it was escalated already
+func (e *abortEscalator) giveUp(p *pendingAbort, err error) {
+	e.mu.Lock()
+	p.Attempts++
+	p.LastError = err
+	escalate := !p.escalated
+	p.escalated = true
+	snapshot := p.PendingAbort
+	e.mu.Unlock()
+
+	fmt.Printf("Warning: abort of transaction %s refused, giving up: %v\n", snapshot.GID, err)
+	if escalate && e.config.OnAbortEscalation != nil {
+		e.config.OnAbortEscalation(snapshot)
+	}
+}
+
+// abortRetryable reports whether a failed abort may succeed later: the TC
+// could not be reached or failed with a server error
+func abortRetryable(err error) bool {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.StatusCode >= 500
+	}
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown, codes.Aborted:
+			return true
+		}
+		return false
+	}
+	return !errors.Is(err, ErrUnauthorized) && !errors.Is(err, ErrTransportDisabled)
+}
+
+// abortSettled reports whether the TC answered a failed abort with the
+// transaction already aborted or unknown, which leaves nothing to abort
+func abortSettled(err error) bool {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.StatusCode == 404 || reqErr.StatusCode == 409
+	}
+	if s, ok := status.FromError(err); ok {
+		return s.Code() == codes.NotFound || s.Code() == codes.AlreadyExists
+	}
+	return false
+}
+
+// savedAbort is the payload of a persisted pending abort
+type savedAbort struct {
+	SkipBranchIDs []string `json:"skip_branch_ids,omitempty"`
+	Cancel        bool     `json:"cancel,omitempty"`
+}
+
+// save persists a pending abort with status
+func (e *abortEscalator) save(ctx context.Context, p PendingAbort, status string) {
+	if e.config.Store == nil {
+		return
+	}
+	payload, _ := json.Marshal(savedAbort{SkipBranchIDs: p.SkipBranchIDs, Cancel: p.Cancel})
+	state := &WorkflowState{
+		ID:          abortStatePrefix + p.GID,
+		Mode:        p.Mode,
+		GID:         p.GID,
+		Status:      status,
+		Payload:     payload,
+		CreatedUnix: p.Since.Unix(),
+		UpdatedUnix: time.Now().Unix(),
+	}
+	if err := e.config.Store.Save(ctx, state); err != nil {
+		fmt.Printf("Warning: failed to persist pending abort of %s: %v\n", p.GID, err)
+	}
+}
@@ -0,0 +1,121 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Cluster node roles reported by the TC admin API
+const (
+	NodeRoleLeader   = "leader"
+	NodeRoleFollower = "follower"
+	NodeRoleLearner  = "learner"
+)
+
+// AdminNode is a TC cluster member
+type AdminNode struct {
+	ID                string `json:"id"`
+	Address           string `json:"address"`
+	Role              string `json:"role"`
+	State             string `json:"state"`
+	Maintenance       bool   `json:"maintenance"`
+	LastHeartbeatUnix int64  `json:"last_heartbeat_unix"`
+}
+
+// AdminNodeStatus is the detailed status of a TC node
+type AdminNodeStatus struct {
+	AdminNode
+	Term         uint64 `json:"term"`
+	CommitIndex  uint64 `json:"commit_index"`
+	AppliedIndex uint64 `json:"applied_index"`
+	StorageBytes int64  `json:"storage_bytes"`
+	ActiveTx     int64  `json:"active_transactions"`
+	Version      string `json:"version"`
+}
+
+// AdminClient performs cluster-level operations against the TC admin endpoints.
+// It shares the HTTP client, endpoint selection and error handling of Client.
+type AdminClient struct {
+	client *Client
+}
+
+// NewAdminClient creates an admin client on top of client
+func NewAdminClient(client *Client) *AdminClient {
+	return &AdminClient{client: client}
+}
+
+// ListNodes returns all members of the TC cluster
+func (ac *AdminClient) ListNodes(ctx context.Context) ([]*AdminNode, error) {
+	var nodes []*AdminNode
+	if err := ac.do(ctx, "GET", "/admin/nodes", nil, &nodes, "failed to list nodes"); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// NodeStatus returns the detailed status of one node
+func (ac *AdminClient) NodeStatus(ctx context.Context, nodeID string) (*AdminNodeStatus, error) {
+	if nodeID == "" {
+		return nil, fmt.Errorf("node ID cannot be empty")
+	}
+	var status AdminNodeStatus
+	if err := ac.do(ctx, "GET", "/admin/nodes/"+url.PathEscape(nodeID), nil, &status, "failed to get node status"); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// TransferLeader asks the current leader to hand leadership to nodeID
+func (ac *AdminClient) TransferLeader(ctx context.Context, nodeID string) error {
+	if nodeID == "" {
+		return fmt.Errorf("node ID cannot be empty")
+	}
+	body := map[string]interface{}{"target": nodeID}
+	return ac.do(ctx, "POST", "/admin/leader/transfer", body, nil, "failed to transfer leader")
+}
+
+// FlushStorage forces buffered writes on every node to durable storage
+func (ac *AdminClient) FlushStorage(ctx context.Context) error {
+	return ac.do(ctx, "POST", "/admin/storage/flush", nil, nil, "failed to flush storage")
+}
+
+// CompactStorage compacts the TC storage, reclaiming space of finished transactions
+func (ac *AdminClient) CompactStorage(ctx context.Context) error {
+	return ac.do(ctx, "POST", "/admin/storage/compact", nil, nil, "failed to compact storage")
+}
+
+// SetMaintenance toggles maintenance mode on a node. A node in maintenance
+// rejects new transactions but keeps driving existing ones to completion.
+func (ac *AdminClient) SetMaintenance(ctx context.Context, nodeID string, enabled bool) error {
+	if nodeID == "" {
+		return fmt.Errorf("node ID cannot be empty")
+	}
+	body := map[string]interface{}{"enabled": enabled}
+	path := "/admin/nodes/" + url.PathEscape(nodeID) + "/maintenance"
+	return ac.do(ctx, "PUT", path, body, nil, "failed to set maintenance mode")
+}
+
+// do sends an admin request and decodes the JSON response into out, if non-nil
+func (ac *AdminClient) do(ctx context.Context, method, path string, body, out interface{}, msg string) error {
+	httpBase, _ := ac.client.currentTargets()
+	req := ac.client.httpClient.R().SetContext(ctx)
+	if body != nil {
+		req.SetHeader("Content-Type", "application/json").SetBody(body)
+	}
+
+	resp, err := req.Execute(method, joinURL(httpBase, path))
+	if err != nil {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	if resp.StatusCode() != 200 {
+		return ac.client.statusError(msg, resp)
+	}
+
+	if out != nil {
+		if err := decodeJSON(resp.Body(), out); err != nil {
+			return fmt.Errorf("%s: failed to parse response: %w", msg, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package seata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ErrArchivedTransactionNotFound is returned for a gid missing from the archive
+var ErrArchivedTransactionNotFound = errors.New("archived transaction not found")
+
+// ArchiveStore reads transactions evicted from the TC's hot storage, e.g. an
+// export to S3 or GCS. Set Config.ArchiveStore to read it instead of the TC's
+// archive API.
+type ArchiveStore interface {
+	// Get returns an archived transaction or ErrArchivedTransactionNotFound
+	Get(ctx context.Context, gid string) (*TransactionInfo, error)
+	// List returns archived transactions, optionally only those with status
+	List(ctx context.Context, limit, offset int, status string) ([]*TransactionInfo, error)
+}
+
+// ObjectStore is the part of an object storage bucket read by ObjectArchive.
+// Adapters typically wrap an S3 or GCS client.
+type ObjectStore interface {
+	// Get returns the object at key; a missing object must be reported with
+	// an error matching ErrArchivedTransactionNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List returns the keys starting with prefix
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// ObjectArchive is an ArchiveStore over an exporter writing each finished
+// transaction as JSON to <Prefix><gid>.json
+type ObjectArchive struct {
+	Store  ObjectStore
+	Prefix string
+}
+
+// Get reads the exported transaction gid
+func (a *ObjectArchive) Get(ctx context.Context, gid string) (*TransactionInfo, error) {
+	data, err := a.Store.Get(ctx, a.Prefix+gid+".json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archived transaction %s: %w", gid, err)
+	}
+	var txInfo TransactionInfo
+	if err := json.Unmarshal(data, &txInfo); err != nil {
+		return nil, fmt.Errorf("failed to parse archived transaction %s: %w", gid, err)
+	}
+	return &txInfo, nil
+}
+
+// List reads the exported transactions in key order. Filtering by status
+// reads every object, so prefer partitioned prefixes for large archives.
+func (a *ObjectArchive) List(ctx context.Context, limit, offset int, status string) ([]*TransactionInfo, error) {
+	keys, err := a.Store.List(ctx, a.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived transactions: %w", err)
+	}
+	sort.Strings(keys)
+
+	var transactions []*TransactionInfo
+	skipped := 0
+	for _, key := range keys {
+		if limit > 0 && len(transactions) >= limit {
+			break
+		}
+		if !strings.HasSuffix(key, ".json") {
+			continue
+		}
+		if status == "" && skipped < offset {
+			skipped++
+			continue
+		}
+		txInfo, err := a.Get(ctx, strings.TrimSuffix(strings.TrimPrefix(key, a.Prefix), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		if status != "" && txInfo.Status != status {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		transactions = append(transactions, txInfo)
+	}
+	return transactions, nil
+}
+
+// ArchiveTransaction soft-deletes a finished transaction: the TC moves it
+// from hot storage to its archive, where GetArchivedTransaction still finds it
+func (c *Client) ArchiveTransaction(ctx context.Context, gid string) error {
+	if gid == "" {
+		return fmt.Errorf("gid cannot be empty")
+	}
+	if regional := c.ForGID(gid); regional != c {
+		return regional.ArchiveTransaction(ctx, gid)
+	}
+
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		Post(fmt.Sprintf("/api/tx/%s/archive", url.PathEscape(gid)))
+	if err != nil {
+		return fmt.Errorf("failed to archive transaction: %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return c.statusError("failed to archive transaction", resp)
+	}
+	return nil
+}
+
+// GetArchivedTransaction retrieves a transaction evicted from hot storage,
+// from Config.ArchiveStore if set and the TC's archive otherwise
+func (c *Client) GetArchivedTransaction(ctx context.Context, gid string) (*TransactionInfo, error) {
+	if regional := c.ForGID(gid); regional != c {
+		return regional.GetArchivedTransaction(ctx, gid)
+	}
+	var txInfo *TransactionInfo
+	if c.config.ArchiveStore != nil {
+		var err error
+		if txInfo, err = c.config.ArchiveStore.Get(ctx, gid); err != nil {
+			return nil, err
+		}
+	} else {
+		resp, err := c.readGet(ctx, fmt.Sprintf("/api/archive/tx/%s", url.PathEscape(gid)), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get archived transaction: %w", err)
+		}
+		if resp.StatusCode() == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrArchivedTransactionNotFound, gid)
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return nil, c.statusError("failed to get archived transaction", resp)
+		}
+		if err := decodeJSON(resp.Body(), &txInfo); err != nil {
+			return nil, fmt.Errorf("failed to parse archived transaction: %w", err)
+		}
+	}
+	if err := c.decodeTransactionInfo(txInfo); err != nil {
+		return nil, err
+	}
+	return txInfo, nil
+}
+
+// ListArchivedTransactions lists archived transactions like ListTransactions
+func (c *Client) ListArchivedTransactions(ctx context.Context, limit, offset int, status string) ([]*TransactionInfo, error) {
+	var transactions []*TransactionInfo
+	if c.config.ArchiveStore != nil {
+		var err error
+		if transactions, err = c.config.ArchiveStore.List(ctx, limit, offset, status); err != nil {
+			return nil, err
+		}
+	} else {
+		query := url.Values{}
+		if limit > 0 {
+			query.Set("limit", fmt.Sprintf("%d", limit))
+		}
+		if offset > 0 {
+			query.Set("offset", fmt.Sprintf("%d", offset))
+		}
+		if status != "" {
+			query.Set("status", status)
+		}
+		resp, err := c.readGet(ctx, "/api/archive/tx", query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list archived transactions: %w", err)
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return nil, c.statusError("failed to list archived transactions", resp)
+		}
+		if err := decodeJSON(resp.Body(), &transactions); err != nil {
+			return nil, fmt.Errorf("failed to parse archived transactions: %w", err)
+		}
+	}
+	for _, txInfo := range transactions {
+		if err := c.decodeTransactionInfo(txInfo); err != nil {
+			return nil, err
+		}
+	}
+	return transactions, nil
+}
@@ -0,0 +1,164 @@
+package seata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrUnauthorized is returned when the configured Authorizer denies an
+// operation
+var ErrUnauthorized = errors.New("transaction operation not authorized")
+
+// Operations checked by an Authorizer
+const (
+	AuthorizeStart  = "start"
+	AuthorizeSubmit = "submit"
+	AuthorizeAbort  = "abort"
+)
+
+// AuthorizationRequest describes an operation for an Authorizer. The
+// payload itself is not sent, only its size and digest.
+type AuthorizationRequest struct {
+	Operation     string            `json:"operation"`
+	Mode          string            `json:"mode"`
+	GID           string            `json:"gid"`
+	Caller        string            `json:"caller,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+	PayloadSize   int               `json:"payload_size"`
+	PayloadSHA256 string            `json:"payload_sha256,omitempty"`
+}
+
+// Authorizer decides whether an operation may proceed, returning an error
+// to deny it
+type Authorizer interface {
+	Authorize(ctx context.Context, req *AuthorizationRequest) error
+}
+
+// AuthorizerFunc adapts a function to Authorizer
+type AuthorizerFunc func(ctx context.Context, req *AuthorizationRequest) error
+
+// Authorize calls f
+func (f AuthorizerFunc) Authorize(ctx context.Context, req *AuthorizationRequest) error {
+	return f(ctx, req)
+}
+
+type authorizationKey struct{}
+
+type authorizationValues struct {
+	caller string
+	tags   map[string]string
+}
+
+// WithCaller returns a context whose operations are authorized as caller
+// instead of Config.CallerIdentity
+func WithCaller(ctx context.Context, caller string) context.Context {
+	values, _ := ctx.Value(authorizationKey{}).(authorizationValues)
+	values.caller = caller
+	return context.WithValue(ctx, authorizationKey{}, values)
+}
+
+// WithAuthorizationTags returns a context whose operations carry tags to
+// the Authorizer, e.g. {"team": "payments"}
+func WithAuthorizationTags(ctx context.Context, tags map[string]string) context.Context {
+	values, _ := ctx.Value(authorizationKey{}).(authorizationValues)
+	values.tags = tags
+	return context.WithValue(ctx, authorizationKey{}, values)
+}
+
+// authorize asks the configured Authorizer whether operation may proceed
+func (c *Client) authorize(ctx context.Context, operation, mode, gid string, payload []byte) error {
+	if c.config.Authorizer == nil {
+		return nil
+	}
+	values, _ := ctx.Value(authorizationKey{}).(authorizationValues)
+	req := &AuthorizationRequest{
+		Operation:   operation,
+		Mode:        mode,
+		GID:         gid,
+		Caller:      c.config.CallerIdentity,
+		Tags:        values.tags,
+		PayloadSize: len(payload),
+	}
+	if values.caller != "" {
+		req.Caller = values.caller
+	}
+	if len(payload) > 0 {
+		sum := sha256.Sum256(payload)
+		req.PayloadSHA256 = hex.EncodeToString(sum[:])
+	}
+	if err := c.config.Authorizer.Authorize(ctx, req); err != nil {
+		return fmt.Errorf("%w: %s %s: %v", ErrUnauthorized, operation, mode, err)
+	}
+	return nil
+}
+
+// OPAAuthorizer evaluates an Open Policy Agent decision through its Data
+// API. The AuthorizationRequest is sent as the input document; the decision
+// must be a boolean, or an object with an "allow" boolean and an optional
+// "reason". An undefined decision denies.
+type OPAAuthorizer struct {
+	// URL of the decision, e.g. http://opa:8181/v1/data/seata/authz
+	URL    string
+	client *resty.Client
+}
+
+// NewOPAAuthorizer creates an authorizer querying the decision at url
+func NewOPAAuthorizer(url string, timeout time.Duration) *OPAAuthorizer {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &OPAAuthorizer{URL: url, client: resty.New().SetTimeout(timeout)}
+}
+
+// Authorize queries OPA; a policy that cannot be evaluated denies
+func (a *OPAAuthorizer) Authorize(ctx context.Context, req *AuthorizationRequest) error {
+	resp, err := a.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(map[string]interface{}{"input": req}).
+		Post(a.URL)
+	if err != nil {
+		return fmt.Errorf("failed to query OPA: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return fmt.Errorf("failed to query OPA: status %d", resp.StatusCode())
+	}
+
+	var decision struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(resp.Body(), &decision); err != nil {
+		return fmt.Errorf("failed to parse OPA decision: %w", err)
+	}
+	if len(decision.Result) == 0 {
+		return errors.New("policy decision is undefined")
+	}
+	var allow bool
+	if json.Unmarshal(decision.Result, &allow) == nil {
+		if !allow {
+			return errors.New("denied by policy")
+		}
+		return nil
+	}
+	var result struct {
+		Allow  bool   `json:"allow"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal(decision.Result, &result); err != nil {
+		return fmt.Errorf("failed to parse OPA decision: %w", err)
+	}
+	if !result.Allow {
+		if result.Reason != "" {
+			return errors.New(result.Reason)
+		}
+		return errors.New("denied by policy")
+	}
+	return nil
+}
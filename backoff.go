@@ -0,0 +1,77 @@
+package seata
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// BackoffStrategy computes the delay before the next retry. attempt starts
+// at 0 for the first retry and prev is the delay returned for the previous
+// attempt (zero before the first retry). Implementations must be safe for
+// concurrent use.
+type BackoffStrategy interface {
+	Next(attempt int, prev time.Duration) time.Duration
+}
+
+// ConstantBackoff waits the same interval before every retry
+type ConstantBackoff struct {
+	Interval time.Duration
+}
+
+// Next implements BackoffStrategy
+func (b ConstantBackoff) Next(int, time.Duration) time.Duration {
+	return b.Interval
+}
+
+// ExponentialBackoff waits a uniformly random delay in
+// [0, min(Max, Base*Factor^attempt)] ("full jitter")
+type ExponentialBackoff struct {
+	Base   time.Duration
+	Factor float64       // zero means 2
+	Max    time.Duration // zero means no cap
+}
+
+// Next implements BackoffStrategy
+func (b ExponentialBackoff) Next(attempt int, _ time.Duration) time.Duration {
+	factor := b.Factor
+	if factor == 0 {
+		factor = 2
+	}
+	ceiling := capDuration(float64(b.Base)*math.Pow(factor, float64(attempt)), b.Max)
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
+
+// DecorrelatedJitterBackoff waits a uniformly random delay in
+// [Base, prev*3], capped at Max, so delays grow without synchronizing clients
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration // zero means no cap
+}
+
+// Next implements BackoffStrategy
+func (b DecorrelatedJitterBackoff) Next(_ int, prev time.Duration) time.Duration {
+	if prev < b.Base {
+		prev = b.Base
+	}
+	upper := capDuration(float64(prev)*3, b.Max)
+	if upper <= b.Base {
+		return upper
+	}
+	return b.Base + time.Duration(rand.Int64N(int64(upper-b.Base)+1))
+}
+
+// capDuration converts d to a duration no larger than max (when max > 0),
+// guarding against overflow
+func capDuration(d float64, max time.Duration) time.Duration {
+	if max > 0 && d > float64(max) {
+		return max
+	}
+	if d >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(d)
+}
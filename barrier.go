@@ -0,0 +1,216 @@
+package seata
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Barrier operations recorded in barrier storage
+const (
+	BarrierOpTry        = "try"
+	BarrierOpConfirm    = "confirm"
+	BarrierOpCancel     = "cancel"
+	BarrierOpAction     = "action"
+	BarrierOpCompensate = "compensate"
+)
+
+// Barrier sentinel errors returned to participant services
+var (
+	// ErrNullCompensation means a cancel/compensation arrived for a branch whose try never ran;
+	// the participant should return success without undoing anything
+	ErrNullCompensation = errors.New("null compensation: branch was never tried")
+	// ErrHangingRequest means a try arrived after the branch was already cancelled;
+	// the participant must not reserve resources
+	ErrHangingRequest = errors.New("hanging request: branch was already cancelled")
+	// ErrDuplicateRequest means the operation was already applied for this branch
+	ErrDuplicateRequest = errors.New("duplicate request: operation already applied")
+)
+
+// BarrierStore persists which operations were applied to which branch
+type BarrierStore interface {
+	// Insert records op for the branch and reports whether it was newly inserted
+	Insert(ctx context.Context, gid, branchID, op string) (bool, error)
+	// Exists reports whether op was recorded for the branch
+	Exists(ctx context.Context, gid, branchID, op string) (bool, error)
+}
+
+// Barrier implements null-compensation and hanging-prevention checks on top of a BarrierStore
+type Barrier struct {
+	store BarrierStore
+}
+
+// NewBarrier creates a barrier backed by store
+func NewBarrier(store BarrierStore) *Barrier {
+	return &Barrier{store: store}
+}
+
+// SuppressHanging must be called by a participant before executing a try
+// (or saga action). It returns ErrHangingRequest if the branch was already
+// cancelled, ErrDuplicateRequest if the try already ran, and nil if the try
+// should proceed.
+func (b *Barrier) SuppressHanging(ctx context.Context, gid, branchID string) error {
+	inserted, err := b.store.Insert(ctx, gid, branchID, BarrierOpTry)
+	if err != nil {
+		return fmt.Errorf("failed to record try barrier: %w", err)
+	}
+	if inserted {
+		return nil
+	}
+
+	cancelled, err := b.store.Exists(ctx, gid, branchID, BarrierOpCancel)
+	if err != nil {
+		return fmt.Errorf("failed to check cancel barrier: %w", err)
+	}
+	if cancelled {
+		return ErrHangingRequest
+	}
+	return ErrDuplicateRequest
+}
+
+// DetectNullCompensation must be called by a participant before executing a
+// cancel (or saga compensation). It returns ErrNullCompensation if the try
+// never ran (and records the branch so a late try is rejected as hanging),
+// ErrDuplicateRequest if the cancel already ran, and nil if the cancel should
+// proceed.
+func (b *Barrier) DetectNullCompensation(ctx context.Context, gid, branchID string) error {
+	// Occupy the try slot first: if that succeeds the try never ran
+	neverTried, err := b.store.Insert(ctx, gid, branchID, BarrierOpTry)
+	if err != nil {
+		return fmt.Errorf("failed to record try barrier: %w", err)
+	}
+
+	inserted, err := b.store.Insert(ctx, gid, branchID, BarrierOpCancel)
+	if err != nil {
+		return fmt.Errorf("failed to record cancel barrier: %w", err)
+	}
+	if !inserted {
+		return ErrDuplicateRequest
+	}
+	if neverTried {
+		return ErrNullCompensation
+	}
+	return nil
+}
+
+// MemoryBarrierStore is an in-process BarrierStore, suitable for tests and single-instance services
+type MemoryBarrierStore struct {
+	mu      sync.Mutex
+	records map[string]bool
+}
+
+// NewMemoryBarrierStore creates an empty in-memory barrier store
+func NewMemoryBarrierStore() *MemoryBarrierStore {
+	return &MemoryBarrierStore{records: make(map[string]bool)}
+}
+
+// Insert records op for the branch
+func (s *MemoryBarrierStore) Insert(ctx context.Context, gid, branchID, op string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := gid + "|" + branchID + "|" + op
+	if s.records[key] {
+		return false, nil
+	}
+	s.records[key] = true
+	return true, nil
+}
+
+// Exists reports whether op was recorded for the branch
+func (s *MemoryBarrierStore) Exists(ctx context.Context, gid, branchID, op string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records[gid+"|"+branchID+"|"+op], nil
+}
+
+// SQL dialects supported by SQLBarrierStore
+const (
+	SQLDialectMySQL    = "mysql"
+	SQLDialectPostgres = "postgres"
+)
+
+// SQLBarrierStore stores barriers in a SQL table with a unique key on
+// (gid, branch_id, op), e.g.:
+//
+//	CREATE TABLE seata_barrier (
+//	  gid VARCHAR(128) NOT NULL,
+//	  branch_id VARCHAR(128) NOT NULL,
+//	  op VARCHAR(45) NOT NULL,
+//	  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+//	  PRIMARY KEY (gid, branch_id, op)
+//	);
+type SQLBarrierStore struct {
+	db      *sql.DB
+	table   string
+	dialect string
+}
+
+// NewSQLBarrierStore creates a barrier store using table (default "seata_barrier")
+func NewSQLBarrierStore(db *sql.DB, dialect, table string) (*SQLBarrierStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database cannot be nil")
+	}
+	if dialect != SQLDialectMySQL && dialect != SQLDialectPostgres {
+		return nil, fmt.Errorf("unsupported SQL dialect: %s", dialect)
+	}
+	if table == "" {
+		table = "seata_barrier"
+	}
+	return &SQLBarrierStore{db: db, table: table, dialect: dialect}, nil
+}
+
+// Insert records op for the branch, ignoring duplicates
+func (s *SQLBarrierStore) Insert(ctx context.Context, gid, branchID, op string) (bool, error) {
+	return s.insert(ctx, s.db, gid, branchID, op)
+}
+
+// InsertTx records op inside tx, so the barrier commits atomically with business changes
+func (s *SQLBarrierStore) InsertTx(ctx context.Context, tx *sql.Tx, gid, branchID, op string) (bool, error) {
+	return s.insert(ctx, tx, gid, branchID, op)
+}
+
+// Exists reports whether op was recorded for the branch
+func (s *SQLBarrierStore) Exists(ctx context.Context, gid, branchID, op string) (bool, error) {
+	var query string
+	if s.dialect == SQLDialectPostgres {
+		query = fmt.Sprintf("SELECT 1 FROM %s WHERE gid = $1 AND branch_id = $2 AND op = $3", s.table)
+	} else {
+		query = fmt.Sprintf("SELECT 1 FROM %s WHERE gid = ? AND branch_id = ? AND op = ?", s.table)
+	}
+
+	var one int
+	err := s.db.QueryRowContext(ctx, query, gid, branchID, op).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// sqlExecer is implemented by *sql.DB and *sql.Tx
+type sqlExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (s *SQLBarrierStore) insert(ctx context.Context, execer sqlExecer, gid, branchID, op string) (bool, error) {
+	var query string
+	if s.dialect == SQLDialectPostgres {
+		query = fmt.Sprintf("INSERT INTO %s (gid, branch_id, op) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING", s.table)
+	} else {
+		query = fmt.Sprintf("INSERT IGNORE INTO %s (gid, branch_id, op) VALUES (?, ?, ?)", s.table)
+	}
+
+	result, err := execer.ExecContext(ctx, query, gid, branchID, op)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,99 @@
+package seata
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TryItem is one branch of a batched try
+type TryItem struct {
+	BranchID string
+	Action   string
+	Payload  []byte
+}
+
+// TryResult is the outcome of one branch of a batched try
+type TryResult struct {
+	BranchID string `json:"branch_id"`
+	Result   string `json:"result"` // ResultSuccess or ResultFailure
+	Error    string `json:"error,omitempty"`
+}
+
+// BatchTryError is returned when the TC rejected a batched try. No branch of
+// the batch holds a reservation, so nothing needs to be cancelled.
+type BatchTryError struct {
+	Results []TryResult
+}
+
+func (e *BatchTryError) Error() string {
+	failed := e.Failed()
+	if len(failed) == 0 {
+		return "batch try rejected"
+	}
+	return "batch try rejected by " + strings.Join(failed, ", ")
+}
+
+// Is makes errors.Is(err, ErrBranchFailure) match
+func (e *BatchTryError) Is(target error) bool {
+	return target == ErrBranchFailure
+}
+
+// Failed returns the branch IDs that could not reserve
+func (e *BatchTryError) Failed() []string {
+	var failed []string
+	for _, r := range e.Results {
+		if r.Result != ResultSuccess {
+			failed = append(failed, r.BranchID)
+		}
+	}
+	return failed
+}
+
+// TryAll runs the try phase of several branches in a single request. The TC
+// calls every participant and, when any of them cannot reserve, cancels the
+// ones that did and rejects the whole batch with 409 and a *BatchTryError,
+// so the caller never sees a partial reservation.
+func (tx *Transaction) TryAll(ctx context.Context, items []TryItem) ([]TryResult, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("batch try needs at least one item")
+	}
+	reqItems := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		reqItems[i] = map[string]interface{}{
+			"branch_id": item.BranchID,
+			"action":    item.Action,
+			"payload":   base64.StdEncoding.EncodeToString(item.Payload),
+		}
+	}
+	req := map[string]interface{}{
+		"gid":   tx.gid,
+		"items": reqItems,
+	}
+
+	resp, err := tx.client.httpClient.R().
+		SetContext(tx.Context(ctx)).
+		SetHeader("Content-Type", "application/json").
+		SetBody(req).
+		Post(tx.url("/api/branch/try_batch"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute batch try: %w", err)
+	}
+
+	var result struct {
+		Results []TryResult `json:"results"`
+	}
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		if err := decodeJSON(resp.Body(), &result); err != nil {
+			return nil, fmt.Errorf("failed to parse batch try response: %w", err)
+		}
+		return result.Results, nil
+	case http.StatusConflict:
+		_ = decodeJSON(resp.Body(), &result)
+		return nil, &BatchTryError{Results: result.Results}
+	}
+	return nil, tx.client.statusError("failed to execute batch try", resp)
+}
@@ -2,9 +2,15 @@ package benchmarks
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/seata-team/seata-go-client"
+	"github.com/seata-team/seata-go-client/seatatest"
 )
 
 func BenchmarkClientCreation(b *testing.B) {
@@ -16,7 +22,8 @@ func BenchmarkClientCreation(b *testing.B) {
 }
 
 func BenchmarkTransactionCreation(b *testing.B) {
-	client := seata.NewClientWithDefaults()
+	client, server := fakeClient()
+	defer server.Close()
 	defer client.Close()
 
 	ctx := context.Background()
@@ -24,11 +31,22 @@ func BenchmarkTransactionCreation(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		// This will fail without a running server, but we can measure the overhead
-		_, _ = client.StartTransaction(ctx, seata.ModeSaga, payload)
+		if _, err := client.StartTransaction(ctx, seata.ModeSaga, payload); err != nil {
+			b.Fatal(err)
+		}
 	}
 }
 
+// fakeClient returns a client of an in-process fake TC, so benchmarks measure
+// real round trips; use cmd/seata-bench for load against a real TC
+func fakeClient() (*seata.Client, *seatatest.Server) {
+	server := seatatest.NewServer()
+	config := seata.DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	return seata.NewClient(config), server
+}
+
 func BenchmarkSagaWorkflowCreation(b *testing.B) {
 	client := seata.NewClientWithDefaults()
 	defer client.Close()
@@ -60,7 +78,8 @@ func BenchmarkSagaWorkflowCreation(b *testing.B) {
 }
 
 func BenchmarkTCCWorkflowCreation(b *testing.B) {
-	client := seata.NewClientWithDefaults()
+	client, server := fakeClient()
+	defer server.Close()
 	defer client.Close()
 
 	tccManager := seata.NewTCCManager(client)
@@ -68,15 +87,15 @@ func BenchmarkTCCWorkflowCreation(b *testing.B) {
 	workflow := seata.CreateTCCWorkflow([]seata.TCCStep{
 		{
 			BranchID: "step1",
-			Try:      "http://example.com/step1/try",
-			Confirm:  "http://example.com/step1/confirm",
-			Cancel:   "http://example.com/step1/cancel",
+			Try:      server.URL + "/tcc/step1/try",
+			Confirm:  server.URL + "/tcc/step1/confirm",
+			Cancel:   server.URL + "/tcc/step1/cancel",
 		},
 		{
 			BranchID: "step2",
-			Try:      "http://example.com/step2/try",
-			Confirm:  "http://example.com/step2/confirm",
-			Cancel:   "http://example.com/step2/cancel",
+			Try:      server.URL + "/tcc/step2/try",
+			Confirm:  server.URL + "/tcc/step2/confirm",
+			Cancel:   server.URL + "/tcc/step2/cancel",
 		},
 	})
 
@@ -86,8 +105,9 @@ func BenchmarkTCCWorkflowCreation(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		// This will fail without a running server, but we can measure the overhead
-		_ = tccManager.ExecuteTCC(ctx, workflow, payload, options)
+		if err := tccManager.ExecuteTCC(ctx, workflow, payload, options); err != nil {
+			b.Fatal(err)
+		}
 	}
 }
 
@@ -145,6 +165,25 @@ func BenchmarkWorkflowValidation(b *testing.B) {
 	}
 }
 
+func BenchmarkRegisteredWorkflowLookup(b *testing.B) {
+	registry := seata.NewWorkflowRegistry()
+	workflow := seata.CreateSagaWorkflow([]seata.SagaStep{
+		{
+			BranchID:   "step1",
+			Action:     "http://example.com/step1",
+			Compensate: "http://example.com/step1/compensate",
+		},
+	})
+	if err := registry.RegisterSaga("order", workflow); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = registry.Saga("order")
+	}
+}
+
 func BenchmarkConfigCreation(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -155,6 +194,63 @@ func BenchmarkConfigCreation(b *testing.B) {
 	}
 }
 
+// BenchmarkLargeTCCWorkflow runs a 10k-branch TCC workflow against a stub TC
+// and reports the peak number of goroutines, which the bounded fan-out keeps
+// near MaxConcurrency
+func BenchmarkLargeTCCWorkflow(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/start" {
+			_, _ = w.Write([]byte(`{"gid":"g1"}`))
+		}
+	}))
+	defer server.Close()
+
+	config := seata.DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := seata.NewClient(config)
+	defer client.Close()
+
+	workflow := seata.CreateTCCWorkflow(nil)
+	for i := 0; i < 10000; i++ {
+		id := fmt.Sprintf("b%d", i)
+		workflow.AddStep(id, "http://svc/try/"+id, "http://svc/confirm/"+id, "http://svc/cancel/"+id)
+	}
+	tm := seata.NewTCCManager(client)
+	options := seata.DefaultExecutionOptions()
+
+	peak := runtime.NumGoroutine()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if n := runtime.NumGoroutine(); n > peak {
+					peak = n
+				}
+			}
+		}
+	}()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := tm.ExecuteTCC(context.Background(), workflow, nil, options); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	close(stop)
+	<-done
+	b.ReportMetric(float64(peak), "peak-goroutines")
+}
+
 // Memory allocation benchmarks
 func BenchmarkMemoryAllocation(b *testing.B) {
 	b.ReportAllocs()
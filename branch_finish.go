@@ -0,0 +1,39 @@
+package seata
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrBranchAlreadyFinished is joined to the error of a confirm or cancel of
+// a branch that already reached that state, when Config.StrictBranchFinish
+// is set
+var ErrBranchAlreadyFinished = errors.New("branch already finished")
+
+// ErrBranchFinishConflict is joined to the error of a confirm of a cancelled
+// branch or a cancel of a confirmed one
+var ErrBranchFinishConflict = errors.New("branch already finished in the opposite state")
+
+// finishOutcome classifies a rejected confirm (path /api/branch/succeed) or
+// cancel. same means the TC reports the branch already in the requested
+// state; a 409 without a known code counts as same. opposite means it is
+// in the other final state.
+func finishOutcome(path string, resp *resty.Response) (same, opposite bool) {
+	var body SeataError
+	_ = json.Unmarshal(resp.Body(), &body)
+
+	sameCode, oppositeCode := ErrCodeBranchAlreadyCancelled, ErrCodeBranchAlreadyConfirmed
+	if path == "/api/branch/succeed" {
+		sameCode, oppositeCode = oppositeCode, sameCode
+	}
+	switch body.Code {
+	case sameCode:
+		return true, false
+	case oppositeCode:
+		return false, true
+	}
+	return resp.StatusCode() == http.StatusConflict, false
+}
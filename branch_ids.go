@@ -0,0 +1,69 @@
+package seata
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// DeriveBranchID returns the branch ID of the step at index of a workflow
+// with DeriveBranchIDs set. It depends only on the gid, the index and the
+// action, so a retried registration of the same step reuses its ID.
+func DeriveBranchID(gid string, index int, action string) string {
+	sum := sha256.Sum256([]byte(gid + "\x00" + strconv.Itoa(index) + "\x00" + action))
+	return "b" + strconv.Itoa(index) + "-" + hex.EncodeToString(sum[:6])
+}
+
+// branchID returns the branch ID of the step at index in the transaction
+// gid: the declared one, or the derived one when it is empty
+func (sw *SagaWorkflow) branchID(index int, gid string) string {
+	step := sw.Steps[index]
+	if step.BranchID != "" || !sw.DeriveBranchIDs {
+		return step.BranchID
+	}
+	return DeriveBranchID(gid, index, step.Action)
+}
+
+// branchID returns the branch ID of the step at index like
+// SagaWorkflow.branchID, deriving it from the Try action
+func (tw *TCCWorkflow) branchID(index int, gid string) string {
+	step := tw.Steps[index]
+	if step.BranchID != "" || !tw.DeriveBranchIDs {
+		return step.BranchID
+	}
+	return DeriveBranchID(gid, index, step.Try)
+}
+
+// deriveSagaBranchIDs fills in the branch IDs of steps selected from
+// workflow
+func deriveSagaBranchIDs(workflow *SagaWorkflow, steps []SagaStep, gid string) {
+	for i := range steps {
+		steps[i].BranchID = workflow.branchID(steps[i].index, gid)
+	}
+}
+
+// deriveTCCBranchIDs fills in the branch IDs of steps selected from
+// workflow
+func deriveTCCBranchIDs(workflow *TCCWorkflow, steps []TCCStep, gid string) {
+	for i := range steps {
+		steps[i].BranchID = workflow.branchID(steps[i].index, gid)
+	}
+}
+
+// skippedSagaSteps returns the branch IDs of the skipped steps of workflow
+func skippedSagaSteps(workflow *SagaWorkflow, skipped []int, gid string) []string {
+	var branchIDs []string
+	for _, index := range skipped {
+		branchIDs = append(branchIDs, workflow.branchID(index, gid))
+	}
+	return branchIDs
+}
+
+// skippedTCCSteps returns the branch IDs of the skipped steps of workflow
+func skippedTCCSteps(workflow *TCCWorkflow, skipped []int, gid string) []string {
+	var branchIDs []string
+	for _, index := range skipped {
+		branchIDs = append(branchIDs, workflow.branchID(index, gid))
+	}
+	return branchIDs
+}
@@ -0,0 +1,114 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// BranchOptions tell the TC how to execute one branch
+type BranchOptions struct {
+	// Compensate is the URL the TC calls to roll the branch back
+	Compensate string
+	// Timeout bounds each call of the branch action; zero uses the submit
+	// or server default
+	Timeout time.Duration
+	// MaxRetries the TC makes for a failing action; zero uses the server default
+	MaxRetries int
+	// Headers the TC passes to the action and compensation calls
+	Headers map[string]string
+	// Fallback is the URL the TC calls once Action has exhausted its
+	// retries; FallbackCompensate rolls a fallback back and defaults to
+	// Compensate
+	Fallback           string
+	FallbackCompensate string
+	// Method and CompensateMethod are the HTTP methods of the action and
+	// compensation calls, e.g. http.MethodDelete; empty means POST. The
+	// compensation URLs may be templates such as
+	// "http://orders/orders/{{.resp.id}}" (see RenderCompensateURL).
+	Method           string
+	CompensateMethod string
+}
+
+// isZero reports whether no option is set
+func (o *BranchOptions) isZero() bool {
+	return o == nil || (o.Compensate == "" && o.Timeout == 0 && o.MaxRetries == 0 && len(o.Headers) == 0 &&
+		o.Fallback == "" && o.FallbackCompensate == "" && o.Method == "" && o.CompensateMethod == "")
+}
+
+// apply adds the options to an /api/branch/add request
+func (o *BranchOptions) apply(req map[string]interface{}) {
+	if o == nil {
+		return
+	}
+	if o.Compensate != "" {
+		req["compensate"] = o.Compensate
+	}
+	if o.Timeout > 0 {
+		req["timeout_ms"] = o.Timeout.Milliseconds()
+	}
+	if o.MaxRetries > 0 {
+		req["max_retries"] = o.MaxRetries
+	}
+	if len(o.Headers) > 0 {
+		req["headers"] = o.Headers
+	}
+	if o.Fallback != "" {
+		req["fallback"] = o.Fallback
+	}
+	if o.FallbackCompensate != "" {
+		req["fallback_compensate"] = o.FallbackCompensate
+	}
+	if o.Method != "" {
+		req["method"] = o.Method
+	}
+	if o.CompensateMethod != "" {
+		req["compensate_method"] = o.CompensateMethod
+	}
+}
+
+// validate rejects negative limits, unknown methods and malformed
+// compensation templates
+func (o *BranchOptions) validate() error {
+	if o.Timeout < 0 || o.MaxRetries < 0 {
+		return fmt.Errorf("invalid branch options: limits cannot be negative")
+	}
+	for _, method := range []string{o.Method, o.CompensateMethod} {
+		switch method {
+		case "", http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			return fmt.Errorf("invalid branch options: unsupported HTTP method %q", method)
+		}
+	}
+	for _, compensate := range []string{o.Compensate, o.FallbackCompensate} {
+		if _, err := parseCompensateTemplate(compensate); err != nil {
+			return fmt.Errorf("invalid branch options: %w", err)
+		}
+	}
+	return nil
+}
+
+// compensateOf returns the compensation URL in options, if any
+func compensateOf(options *BranchOptions) string {
+	if options == nil {
+		return ""
+	}
+	return options.Compensate
+}
+
+// AddBranchWithOptions adds a branch with per-branch execution options. The
+// gRPC API cannot carry them, so the branch is added over HTTP unless
+// options is empty.
+func (tx *Transaction) AddBranchWithOptions(ctx context.Context, branchID, action string, options *BranchOptions) error {
+	if options.isZero() {
+		return tx.AddBranch(ctx, branchID, action)
+	}
+	if err := options.validate(); err != nil {
+		return err
+	}
+	if added, err := tx.branchAdded(branchID, action, options.Compensate); added || err != nil {
+		return err
+	}
+	return tx.addBranchHTTP(ctx, branchID, action, options)
+}
@@ -0,0 +1,159 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// BranchPoolConfig bounds the branch calls the client orchestrates (TCC
+// try, confirm and cancel, ONGOING polls and saga compensations) across
+// all executions
+type BranchPoolConfig struct {
+	// MaxConcurrency is the number of branch calls in flight at once over
+	// all executions; 0 uses DefaultExecutionOptions().MaxConcurrency
+	MaxConcurrency int
+	// MaxPerHost caps the calls in flight to one participant host, so a
+	// slow participant cannot take every slot; 0 means no per-host cap
+	MaxPerHost int
+}
+
+// DefaultBranchPoolConfig returns a pool of 10 slots with at most 4 per
+// participant host
+func DefaultBranchPoolConfig() *BranchPoolConfig {
+	return &BranchPoolConfig{
+		MaxConcurrency: DefaultExecutionOptions().MaxConcurrency,
+		MaxPerHost:     4,
+	}
+}
+
+// BranchPoolStats is a snapshot of the branch pool
+type BranchPoolStats struct {
+	InFlight int
+	// Waiting counts the calls blocked on a per-host or pool slot
+	Waiting int
+	// Hosts holds the calls in flight or waiting by participant host
+	Hosts map[string]int
+}
+
+// branchPool is the semaphore shared by the branch calls of a client. A
+// call first takes a slot of its participant host, then a pool slot, so
+// calls queued behind a saturated host hold no pool slot.
+type branchPool struct {
+	slots   chan struct{}
+	perHost int
+
+	mu      sync.Mutex
+	hosts   map[string]*hostSlots
+	waiting int
+}
+
+// hostSlots is the semaphore of one participant host, dropped once unused
+type hostSlots struct {
+	slots chan struct{}
+	users int
+}
+
+func newBranchPool(config *BranchPoolConfig) *branchPool {
+	if config == nil {
+		return nil
+	}
+	size := config.MaxConcurrency
+	if size <= 0 {
+		size = DefaultExecutionOptions().MaxConcurrency
+	}
+	return &branchPool{
+		slots:   make(chan struct{}, size),
+		perHost: config.MaxPerHost,
+		hosts:   make(map[string]*hostSlots),
+	}
+}
+
+// acquire waits for a slot for a call to host and returns its release
+func (p *branchPool) acquire(ctx context.Context, host string) (func(), error) {
+	if p == nil {
+		return func() {}, nil
+	}
+	p.mu.Lock()
+	h := p.hosts[host]
+	if h == nil {
+		h = &hostSlots{}
+		if p.perHost > 0 {
+			h.slots = make(chan struct{}, p.perHost)
+		}
+		p.hosts[host] = h
+	}
+	h.users++
+	p.waiting++
+	p.mu.Unlock()
+
+	done := func(acquired bool) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if !acquired {
+			p.waiting--
+		}
+		if h.users--; h.users == 0 {
+			delete(p.hosts, host)
+		}
+	}
+
+	if h.slots != nil {
+		select {
+		case h.slots <- struct{}{}:
+		case <-ctx.Done():
+			done(false)
+			return nil, fmt.Errorf("waiting for a branch slot for %s: %w", host, ctx.Err())
+		}
+	}
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		if h.slots != nil {
+			<-h.slots
+		}
+		done(false)
+		return nil, fmt.Errorf("waiting for a branch slot for %s: %w", host, ctx.Err())
+	}
+	p.mu.Lock()
+	p.waiting--
+	p.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-p.slots
+			if h.slots != nil {
+				<-h.slots
+			}
+			done(true)
+		})
+	}, nil
+}
+
+// withBranchSlot runs call holding a branch pool slot for the participant
+// host of action; without Config.BranchPool it just runs call
+func (c *Client) withBranchSlot(ctx context.Context, action string, call func() error) error {
+	release, err := c.branchPool.acquire(ctx, participantHost(action))
+	if err != nil {
+		return err
+	}
+	defer release()
+	return call()
+}
+
+// BranchPoolStats returns the state of the branch pool, or nil without
+// Config.BranchPool
+func (c *Client) BranchPoolStats() *BranchPoolStats {
+	p := c.branchPool
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := &BranchPoolStats{InFlight: len(p.slots), Waiting: p.waiting, Hosts: make(map[string]int, len(p.hosts))}
+	for host, h := range p.hosts {
+		stats.Hosts[host] = h.users
+	}
+	return stats
+}
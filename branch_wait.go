@@ -0,0 +1,77 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBranchStatusUnreachable is returned by WaitForBranch when the branch
+// or its transaction reached a final state other than the awaited one
+var ErrBranchStatusUnreachable = errors.New("branch can no longer reach the awaited status")
+
+// Poll intervals of WaitForBranch; the interval doubles between polls
+const (
+	branchWaitMinInterval = 50 * time.Millisecond
+	branchWaitMaxInterval = time.Second
+)
+
+// WaitForBranch polls the TC until branchID reports status, e.g. to add a
+// shipping branch only once the payment branch succeeded. It fails with
+// ErrBranchStatusUnreachable as soon as the branch settles in another final
+// status or the transaction finishes without it, and with
+// context.DeadlineExceeded after timeout; a zero timeout waits on ctx alone.
+func (tx *Transaction) WaitForBranch(ctx context.Context, branchID, status string, timeout time.Duration) (*Branch, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	interval := branchWaitMinInterval
+	for {
+		info, err := tx.GetInfo(ctx)
+		if err != nil && ctx.Err() == nil {
+			return nil, fmt.Errorf("failed to wait for branch %s: %w", branchID, err)
+		}
+		if err == nil {
+			branch, done, err := branchWaitOutcome(info, branchID, status)
+			if done {
+				return branch, err
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("failed to wait for branch %s to become %s: %w", branchID, status, ctx.Err())
+		case <-timer.C:
+		}
+		interval = min(interval*2, branchWaitMaxInterval)
+	}
+}
+
+// branchWaitOutcome decides whether waiting for branchID to become status
+// is over
+func branchWaitOutcome(info *TransactionInfo, branchID, status string) (*Branch, bool, error) {
+	for i := range info.Branches {
+		branch := &info.Branches[i]
+		if branch.BranchID != branchID {
+			continue
+		}
+		if branch.Status == status {
+			return branch, true, nil
+		}
+		if branch.Status == BranchStatusSucceed || branch.Status == BranchStatusFailed {
+			return branch, true, fmt.Errorf("%w: branch %s is %s", ErrBranchStatusUnreachable, branchID, branch.Status)
+		}
+		break
+	}
+	switch info.Status {
+	case StatusCommitted, StatusAborted, StatusTimeout:
+		return nil, true, fmt.Errorf("%w: transaction %s is %s", ErrBranchStatusUnreachable, info.GID, info.Status)
+	}
+	return nil, false, nil
+}
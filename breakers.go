@@ -0,0 +1,136 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// participantBreakers holds one circuit breaker per participant host
+type participantBreakers struct {
+	mu       sync.Mutex
+	breakers map[string]*CircuitBreaker
+}
+
+// participantHost returns the host an action URL calls, or the action
+// itself when it has none
+func participantHost(action string) string {
+	if u, err := url.Parse(action); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return action
+}
+
+// participantBreaker returns the breaker of the action's host, created
+// with config on first use, or nil when config is nil
+func (c *Client) participantBreaker(action string, config *CircuitBreakerConfig) *CircuitBreaker {
+	if config == nil || config.FailureThreshold <= 0 {
+		return nil
+	}
+	host := participantHost(action)
+	c.breakers.mu.Lock()
+	defer c.breakers.mu.Unlock()
+	if c.breakers.breakers == nil {
+		c.breakers.breakers = make(map[string]*CircuitBreaker)
+	}
+	cb, ok := c.breakers.breakers[host]
+	if !ok {
+		cb = NewCircuitBreaker(config)
+		c.breakers.breakers[host] = cb
+	}
+	return cb
+}
+
+// ParticipantState returns the circuit breaker state of the participant
+// host an action URL calls
+func (c *Client) ParticipantState(action string) CircuitBreakerState {
+	c.breakers.mu.Lock()
+	cb := c.breakers.breakers[participantHost(action)]
+	c.breakers.mu.Unlock()
+	if cb == nil {
+		return CircuitBreakerClosed
+	}
+	return cb.GetState()
+}
+
+// allowParticipant fails fast with ErrCircuitOpen when the breaker of the
+// action's host is open
+func (c *Client) allowParticipant(action string, config *CircuitBreakerConfig) error {
+	if err := c.participantBreaker(action, config).Allow(); err != nil {
+		return fmt.Errorf("participant %s: %w", participantHost(action), err)
+	}
+	return nil
+}
+
+// recordParticipant reports a call to the action's host. Business
+// failures, ONGOING answers and cancellations say nothing about the
+// participant's health and are not counted.
+func (c *Client) recordParticipant(action string, config *CircuitBreakerConfig, err error) {
+	if errors.Is(err, ErrBranchFailure) || errors.Is(err, ErrBranchRejected) || errors.Is(err, ErrBranchOngoing) ||
+		errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrRetryBudgetExhausted) ||
+		errors.Is(err, context.Canceled) {
+		return
+	}
+	c.participantBreaker(action, config).Record(err)
+}
+
+// routeSagaSteps returns the steps to register for a saga. A step whose
+// participant is open is routed to its FallbackAction, or fails the saga
+// fast before it starts when it has none.
+func (sm *SagaManager) routeSagaSteps(workflow *SagaWorkflow, options *ExecutionOptions) ([]SagaStep, error) {
+	allowed := make(map[string]error)
+	allow := func(action string) error {
+		host := participantHost(action)
+		if err, ok := allowed[host]; ok {
+			return err
+		}
+		err := sm.client.allowParticipant(action, options.CircuitBreaker)
+		allowed[host] = err
+		return err
+	}
+
+	steps := make([]SagaStep, len(workflow.Steps))
+	for i, step := range workflow.Steps {
+		steps[i] = step
+		if step.Include != "" {
+			continue
+		}
+		err := allow(step.Action)
+		if err == nil {
+			continue
+		}
+		if step.FallbackAction == "" || allow(step.FallbackAction) != nil {
+			return nil, fmt.Errorf("saga step %s: %w", step.BranchID, err)
+		}
+		steps[i].Action = step.FallbackAction
+		if step.FallbackCompensate != "" {
+			steps[i].Compensate = step.FallbackCompensate
+		}
+		steps[i].FallbackAction, steps[i].FallbackCompensate = "", ""
+	}
+	return steps, nil
+}
+
+// recordSagaBranches reports the branch outcomes of a finished saga, as
+// executed by the TC, to the breakers of their participants. A branch that
+// ran its fallback counts as a failure of its primary action.
+func (sm *SagaManager) recordSagaBranches(branches []Branch, options *ExecutionOptions) {
+	for _, branch := range branches {
+		var err error
+		switch branch.Status {
+		case BranchStatusSucceed:
+		case BranchStatusFailed:
+			err = fmt.Errorf("branch %s failed", branch.BranchID)
+		default:
+			continue
+		}
+		executed := branch.Action
+		if branch.ExecutedAction != "" && branch.ExecutedAction != branch.Action {
+			executed = branch.ExecutedAction
+			sm.client.recordParticipant(branch.Action, options.CircuitBreaker, fmt.Errorf("branch %s fell back", branch.BranchID))
+		}
+		sm.client.recordParticipant(executed, options.CircuitBreaker, err)
+	}
+}
@@ -0,0 +1,130 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// bulkGetConcurrency bounds the requests in flight when GetTransactions falls
+// back to fetching transactions one by one
+const bulkGetConcurrency = 10
+
+// GetTransactions retrieves several transactions at once, keyed by gid. It
+// uses the TC batch endpoint and, when the TC does not offer one, fetches
+// the transactions concurrently. Unknown gids are left out of the map. When
+// some lookups fail, the transactions that could be fetched are returned
+// together with an error.
+func (c *Client) GetTransactions(ctx context.Context, gids []string) (map[string]*TransactionInfo, error) {
+	if len(gids) == 0 {
+		return map[string]*TransactionInfo{}, nil
+	}
+	if c.regions == nil {
+		return c.getTransactions(ctx, gids)
+	}
+
+	// Each region's TC is asked for the gids it started
+	var regions []*Client
+	byRegion := make(map[*Client][]string)
+	for _, gid := range gids {
+		regional := c.ForGID(gid)
+		if _, ok := byRegion[regional]; !ok {
+			regions = append(regions, regional)
+		}
+		byRegion[regional] = append(byRegion[regional], gid)
+	}
+	result := make(map[string]*TransactionInfo, len(gids))
+	var errs []error
+	for _, regional := range regions {
+		found, err := regional.getTransactions(ctx, byRegion[regional])
+		for gid, txInfo := range found {
+			result[gid] = txInfo
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// getTransactions looks gids up on this client's TC. The batch endpoint is
+// a POST, which read replicas do not serve (see readGet), so it always goes
+// to the primary; the one-by-one fallback reads from the replicas.
+func (c *Client) getTransactions(ctx context.Context, gids []string) (map[string]*TransactionInfo, error) {
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(map[string]interface{}{"gids": gids}).
+		Post("/api/tx/batch")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+
+	switch resp.StatusCode() {
+	case http.StatusOK:
+		var transactions []*TransactionInfo
+		if err := decodeJSON(resp.Body(), &transactions); err != nil {
+			return nil, fmt.Errorf("failed to parse transactions: %w", err)
+		}
+		result := make(map[string]*TransactionInfo, len(transactions))
+		for _, txInfo := range transactions {
+			if err := c.decodeTransactionInfo(txInfo); err != nil {
+				return nil, err
+			}
+			result[txInfo.GID] = txInfo
+		}
+		return result, nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return c.getTransactionsEach(ctx, gids)
+	}
+	return nil, c.statusError("failed to get transactions", resp)
+}
+
+// getTransactionsEach fetches gids with bounded concurrency
+func (c *Client) getTransactionsEach(ctx context.Context, gids []string) (map[string]*TransactionInfo, error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result = make(map[string]*TransactionInfo, len(gids))
+		errs   []error
+	)
+	semaphore := make(chan struct{}, bulkGetConcurrency)
+
+	for _, gid := range gids {
+		wg.Add(1)
+		go func(gid string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			txInfo, err := c.getTransactionIfExists(ctx, gid)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", gid, err))
+				return
+			}
+			if txInfo != nil {
+				result[gid] = txInfo
+			}
+		}(gid)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return result, fmt.Errorf("failed to get %d of %d transactions: %w", len(errs), len(gids), errors.Join(errs...))
+	}
+	return result, nil
+}
+
+// getTransactionIfExists is GetTransaction returning nil for an unknown gid
+func (c *Client) getTransactionIfExists(ctx context.Context, gid string) (*TransactionInfo, error) {
+	txInfo, err := c.getTransactionHTTP(ctx, gid)
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) && reqErr.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	return txInfo, err
+}
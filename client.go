@@ -2,13 +2,14 @@ package seata
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
 	"fmt"
 	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/go-resty/resty/v2"
-	"github.com/google/uuid"
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
@@ -18,11 +19,40 @@ type Client struct {
 	grpcClient *GrpcClient
 	config     *Config
 	discovery  *EtcdDiscovery
+	registrar  *EtcdRegistrar
 	// lb state
-	httpAddrs []string
-	grpcAddrs []string
-	lbIndex   int
-	lbStop    chan struct{}
+	lbMu        sync.RWMutex
+	httpAddrs   []string
+	grpcAddrs   []string
+	lbIndex     int
+	lbStop      chan struct{}
+	currentHTTP string
+	grpcPool    map[string]*GrpcClient
+	stats       *endpointStats
+	histograms  *latencyHistograms
+	// guards dedupLocks, the per business key locks of StartTransactionIfAbsent
+	dedupMu       sync.Mutex
+	dedupLocks    map[string]*businessKeyLock
+	metricsPusher *metricsPusher
+	watchdog      *Watchdog
+	debug         *debugTrace
+	// retries failed aborts when Config.AbortEscalation is set
+	abortEscalator *abortEscalator
+	// delivers lifecycle events when Config.Events is set
+	events *eventEmitter
+	// serves nats:// branches when Config.NATS is set
+	nats *natsBranches
+	// per-participant breakers driven by ExecutionOptions.CircuitBreaker
+	breakers participantBreakers
+	// bounds branch calls when Config.BranchPool is set
+	branchPool *branchPool
+	// calls participants directly, e.g. to poll ONGOING branches
+	participants *resty.Client
+	// region tags generated gids; regions routes across Config.Regions
+	region  string
+	regions *regionRouter
+	// serves reads when Config.ReadEndpoints or ReadDiscovery is set
+	replicas *readReplicas
 }
 
 // bytesToIntArray converts a byte slice to an int slice for JSON serialization
@@ -36,9 +66,18 @@ func bytesToIntArray(source []byte) []int {
 
 // Config holds the client configuration
 type Config struct {
-	// Server configuration
+	// Server configuration; both endpoints accept unix:///path/to.sock
+	// (and http+unix://) to reach a TC listening on a Unix domain socket
 	HTTPEndpoint string
 	GrpcEndpoint string
+	// PreferredTransport is TransportPreferGRPC or TransportPreferHTTP; empty
+	// uses gRPC for writes and HTTP for reads. An operation whose preferred
+	// transport is unreachable is retried over the other one.
+	PreferredTransport string
+	// Transport restricts the client to one transport (TransportGRPCOnly,
+	// TransportHTTPOnly) or speaks Connect instead of gRPC (TransportConnect);
+	// the default TransportAuto uses both
+	Transport string
 
 	// Timeout settings
 	RequestTimeout time.Duration
@@ -49,24 +88,166 @@ type Config struct {
 	MaxIdleConns    int
 	MaxConnsPerHost int
 
+	// HTTP transport tuning; a zero IdleConnTimeout falls back to 90s, other
+	// zero durations disable the corresponding timeout
+	EnableHTTP2           bool // attempt HTTP/2 over TLS endpoints
+	IdleConnTimeout       time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	ExpectContinueTimeout time.Duration
+	DisableKeepAlives     bool
+
 	// Authentication (for future use)
 	AuthToken string
 
+	// TLS settings for both endpoints; when set, endpoints without a scheme
+	// and http:// endpoints are upgraded to https://
+	TLS *tls.Config
+
+	// gRPC connection settings (keepalive, retry policy, message sizes)
+	Grpc *GrpcConfig
+
 	// Optional service discovery using etcd
 	Discovery *DiscoveryConfig
+
+	// Optional self-registration in etcd (uses the Discovery etcd settings)
+	// so a TC pushing branch commands can find this client
+	Registration *RegistrationConfig
+
+	// Load balancing across discovered endpoints. By default the client only
+	// moves to the next endpoint when the current one fails; LBRotateInterval
+	// restores periodic round-robin rotation. StickySessions pins each
+	// Transaction to the endpoints it was started on. LBStrategy selects
+	// round-robin or least-latency endpoint selection.
+	LBRotation       string
+	LBRotateInterval time.Duration
+	LBStrategy       string
+	StickySessions   bool
+
+	// Optional payload compression; payloads of at least CompressionThreshold
+	// bytes are compressed before being sent and decompressed when read back.
+	// HTTP request bodies of that size are sent with Content-Encoding.
+	Compressor           PayloadCompressor
+	CompressionThreshold int
+
+	// Optional payload encryption with client-held keys; payloads are
+	// compressed first, then encrypted
+	Encrypter PayloadEncrypter
+
+	// Redaction of response bodies in logs and errors; set OmitBodyInErrors
+	// in production to keep bodies out of error strings entirely.
+	// MaxErrorBody caps the body bytes quoted in error strings (default
+	// 512); RequestError.Body has the rest.
+	Redactor         Redactor
+	OmitBodyInErrors bool
+	MaxErrorBody     int
+
+	// Default check applied to TCC Try responses, e.g. to treat a 200 with
+	// {"ok":false} as failure; steps can override it with their own Validator
+	ResponseValidator ResponseValidator
+
+	// HTTP statuses treated as success for branch calls (try, confirm,
+	// cancel, report); empty means 200 only
+	SuccessStatus []StatusRange
+	// StrictBranchFinish makes a confirm or cancel of a branch that already
+	// reached that state fail with ErrBranchAlreadyFinished. By default it
+	// succeeds, so retries and recovery replays are safe.
+	StrictBranchFinish bool
+
+	// Optional capture of request/response dumps (see Client.DebugTrace)
+	Debug *DebugConfig
+
+	// Optional background retry and escalation of failed aborts
+	AbortEscalation *AbortEscalationConfig
+
+	// Optional CloudEvents emission of the transaction lifecycle
+	Events *EventsConfig
+
+	// Optional NATS JetStream connection for nats:// TCC branches
+	NATS *NATSConfig
+
+	// Optional export of archived transactions (e.g. S3 or GCS) read by
+	// GetArchivedTransaction instead of the TC's archive API
+	ArchiveStore ArchiveStore
+
+	// Optional HTTP middleware wrapping the client's transport, e.g.
+	// Recorder.Wrap to capture TC traffic or ReplayTransport.Wrap to serve
+	// a recording back without a server
+	WrapTransport func(http.RoundTripper) http.RoundTripper
+
+	// Optional push of client metrics to an OTLP collector or StatsD
+	MetricsPush *MetricsPushConfig
+
+	// Optional per-operation latency histograms with trace exemplars,
+	// served by MetricsHandler
+	LatencyHistograms *HistogramConfig
+
+	// Optional shared pool bounding the branch calls of all executions,
+	// with per-participant-host caps
+	BranchPool *BranchPoolConfig
+
+	// Optional hedging of idempotent reads across discovered endpoints
+	Hedging *HedgeConfig
+
+	// Optional alerting on locally started transactions that stay
+	// non-terminal beyond an SLA
+	Watchdog *WatchdogConfig
+
+	// Optional gid namespacing for clusters shared by several teams.
+	// Generated gids start with GIDPrefix (e.g. "orders-"); with
+	// EnforceGIDPrefix, gids without it are rejected with ErrInvalidGID.
+	// GIDValidator adds a custom format check to every gid started or
+	// attached.
+	GIDPrefix        string
+	EnforceGIDPrefix bool
+	GIDValidator     func(gid string) error
+
+	// Optional policy check run on every Submit with the assembled
+	// transaction; an error vetoes the submission with ErrSubmitVetoed.
+	// See RequireCompensations and NewWebhookValidator.
+	PreSubmitValidator PreSubmitValidator
+
+	// Optional authorization of every start, submit and abort, e.g. an
+	// OPAAuthorizer. CallerIdentity names this service to the Authorizer;
+	// WithCaller overrides it per call.
+	Authorizer     Authorizer
+	CallerIdentity string
+
+	// Optional TC clusters in several regions. The client connects to
+	// LocalRegion, whose endpoints and Discovery replace the top-level ones.
+	// New transactions fail over to the other regions, in order, while the
+	// local one is unreachable. Gids carry their region (after GIDPrefix),
+	// so a transaction stays with the cluster that started it.
+	Regions     []RegionConfig
+	LocalRegion string
+
+	// Optional read replicas serving GetTransaction, ListTransactions,
+	// Metrics and other reads, so dashboards do not load the TC nodes
+	// handling commits. ReadDiscovery lists replicas under its own
+	// Namespace (<namespace>/endpoints/http/). Writes and Health always go
+	// to the primary endpoints.
+	ReadEndpoints []string
+	ReadDiscovery *DiscoveryConfig
 }
 
 // DefaultConfig returns a default configuration
 func DefaultConfig() *Config {
 	return &Config{
-		HTTPEndpoint:    "http://localhost:36789",
-		GrpcEndpoint:    "localhost:36790",
-		RequestTimeout:  30 * time.Second,
-		RetryInterval:   1 * time.Second,
-		MaxRetries:      3,
-		MaxIdleConns:    100,
-		MaxConnsPerHost: 100,
-		Discovery:       nil,
+		HTTPEndpoint:         "http://localhost:36789",
+		GrpcEndpoint:         "localhost:36790",
+		RequestTimeout:       30 * time.Second,
+		RetryInterval:        1 * time.Second,
+		MaxRetries:           3,
+		MaxIdleConns:         100,
+		MaxConnsPerHost:      100,
+		EnableHTTP2:          true,
+		IdleConnTimeout:      90 * time.Second,
+		TLSHandshakeTimeout:  10 * time.Second,
+		Discovery:            nil,
+		LBRotation:           LBRotateOnFailure,
+		LBRotateInterval:     15 * time.Second,
+		LBStrategy:           LBStrategyRoundRobin,
+		CompressionThreshold: DefaultCompressionThreshold,
 	}
 }
 
@@ -74,76 +255,273 @@ func DefaultConfig() *Config {
 type DiscoveryConfig struct {
 	EtcdEndpoints []string
 	Namespace     string // e.g. "/seata"
+
+	// Authentication and transport security
+	Username    string
+	Password    string
+	TLS         *tls.Config
+	DialTimeout time.Duration
+
+	// Liveness: RequireLease ignores endpoint keys not bound to a lease, so
+	// only instances that keep their lease alive are used. ResyncInterval
+	// periodically re-reads endpoints in case watch events were missed.
+	RequireLease   bool
+	ResyncInterval time.Duration
 }
 
-// NewClient creates a new Seata client with the given configuration
+// etcdConfig builds the etcd client configuration
+func (dc *DiscoveryConfig) etcdConfig() clientv3.Config {
+	dialTimeout := dc.DialTimeout
+	if dialTimeout <= 0 {
+		dialTimeout = 5 * time.Second
+	}
+	return clientv3.Config{
+		Endpoints:   dc.EtcdEndpoints,
+		DialTimeout: dialTimeout,
+		Username:    dc.Username,
+		Password:    dc.Password,
+		TLS:         dc.TLS,
+	}
+}
+
+// NewClient creates a new Seata client with the given configuration.
+// Endpoints in config are normalized in place (see NormalizeHTTPEndpoint).
+// Configuration problems are only printed as a warning; use NewClientE
+// to fail fast instead.
 func NewClient(config *Config) *Client {
 	if config == nil {
 		config = DefaultConfig()
 	}
+	c, err := NewClientE(config)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return newClient(config)
+	}
+	return c
+}
 
+// newClient creates a client from a normalized config
+func newClient(config *Config) *Client {
 	// Create HTTP client
 	httpClient := resty.New()
-	httpClient.SetBaseURL(config.HTTPEndpoint)
+	transport := newHTTPTransport(config)
+	if socketPath, baseURL, ok := parseUnixHTTPEndpoint(config.HTTPEndpoint); ok {
+		useUnixSocket(transport, socketPath)
+		httpClient.SetBaseURL(baseURL)
+	} else {
+		httpClient.SetBaseURL(config.HTTPEndpoint)
+	}
 	httpClient.SetTimeout(config.RequestTimeout)
 	httpClient.SetRetryCount(config.MaxRetries)
 	httpClient.SetRetryWaitTime(config.RetryInterval)
 	httpClient.SetRetryMaxWaitTime(config.RetryInterval * 3)
 
 	// Set connection pool settings
-	httpClient.GetClient().Transport = &http.Transport{
-		MaxIdleConns:       config.MaxIdleConns,
-		MaxConnsPerHost:    config.MaxConnsPerHost,
-		IdleConnTimeout:    90 * time.Second,
-		DisableKeepAlives:  false,
-		DisableCompression: false,
+	httpClient.GetClient().Transport = transport
+	if config.WrapTransport != nil {
+		httpClient.GetClient().Transport = config.WrapTransport(transport)
 	}
 
-	// Create gRPC client
-	grpcClient := NewGrpcClient(config.GrpcEndpoint)
-
 	c := &Client{
-		httpClient: httpClient,
-		grpcClient: grpcClient,
-		config:     config,
-		lbStop:     make(chan struct{}),
-	}
+		httpClient:   httpClient,
+		config:       config,
+		lbStop:       make(chan struct{}),
+		stats:        newEndpointStats(),
+		histograms:   newLatencyHistograms(config.LatencyHistograms),
+		branchPool:   newBranchPool(config.BranchPool),
+		participants: newParticipantClient(config),
+		debug:        newDebugTrace(config.Debug),
+		region:       config.LocalRegion,
+	}
+	c.installStatsHooks()
+	c.installCorrelationHook()
+	c.installDebugHooks()
+	c.installTransportGuard()
+
+	// Create gRPC client
+	c.grpcClient = c.newGrpcClient(config.GrpcEndpoint)
+	c.grpcPool = map[string]*GrpcClient{config.GrpcEndpoint: c.grpcClient}
 
 	// Start discovery if configured
 	if config.Discovery != nil && len(config.Discovery.EtcdEndpoints) > 0 {
-		d := NewEtcdDiscovery(config.Discovery.EtcdEndpoints, config.Discovery.Namespace, func(httpAddrs []string, grpcAddrs []string) {
+		d := NewEtcdDiscoveryWithConfig(config.Discovery, func(httpAddrs []string, grpcAddrs []string) {
+			c.lbMu.Lock()
 			c.httpAddrs = httpAddrs
 			c.grpcAddrs = grpcAddrs
 			c.lbIndex = 0
+			c.pruneGrpcPool()
 			c.applyTargets()
+			c.lbMu.Unlock()
 		})
 		c.discovery = d
+		c.installFailureHooks()
 		go d.Run(context.Background())
-		go c.startLB()
+		if config.LBRotation == LBRotateInterval {
+			go c.startLB()
+		}
+	}
+
+	if config.Watchdog != nil && config.Watchdog.SLA > 0 {
+		c.watchdog = newWatchdog(c, config.Watchdog)
+		go c.watchdog.run()
+	}
+	if config.MetricsPush != nil {
+		c.metricsPusher = newMetricsPusher(config.MetricsPush, c.stats)
+		c.metricsPusher.watchdog = c.watchdog
+		go c.metricsPusher.run()
+	}
+	if config.AbortEscalation != nil {
+		c.abortEscalator = newAbortEscalator(c, config.AbortEscalation)
+		go c.abortEscalator.run()
+	}
+	if config.Events != nil && config.Events.Sink != nil {
+		c.events = newEventEmitter(config.Events)
+		go c.events.run()
+	}
+	if config.NATS != nil && config.NATS.Conn != nil {
+		if n, err := newNATSBranches(config.NATS); err != nil {
+			fmt.Printf("Warning: nats:// branches disabled: %v\n", err)
+		} else {
+			c.nats = n
+		}
+	}
+
+	if len(config.Regions) > 0 {
+		c.regions = newRegionRouter(c)
+	}
+	c.replicas = newReadReplicas(config)
+
+	// Register this client instance if configured
+	if config.Registration != nil {
+		if r, err := NewEtcdRegistrar(config.Discovery, config.Registration); err != nil {
+			fmt.Printf("Warning: client registration disabled: %v\n", err)
+		} else {
+			c.registrar = r
+			go r.Run(context.Background())
+		}
 	}
 	return c
 }
 
+// newHTTPTransport builds the HTTP transport from the connection settings
+func newHTTPTransport(config *Config) *http.Transport {
+	idleConnTimeout := config.IdleConnTimeout
+	if idleConnTimeout <= 0 {
+		idleConnTimeout = 90 * time.Second
+	}
+	return &http.Transport{
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+		ExpectContinueTimeout: config.ExpectContinueTimeout,
+		ForceAttemptHTTP2:     config.EnableHTTP2,
+		DisableKeepAlives:     config.DisableKeepAlives,
+		DisableCompression:    false,
+		TLSClientConfig:       config.TLS,
+	}
+}
+
+// NewClientE creates a new Seata client after validating config, returning
+// a *ConfigError instead of a client when the configuration is invalid
+func NewClientE(config *Config) (*Client, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	config.applyLocalRegion()
+	config.normalizeEndpoints()
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return newClient(config), nil
+}
+
 // NewClientWithDefaults creates a new Seata client with default configuration
 func NewClientWithDefaults() *Client {
 	return NewClient(DefaultConfig())
 }
 
-// StartTransaction creates a new global transaction
+// StartTransaction creates a new global transaction. With Config.Regions it
+// is started in the local region, or the next reachable one on an outage.
 func (c *Client) StartTransaction(ctx context.Context, mode string, payload []byte) (*Transaction, error) {
+	if c.regions != nil {
+		return c.regions.startTransaction(ctx, mode, payload)
+	}
+	return c.startTransaction(ctx, mode, payload)
+}
+
+// startTransaction starts a transaction on this client's TC
+func (c *Client) startTransaction(ctx context.Context, mode string, payload []byte) (*Transaction, error) {
 	// Generate transaction ID
-	gid := uuid.New().String()
+	gid := c.newGID()
+	if err := c.ValidateGID(gid); err != nil {
+		return nil, err
+	}
+	if err := c.authorize(ctx, AuthorizeStart, mode, gid, payload); err != nil {
+		return nil, err
+	}
+	correlationID := newCorrelationID(ctx)
+	ctx = WithCorrelationID(ctx, correlationID)
+
+	encoded, err := c.encodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
 
 	// Use gRPC if available, otherwise fall back to HTTP
-	if c.grpcClient != nil && c.grpcClient.client != nil {
-		return c.startTransactionGRPC(ctx, gid, mode, payload)
+	httpBase, gc := c.currentTargets()
+	var tx *Transaction
+	err = c.withFailover(gc, true, func() (err error) {
+		tx, err = c.startTransactionGRPC(ctx, gc, gid, mode, encoded)
+		return err
+	}, func() (err error) {
+		tx, err = c.startTransactionHTTP(ctx, httpBase, gid, mode, encoded, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return c.startTransactionHTTP(ctx, gid, mode, payload)
+	// Keep the caller's payload on the local transaction object
+	tx.payload = payload
+	tx.correlationID = correlationID
+	if c.config.StickySessions {
+		tx.httpBase = httpBase
+		tx.grpc = gc
+	}
+	if c.watchdog != nil {
+		c.watchdog.Track(tx)
+	}
+	c.emit(EventTransactionStarted, tx, "")
+	return tx, nil
+}
+
+// encodePayload prepares a payload before it is sent to the TC
+func (c *Client) encodePayload(payload []byte) ([]byte, error) {
+	compressed, err := c.compressPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	return c.encryptPayload(compressed)
 }
 
-// startTransactionHTTP creates a transaction via HTTP
-func (c *Client) startTransactionHTTP(ctx context.Context, gid, mode string, payload []byte) (*Transaction, error) {
+// decodeTransactionInfo restores payloads read back from the TC
+func (c *Client) decodeTransactionInfo(info *TransactionInfo) error {
+	payload, err := c.decryptPayload(info.Payload)
+	if err == nil {
+		payload, err = c.decompressPayload(payload)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode payload of transaction %s: %w", info.GID, err)
+	}
+	info.Payload = payload
+	return nil
+}
+
+// startTransactionHTTP creates a transaction via HTTP, adding fields such as
+// business_key or parent_gid to the request
+func (c *Client) startTransactionHTTP(ctx context.Context, httpBase, gid, mode string, payload []byte, fields map[string]string) (*Transaction, error) {
 	// Prepare request - convert payload to integer array for JSON serialization
 	payloadArray := bytesToIntArray(payload)
 
@@ -152,34 +530,37 @@ func (c *Client) startTransactionHTTP(ctx context.Context, gid, mode string, pay
 		"mode":    mode,
 		"payload": payloadArray,
 	}
+	for k, v := range fields {
+		req[k] = v
+	}
 
 	// Make HTTP request
-	resp, err := c.httpClient.R().
-		SetContext(ctx).
-		SetHeader("Content-Type", "application/json").
-		SetBody(req).
-		Post("/api/start")
+	request, err := c.setJSONBody(c.httpClient.R().SetContext(ctx), req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	resp, err := request.Post(joinURL(httpBase, "/api/start"))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to start transaction: %w", err)
 	}
 
 	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("failed to start transaction: status %d, body: %s", resp.StatusCode(), resp.String())
+		return nil, c.statusError("failed to start transaction", resp)
 	}
 
 	// Parse response
 	var result struct {
-		GID string `json:"gid"`
+		GID flexString `json:"gid"`
 	}
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+	if err := decodeJSON(resp.Body(), &result); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Create transaction object
 	tx := &Transaction{
 		client:   c,
-		gid:      result.GID,
+		gid:      string(result.GID),
 		mode:     mode,
 		payload:  payload,
 		branches: make([]*Branch, 0),
@@ -189,9 +570,10 @@ func (c *Client) startTransactionHTTP(ctx context.Context, gid, mode string, pay
 }
 
 // startTransactionGRPC creates a transaction via gRPC
-func (c *Client) startTransactionGRPC(ctx context.Context, gid, mode string, payload []byte) (*Transaction, error) {
-	resp, err := c.grpcClient.StartGlobal(ctx, gid, mode, payload)
+func (c *Client) startTransactionGRPC(ctx context.Context, gc *GrpcClient, gid, mode string, payload []byte) (*Transaction, error) {
+	resp, err := gc.StartGlobal(ctx, gid, mode, payload, c.grpcCallOptions()...)
 	if err != nil {
+		c.reportGrpcFailure(gc, err)
 		return nil, fmt.Errorf("failed to start transaction via gRPC: %w", err)
 	}
 
@@ -209,70 +591,122 @@ func (c *Client) startTransactionGRPC(ctx context.Context, gid, mode string, pay
 
 // GetTransaction retrieves a transaction by its global ID
 func (c *Client) GetTransaction(ctx context.Context, gid string) (*TransactionInfo, error) {
-	resp, err := c.httpClient.R().
-		SetContext(ctx).
-		Get(fmt.Sprintf("/api/tx/%s", gid))
+	if regional := c.ForGID(gid); regional != c {
+		return regional.GetTransaction(ctx, gid)
+	}
+	_, gc := c.currentTargets()
+	var txInfo *TransactionInfo
+	err := c.withFailover(gc, false, func() (err error) {
+		txInfo, err = c.getTransactionGRPC(ctx, gc, gid)
+		return err
+	}, func() (err error) {
+		txInfo, err = c.getTransactionHTTP(ctx, gid)
+		return err
+	})
+	return txInfo, err
+}
+
+// getTransactionHTTP retrieves a transaction via HTTP
+func (c *Client) getTransactionHTTP(ctx context.Context, gid string) (*TransactionInfo, error) {
+	resp, err := c.readGet(ctx, fmt.Sprintf("/api/tx/%s", gid), nil)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transaction: %w", err)
 	}
 
 	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("failed to get transaction: status %d, body: %s", resp.StatusCode(), resp.String())
+		return nil, c.statusError("failed to get transaction", resp)
 	}
 
 	var txInfo TransactionInfo
-	if err := json.Unmarshal(resp.Body(), &txInfo); err != nil {
+	if err := decodeJSON(resp.Body(), &txInfo); err != nil {
 		return nil, fmt.Errorf("failed to parse transaction info: %w", err)
 	}
+	if err := c.decodeTransactionInfo(&txInfo); err != nil {
+		return nil, err
+	}
 
 	return &txInfo, nil
 }
 
 // ListTransactions retrieves a list of transactions with optional filtering
 func (c *Client) ListTransactions(ctx context.Context, limit, offset int, status string) ([]*TransactionInfo, error) {
-	req := c.httpClient.R().SetContext(ctx)
+	_, gc := c.currentTargets()
+	var transactions []*TransactionInfo
+	err := c.withFailover(gc, false, func() (err error) {
+		transactions, err = c.listTransactionsGRPC(ctx, gc, limit, offset, status)
+		return err
+	}, func() (err error) {
+		transactions, err = c.listTransactionsHTTP(ctx, limit, offset, status)
+		return err
+	})
+	return transactions, err
+}
+
+// listTransactionsHTTP lists transactions via HTTP
+func (c *Client) listTransactionsHTTP(ctx context.Context, limit, offset int, status string) ([]*TransactionInfo, error) {
+	query := url.Values{}
 
 	if limit > 0 {
-		req.SetQueryParam("limit", fmt.Sprintf("%d", limit))
+		query.Set("limit", fmt.Sprintf("%d", limit))
 	}
 	if offset > 0 {
-		req.SetQueryParam("offset", fmt.Sprintf("%d", offset))
+		query.Set("offset", fmt.Sprintf("%d", offset))
 	}
 	if status != "" {
-		req.SetQueryParam("status", status)
+		query.Set("status", status)
 	}
 
-	resp, err := req.Get("/api/tx")
+	resp, err := c.readGet(ctx, "/api/tx", query)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to list transactions: %w", err)
 	}
 
 	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("failed to list transactions: status %d, body: %s", resp.StatusCode(), resp.String())
+		return nil, c.statusError("failed to list transactions", resp)
 	}
 
 	var transactions []*TransactionInfo
-	if err := json.Unmarshal(resp.Body(), &transactions); err != nil {
+	if err := decodeJSON(resp.Body(), &transactions); err != nil {
 		return nil, fmt.Errorf("failed to parse transactions list: %w", err)
 	}
+	for _, txInfo := range transactions {
+		if err := c.decodeTransactionInfo(txInfo); err != nil {
+			return nil, err
+		}
+	}
 
 	return transactions, nil
 }
 
+// CancelSaga cancels the submitted saga gid like Transaction.CancelSaga,
+// on the client of its region
+func (c *Client) CancelSaga(ctx context.Context, gid string) error {
+	if gid == "" {
+		return fmt.Errorf("gid cannot be empty")
+	}
+	tx := &Transaction{
+		client:        c.ForGID(gid),
+		gid:           gid,
+		mode:          ModeSaga,
+		branches:      make([]*Branch, 0),
+		correlationID: newCorrelationID(ctx),
+	}
+	return tx.CancelSaga(ctx)
+}
+
 // Health checks the health of the Seata server
 func (c *Client) Health(ctx context.Context) (*HealthStatus, error) {
-	resp, err := c.httpClient.R().
-		SetContext(ctx).
-		Get("/health")
+	// Health reports on the primary TC, never on a read replica
+	resp, err := c.hedgedGet(ctx, "/health", nil)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to check health: %w", err)
 	}
 
 	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("health check failed: status %d, body: %s", resp.StatusCode(), resp.String())
+		return nil, c.statusError("health check failed", resp)
 	}
 
 	// Seata server returns plain text "ok" for health check
@@ -286,7 +720,7 @@ func (c *Client) Health(ctx context.Context) (*HealthStatus, error) {
 
 	// Try to parse as JSON if not plain text
 	var health HealthStatus
-	if err := json.Unmarshal(resp.Body(), &health); err != nil {
+	if err := decodeJSON(resp.Body(), &health); err != nil {
 		return nil, fmt.Errorf("failed to parse health status: %w", err)
 	}
 
@@ -295,16 +729,13 @@ func (c *Client) Health(ctx context.Context) (*HealthStatus, error) {
 
 // Metrics retrieves Prometheus metrics from the server
 func (c *Client) Metrics(ctx context.Context) (string, error) {
-	resp, err := c.httpClient.R().
-		SetContext(ctx).
-		Get("/metrics")
-
+	resp, err := c.readGet(ctx, "/metrics", nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to get metrics: %w", err)
 	}
 
 	if resp.StatusCode() != 200 {
-		return "", fmt.Errorf("failed to get metrics: status %d, body: %s", resp.StatusCode(), resp.String())
+		return "", c.statusError("failed to get metrics", resp)
 	}
 
 	return resp.String(), nil
@@ -312,52 +743,76 @@ func (c *Client) Metrics(ctx context.Context) (string, error) {
 
 // Close closes the client and releases resources
 func (c *Client) Close() error {
+	if c.registrar != nil {
+		c.registrar.Stop()
+	}
 	if c.discovery != nil {
 		c.discovery.Stop()
 	}
 	if c.lbStop != nil {
 		close(c.lbStop)
 	}
-	if c.grpcClient != nil {
-		return c.grpcClient.Close()
+	if c.watchdog != nil {
+		c.watchdog.close()
 	}
-	return nil
+	if c.metricsPusher != nil {
+		c.metricsPusher.close()
+	}
+	if c.abortEscalator != nil {
+		c.abortEscalator.close()
+	}
+	if c.events != nil {
+		c.events.close()
+	}
+	if c.nats != nil {
+		c.nats.close()
+	}
+	if c.regions != nil {
+		c.regions.close()
+	}
+	c.replicas.close()
+
+	c.lbMu.Lock()
+	defer c.lbMu.Unlock()
+	var firstErr error
+	for addr, gc := range c.grpcPool {
+		if err := gc.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(c.grpcPool, addr)
+	}
+	return firstErr
 }
 
-// startLB starts a simple round-robin rotation across discovered endpoints
+// startLB starts a periodic round-robin rotation across discovered endpoints
 func (c *Client) startLB() {
-	ticker := time.NewTicker(15 * time.Second)
+	interval := c.config.LBRotateInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-c.lbStop:
 			return
 		case <-ticker.C:
-			if len(c.httpAddrs) == 0 && len(c.grpcAddrs) == 0 {
-				continue
-			}
-			c.lbIndex++
-			c.applyTargets()
+			c.rotate()
 		}
 	}
 }
 
-// applyTargets applies the current index to set HTTP BaseURL and gRPC target
+// applyTargets applies the current index to set HTTP BaseURL and gRPC target.
+// The caller must hold lbMu.
 func (c *Client) applyTargets() {
 	if len(c.httpAddrs) > 0 {
-		idx := c.lbIndex % len(c.httpAddrs)
-		if idx < 0 {
-			idx = 0
-		}
-		c.httpClient.SetBaseURL(c.httpAddrs[idx])
+		idx := c.selectIndex("http", c.httpAddrs, endpointOf)
+		c.currentHTTP = c.httpAddrs[idx]
+		c.httpClient.SetBaseURL(c.currentHTTP)
 	}
 	if len(c.grpcAddrs) > 0 {
-		idx := c.lbIndex % len(c.grpcAddrs)
-		if idx < 0 {
-			idx = 0
-		}
-		_ = c.grpcClient.Close()
-		c.grpcClient = NewGrpcClient(c.grpcAddrs[idx])
+		idx := c.selectIndex("grpc", c.grpcAddrs, func(addr string) string { return addr })
+		c.grpcClient = c.pooledGrpcClient(c.grpcAddrs[idx])
 	}
 }
 
@@ -365,27 +820,47 @@ func (c *Client) applyTargets() {
 type EtcdDiscovery struct {
 	endpoints []string
 	namespace string
+	config    *DiscoveryConfig
 	onUpdate  func([]string, []string)
 	stopCh    chan struct{}
+
+	// newClient opens the etcd client; replaced in tests
+	newClient func() (*clientv3.Client, error)
 }
 
+// discoveryTombstone marks an endpoint key as dead without deleting it
+const discoveryTombstone = "tombstone"
+
 func NewEtcdDiscovery(endpoints []string, namespace string, onUpdate func([]string, []string)) *EtcdDiscovery {
+	return NewEtcdDiscoveryWithConfig(&DiscoveryConfig{EtcdEndpoints: endpoints, Namespace: namespace}, onUpdate)
+}
+
+// NewEtcdDiscoveryWithConfig creates a discovery watcher using the full discovery settings
+func NewEtcdDiscoveryWithConfig(config *DiscoveryConfig, onUpdate func([]string, []string)) *EtcdDiscovery {
+	namespace := config.Namespace
 	if namespace == "" {
 		namespace = "/seata"
 	}
-	return &EtcdDiscovery{endpoints: endpoints, namespace: namespace, onUpdate: onUpdate, stopCh: make(chan struct{})}
+	d := &EtcdDiscovery{endpoints: config.EtcdEndpoints, namespace: namespace, config: config, onUpdate: onUpdate, stopCh: make(chan struct{})}
+	d.newClient = func() (*clientv3.Client, error) {
+		return clientv3.New(config.etcdConfig())
+	}
+	return d
 }
 
 func (d *EtcdDiscovery) Run(ctx context.Context) {
-	cli, err := clientv3.New(clientv3.Config{Endpoints: d.endpoints, DialTimeout: 5 * time.Second})
+	cli, err := d.newClient()
 	if err != nil {
 		return
 	}
 	defer cli.Close()
 
+	httpPrefix := d.namespace + "/endpoints/http/"
+	grpcPrefix := d.namespace + "/endpoints/grpc/"
+
 	// initial fetch
-	httpAddrs := d.fetch(cli, d.namespace+"/endpoints/http/")
-	grpcAddrs := d.fetch(cli, d.namespace+"/endpoints/grpc/")
+	httpAddrs, _ := d.fetch(cli, httpPrefix)
+	grpcAddrs, _ := d.fetch(cli, grpcPrefix)
 	if d.onUpdate != nil {
 		d.onUpdate(httpAddrs, grpcAddrs)
 	}
@@ -393,8 +868,28 @@ func (d *EtcdDiscovery) Run(ctx context.Context) {
 	// watch
 	watchCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	wchHttp := cli.Watch(watchCtx, d.namespace+"/endpoints/http/", clientv3.WithPrefix())
-	wchGrpc := cli.Watch(watchCtx, d.namespace+"/endpoints/grpc/", clientv3.WithPrefix())
+	wchHttp := cli.Watch(watchCtx, httpPrefix, clientv3.WithPrefix())
+	wchGrpc := cli.Watch(watchCtx, grpcPrefix, clientv3.WithPrefix())
+
+	// Periodic resync catches changes missed while a watch was broken
+	var resync <-chan time.Time
+	if d.config.ResyncInterval > 0 {
+		ticker := time.NewTicker(d.config.ResyncInterval)
+		defer ticker.Stop()
+		resync = ticker.C
+	}
+
+	refresh := func(prefix string, addrs *[]string) {
+		fetched, err := d.fetch(cli, prefix)
+		if err != nil {
+			// Keep the last known endpoints rather than dropping them all
+			return
+		}
+		*addrs = fetched
+		if d.onUpdate != nil {
+			d.onUpdate(httpAddrs, grpcAddrs)
+		}
+	}
 
 	for {
 		select {
@@ -402,32 +897,51 @@ func (d *EtcdDiscovery) Run(ctx context.Context) {
 			return
 		case <-watchCtx.Done():
 			return
-		case <-wchHttp:
-			httpAddrs = d.fetch(cli, d.namespace+"/endpoints/http/")
-			if d.onUpdate != nil {
-				d.onUpdate(httpAddrs, grpcAddrs)
+		case wresp, ok := <-wchHttp:
+			if watchCtx.Err() != nil {
+				return
+			}
+			if !ok || wresp.Err() != nil {
+				// Watch was cancelled or compacted; start a new one
+				wchHttp = cli.Watch(watchCtx, httpPrefix, clientv3.WithPrefix())
 			}
-		case <-wchGrpc:
-			grpcAddrs = d.fetch(cli, d.namespace+"/endpoints/grpc/")
-			if d.onUpdate != nil {
-				d.onUpdate(httpAddrs, grpcAddrs)
+			refresh(httpPrefix, &httpAddrs)
+		case wresp, ok := <-wchGrpc:
+			if watchCtx.Err() != nil {
+				return
 			}
+			if !ok || wresp.Err() != nil {
+				wchGrpc = cli.Watch(watchCtx, grpcPrefix, clientv3.WithPrefix())
+			}
+			refresh(grpcPrefix, &grpcAddrs)
+		case <-resync:
+			refresh(httpPrefix, &httpAddrs)
+			refresh(grpcPrefix, &grpcAddrs)
 		}
 	}
 }
 
 func (d *EtcdDiscovery) Stop() { close(d.stopCh) }
 
-func (d *EtcdDiscovery) fetch(cli *clientv3.Client, prefix string) []string {
+// fetch lists the live endpoints under prefix. Tombstoned keys and, when
+// RequireLease is set, keys not attached to a lease are ignored.
+func (d *EtcdDiscovery) fetch(cli *clientv3.Client, prefix string) ([]string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 	resp, err := cli.Get(ctx, prefix, clientv3.WithPrefix())
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	addrs := make([]string, 0, len(resp.Kvs))
 	for _, kv := range resp.Kvs {
-		addrs = append(addrs, string(kv.Value))
+		value := string(kv.Value)
+		if value == "" || value == discoveryTombstone {
+			continue
+		}
+		if d.config.RequireLease && kv.Lease == 0 {
+			continue
+		}
+		addrs = append(addrs, value)
 	}
-	return addrs
+	return addrs, nil
 }
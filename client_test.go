@@ -1,11 +1,48 @@
 package seata
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+	"unicode/utf8"
 
+	seata_proto "github.com/seata-team/seata-go-client/proto"
+	"github.com/seata-team/seata-go-client/seatatest"
 	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 func TestNewClient(t *testing.T) {
@@ -332,3 +369,5459 @@ func TestCircuitBreakerExecution(t *testing.T) {
 	assert.Equal(t, CircuitBreakerClosed, cb.GetState())
 	assert.Equal(t, 0, cb.failureCount)
 }
+
+func TestExportTransactions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") != "" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"gid":"g1","mode":"saga","status":"COMMITTED"},{"gid":"g2","mode":"tcc","status":"ABORTED"}]`))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	var jsonl bytes.Buffer
+	n, err := client.ExportTransactions(context.Background(), &jsonl, ExportFormatJSONL, &ExportFilter{PageSize: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, strings.Count(jsonl.String(), "\n"))
+
+	var csvOut bytes.Buffer
+	n, err = client.ExportTransactions(context.Background(), &csvOut, ExportFormatCSV, &ExportFilter{PageSize: 10, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.True(t, strings.HasPrefix(csvOut.String(), "gid,mode,status"))
+
+	_, err = client.ExportTransactions(context.Background(), &csvOut, "xml", nil)
+	assert.Error(t, err)
+}
+
+func TestPayloadCompression(t *testing.T) {
+	config := DefaultConfig()
+	config.Compressor = NewGzipCompressor()
+	config.CompressionThreshold = 16
+	client := &Client{config: config}
+
+	small := []byte("tiny")
+	encoded, err := client.encodePayload(small)
+	assert.NoError(t, err)
+	assert.Equal(t, small, encoded)
+
+	large := []byte(strings.Repeat("payload-", 64))
+	encoded, err = client.encodePayload(large)
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(encoded, compressedPayloadMagic))
+	assert.Less(t, len(encoded), len(large))
+
+	info := &TransactionInfo{GID: "gid", Payload: encoded}
+	assert.NoError(t, client.decodeTransactionInfo(info))
+	assert.Equal(t, large, info.Payload)
+
+	// A raw payload that happens to start like gzip is left alone
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte("raw"))
+	assert.NoError(t, zw.Close())
+	info = &TransactionInfo{GID: "gid", Payload: gz.Bytes()}
+	assert.NoError(t, client.decodeTransactionInfo(info))
+	assert.Equal(t, gz.Bytes(), info.Payload)
+
+	// Compressed payloads stay readable without a Compressor
+	plain := &Client{config: DefaultConfig()}
+	info = &TransactionInfo{GID: "gid", Payload: encoded}
+	assert.NoError(t, plain.decodeTransactionInfo(info))
+	assert.Equal(t, large, info.Payload)
+}
+
+func TestPayloadEncryption(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	encrypter, err := NewAESGCMEncrypter("k1", oldKey)
+	assert.NoError(t, err)
+
+	config := DefaultConfig()
+	config.Encrypter = encrypter
+	client := &Client{config: config}
+
+	plaintext := []byte(`{"card":"4111"}`)
+	sealed, err := client.encodePayload(plaintext)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(sealed), "4111")
+
+	// Rotation keeps old payloads readable
+	assert.NoError(t, encrypter.Rotate("k2", bytes.Repeat([]byte{2}, 32)))
+	info := &TransactionInfo{GID: "gid", Payload: sealed}
+	assert.NoError(t, client.decodeTransactionInfo(info))
+	assert.Equal(t, plaintext, info.Payload)
+
+	// Tampered payloads are rejected
+	sealed[len(sealed)-1] ^= 0xff
+	info = &TransactionInfo{GID: "gid", Payload: sealed}
+	assert.Error(t, client.decodeTransactionInfo(info))
+}
+
+func TestRedaction(t *testing.T) {
+	redact := NewJSONFieldRedactor("payload", "card")
+	body := `{"gid":"g1","payload":[1,2,3],"card": "4111-1111","amount":10}`
+	redacted := redact(body)
+	assert.NotContains(t, redacted, "4111")
+	assert.NotContains(t, redacted, "[1,2,3]")
+	assert.Contains(t, redacted, `"gid":"g1"`)
+	assert.Contains(t, redacted, `"amount":10`)
+
+	// Nested objects and arrays under a sensitive key are masked whole, and
+	// sensitive keys are found at any depth
+	nested := redact(`{"payload":{"card":{"number":"4111"},"items":[{"sku":"a"}]},"branches":[{"CARD":["4222"],"id":"b1"}]}`)
+	assert.NotContains(t, nested, "4111")
+	assert.NotContains(t, nested, "4222")
+	assert.NotContains(t, nested, "sku")
+	assert.Contains(t, nested, `"payload":"[REDACTED]"`)
+	assert.Contains(t, nested, `"id":"b1"`)
+
+	// Truncated bodies still have flat values masked
+	assert.NotContains(t, redact(`{"card":"4111","gid":`), "4111")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"card":"4111-1111"}`))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.MaxRetries = 0
+	config.Redactor = redact
+	client := NewClient(config)
+	defer client.Close()
+
+	_, err := client.GetTransaction(context.Background(), "g1")
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "4111")
+
+	config.OmitBodyInErrors = true
+	_, err = client.GetTransaction(context.Background(), "g1")
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "body:")
+}
+
+func TestGrpcConfigDialOptions(t *testing.T) {
+	config := DefaultGrpcConfig()
+	config.KeepaliveTime = 30 * time.Second
+	config.KeepaliveTimeout = 5 * time.Second
+	config.RetryPolicy = DefaultGrpcRetryPolicy()
+	config.MaxRecvMsgSize = 8 << 20
+	config.InitialBackoff = 200 * time.Millisecond
+
+	serviceConfig, err := config.serviceConfig()
+	assert.NoError(t, err)
+	assert.Contains(t, serviceConfig, grpcServiceName)
+	assert.Contains(t, serviceConfig, `"initialBackoff":"0.1s"`)
+
+	// An invalid service config would make the dial fail
+	gc := NewGrpcClientWithConfig("localhost:36790", config)
+	assert.NotNil(t, gc.client)
+	assert.NoError(t, gc.Close())
+
+	config.RetryPolicy = &GrpcRetryPolicy{MaxAttempts: 1}
+	_, err = config.dialOptions()
+	assert.Error(t, err)
+}
+
+func TestUnixSocketEndpoint(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "seata.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = "unix://" + socketPath
+	config.GrpcEndpoint = "unix://" + socketPath
+	client := NewClient(config)
+	defer client.Close()
+
+	health, err := client.Health(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "healthy", health.Status)
+
+	socket, baseURL, ok := parseUnixHTTPEndpoint("http+unix://%2Ftmp%2Fseata.sock/tc")
+	assert.True(t, ok)
+	assert.Equal(t, "/tmp/seata.sock", socket)
+	assert.Equal(t, "http://unix/tc", baseURL)
+	assert.Equal(t, "unix:///tmp/seata.sock", grpcTarget("http+unix:///tmp/seata.sock"))
+}
+
+func TestLBRotateOnFailureAndStickySessions(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	var healthyPaths []string
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthyPaths = append(healthyPaths, r.URL.Path)
+		_, _ = w.Write([]byte(`{"gid":"g1"}`))
+	}))
+	defer healthy.Close()
+
+	config := DefaultConfig()
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	config.StickySessions = true
+	client := NewClient(config)
+	defer client.Close()
+
+	client.lbMu.Lock()
+	client.httpAddrs = []string{failing.URL, healthy.URL}
+	client.applyTargets()
+	client.lbMu.Unlock()
+	client.installFailureHooks()
+
+	_, err := client.GetTransaction(context.Background(), "g1")
+	assert.Error(t, err)
+	httpBase, _ := client.currentTargets()
+	assert.Equal(t, healthy.URL, httpBase)
+
+	tx, err := client.StartTransaction(context.Background(), ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, healthy.URL, tx.httpBase)
+
+	// Rotating back to the failing endpoint does not move the pinned transaction
+	client.rotate()
+	assert.NoError(t, tx.Submit(context.Background()))
+	assert.Equal(t, []string{"/api/start", "/api/submit"}, healthyPaths)
+}
+
+func TestEndpointStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			_, _ = w.Write([]byte("ok"))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := NewClient(config)
+	defer client.Close()
+
+	_, err := client.Health(context.Background())
+	assert.NoError(t, err)
+	_, err = client.GetTransaction(context.Background(), "g1")
+	assert.Error(t, err)
+
+	stats := client.EndpointStats()
+	assert.Len(t, stats, 1)
+	assert.Equal(t, server.URL, stats[0].Endpoint)
+	assert.Equal(t, int64(2), stats[0].Requests)
+	assert.Equal(t, int64(1), stats[0].Errors)
+	assert.InDelta(t, endpointStatsAlpha, stats[0].ErrorRate, 0.001)
+}
+
+func TestResourceManagerDispatch(t *testing.T) {
+	rm := NewResourceManager()
+	var committed, rolledBack []string
+	assert.NoError(t, rm.RegisterResource("stock", BranchHandlerFuncs{
+		CommitFunc: func(ctx context.Context, cmd *BranchCommand) error {
+			committed = append(committed, cmd.BranchID)
+			return nil
+		},
+		RollbackFunc: func(ctx context.Context, cmd *BranchCommand) error {
+			rolledBack = append(rolledBack, cmd.BranchID)
+			return assert.AnError
+		},
+	}))
+	assert.Error(t, rm.RegisterResource("stock", BranchHandlerFuncs{}))
+
+	server := httptest.NewServer(rm)
+	defer server.Close()
+
+	post := func(path, body string) int {
+		resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, post(rmCommitPath, `{"gid":"g1","branch_id":"b1","resource_id":"stock"}`))
+	assert.Equal(t, http.StatusInternalServerError, post(rmRollbackPath, `{"gid":"g1","branch_id":"b2","resource_id":"stock"}`))
+	assert.Equal(t, http.StatusNotFound, post(rmCommitPath, `{"gid":"g1","branch_id":"b3","resource_id":"unknown"}`))
+	assert.Equal(t, []string{"b1"}, committed)
+	assert.Equal(t, []string{"b2"}, rolledBack)
+}
+
+func TestBarrierNullCompensationAndHanging(t *testing.T) {
+	ctx := context.Background()
+	barrier := NewBarrier(NewMemoryBarrierStore())
+
+	// Normal flow: try then cancel
+	assert.NoError(t, barrier.SuppressHanging(ctx, "g1", "b1"))
+	assert.ErrorIs(t, barrier.SuppressHanging(ctx, "g1", "b1"), ErrDuplicateRequest)
+	assert.NoError(t, barrier.DetectNullCompensation(ctx, "g1", "b1"))
+	assert.ErrorIs(t, barrier.DetectNullCompensation(ctx, "g1", "b1"), ErrDuplicateRequest)
+
+	// Cancel arrives before try: null compensation, then the late try hangs
+	assert.ErrorIs(t, barrier.DetectNullCompensation(ctx, "g2", "b1"), ErrNullCompensation)
+	assert.ErrorIs(t, barrier.SuppressHanging(ctx, "g2", "b1"), ErrHangingRequest)
+}
+
+func TestMemoryWorkflowStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryWorkflowStore()
+
+	state := &WorkflowState{ID: "wf-1", Mode: ModeSaga, Steps: []WorkflowStepState{
+		{BranchID: "step1", Status: StepStatusPending},
+		{BranchID: "step2", Status: StepStatusPending},
+	}}
+	assert.NoError(t, store.Save(ctx, state))
+	assert.NoError(t, store.MarkStep(ctx, "wf-1", "step1", StepStatusSucceeded, nil))
+
+	loaded, err := store.Load(ctx, "wf-1")
+	assert.NoError(t, err)
+	assert.Equal(t, WorkflowStatusPending, loaded.Status)
+	assert.Equal(t, 1, loaded.NextPendingStep())
+
+	pending, err := store.ListPending(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	loaded.Status = WorkflowStatusCompleted
+	assert.NoError(t, store.Save(ctx, loaded))
+	pending, err = store.ListPending(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	_, err = store.Load(ctx, "missing")
+	assert.ErrorIs(t, err, ErrWorkflowNotFound)
+}
+
+func TestCronSchedule(t *testing.T) {
+	schedule, err := ParseCron("30 2 * * 1-5")
+	assert.NoError(t, err)
+	// Saturday 2024-06-01 -> Monday 2024-06-03 02:30
+	from := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 6, 3, 2, 30, 0, 0, time.UTC), schedule.Next(from))
+
+	schedule, err = ParseCron("*/15 * * * *")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 6, 1, 12, 15, 0, 0, time.UTC), schedule.Next(from))
+
+	_, err = ParseCron("61 * * * *")
+	assert.Error(t, err)
+	_, err = ParseCron("* * *")
+	assert.Error(t, err)
+}
+
+func TestSchedulerOverlapSkip(t *testing.T) {
+	scheduler := NewScheduler(nil, nil)
+	var mu sync.Mutex
+	runs := 0
+	err := scheduler.Register("reconcile", "@every 20ms", func(ctx context.Context, _ *Client) error {
+		mu.Lock()
+		runs++
+		mu.Unlock()
+		<-ctx.Done()
+		return nil
+	}, nil)
+	assert.NoError(t, err)
+	assert.Error(t, scheduler.Register("reconcile", "@daily", func(context.Context, *Client) error { return nil }, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	scheduler.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, runs)
+}
+
+func TestStartTransactionIfAbsent(t *testing.T) {
+	var mu sync.Mutex
+	started := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/api/start":
+			var req map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			started[req["business_key"].(string)] = req["gid"].(string)
+			_, _ = w.Write([]byte(`{"gid":"` + req["gid"].(string) + `"}`))
+		case "/api/tx":
+			key := r.URL.Query().Get("business_key")
+			if gid, ok := started[key]; ok {
+				_, _ = w.Write([]byte(`[{"gid":"` + gid + `","mode":"saga","status":"SUBMITTED","business_key":"` + key + `"}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	first, created, err := client.StartTransactionIfAbsent(ctx, "order-42", ModeSaga, []byte("p"))
+	assert.NoError(t, err)
+	assert.True(t, created)
+
+	second, created, err := client.StartTransactionIfAbsent(ctx, "order-42", ModeSaga, []byte("p"))
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, first.GetGID(), second.GetGID())
+
+	_, _, err = client.StartTransactionIfAbsent(ctx, "", ModeSaga, nil)
+	assert.Error(t, err)
+
+	// An existing transaction is bound to the region that started it
+	eu := seatatest.NewServer()
+	defer eu.Close()
+	mu.Lock()
+	started["order-eu"] = "eu-1"
+	mu.Unlock()
+	config = DefaultConfig()
+	config.GrpcEndpoint = ""
+	config.Regions = []RegionConfig{{Name: "us", HTTPEndpoint: server.URL}, {Name: "eu", HTTPEndpoint: eu.URL}}
+	config.LocalRegion = "us"
+	regional := NewClient(config)
+	defer regional.Close()
+	existing, created, err := regional.StartTransactionIfAbsent(ctx, "order-eu", ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, "eu", existing.client.Region())
+
+	// The lookup follows the transport mode
+	config = DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.Transport = TransportGRPCOnly
+	grpcOnly := NewClient(config)
+	defer grpcOnly.Close()
+	_, _, err = grpcOnly.StartTransactionIfAbsent(ctx, "order-42", ModeSaga, nil)
+	assert.ErrorIs(t, err, ErrTransportDisabled)
+}
+
+func TestResponseValidation(t *testing.T) {
+	criteria := &SuccessCriteria{BodyMatchers: []BodyMatcher{JSONFieldEquals("ok", true)}}
+	validate := criteria.Validator()
+	assert.NoError(t, validate(200, []byte(`{"ok":true}`)))
+	assert.ErrorIs(t, validate(200, []byte(`{"ok":false}`)), ErrBranchRejected)
+	assert.ErrorIs(t, validate(500, []byte(`{"ok":true}`)), ErrBranchRejected)
+
+	schema, err := NewJSONSchemaValidator([]byte(`{"type":"object","required":["code"],"properties":{"code":{"type":"integer","enum":[0]}}}`))
+	assert.NoError(t, err)
+	assert.NoError(t, schema(200, []byte(`{"code":0}`)))
+	assert.ErrorIs(t, schema(200, []byte(`{"code":1}`)), ErrBranchRejected)
+	assert.ErrorIs(t, schema(200, []byte(`{}`)), ErrBranchRejected)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"ok":false}`))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	tx := &Transaction{client: client, gid: "g1"}
+	assert.NoError(t, tx.Try(context.Background(), "b1", "http://svc/try", nil))
+	err = tx.TryWithValidator(context.Background(), "b1", "http://svc/try", nil, validate)
+	assert.ErrorIs(t, err, ErrBranchRejected)
+}
+
+func TestDTMResultConvention(t *testing.T) {
+	validate := DTMResultValidator()
+	assert.NoError(t, validate(200, []byte(`{"dtm_result":"SUCCESS"}`)))
+	assert.ErrorIs(t, validate(200, []byte("FAILURE")), ErrBranchFailure)
+	assert.ErrorIs(t, validate(409, nil), ErrBranchFailure)
+	assert.ErrorIs(t, validate(200, []byte(`{"dtm_result":"ONGOING"}`)), ErrBranchOngoing)
+	assert.ErrorIs(t, validate(425, nil), ErrBranchOngoing)
+	assert.Error(t, validate(500, nil))
+
+	var mu sync.Mutex
+	tries := 0
+	failed := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/api/start":
+			_, _ = w.Write([]byte(`{"gid":"g1"}`))
+		case "/api/branch/try":
+			tries++
+			if tries == 1 {
+				_, _ = w.Write([]byte("ONGOING"))
+				return
+			}
+			_, _ = w.Write([]byte("SUCCESS"))
+		case "/api/branch/fail":
+			failed++
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.ResponseValidator = DTMResultValidator()
+	client := NewClient(config)
+	defer client.Close()
+
+	options := DefaultExecutionOptions()
+	options.RetryConfig = &RetryConfig{MaxRetries: 2, RetryInterval: time.Millisecond, BackoffFactor: 1}
+	workflow := CreateTCCWorkflow([]TCCStep{{BranchID: "b1", Try: "http://svc/try", Confirm: "http://svc/confirm", Cancel: "http://svc/cancel"}})
+	assert.NoError(t, NewTCCManager(client).ExecuteTCC(context.Background(), workflow, nil, options))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, tries)
+	assert.Equal(t, 0, failed)
+}
+
+func TestOngoingPolling(t *testing.T) {
+	var mu sync.Mutex
+	polls, tcCalls := 0, 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			tcCalls++
+		}
+		switch r.URL.Path {
+		case "/api/start":
+			_, _ = w.Write([]byte(`{"gid":"g1"}`))
+		case "/api/branch/try":
+			_, _ = w.Write([]byte(`{"dtm_result":"ONGOING","poll_url":"` + server.URL + `/status/b1"}`))
+		case "/status/b1":
+			polls++
+			if polls < 3 {
+				_, _ = w.Write([]byte(`{"dtm_result":"ONGOING"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"dtm_result":"SUCCESS"}`))
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.ResponseValidator = DTMResultValidator()
+	client := NewClient(config)
+	defer client.Close()
+
+	options := DefaultExecutionOptions()
+	options.RetryConfig = &RetryConfig{MaxRetries: 0, RetryInterval: time.Millisecond, BackoffFactor: 2}
+	workflow := CreateTCCWorkflow([]TCCStep{{BranchID: "b1", Try: "http://svc/try", Confirm: "c", Cancel: "x", PollTimeout: time.Second}})
+	assert.NoError(t, NewTCCManager(client).ExecuteTCC(context.Background(), workflow, nil, options))
+
+	mu.Lock()
+	assert.Equal(t, 3, polls)
+	polls = -100
+	mu.Unlock()
+	// Polls go to the participant directly, so TC stats only count TC calls
+	var tcRequests int64
+	for _, stat := range client.EndpointStats() {
+		tcRequests += stat.Requests
+	}
+	mu.Lock()
+	assert.Equal(t, int64(tcCalls), tcRequests)
+	mu.Unlock()
+
+	workflow.Steps[0].PollTimeout = 50 * time.Millisecond
+	err := NewTCCManager(client).ExecuteTCC(context.Background(), workflow, nil, options)
+	assert.ErrorIs(t, err, ErrBranchOngoing)
+
+	// Cancelling the caller is not reported as a poll timeout
+	workflow.Steps[0].PollTimeout = time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = NewTCCManager(client).ExecuteTCC(ctx, workflow, nil, options)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, ErrBranchOngoing)
+}
+
+func TestAdminClient(t *testing.T) {
+	var maintenance map[string]interface{}
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/admin/nodes":
+			_, _ = w.Write([]byte(`[{"id":"n1","role":"leader"},{"id":"n2","role":"follower"}]`))
+		case r.Method == "GET" && r.URL.Path == "/admin/nodes/n2":
+			_, _ = w.Write([]byte(`{"id":"n2","role":"follower","commit_index":42}`))
+		case r.Method == "PUT" && r.URL.Path == "/admin/nodes/n2/maintenance":
+			_ = json.NewDecoder(r.Body).Decode(&maintenance)
+		case r.Method == "POST" && r.URL.Path == "/admin/storage/compact":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+	admin := NewAdminClient(client)
+	ctx := context.Background()
+
+	nodes, err := admin.ListNodes(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 2)
+	assert.Equal(t, NodeRoleLeader, nodes[0].Role)
+
+	status, err := admin.NodeStatus(ctx, "n2")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), status.CommitIndex)
+
+	assert.NoError(t, admin.SetMaintenance(ctx, "n2", true))
+	assert.Equal(t, true, maintenance["enabled"])
+	assert.NoError(t, admin.CompactStorage(ctx))
+	assert.Error(t, admin.FlushStorage(ctx))
+
+	// An empty node ID would address the node collection; nothing is sent
+	sent := requests.Load()
+	_, err = admin.NodeStatus(ctx, "")
+	assert.Error(t, err)
+	assert.Error(t, admin.SetMaintenance(ctx, "", true))
+	assert.Equal(t, sent, requests.Load())
+}
+
+func TestSubmitWithOptions(t *testing.T) {
+	var submitted map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/submit" {
+			_ = json.NewDecoder(r.Body).Decode(&submitted)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	tx := &Transaction{client: client, gid: "g1"}
+	err := tx.SubmitWithOptions(context.Background(), &SubmitOptions{MaxParallelBranches: 2, Ordered: true, BranchTimeout: 3 * time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(2), submitted["max_parallel_branches"])
+	assert.Equal(t, true, submitted["ordered"])
+	assert.Equal(t, float64(3000), submitted["branch_timeout_ms"])
+
+	submitted = nil
+	assert.NoError(t, tx.Submit(context.Background()))
+	assert.NotContains(t, submitted, "ordered")
+
+	assert.Error(t, tx.SubmitWithOptions(context.Background(), &SubmitOptions{MaxParallelBranches: -1}))
+}
+
+func TestAbortExcept(t *testing.T) {
+	var aborted map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/abort":
+			_ = json.NewDecoder(r.Body).Decode(&aborted)
+		case "/api/tx/g1":
+			_, _ = w.Write([]byte(`{"gid":"g1","status":"ABORTED","skip_compensation":["notify"]}`))
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	tx := &Transaction{client: client, gid: "g1"}
+	assert.NoError(t, tx.AbortExcept(context.Background(), "notify"))
+	assert.Equal(t, []interface{}{"notify"}, aborted["skip_compensation"])
+
+	info, err := tx.GetInfo(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"notify"}, info.SkippedBranches)
+
+	aborted = nil
+	assert.NoError(t, tx.Abort(context.Background()))
+	assert.NotContains(t, aborted, "skip_compensation")
+}
+
+func TestNumericGIDsAndTimestamps(t *testing.T) {
+	var info TransactionInfo
+	err := json.Unmarshal([]byte(`{"gid":1782654321987654321,"status":"SUBMITTED","created_unix":1.7e12,"updated_unix":"1700000000123"}`), &info)
+	assert.NoError(t, err)
+	assert.Equal(t, "1782654321987654321", info.GID)
+	assert.Equal(t, int64(1700000000000), info.CreatedUnix)
+	assert.Equal(t, int64(1700000000123), info.UpdatedUnix)
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"gid":"abc","created_unix":5}`), &info))
+	assert.Equal(t, "abc", info.GID)
+	assert.Error(t, json.Unmarshal([]byte(`{"gid":"abc","created_unix":1.5}`), &info))
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"gid":"abc","parent_gid":1782654321987654322,"deadline_unix":"1700000000456"}`), &info))
+	assert.Equal(t, "1782654321987654322", info.ParentGID)
+	assert.Equal(t, int64(1700000000456), info.DeadlineUnix)
+
+	var cmd BranchCommand
+	assert.NoError(t, json.Unmarshal([]byte(`{"gid":1782654321987654321,"branch_id":7,"resource_id":"orders","application_data":"eA=="}`), &cmd))
+	assert.Equal(t, BranchCommand{GID: "1782654321987654321", BranchID: "7", ResourceID: "orders", ApplicationData: []byte("x")}, cmd)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"gid":9007199254740993}`))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	tx, err := client.StartTransaction(context.Background(), ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "9007199254740993", tx.GetGID())
+}
+
+func TestExecuteSagaT(t *testing.T) {
+	type order struct {
+		ID string `json:"id"`
+	}
+	type receipt struct {
+		Charged   int    `json:"charged"`
+		Shipment  string `json:"shipment"`
+		Published bool
+	}
+
+	var payload []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/start":
+			var req struct {
+				Payload []int `json:"payload"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			for _, v := range req.Payload {
+				payload = append(payload, byte(v))
+			}
+			_, _ = w.Write([]byte(`{"gid":"g1"}`))
+		case "/api/tx/g1":
+			charge, _ := json.Marshal([]byte(`{"charged":100}`))
+			ship, _ := json.Marshal([]byte(`{"shipment":"S-1"}`))
+			_, _ = w.Write([]byte(`{"gid":"g1","status":"COMMITTED","branches":[` +
+				`{"branch_id":"charge","application_data":` + string(charge) + `},` +
+				`{"branch_id":"ship","application_data":` + string(ship) + `}]}`))
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	workflow := &TypedSagaWorkflow[order, receipt]{}
+	workflow.AddStep("charge", "http://svc/charge", "http://svc/refund", nil)
+	workflow.AddStep("ship", "http://svc/ship", "http://svc/unship", nil)
+	workflow.AddStep("publish", "http://svc/publish", "http://svc/unpublish", func(out *receipt, data []byte) error {
+		out.Published = true
+		return nil
+	})
+
+	out, err := ExecuteSagaT(context.Background(), NewSagaManager(client), workflow, order{ID: "o-1"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, receipt{Charged: 100, Shipment: "S-1", Published: true}, out)
+	assert.JSONEq(t, `{"id":"o-1"}`, string(payload))
+}
+
+func TestCancelSaga(t *testing.T) {
+	var cancelled map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/cancel" {
+			_ = json.NewDecoder(r.Body).Decode(&cancelled)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	assert.NoError(t, client.CancelSaga(context.Background(), "g1"))
+	assert.Equal(t, "g1", cancelled["gid"])
+	assert.Error(t, client.CancelSaga(context.Background(), ""))
+	tcc := &Transaction{client: client, gid: "g2", mode: ModeTCC}
+	assert.ErrorContains(t, tcc.CancelSaga(context.Background()), "only sagas")
+}
+
+func TestCancelSagaEscalation(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/api/cancel" && failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	store := NewMemoryWorkflowStore()
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	config.AbortEscalation = &AbortEscalationConfig{Store: store, RetryInterval: 10 * time.Millisecond}
+	config.Authorizer = AuthorizerFunc(func(ctx context.Context, req *AuthorizationRequest) error {
+		if req.GID == "denied" {
+			return errors.New("not allowed")
+		}
+		return nil
+	})
+	client := NewClient(config)
+	defer client.Close()
+	ctx := context.Background()
+
+	assert.ErrorIs(t, client.CancelSaga(ctx, "denied"), ErrUnauthorized)
+
+	err := client.CancelSaga(ctx, "g1")
+	assert.ErrorIs(t, err, ErrAbortQueued)
+	pending := client.PendingAborts()
+	assert.Len(t, pending, 1)
+	assert.True(t, pending[0].Cancel)
+
+	// A restarted client resumes the cancellation, not an abort
+	restarted := NewClient(config)
+	defer restarted.Close()
+	assert.Eventually(t, func() bool { return len(restarted.PendingAborts()) == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.True(t, restarted.PendingAborts()[0].Cancel)
+
+	failing.Store(false)
+	assert.Eventually(t, func() bool { return len(client.PendingAborts()) == 0 }, 2*time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotContains(t, paths, "/api/abort")
+}
+
+func TestExtendedStatuses(t *testing.T) {
+	assert.True(t, IsTerminal(StatusCommitted))
+	assert.True(t, IsTerminal(StatusTimeout))
+	assert.False(t, IsTerminal(StatusRollbacking))
+	assert.False(t, IsTerminal("SOMETHING_NEW"))
+
+	var mu sync.Mutex
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		status := StatusCommitting
+		if polls > 1 {
+			status = StatusCommitted
+		}
+		_, _ = w.Write([]byte(`{"gid":"g1","status":"` + status + `"}`))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	tx := &Transaction{client: client, gid: "g1"}
+	sm := NewSagaManager(client)
+	assert.NoError(t, sm.waitForCompletion(context.Background(), tx, &SagaWorkflow{}, DefaultExecutionOptions()))
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = ""
+	config.RequestTimeout = -time.Second
+	config.LBStrategy = "random"
+	err := config.Validate()
+	var configErr *ConfigError
+	assert.ErrorAs(t, err, &configErr)
+	assert.Len(t, configErr.Problems, 3)
+	assert.Contains(t, err.Error(), "HTTPEndpoint is empty")
+
+	config = DefaultConfig()
+	config.HTTPEndpoint = "ftp://localhost:36789"
+	config.GrpcEndpoint = "localhost"
+	_, err = NewClientE(config)
+	assert.ErrorAs(t, err, &configErr)
+	assert.Len(t, configErr.Problems, 2)
+
+	config = DefaultConfig()
+	config.HTTPEndpoint = "unix:///tmp/seata.sock"
+	client, err := NewClientE(config)
+	assert.NoError(t, err)
+	client.Close()
+
+	// NewClient still builds a client, warning about the problems once
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if !assert.NoError(t, err) {
+		return
+	}
+	os.Stdout = w
+	config = DefaultConfig()
+	config.GrpcEndpoint = ""
+	config.LBStrategy = "random"
+	client = NewClient(config)
+	os.Stdout = stdout
+	w.Close()
+	output, _ := io.ReadAll(r)
+	assert.NotNil(t, client)
+	client.Close()
+	assert.Equal(t, 1, strings.Count(string(output), "LBStrategy"), string(output))
+}
+
+func TestEndpointNormalization(t *testing.T) {
+	cases := []struct {
+		in, want string
+		tls      bool
+	}{
+		{"localhost:36789", "http://localhost:36789", false},
+		{"http://localhost:36789/", "http://localhost:36789", false},
+		{" https://tc.example.com/seata// ", "https://tc.example.com/seata", false},
+		{"localhost:36789", "https://localhost:36789", true},
+		{"http://localhost:36789", "https://localhost:36789", true},
+		{"unix:///tmp/seata.sock", "unix:///tmp/seata.sock", true},
+	}
+	for _, tc := range cases {
+		got, err := NormalizeHTTPEndpoint(tc.in, tc.tls)
+		assert.NoError(t, err, tc.in)
+		assert.Equal(t, tc.want, got, tc.in)
+	}
+	_, err := NormalizeHTTPEndpoint("ftp://host", false)
+	assert.Error(t, err)
+
+	for in, want := range map[string]string{
+		"localhost:36790":              "localhost:36790",
+		"grpc://localhost:36790/":      "localhost:36790",
+		"http://10.0.0.1:36790":        "10.0.0.1:36790",
+		":36790":                       "localhost:36790",
+		"unix:///tmp/seata.sock":       "unix:///tmp/seata.sock",
+		"grpcs://tc.example.com:443/":  "grpcs://tc.example.com:443",
+		"dns:///tc.svc:36790":          "dns:///tc.svc:36790",
+		"dns://10.0.0.53/tc.svc:36790": "dns://10.0.0.53/tc.svc:36790",
+	} {
+		got, err := NormalizeGrpcEndpoint(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err = NormalizeGrpcEndpoint("localhost")
+	assert.Error(t, err)
+	_, err = NormalizeGrpcEndpoint("dns:///tc.svc")
+	assert.Error(t, err)
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = "localhost:36789/"
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+	assert.Equal(t, "http://localhost:36789", config.HTTPEndpoint)
+}
+
+func TestRecordReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case r.URL.Path == "/api/start":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"gid": req["gid"]})
+		case strings.HasPrefix(r.URL.Path, "/api/tx/"):
+			gid := strings.TrimPrefix(r.URL.Path, "/api/tx/")
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"gid": gid, "status": StatusCommitted})
+		}
+	}))
+
+	run := func(wrap func(http.RoundTripper) http.RoundTripper) (*Transaction, *TransactionInfo, error) {
+		config := DefaultConfig()
+		config.HTTPEndpoint = server.URL
+		config.GrpcEndpoint = ""
+		config.MaxRetries = 0
+		config.WrapTransport = wrap
+		client := NewClient(config)
+		defer client.Close()
+
+		ctx := context.Background()
+		tx, err := client.StartTransaction(ctx, ModeSaga, []byte("p"))
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := tx.AddBranch(ctx, "b1", "http://svc/ok"); err != nil {
+			return nil, nil, err
+		}
+		if err := tx.Submit(ctx); err != nil {
+			return nil, nil, err
+		}
+		info, err := tx.GetInfo(ctx)
+		return tx, info, err
+	}
+
+	path := filepath.Join(t.TempDir(), "run.json")
+	recorder := NewRecorder(path)
+	_, _, err := run(recorder.Wrap)
+	assert.NoError(t, err)
+	assert.Len(t, recorder.Interactions(), 4)
+	assert.NoError(t, recorder.Save())
+	server.Close()
+
+	replay, err := LoadReplay(path)
+	assert.NoError(t, err)
+	tx, info, err := run(replay.Wrap)
+	assert.NoError(t, err)
+	assert.Equal(t, tx.GetGID(), info.GID)
+	assert.Equal(t, StatusCommitted, info.Status)
+	assert.Equal(t, 0, replay.Remaining())
+
+	// Nothing left to serve
+	_, _, err = run(replay.Wrap)
+	assert.Error(t, err)
+}
+
+func TestMetricsPush(t *testing.T) {
+	tc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"healthy"}`))
+	}))
+	defer tc.Close()
+
+	var mu sync.Mutex
+	var pushed map[string]interface{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		mu.Lock()
+		pushed = nil
+		_ = json.NewDecoder(r.Body).Decode(&pushed)
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = tc.URL
+	config.GrpcEndpoint = ""
+	config.MetricsPush = &MetricsPushConfig{Protocol: MetricsPushOTLP, Endpoint: collector.URL, Interval: time.Hour, Tags: map[string]string{"job": "batch"}}
+	client := NewClient(config)
+	_, _ = client.Health(context.Background())
+
+	// Close flushes a final push
+	assert.NoError(t, client.Close())
+	mu.Lock()
+	body, _ := json.Marshal(pushed)
+	mu.Unlock()
+	assert.Contains(t, string(body), `"seata_client_requests_total"`)
+	assert.Contains(t, string(body), `"asInt":"1"`)
+	assert.Contains(t, string(body), `"batch"`)
+
+	// StatsD sends deltas over UDP
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+	config = DefaultConfig()
+	config.HTTPEndpoint = tc.URL
+	config.GrpcEndpoint = ""
+	config.MetricsPush = &MetricsPushConfig{Protocol: MetricsPushStatsD, Endpoint: conn.LocalAddr().String(), Interval: time.Hour}
+	client = NewClient(config)
+	defer client.Close()
+	_, _ = client.Health(context.Background())
+	assert.NoError(t, client.PushMetrics(context.Background()))
+
+	buf := make([]byte, 2048)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Contains(t, string(buf[:n]), "seata_client.requests:1|c|#protocol:http,endpoint:"+tc.URL)
+
+	config.MetricsPush = &MetricsPushConfig{Protocol: "graphite"}
+	assert.Error(t, config.Validate())
+}
+
+func TestWatchdog(t *testing.T) {
+	var mu sync.Mutex
+	status := StatusSubmitted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/api/start":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"gid": req["gid"]})
+		case strings.HasPrefix(r.URL.Path, "/api/tx/"):
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"gid": strings.TrimPrefix(r.URL.Path, "/api/tx/"), "status": status})
+		}
+	}))
+	defer server.Close()
+
+	stuck := make(chan StuckTransaction, 4)
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.Watchdog = &WatchdogConfig{
+		SLA:           20 * time.Millisecond,
+		CheckInterval: 10 * time.Millisecond,
+		Grace:         time.Minute,
+		OnStuck:       func(s StuckTransaction) { stuck <- s },
+	}
+	client := NewClient(config)
+	defer client.Close()
+
+	tx, err := client.StartTransaction(context.Background(), ModeSaga, nil)
+	assert.NoError(t, err)
+	select {
+	case s := <-stuck:
+		assert.Equal(t, tx.GetGID(), s.GID)
+		assert.Equal(t, StatusSubmitted, s.Status)
+		assert.GreaterOrEqual(t, s.Age, 20*time.Millisecond)
+	case <-time.After(2 * time.Second):
+		t.Fatal("watchdog did not report the stuck transaction")
+	}
+
+	// Reported once, then forgotten when it finishes
+	mu.Lock()
+	status = StatusCommitted
+	mu.Unlock()
+	assert.Eventually(t, func() bool { return client.Watchdog().Tracked() == 0 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(1), client.Watchdog().Alerts())
+	assert.Empty(t, stuck)
+}
+
+func TestBranchQueryReconciliation(t *testing.T) {
+	var mu sync.Mutex
+	state := BranchStateTried
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/start":
+			var req map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"gid": req["gid"]})
+		case "/api/branch/try", "/api/branch/succeed":
+			// The participant acted but the response was lost
+			w.WriteHeader(http.StatusBadGateway)
+		case "/query":
+			assert.Equal(t, "b1", r.URL.Query().Get("branch_id"))
+			_, _ = w.Write([]byte(`{"state":"` + state + `"}`))
+			if state == BranchStateTried {
+				state = BranchStateConfirmed
+			}
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := NewClient(config)
+	defer client.Close()
+
+	tm := NewTCCManager(client)
+	workflow := CreateTCCWorkflow([]TCCStep{{
+		BranchID: "b1", Try: server.URL + "/try", Confirm: server.URL + "/confirm", Cancel: server.URL + "/cancel",
+		Query: server.URL + "/query",
+	}})
+	options := DefaultExecutionOptions()
+	options.ParallelBranches = false
+	assert.NoError(t, tm.ExecuteTCC(context.Background(), workflow, nil, options))
+	mu.Lock()
+	assert.NotContains(t, calls, "/api/branch/fail")
+	mu.Unlock()
+
+	// Without a recorded try the lost response leads to cancellation
+	mu.Lock()
+	state, calls = BranchStateNotFound, nil
+	mu.Unlock()
+	assert.Error(t, tm.ExecuteTCC(context.Background(), workflow, nil, options))
+	mu.Lock()
+	assert.Contains(t, calls, "/api/branch/fail")
+	mu.Unlock()
+}
+
+func TestWorkflowMigration(t *testing.T) {
+	migrator := NewWorkflowMigrator()
+	assert.NoError(t, migrator.Register(WorkflowMigration{
+		From:    1,
+		To:      2,
+		StepMap: map[string]string{"reserve": "reserve-stock"},
+		Removed: []string{"notify"},
+	}))
+	assert.Error(t, migrator.Register(WorkflowMigration{From: 1, To: 3}))
+	assert.Error(t, migrator.Register(WorkflowMigration{From: 3, To: 3}))
+
+	v2 := CreateSagaWorkflow([]SagaStep{
+		{BranchID: "reserve-stock", Action: "http://svc/reserve"},
+		{BranchID: "charge", Action: "http://svc/charge"},
+		{BranchID: "ship", Action: "http://svc/ship"},
+	})
+	v2.Version = 2
+
+	state := &WorkflowState{ID: "w1", Version: 1, Steps: []WorkflowStepState{
+		{BranchID: "reserve", Status: StepStatusSucceeded},
+		{BranchID: "notify", Status: StepStatusPending},
+		{BranchID: "charge", Status: StepStatusPending},
+	}}
+	assert.NoError(t, migrator.Migrate(state, v2.Version, v2.BranchIDs()))
+	assert.Equal(t, 2, state.Version)
+	assert.Equal(t, []WorkflowStepState{
+		{BranchID: "reserve-stock", Status: StepStatusSucceeded},
+		{BranchID: "charge", Status: StepStatusPending},
+		{BranchID: "ship", Status: StepStatusPending, UpdatedUnix: state.Steps[2].UpdatedUnix},
+		{BranchID: "notify", Status: StepStatusSkipped},
+	}, state.Steps)
+	assert.Equal(t, 1, state.NextPendingStep())
+
+	// No path from an unknown version, and no downgrades
+	assert.Error(t, migrator.Migrate(&WorkflowState{ID: "w2", Version: 0}, 2, nil))
+	assert.Error(t, migrator.Migrate(&WorkflowState{ID: "w3", Version: 3}, 2, nil))
+}
+
+func TestTransactionGuard(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.URL.Path)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+	tx := &Transaction{client: client, gid: "g1", mode: ModeTCC}
+
+	run := func(fail bool) (err error) {
+		done := tx.Guard(context.Background())
+		defer done(&err)
+		if fail {
+			return assert.AnError
+		}
+		return nil
+	}
+	last := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return calls[len(calls)-1]
+	}
+
+	assert.NoError(t, run(false))
+	assert.Equal(t, "/api/submit", last())
+	assert.ErrorIs(t, run(true), assert.AnError)
+	assert.Equal(t, "/api/abort", last())
+
+	assert.PanicsWithValue(t, "boom", func() {
+		var err error
+		done := tx.Guard(context.Background())
+		defer done(&err)
+		panic("boom")
+	})
+	assert.Equal(t, "/api/abort", last())
+	assert.Len(t, calls, 3)
+
+	// A nil errp still aborts on a panic or a done ctx, and otherwise leaves
+	// the transaction to the caller
+	assert.Panics(t, func() {
+		defer tx.Guard(context.Background())(nil)
+		panic("boom")
+	})
+	assert.Len(t, calls, 4)
+	tx.Guard(context.Background())(nil)
+	assert.Len(t, calls, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	tx.Guard(ctx)(nil)
+	assert.Len(t, calls, 5)
+	assert.Equal(t, "/api/abort", last())
+
+	// A done ctx aborts instead of submitting
+	err := func() (err error) {
+		defer tx.Guard(ctx)(&err)
+		return nil
+	}()
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, calls, 6)
+	assert.Equal(t, "/api/abort", last())
+}
+
+func TestTryAll(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var batch map[string]interface{}
+	reject := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/start":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"gid": req["gid"]})
+		case "/api/branch/try_batch":
+			batch = req
+			if reject {
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`{"results":[{"branch_id":"b1","result":"SUCCESS"},{"branch_id":"b2","result":"FAILURE","error":"out of stock"}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"results":[{"branch_id":"b1","result":"SUCCESS"},{"branch_id":"b2","result":"SUCCESS"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	tx := &Transaction{client: client, gid: "g1", mode: ModeTCC}
+	results, err := tx.TryAll(context.Background(), []TryItem{
+		{BranchID: "b1", Action: "http://svc/a/try", Payload: []byte("x")},
+		{BranchID: "b2", Action: "http://svc/b/try"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "g1", batch["gid"])
+	assert.Len(t, batch["items"], 2)
+
+	// A rejected batch fails the workflow without cancelling any branch
+	mu.Lock()
+	reject = true
+	mu.Unlock()
+	options := DefaultExecutionOptions()
+	options.BatchTry = true
+	workflow := CreateTCCWorkflow([]TCCStep{
+		{BranchID: "b1", Try: "http://svc/a/try", Confirm: "http://svc/a/confirm", Cancel: "http://svc/a/cancel"},
+		{BranchID: "b2", Try: "http://svc/b/try", Confirm: "http://svc/b/confirm", Cancel: "http://svc/b/cancel"},
+	})
+	err = NewTCCManager(client).ExecuteTCC(context.Background(), workflow, nil, options)
+	assert.ErrorIs(t, err, ErrBranchFailure)
+	var batchErr *BatchTryError
+	assert.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, []string{"b2"}, batchErr.Failed())
+	mu.Lock()
+	assert.NotContains(t, paths, "/api/branch/fail")
+	mu.Unlock()
+}
+
+func TestBackoffStrategies(t *testing.T) {
+	const samples = 20000
+	mean := func(next func() time.Duration, min, max time.Duration) time.Duration {
+		var total time.Duration
+		for i := 0; i < samples; i++ {
+			d := next()
+			assert.GreaterOrEqual(t, d, min)
+			assert.LessOrEqual(t, d, max)
+			total += d
+		}
+		return total / samples
+	}
+
+	assert.Equal(t, 50*time.Millisecond, ConstantBackoff{Interval: 50 * time.Millisecond}.Next(7, time.Second))
+
+	// Full jitter is uniform over [0, ceiling], so the mean is ceiling/2
+	exp := ExponentialBackoff{Base: 100 * time.Millisecond, Max: time.Second}
+	avg := mean(func() time.Duration { return exp.Next(2, 0) }, 0, 400*time.Millisecond)
+	assert.InDelta(t, float64(200*time.Millisecond), float64(avg), float64(15*time.Millisecond))
+	avg = mean(func() time.Duration { return exp.Next(30, 0) }, 0, time.Second)
+	assert.InDelta(t, float64(500*time.Millisecond), float64(avg), float64(30*time.Millisecond))
+
+	// Decorrelated jitter is uniform over [base, 3*prev], capped
+	dj := DecorrelatedJitterBackoff{Base: 100 * time.Millisecond, Max: 2 * time.Second}
+	avg = mean(func() time.Duration { return dj.Next(0, 0) }, 100*time.Millisecond, 300*time.Millisecond)
+	assert.InDelta(t, float64(200*time.Millisecond), float64(avg), float64(15*time.Millisecond))
+	avg = mean(func() time.Duration { return dj.Next(5, time.Second) }, 100*time.Millisecond, 2*time.Second)
+	assert.InDelta(t, float64(1050*time.Millisecond), float64(avg), float64(50*time.Millisecond))
+
+	// The default backoff never goes negative and honours a custom strategy
+	rm := NewRetryManager(&RetryConfig{RetryInterval: time.Millisecond, BackoffFactor: 2})
+	for i := 0; i < 1000; i++ {
+		assert.GreaterOrEqual(t, rm.calculateBackoff(3, 0), time.Duration(0))
+	}
+	rm = NewRetryManager(&RetryConfig{Backoff: ConstantBackoff{Interval: time.Second}})
+	assert.Equal(t, time.Second, rm.calculateBackoff(3, 0))
+}
+
+func TestRetryBudget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch r.URL.Path {
+		case "/api/start":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"gid": req["gid"]})
+		case "/api/branch/try":
+			w.WriteHeader(http.StatusTooEarly)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := NewClient(config)
+	defer client.Close()
+
+	workflow := CreateTCCWorkflow([]TCCStep{
+		{BranchID: "b1", Try: "http://svc/a/try", Confirm: "http://svc/a/confirm", Cancel: "http://svc/a/cancel", Validator: DTMResultValidator()},
+		{BranchID: "b2", Try: "http://svc/b/try", Confirm: "http://svc/b/confirm", Cancel: "http://svc/b/cancel", Validator: DTMResultValidator()},
+	})
+	options := DefaultExecutionOptions()
+	options.RetryConfig = &RetryConfig{MaxRetries: 10, Backoff: ConstantBackoff{Interval: time.Millisecond}}
+	options.RetryBudget = &RetryBudget{MaxRetries: 3}
+
+	result, err := NewTCCManager(client).ExecuteTCCWithResult(context.Background(), workflow, nil, options)
+	assert.ErrorIs(t, err, ErrRetryBudgetExhausted)
+	assert.NotEmpty(t, result.GID)
+	assert.Equal(t, 3, result.Retries)
+	assert.Equal(t, 3*time.Millisecond, result.RetryDelay)
+	assert.True(t, result.BudgetExhausted)
+}
+
+func TestHedgedReads(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+		_, _ = w.Write([]byte(`{"gid":"g1","status":"SUBMITTED"}`))
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"gid":"g1","status":"COMMITTED"}`))
+	}))
+	defer fast.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = slow.URL
+	config.GrpcEndpoint = ""
+	config.Hedging = &HedgeConfig{Delay: 20 * time.Millisecond}
+	client := NewClient(config)
+	defer client.Close()
+
+	// Without a second endpoint the read is not hedged
+	_, alternate := client.hedgeTargets()
+	assert.Empty(t, alternate)
+
+	client.lbMu.Lock()
+	client.httpAddrs = []string{slow.URL, fast.URL}
+	client.currentHTTP = slow.URL
+	client.lbMu.Unlock()
+
+	start := time.Now()
+	info, err := client.GetTransaction(context.Background(), "g1")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusCommitted, info.Status)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// The P95 of observed latencies drives the delay when none is configured
+	config.Hedging = &HedgeConfig{MinDelay: time.Millisecond, MaxDelay: time.Second}
+	assert.Equal(t, time.Second, client.hedgeDelay("http://unseen:1"))
+	for i := 0; i < 100; i++ {
+		client.stats.record("http", "http://seen:1", time.Duration(i+1)*time.Millisecond, nil)
+	}
+	assert.Equal(t, 95*time.Millisecond, client.hedgeDelay("http://seen:1"))
+}
+
+func TestWorkflowRegistry(t *testing.T) {
+	registry := NewWorkflowRegistry()
+
+	saga := &SagaWorkflow{}
+	saga.AddStep("step1", "http://svc/step1", "http://svc/step1/compensate")
+	assert.NoError(t, registry.RegisterSaga("order", saga))
+
+	// The registry keeps its own copy
+	saga.AddStep("step2", "http://svc/step2", "")
+	registered, ok := registry.Saga("order")
+	assert.True(t, ok)
+	assert.Len(t, registered.Steps, 1)
+
+	relative := &SagaWorkflow{}
+	relative.AddStep("step1", "/step1", "")
+	assert.Error(t, registry.RegisterSaga("relative", relative))
+	assert.Error(t, registry.RegisterTCC("empty", &TCCWorkflow{}))
+	_, ok = registry.TCC("empty")
+	assert.False(t, ok)
+
+	// Typed workflows are validated again after Steps change, also in place
+	typed := &TypedSagaWorkflow[struct{}, struct{}]{}
+	typed.AddStep("step1", "http://svc/step1", "", nil)
+	_, err := typed.validated()
+	assert.NoError(t, err)
+	typed.Steps[0].Action = ""
+	_, err = typed.validated()
+	assert.Error(t, err)
+	typed.Steps[0].Action = "http://svc/step1"
+	typed.AddStep("step1", "http://svc/again", "", nil)
+	_, err = typed.validated()
+	assert.Error(t, err)
+}
+
+func TestGetTransactions(t *testing.T) {
+	var batch atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/tx/batch":
+			if !batch.Load() {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`[{"gid":"g1","status":"COMMITTED"}]`))
+		case r.URL.Path == "/api/tx/g1":
+			_, _ = w.Write([]byte(`{"gid":"g1","status":"COMMITTED"}`))
+		case r.URL.Path == "/api/tx/g2":
+			_, _ = w.Write([]byte(`{"gid":"g2","status":"ABORTED"}`))
+		case r.URL.Path == "/api/tx/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := NewClient(config)
+	defer client.Close()
+
+	// Without a batch endpoint the gids are fetched one by one
+	txs, err := client.GetTransactions(context.Background(), []string{"g1", "g2", "unknown"})
+	assert.NoError(t, err)
+	assert.Len(t, txs, 2)
+	assert.Equal(t, StatusAborted, txs["g2"].Status)
+
+	txs, err = client.GetTransactions(context.Background(), []string{"g1", "broken"})
+	assert.Error(t, err)
+	assert.Contains(t, txs, "g1")
+
+	batch.Store(true)
+	txs, err = client.GetTransactions(context.Background(), []string{"g1", "unknown"})
+	assert.NoError(t, err)
+	assert.Equal(t, StatusCommitted, txs["g1"].Status)
+	assert.Len(t, txs, 1)
+}
+
+func TestCorrelationID(t *testing.T) {
+	var mu sync.Mutex
+	headers := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers[r.URL.Path] = r.Header.Get(CorrelationIDHeader)
+		mu.Unlock()
+		if r.URL.Path == "/api/start" {
+			_, _ = w.Write([]byte(`{"gid":"g1"}`))
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tx.CorrelationID())
+	assert.NoError(t, tx.AddBranch(ctx, "b1", "http://svc/b1"))
+	assert.Equal(t, tx.CorrelationID(), headers["/api/start"])
+	assert.Equal(t, tx.CorrelationID(), headers["/api/branch/add"])
+
+	// A correlation ID on the context is reused
+	tx, err = client.StartTransaction(WithCorrelationID(ctx, "req-42"), ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "req-42", tx.CorrelationID())
+
+	var buf bytes.Buffer
+	logger := ContextLogger(tx.BranchContext(ctx, "b1"), slog.New(slog.NewTextHandler(&buf, nil)))
+	logger.Info("trying")
+	assert.Contains(t, buf.String(), "correlation_id=req-42 gid=g1 branch_id=b1")
+}
+
+func TestSuccessStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/branch/try":
+			w.WriteHeader(http.StatusCreated)
+		case "/api/branch/succeed":
+			w.WriteHeader(http.StatusConflict)
+		case "/api/branch/fail":
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx := &Transaction{client: client, gid: "g1", mode: ModeTCC}
+
+	// Only 200 is a success by default, but 409 means already confirmed
+	assert.Error(t, tx.Try(ctx, "b1", "http://svc/try", nil))
+	assert.Error(t, tx.Cancel(ctx, "b1"))
+	assert.NoError(t, tx.Confirm(ctx, "b1"))
+
+	step := TCCStep{BranchID: "b1", Try: "http://svc/try", SuccessStatus: []StatusRange{{Min: 200, Max: 202}}}
+	assert.NoError(t, tx.TryWithValidator(ctx, "b1", step.Try, nil, stepValidator(step)))
+	assert.NoError(t, tx.cancelStep(ctx, step))
+
+	config.SuccessStatus = []StatusRange{{Min: 200, Max: 299}}
+	assert.NoError(t, tx.Try(ctx, "b1", "http://svc/try", nil))
+	assert.NoError(t, tx.Cancel(ctx, "b1"))
+
+	config.SuccessStatus = []StatusRange{{Min: 300, Max: 200}}
+	assert.Error(t, config.Validate())
+}
+
+func TestDebugTrace(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"gid":"g1","status":"COMMITTED","payload":"c2VjcmV0"}`))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.Redactor = NewJSONFieldRedactor("payload")
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err := client.GetTransaction(ctx, "g1")
+	assert.NoError(t, err)
+	assert.Empty(t, client.DebugTrace())
+
+	// A single call can be traced without enabling debug mode
+	_, err = client.GetTransaction(WithDebugTrace(ctx), "g1")
+	assert.NoError(t, err)
+	assert.Len(t, client.DebugTrace(), 1)
+
+	config.Debug = &DebugConfig{}
+	client = NewClient(config)
+	defer client.Close()
+	client.httpClient.SetHeader("Authorization", "Bearer token")
+	for i := 0; i < defaultDebugCapacity+5; i++ {
+		_, _ = client.GetTransaction(ctx, "g1")
+	}
+	trace := client.DebugTrace()
+	assert.Len(t, trace, defaultDebugCapacity)
+	entry := trace[len(trace)-1]
+	assert.Equal(t, http.MethodGet, entry.Method)
+	assert.Equal(t, http.StatusOK, entry.StatusCode)
+	assert.Contains(t, entry.URL, "/api/tx/g1")
+	assert.Contains(t, entry.ResponseBody, `"payload":"[REDACTED]"`)
+	assert.Equal(t, "[REDACTED]", entry.RequestHeaders.Get("Authorization"))
+
+	// Truncation never splits a multi-byte character
+	client.debug.maxBody = 4
+	truncated := client.debugBody("ab€cd")
+	assert.Equal(t, "ab...(truncated)", truncated)
+	assert.True(t, utf8.ValidString(truncated))
+}
+
+func TestTransportFailover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/start":
+			_, _ = w.Write([]byte(`{"gid":"g1"}`))
+		case "/api/tx/g1":
+			_, _ = w.Write([]byte(`{"gid":"g1","status":"SUBMITTED"}`))
+		}
+	}))
+	defer server.Close()
+
+	// Nothing listens on the gRPC endpoint, so gRPC calls fail with UNAVAILABLE
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	grpcAddr := listener.Addr().String()
+	listener.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = grpcAddr
+	client := NewClient(config)
+	defer client.Close()
+	assert.NotNil(t, client.grpcClient.client)
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "g1", tx.GetGID())
+	assert.NoError(t, tx.AddBranch(ctx, "b1", "http://svc/b1"))
+	assert.NoError(t, tx.Submit(ctx))
+	assert.NoError(t, tx.Abort(ctx))
+
+	config.PreferredTransport = TransportPreferGRPC
+	info, err := client.GetTransaction(ctx, "g1")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusSubmitted, info.Status)
+
+	// Errors from a reachable transport are not retried elsewhere
+	assert.False(t, transportUnavailable(status.Error(codes.NotFound, "no such transaction")))
+	assert.True(t, transportUnavailable(status.Error(codes.Unavailable, "connection refused")))
+
+	config.PreferredTransport = "carrier-pigeon"
+	assert.Error(t, config.Validate())
+}
+
+func TestTransportModes(t *testing.T) {
+	var httpCalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpCalls.Add(1)
+		_, _ = w.Write([]byte(`{"gid":"g1"}`))
+	}))
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	grpcAddr := listener.Addr().String()
+	listener.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = grpcAddr
+	config.Transport = TransportGRPCOnly
+	client := NewClient(config)
+	defer client.Close()
+
+	// gRPC is down and there is no silent fallback to HTTP
+	ctx := context.Background()
+	_, err = client.StartTransaction(ctx, ModeSaga, nil)
+	assert.ErrorContains(t, err, "gRPC-only mode")
+	_, err = client.Health(ctx)
+	assert.ErrorIs(t, err, ErrTransportDisabled)
+	assert.Zero(t, httpCalls.Load())
+
+	config = DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = grpcAddr
+	config.Transport = TransportHTTPOnly
+	client = NewClient(config)
+	defer client.Close()
+	assert.Nil(t, client.grpcClient.client)
+	_, err = client.StartTransaction(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+
+	config.PreferredTransport = TransportPreferGRPC
+	assert.Error(t, config.Validate())
+	config = DefaultConfig()
+	config.GrpcEndpoint = ""
+	config.Transport = TransportGRPCOnly
+	assert.Error(t, config.Validate())
+}
+
+func TestTransactionLabels(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, tx.SetLabel(ctx, "investigating", "true"))
+	assert.NoError(t, client.SetLabels(ctx, tx.GetGID(), map[string]string{"ticket": "ticket-1234"}))
+	assert.Error(t, tx.SetLabel(ctx, "", "x"))
+
+	labels, err := tx.Labels(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"investigating": "true", "ticket": "ticket-1234"}, labels)
+
+	assert.NoError(t, tx.DeleteLabel(ctx, "investigating"))
+	info, err := tx.GetInfo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"ticket": "ticket-1234"}, info.Labels)
+}
+
+func TestAbortEscalation(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/abort" && failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	store := NewMemoryWorkflowStore()
+	escalated := make(chan PendingAbort, 1)
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	config.AbortEscalation = &AbortEscalationConfig{
+		Store:             store,
+		RetryInterval:     10 * time.Millisecond,
+		EscalateAfter:     3,
+		OnAbortEscalation: func(p PendingAbort) { escalated <- p },
+	}
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx := &Transaction{client: client, gid: "g1", mode: ModeSaga}
+	err := tx.AbortExcept(ctx, "notify")
+	assert.ErrorIs(t, err, ErrAbortQueued)
+	assert.Len(t, client.PendingAborts(), 1)
+
+	pending, err := store.ListPending(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	select {
+	case p := <-escalated:
+		assert.Equal(t, "g1", p.GID)
+		assert.Equal(t, []string{"notify"}, p.SkipBranchIDs)
+		assert.GreaterOrEqual(t, p.Attempts, 3)
+	case <-time.After(2 * time.Second):
+		t.Fatal("abort was not escalated")
+	}
+
+	// Once the TC recovers the abort goes through and is cleared
+	failing.Store(false)
+	assert.Eventually(t, func() bool { return len(client.PendingAborts()) == 0 }, 2*time.Second, 10*time.Millisecond)
+	pending, err = store.ListPending(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// A restarted client resumes aborts persisted by its predecessor
+	failing.Store(true)
+	assert.Error(t, tx.Abort(ctx))
+	config.AbortEscalation.OnAbortEscalation = nil
+	restarted := NewClient(config)
+	defer restarted.Close()
+	assert.Eventually(t, func() bool { return len(restarted.PendingAborts()) == 1 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestRegisterCompensations(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	workflow := &SagaWorkflow{}
+	workflow.AddStep("reserve", server.URL+"/ok", server.URL+"/release")
+	workflow.AddStep("charge", server.URL+"/fail", server.URL+"/refund")
+
+	options := DefaultExecutionOptions()
+	options.Timeout = 5 * time.Second
+	options.RegisterCompensations = true
+	err := NewSagaManager(client).ExecuteSaga(context.Background(), workflow, nil, options)
+	assert.Error(t, err)
+
+	// The TC compensated the step that succeeded using the registered URL
+	assert.Len(t, server.Requests("/release"), 1)
+	assert.Empty(t, server.Requests("/refund"))
+	add := server.Requests("/api/branch/add")
+	assert.Len(t, add, 2)
+	assert.Contains(t, string(add[0].Body), server.URL+"/release")
+}
+
+func TestBranchOptions(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+
+	options := &BranchOptions{
+		Compensate: "http://svc/refund",
+		Timeout:    1500 * time.Millisecond,
+		MaxRetries: 2,
+		Headers:    map[string]string{"X-Tenant": "acme"},
+	}
+	assert.NoError(t, tx.AddBranchWithOptions(ctx, "charge", "http://svc/charge", options))
+	assert.NoError(t, tx.AddBranchWithOptions(ctx, "notify", "http://svc/notify", nil))
+	assert.Error(t, tx.AddBranchWithOptions(ctx, "bad", "http://svc/bad", &BranchOptions{MaxRetries: -1}))
+
+	add := server.Requests("/api/branch/add")
+	assert.Len(t, add, 2)
+	var req map[string]interface{}
+	assert.NoError(t, json.Unmarshal(add[0].Body, &req))
+	assert.Equal(t, "http://svc/refund", req["compensate"])
+	assert.Equal(t, float64(1500), req["timeout_ms"])
+	assert.Equal(t, float64(2), req["max_retries"])
+	assert.Equal(t, map[string]interface{}{"X-Tenant": "acme"}, req["headers"])
+	assert.NotContains(t, string(add[1].Body), "timeout_ms")
+	assert.Equal(t, "http://svc/refund", tx.GetBranches()[0].Compensate)
+}
+
+func TestSetPayload(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, []byte(`{"order":"o-1","items":3}`))
+	assert.NoError(t, err)
+
+	assert.NoError(t, tx.PatchPayload(ctx, []byte(`{"total":42,"items":null}`)))
+	assert.JSONEq(t, `{"order":"o-1","total":42}`, string(tx.GetPayload()))
+	stored, ok := server.Transaction(tx.GetGID())
+	assert.True(t, ok)
+	assert.JSONEq(t, `{"order":"o-1","total":42}`, string(stored.Payload))
+
+	// A rejected update leaves the local payload untouched
+	server.FailNext("/api/payload", seatatest.Response{Status: http.StatusInternalServerError})
+	assert.Error(t, tx.SetPayload(ctx, []byte(`{}`)))
+	assert.JSONEq(t, `{"order":"o-1","total":42}`, string(tx.GetPayload()))
+
+	assert.NoError(t, tx.Submit(ctx))
+	assert.Error(t, tx.SetPayload(ctx, []byte(`{}`)))
+}
+
+func TestProfileLabels(t *testing.T) {
+	options := &ExecutionOptions{Profile: true, WorkflowName: "checkout"}
+	ctx, end := startProfile(context.Background(), options, ModeTCC)
+	ctx = profileGID(ctx, options, "gid-1")
+
+	workflow, _ := pprof.Label(ctx, ProfileLabelWorkflow)
+	mode, _ := pprof.Label(ctx, ProfileLabelMode)
+	gid, _ := pprof.Label(ctx, ProfileLabelGID)
+	assert.Equal(t, "checkout", workflow)
+	assert.Equal(t, ModeTCC, mode)
+	assert.Equal(t, "gid-1", gid)
+	end()
+
+	// Without Profile the context is left alone
+	ctx, end = startProfile(context.Background(), &ExecutionOptions{}, ModeSaga)
+	ctx = profileGID(ctx, &ExecutionOptions{}, "gid-2")
+	_, ok := pprof.Label(ctx, ProfileLabelGID)
+	assert.False(t, ok)
+	end()
+}
+
+func TestForEachStepBounded(t *testing.T) {
+	var running, peak, calls atomic.Int64
+	err := forEachStep(1000, 4, func(i int) error {
+		calls.Add(1)
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Microsecond)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), calls.Load())
+	assert.LessOrEqual(t, peak.Load(), int64(4))
+
+	// No further steps start once one fails
+	calls.Store(0)
+	err = forEachStep(1000, 2, func(i int) error {
+		calls.Add(1)
+		if i == 10 {
+			return assert.AnError
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Less(t, calls.Load(), int64(1000))
+}
+
+func TestTCCCancelDiagnostics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/api/start":
+			_, _ = w.Write([]byte(`{"gid":"g1"}`))
+		case "/api/branch/try":
+			if bytes.Contains(body, []byte(`"b2"`)) {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		case "/api/branch/fail":
+			if bytes.Contains(body, []byte(`"b1"`)) {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := NewClient(config)
+	defer client.Close()
+
+	workflow := CreateTCCWorkflow(nil)
+	workflow.AddStep("b1", "http://svc/try1", "http://svc/confirm1", "http://svc/cancel1")
+	workflow.AddStep("b2", "http://svc/try2", "http://svc/confirm2", "http://svc/cancel2")
+	workflow.AddStep("b3", "http://svc/try3", "http://svc/confirm3", "http://svc/cancel3")
+	options := DefaultExecutionOptions()
+	options.ParallelBranches = false
+
+	result, err := NewTCCManager(client).ExecuteTCCWithResult(context.Background(), workflow, nil, options)
+	var tccErr *TCCError
+	assert.ErrorAs(t, err, &tccErr)
+	assert.Contains(t, err.Error(), "cancel failed for b1")
+	assert.Same(t, tccErr.Result, result.TCC)
+	assert.Contains(t, result.TCC.TryFailures, "b2")
+	assert.Len(t, result.TCC.TryFailures, 1)
+	assert.Equal(t, []string{"b2", "b3"}, result.TCC.Cancelled)
+	assert.Contains(t, result.TCC.CancelFailures, "b1")
+	assert.False(t, result.TCC.CleanupComplete())
+}
+
+func TestDuplicateBranchFinish(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	newTx := func(strict bool) (*Client, *Transaction) {
+		config := DefaultConfig()
+		config.HTTPEndpoint = server.URL
+		config.GrpcEndpoint = ""
+		config.StrictBranchFinish = strict
+		client := NewClient(config)
+		tx, err := client.StartTransaction(context.Background(), ModeTCC, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, tx.AddBranch(context.Background(), "b1", server.URL+"/ok"))
+		return client, tx
+	}
+	ctx := context.Background()
+
+	client, tx := newTx(false)
+	defer client.Close()
+	assert.NoError(t, tx.BranchSucceed(ctx, "b1"))
+	assert.NoError(t, tx.BranchSucceed(ctx, "b1"))
+	assert.ErrorIs(t, tx.BranchFail(ctx, "b1"), ErrBranchFinishConflict)
+
+	strictClient, strictTx := newTx(true)
+	defer strictClient.Close()
+	assert.NoError(t, strictTx.BranchFail(ctx, "b1"))
+	assert.ErrorIs(t, strictTx.BranchFail(ctx, "b1"), ErrBranchAlreadyFinished)
+}
+
+func TestLifecycleEvents(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	var mu sync.Mutex
+	var received []CloudEvent
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/cloudevents+json", r.Header.Get("Content-Type"))
+		var event CloudEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		mu.Lock()
+		received = append(received, event)
+		mu.Unlock()
+	}))
+	defer sink.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.Events = &EventsConfig{Sink: NewHTTPEventSink(sink.URL), Source: "orders"}
+	client := NewClient(config)
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.AddBranch(ctx, "b1", server.URL+"/ok"))
+	assert.NoError(t, tx.Abort(ctx))
+	client.Close() // delivers queued events
+
+	mu.Lock()
+	defer mu.Unlock()
+	var types []string
+	for _, event := range received {
+		types = append(types, event.Type)
+		assert.Equal(t, "1.0", event.SpecVersion)
+		assert.Equal(t, "orders", event.Source)
+		assert.Equal(t, tx.GetGID(), event.Subject)
+	}
+	assert.Equal(t, []string{EventTransactionStarted, EventBranchRegistered, EventTransactionAborted}, types)
+
+	var data EventData
+	assert.NoError(t, json.Unmarshal(received[1].Data, &data))
+	assert.Equal(t, "b1", data.BranchID)
+	assert.Equal(t, tx.CorrelationID(), data.CorrelationID)
+}
+
+// fakeNATS answers every published branch call with the result for its phase
+type fakeNATS struct {
+	mu        sync.Mutex
+	handler   func(map[string]string, []byte)
+	results   map[string]string // phase -> result body; missing means no reply
+	published []string
+}
+
+func (f *fakeNATS) Publish(ctx context.Context, subject string, header map[string]string, data []byte) error {
+	f.mu.Lock()
+	f.published = append(f.published, subject+" "+header[NATSHeaderPhase])
+	result, ok := f.results[header[NATSHeaderPhase]]
+	handler := f.handler
+	f.mu.Unlock()
+	if ok {
+		go handler(header, []byte(result))
+	}
+	return nil
+}
+
+func (f *fakeNATS) Subscribe(subject string, handler func(map[string]string, []byte)) (func(), error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handler = handler
+	return func() {}, nil
+}
+
+func TestNATSBranches(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	conn := &fakeNATS{results: map[string]string{"try": "SUCCESS", "confirm": "SUCCESS"}}
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.NATS = &NATSConfig{Conn: conn}
+	client := NewClient(config)
+	defer client.Close()
+
+	workflow := CreateTCCWorkflow(nil)
+	workflow.AddStep("stock", "nats://stock.try", "nats://stock.confirm", "nats://stock.cancel?timeout=50ms")
+	tm := NewTCCManager(client)
+	assert.NoError(t, tm.ExecuteTCC(context.Background(), workflow, nil, nil))
+	assert.Equal(t, []string{"stock.try try", "stock.confirm confirm"}, conn.published)
+
+	// A refused try is cancelled; the cancel result never arrives
+	conn.published = nil
+	conn.results = map[string]string{"try": "FAILURE"}
+	_, err := tm.ExecuteTCCWithResult(context.Background(), workflow, nil, nil)
+	assert.ErrorIs(t, err, ErrBranchFailure)
+	var tccErr *TCCError
+	assert.ErrorAs(t, err, &tccErr)
+	assert.ErrorIs(t, tccErr.Result.CancelFailures["stock"], context.DeadlineExceeded)
+	assert.Equal(t, []string{"stock.try try", "stock.cancel cancel"}, conn.published)
+}
+
+func TestOutboxRelay(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.AddBranch(ctx, "b1", server.URL+"/ok"))
+
+	outbox := NewMemoryOutbox()
+	assert.NoError(t, outbox.Enqueue(ctx, &OutboxEntry{GID: "missing", Kind: OutboxSubmit}))
+	assert.NoError(t, outbox.Enqueue(ctx, &OutboxEntry{GID: "missing", Kind: OutboxAbort}))
+	assert.NoError(t, outbox.Enqueue(ctx, &OutboxEntry{GID: tx.GetGID(), BranchID: "b1", Kind: OutboxBranchReport, Status: BranchStatusPrepared}))
+	assert.NoError(t, outbox.Enqueue(ctx, &OutboxEntry{GID: tx.GetGID(), Kind: OutboxSubmit}))
+
+	var failures int
+	relay := NewOutboxRelay(client, outbox, &OutboxRelayConfig{MaxAttempts: 1, OnError: func(*OutboxEntry, error) { failures++ }})
+	delivered, err := relay.RelayOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+	// The failed submit blocks the abort queued after it for the same gid
+	assert.Equal(t, 1, failures)
+	assert.Len(t, outbox.Failed(), 1)
+
+	assert.Eventually(t, func() bool {
+		info, err := client.GetTransaction(ctx, tx.GetGID())
+		return err == nil && info.Status == StatusCommitted
+	}, 2*time.Second, 20*time.Millisecond)
+
+	pending, _ := outbox.Pending(ctx, 10)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, OutboxAbort, pending[0].Kind)
+}
+
+func TestForkTransactionTree(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	parent, err := client.StartTransaction(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+	linked, err := parent.ForkWithOptions(ctx, ModeSaga, []byte("a"), &ForkOptions{CascadeAbort: true})
+	assert.NoError(t, err)
+	detached, err := parent.Fork(ctx, ModeSaga, []byte("b"))
+	assert.NoError(t, err)
+	grandchild, err := linked.Fork(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, parent.GetGID(), linked.ParentGID())
+
+	tree, err := client.GetTransactionTree(ctx, parent.GetGID())
+	assert.NoError(t, err)
+	assert.Equal(t, parent.GetGID(), tree.GID)
+	if assert.Len(t, tree.Children, 2) {
+		assert.Equal(t, linked.GetGID(), tree.Children[0].GID)
+		assert.Equal(t, parent.GetGID(), tree.Children[0].ParentGID)
+		if assert.Len(t, tree.Children[0].Children, 1) {
+			assert.Equal(t, grandchild.GetGID(), tree.Children[0].Children[0].GID)
+		}
+		assert.Empty(t, tree.Children[1].Children)
+	}
+
+	// Only the child forked with CascadeAbort follows the parent
+	assert.NoError(t, parent.Abort(ctx))
+	status := func(gid string) string {
+		info, _ := server.Transaction(gid)
+		return info.Status
+	}
+	assert.Equal(t, seatatest.StatusAborted, status(linked.GetGID()))
+	assert.NotEqual(t, seatatest.StatusAborted, status(detached.GetGID()))
+	assert.NotEqual(t, seatatest.StatusAborted, status(grandchild.GetGID()))
+}
+
+type mapObjectStore map[string][]byte
+
+func (m mapObjectStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, ok := m[key]
+	if !ok {
+		return nil, ErrArchivedTransactionNotFound
+	}
+	return data, nil
+}
+
+func (m mapObjectStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for key := range m {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func TestArchivedTransactions(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, []byte("order"))
+	assert.NoError(t, err)
+	// Unfinished transactions stay in hot storage
+	assert.Error(t, client.ArchiveTransaction(ctx, tx.GetGID()))
+	assert.NoError(t, tx.Abort(ctx))
+	assert.NoError(t, client.ArchiveTransaction(ctx, tx.GetGID()))
+
+	_, err = client.GetTransaction(ctx, tx.GetGID())
+	assert.Error(t, err)
+	archived, err := client.GetArchivedTransaction(ctx, tx.GetGID())
+	assert.NoError(t, err)
+	assert.Equal(t, "ABORTED", archived.Status)
+	assert.Equal(t, []byte("order"), archived.Payload)
+	list, err := client.ListArchivedTransactions(ctx, 0, 0, "ABORTED")
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	_, err = client.GetArchivedTransaction(ctx, "missing")
+	assert.ErrorIs(t, err, ErrArchivedTransactionNotFound)
+
+	// An exporter archive replaces the TC's archive API
+	store := mapObjectStore{}
+	for i, status := range []string{"COMMITTED", "ABORTED", "COMMITTED"} {
+		data, _ := json.Marshal(TransactionInfo{GID: "gid-" + strconv.Itoa(i), Status: status})
+		store["seata/gid-"+strconv.Itoa(i)+".json"] = data
+	}
+	config = DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.ArchiveStore = &ObjectArchive{Store: store, Prefix: "seata/"}
+	exported := NewClient(config)
+	defer exported.Close()
+
+	archived, err = exported.GetArchivedTransaction(ctx, "gid-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "ABORTED", archived.Status)
+	list, err = exported.ListArchivedTransactions(ctx, 1, 1, "COMMITTED")
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "gid-2", list[0].GID)
+	}
+	_, err = exported.GetArchivedTransaction(ctx, "gid-9")
+	assert.ErrorIs(t, err, ErrArchivedTransactionNotFound)
+}
+
+func TestContextKeys(t *testing.T) {
+	ctx := WithMode(WithBranchID(WithGID(context.Background(), "g1"), "b1"), ModeTCC)
+	assert.Equal(t, "g1", GIDFromContext(ctx))
+	assert.Equal(t, "b1", BranchIDFromContext(ctx))
+	assert.Equal(t, ModeTCC, ModeFromContext(ctx))
+	assert.Empty(t, CorrelationIDFromContext(ctx))
+
+	header := http.Header{}
+	HeadersFromContext(WithCorrelationID(ctx, "c1"), header.Set)
+	assert.Equal(t, "g1", header.Get(HeaderGID))
+	assert.Equal(t, "c1", header.Get(HeaderCorrelationID))
+
+	var seen context.Context
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Context()
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/confirm", nil)
+	req.Header = header
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "g1", GIDFromContext(seen))
+	assert.Equal(t, "b1", BranchIDFromContext(seen))
+	assert.Equal(t, ModeTCC, ModeFromContext(seen))
+	assert.Equal(t, "c1", CorrelationIDFromContext(seen))
+
+	// Calls made with a transaction context carry the identifiers
+	server := seatatest.NewServer()
+	defer server.Close()
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	tx, err := client.StartTransaction(context.Background(), ModeSaga, nil)
+	assert.NoError(t, err)
+	_, err = client.httpClient.R().SetContext(tx.BranchContext(context.Background(), "b2")).Get(server.URL + "/ok")
+	assert.NoError(t, err)
+	calls := server.Requests("/ok")
+	if assert.Len(t, calls, 1) {
+		assert.Equal(t, tx.GetGID(), calls[0].Header.Get(HeaderGID))
+		assert.Equal(t, "b2", calls[0].Header.Get(HeaderBranchID))
+		assert.Equal(t, ModeSaga, calls[0].Header.Get(HeaderMode))
+	}
+}
+
+func TestConnectTransport(t *testing.T) {
+	var headers []http.Header
+	connectServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		body, _ := io.ReadAll(r.Body)
+		var out proto.Message
+		switch r.URL.Path {
+		case seata_proto.TransactionService_StartGlobal_FullMethodName:
+			var req seata_proto.StartGlobalRequest
+			_ = proto.Unmarshal(body, &req)
+			out = &seata_proto.StartGlobalResponse{Gid: req.Gid}
+		case seata_proto.TransactionService_Get_FullMethodName:
+			var req seata_proto.GetRequest
+			_ = proto.Unmarshal(body, &req)
+			txJSON, _ := json.Marshal(TransactionInfo{GID: req.Gid, Status: StatusSubmitted})
+			out = &seata_proto.GetResponse{TxnJson: txJSON}
+		case seata_proto.TransactionService_Abort_FullMethodName:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":"failed_precondition","message":"transaction already submitted"}`))
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		data, _ := proto.Marshal(out)
+		w.Header().Set("Content-Type", "application/proto")
+		_, _ = w.Write(data)
+	}))
+	defer connectServer.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = "http://127.0.0.1:1"
+	config.GrpcEndpoint = connectServer.URL
+	config.Transport = TransportConnect
+	config.PreferredTransport = TransportPreferGRPC
+	config.MaxRetries = 0
+	assert.NoError(t, config.Validate())
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+	info, err := client.GetTransaction(ctx, tx.GetGID())
+	assert.NoError(t, err)
+	assert.Equal(t, StatusSubmitted, info.Status)
+
+	// Connect errors surface as gRPC status errors
+	err = tx.Abort(ctx)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	if assert.NotEmpty(t, headers) {
+		assert.Equal(t, "1", headers[0].Get("Connect-Protocol-Version"))
+		assert.Equal(t, "application/proto", headers[0].Get("Content-Type"))
+		abort := headers[len(headers)-1]
+		assert.Equal(t, tx.GetGID(), abort.Get(HeaderGID))
+		assert.NotEmpty(t, abort.Get(CorrelationIDHeader))
+	}
+}
+
+func TestExecutionJournal(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	calls := map[string]int{}
+	// run executes the orchestration until the step named crashAt fails
+	run := func(crashAt string) (string, error) {
+		journal, err := NewFileJournal(dir)
+		assert.NoError(t, err)
+		exec, err := NewExecution(ctx, journal, "order-42")
+		if err != nil {
+			return "", err
+		}
+		tx, err := exec.StartTransaction(ctx, client, ModeSaga, []byte("order"))
+		if err != nil {
+			return "", err
+		}
+		for _, step := range []string{"reserve", "charge", "ship"} {
+			_, err := exec.Step(ctx, step, []byte(tx.GetGID()), func(ctx context.Context) ([]byte, error) {
+				calls[step]++
+				if step == crashAt {
+					return nil, assert.AnError
+				}
+				return []byte(step + "-ok"), nil
+			})
+			if err != nil {
+				return tx.GetGID(), err
+			}
+		}
+		return tx.GetGID(), nil
+	}
+
+	gid, err := run("ship")
+	assert.ErrorIs(t, err, assert.AnError)
+	resumed, err := run("")
+	assert.NoError(t, err)
+	assert.Equal(t, gid, resumed)
+	assert.Equal(t, map[string]int{"reserve": 1, "charge": 1, "ship": 2}, calls)
+	assert.Len(t, server.Requests("/api/start"), 1)
+
+	// A partial line left by a crash mid-append is dropped
+	f, err := os.OpenFile(filepath.Join(dir, "order-42.jsonl"), os.O_WRONLY|os.O_APPEND, 0o644)
+	assert.NoError(t, err)
+	_, _ = f.WriteString(`{"seq":5,"st`)
+	f.Close()
+	journal, _ := NewFileJournal(dir)
+	entries, err := journal.Load(ctx, "order-42")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 4)
+
+	// Replaying a different step sequence is detected
+	exec, err := NewExecution(ctx, journal, "order-42")
+	assert.NoError(t, err)
+	assert.True(t, exec.Replaying())
+	_, err = exec.Step(ctx, "refund", nil, func(ctx context.Context) ([]byte, error) { return nil, nil })
+	assert.ErrorIs(t, err, ErrNonDeterministic)
+}
+
+func TestGIDNamespaces(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.GIDPrefix = "orders-"
+	config.EnforceGIDPrefix = true
+	config.GIDValidator = func(gid string) error {
+		if len(gid) > 64 {
+			return assert.AnError
+		}
+		return nil
+	}
+	assert.NoError(t, config.Validate())
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, []byte("order"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(tx.GetGID(), "orders-"))
+
+	attached, err := client.AttachTransaction(ctx, tx.GetGID())
+	assert.NoError(t, err)
+	assert.Equal(t, ModeSaga, attached.GetMode())
+	assert.Equal(t, []byte("order"), attached.payload)
+
+	_, err = client.AttachTransaction(ctx, "billing-1")
+	assert.ErrorIs(t, err, ErrInvalidGID)
+	_, err = client.AttachTransaction(ctx, "orders-"+strings.Repeat("x", 64))
+	assert.ErrorIs(t, err, ErrInvalidGID)
+
+	// Other teams' transactions are skipped when exporting by prefix
+	otherConfig := DefaultConfig()
+	otherConfig.HTTPEndpoint = server.URL
+	otherConfig.GrpcEndpoint = ""
+	other := NewClient(otherConfig)
+	defer other.Close()
+	_, err = other.StartTransaction(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+	var out bytes.Buffer
+	n, err := client.ExportTransactions(ctx, &out, ExportFormatJSONL, &ExportFilter{PageSize: 10, GIDPrefix: "orders-"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	config.GIDPrefix = "bad prefix/"
+	assert.Error(t, config.Validate())
+}
+
+func TestTransactionHeartbeat(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeTCC, nil)
+	assert.NoError(t, err)
+	deadline, err := tx.KeepAlive(ctx, time.Hour)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), deadline, 2*time.Second)
+	info, err := client.GetTransaction(ctx, tx.GetGID())
+	assert.NoError(t, err)
+	assert.Equal(t, deadline.Unix(), info.DeadlineUnix)
+
+	keepAlives := "/api/tx/" + tx.GetGID() + "/keepalive"
+	stop := tx.StartHeartbeat(ctx, &HeartbeatConfig{Interval: 10 * time.Millisecond})
+	time.Sleep(80 * time.Millisecond)
+	stop()
+	time.Sleep(20 * time.Millisecond)
+	sent := len(server.Requests(keepAlives))
+	assert.GreaterOrEqual(t, sent, 3)
+	time.Sleep(30 * time.Millisecond)
+	assert.Len(t, server.Requests(keepAlives), sent)
+
+	// Keep-alives of a finished transaction are reported
+	assert.NoError(t, tx.Abort(ctx))
+	failed := make(chan error, 10)
+	stop = tx.StartHeartbeat(ctx, &HeartbeatConfig{Interval: 10 * time.Millisecond, OnError: func(gid string, err error) { failed <- err }})
+	select {
+	case err := <-failed:
+		assert.Contains(t, err.Error(), "failed to keep transaction alive")
+	case <-time.After(time.Second):
+		t.Error("heartbeat error not reported")
+	}
+	stop()
+	tx.StartHeartbeat(ctx, nil)()
+}
+
+func TestParticipantCircuitBreaker(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+	var badCalls atomic.Int32
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		badCalls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer bad.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := NewClient(config)
+	defer client.Close()
+
+	workflow := CreateTCCWorkflow(nil)
+	workflow.AddStep("stock", server.URL+"/tcc/stock/try", server.URL+"/tcc/stock/confirm", server.URL+"/tcc/stock/cancel")
+	workflow.AddStep("pay", bad.URL+"/try", bad.URL+"/confirm", bad.URL+"/cancel")
+	options := DefaultExecutionOptions()
+	options.ParallelBranches = false
+	options.CircuitBreaker = &CircuitBreakerConfig{FailureThreshold: 2, RecoveryTimeout: time.Hour, HalfOpenMaxCalls: 1}
+
+	ctx := context.Background()
+	tm := NewTCCManager(client)
+	for i := 0; i < 2; i++ {
+		err := tm.ExecuteTCC(ctx, workflow, nil, options)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+	assert.Equal(t, CircuitBreakerOpen, client.ParticipantState(bad.URL+"/try"))
+	assert.Equal(t, CircuitBreakerClosed, client.ParticipantState(server.URL+"/tcc/stock/try"))
+
+	// The open participant is not called and the reserved branches are
+	// cancelled
+	called := badCalls.Load()
+	err := tm.ExecuteTCC(ctx, workflow, nil, options)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, called, badCalls.Load())
+	requests := server.Requests("/tcc/")
+	assert.Equal(t, "/tcc/stock/cancel", requests[len(requests)-1].Path)
+
+	// Sagas calling the participant are not started at all
+	started := len(server.Requests("/api/start"))
+	saga := CreateSagaWorkflow([]SagaStep{{BranchID: "pay", Action: bad.URL + "/pay", Compensate: bad.URL + "/refund"}})
+	err = NewSagaManager(client).ExecuteSaga(ctx, saga, nil, options)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Len(t, server.Requests("/api/start"), started)
+
+	// Without a breaker config every call goes through
+	options.CircuitBreaker = nil
+	err = tm.ExecuteTCC(ctx, workflow, nil, options)
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestSagaFallbackAction(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+	var gatewayCalls atomic.Int32
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gatewayCalls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer gateway.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	workflow := CreateSagaWorkflow([]SagaStep{
+		{BranchID: "pay", Action: gateway.URL + "/pay", Compensate: gateway.URL + "/refund",
+			FallbackAction: server.URL + "/alt/pay", FallbackCompensate: server.URL + "/alt/refund"},
+		{BranchID: "ship", Action: server.URL + "/ship", Compensate: server.URL + "/unship"},
+	})
+	options := DefaultExecutionOptions()
+	options.RegisterCompensations = true
+	options.CircuitBreaker = &CircuitBreakerConfig{FailureThreshold: 1, RecoveryTimeout: time.Hour}
+
+	// The TC falls back once the gateway fails
+	ctx := context.Background()
+	sm := NewSagaManager(client)
+	result, err := sm.ExecuteSagaWithResult(ctx, workflow, nil, options)
+	assert.NoError(t, err)
+	if assert.Len(t, result.Saga, 2) {
+		assert.Equal(t, SagaStepResult{BranchID: "pay", Status: BranchStatusSucceed, Action: server.URL + "/alt/pay", Path: SagaPathFallback}, result.Saga[0])
+		assert.Equal(t, SagaPathPrimary, result.Saga[1].Path)
+	}
+	assert.Equal(t, int32(1), gatewayCalls.Load())
+	assert.Equal(t, CircuitBreakerOpen, client.ParticipantState(gateway.URL))
+
+	// With the gateway's breaker open the fallback is registered directly
+	result, err = sm.ExecuteSagaWithResult(ctx, workflow, nil, options)
+	assert.NoError(t, err)
+	assert.Equal(t, SagaPathFallback, result.Saga[0].Path)
+	assert.Equal(t, int32(1), gatewayCalls.Load())
+	adds := server.Requests("/api/branch/add")
+	var add map[string]interface{}
+	assert.NoError(t, json.Unmarshal(adds[len(adds)-2].Body, &add))
+	assert.Equal(t, server.URL+"/alt/pay", add["action"])
+	assert.Equal(t, server.URL+"/alt/refund", add["compensate"])
+	assert.Nil(t, add["fallback"])
+}
+
+func TestMultiRegion(t *testing.T) {
+	us := seatatest.NewServer()
+	defer us.Close()
+	eu := seatatest.NewServer()
+	defer eu.Close()
+
+	config := DefaultConfig()
+	config.MaxRetries = 0
+	config.Regions = []RegionConfig{{Name: "us", HTTPEndpoint: us.URL}, {Name: "eu", HTTPEndpoint: eu.URL}}
+	config.LocalRegion = "us"
+	config.BranchPool = DefaultBranchPoolConfig()
+	config.Watchdog = &WatchdogConfig{SLA: time.Minute}
+	client, err := NewClientE(config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer client.Close()
+	assert.Equal(t, "us", client.Region())
+
+	ctx := context.Background()
+	local, err := client.StartTransaction(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(local.GetGID(), "us-"))
+	_, ok := us.Transaction(local.GetGID())
+	assert.True(t, ok)
+
+	// An outage of the local region moves new transactions to eu, where
+	// they stay
+	us.Close()
+	remote, err := client.StartTransaction(ctx, ModeSaga, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(remote.GetGID(), "eu-"))
+	assert.NoError(t, remote.AddBranch(ctx, "b1", eu.URL+"/ok"))
+	info, err := client.GetTransaction(ctx, remote.GetGID())
+	assert.NoError(t, err)
+	assert.Len(t, info.Branches, 1)
+	attached, err := client.AttachTransaction(ctx, remote.GetGID())
+	assert.NoError(t, err)
+	assert.Equal(t, "eu", client.ForGID(attached.GetGID()).Region())
+	assert.Same(t, client, client.ForGID(local.GetGID()))
+
+	// gid-based calls go to the region that owns the gid
+	assert.NoError(t, client.SetLabels(ctx, remote.GetGID(), map[string]string{"ticket": "42"}))
+	labels, err := client.GetLabels(ctx, remote.GetGID())
+	assert.NoError(t, err)
+	assert.Equal(t, "42", labels["ticket"])
+	found, err := client.GetTransactions(ctx, []string{remote.GetGID()})
+	assert.NoError(t, err)
+	assert.Contains(t, found, remote.GetGID())
+
+	// Remote clients share the local client's background components
+	assert.Same(t, client.branchPool, client.ForGID(remote.GetGID()).branchPool)
+	assert.Same(t, client.watchdog, client.ForGID(remote.GetGID()).watchdog)
+
+	config.LocalRegion = "ap"
+	assert.Error(t, config.Validate())
+}
+
+func TestMultiRegionLocalReplica(t *testing.T) {
+	us := seatatest.NewServer()
+	defer us.Close()
+	eu := seatatest.NewServer()
+	defer eu.Close()
+
+	var replicaHits atomic.Int32
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		replicaHits.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer replica.Close()
+
+	config := DefaultConfig()
+	config.MaxRetries = 0
+	config.Regions = []RegionConfig{{Name: "us", HTTPEndpoint: us.URL}, {Name: "eu", HTTPEndpoint: eu.URL}}
+	config.LocalRegion = "eu"
+	config.ReadEndpoints = []string{replica.URL}
+	config.Hedging = &HedgeConfig{Delay: time.Millisecond}
+	client, err := NewClientE(config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer client.Close()
+
+	// Reads of a remote region's gid go to that region, not to the local
+	// region's replica
+	eu.Close()
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(tx.GetGID(), "us-"))
+	_, err = client.GetTransaction(ctx, tx.GetGID())
+	assert.NoError(t, err)
+	assert.Zero(t, replicaHits.Load())
+	remote := client.ForGID(tx.GetGID())
+	assert.Nil(t, remote.config.ReadEndpoints)
+	assert.Nil(t, remote.config.Hedging)
+
+	config.LocalRegion = "ap"
+	assert.Error(t, config.Validate())
+}
+
+func TestReadReplicas(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	var replicaHits atomic.Int32
+	primary, _ := url.Parse(server.URL)
+	proxy := httputil.NewSingleHostReverseProxy(primary)
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		replicaHits.Add(1)
+		if r.URL.Path == "/metrics" {
+			w.Write([]byte("seata_transactions_total 1\n"))
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/lagging") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	}))
+	defer replica.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	config.ReadEndpoints = []string{replica.URL}
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = client.GetTransaction(ctx, tx.GetGID())
+	assert.NoError(t, err)
+	_, err = client.ListTransactions(ctx, 10, 0, "")
+	assert.NoError(t, err)
+	metrics, err := client.Metrics(ctx)
+	assert.NoError(t, err)
+	assert.Contains(t, metrics, "seata_transactions_total")
+	assert.Equal(t, int32(3), replicaHits.Load())
+
+	// Health always checks the primary
+	_, err = client.Health(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), replicaHits.Load())
+
+	// A replica that has not caught up, or is down, is bypassed
+	_, err = client.GetTransaction(ctx, "lagging")
+	assert.Error(t, err)
+	assert.Equal(t, int32(4), replicaHits.Load())
+	replica.Close()
+	_, err = client.GetTransaction(ctx, tx.GetGID())
+	assert.NoError(t, err)
+
+	config.ReadEndpoints = []string{"://bad"}
+	assert.Error(t, config.Validate())
+
+	// Replicas are TC URLs for the gRPC-only guard and are never read from
+	var standbyHits atomic.Int32
+	standby := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		standbyHits.Add(1)
+	}))
+	defer standby.Close()
+	config = DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.ReadEndpoints = []string{standby.URL}
+	config.Transport = TransportGRPCOnly
+	assert.Error(t, config.Validate())
+	grpcOnly := NewClient(config)
+	defer grpcOnly.Close()
+	assert.True(t, grpcOnly.isTCURL(standby.URL+"/api/transactions"))
+	assert.False(t, grpcOnly.isTCURL("http://example.invalid/api/transactions"))
+	_, err = grpcOnly.Metrics(ctx)
+	assert.Error(t, err)
+	assert.Zero(t, standbyHits.Load())
+}
+
+func TestExecutionTimings(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	workflow := CreateTCCWorkflow(nil)
+	workflow.AddStep("stock", server.URL+"/tcc/stock/try", server.URL+"/tcc/stock/confirm", server.URL+"/tcc/stock/cancel")
+	workflow.AddStep("pay", server.URL+"/tcc/pay/try", server.URL+"/tcc/pay/confirm", server.URL+"/tcc/pay/cancel")
+	options := DefaultExecutionOptions()
+	options.ParallelBranches = false
+
+	result, err := NewTCCManager(client).ExecuteTCCWithResult(context.Background(), workflow, nil, options)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var phases []string
+	for _, timing := range result.Timings {
+		phases = append(phases, timing.Phase+":"+timing.BranchID)
+		assert.False(t, timing.Failed)
+	}
+	assert.Equal(t, []string{"start:", "try:stock", "try:pay", "confirm:stock", "confirm:pay"}, phases)
+	assert.Equal(t, result.Timings[1].Duration+result.Timings[2].Duration, result.PhaseDuration(PhaseTry))
+
+	observed := map[string]int{}
+	result.ObserveTimings(func(phase, branchID string, seconds float64) {
+		observed[phase]++
+		assert.GreaterOrEqual(t, seconds, 0.0)
+	})
+	assert.Equal(t, map[string]int{PhaseStart: 1, PhaseTry: 2, PhaseConfirm: 2}, observed)
+
+	events := result.SpanEvents()
+	if assert.Len(t, events, 5) {
+		assert.Equal(t, "seata.try", events[1].Name)
+		assert.Equal(t, "stock", events[1].Attributes["seata.branch_id"])
+		assert.Equal(t, result.GID, events[1].Attributes["seata.gid"])
+		assert.False(t, events[1].Time.Before(result.Timings[1].Start))
+	}
+}
+
+func TestPreSubmitValidator(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	var policyCalls atomic.Int32
+	policy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policyCalls.Add(1)
+		var req SubmitRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Branches) > 1 {
+			http.Error(w, "at most one branch allowed", http.StatusForbidden)
+		}
+	}))
+	defer policy.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.PreSubmitValidator = RequireCompensations
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, tx.AddBranch(ctx, "b1", server.URL+"/ok"))
+	err = tx.Submit(ctx)
+	assert.ErrorIs(t, err, ErrSubmitVetoed)
+	assert.Contains(t, err.Error(), "b1")
+	assert.Empty(t, server.Requests("/api/submit"))
+
+	assert.NoError(t, tx.AddBranchWithCompensation(ctx, "b2", server.URL+"/ok", server.URL+"/undo"))
+	client.config.PreSubmitValidator = NewWebhookValidator(policy.URL, time.Second)
+	err = tx.Submit(ctx)
+	assert.ErrorIs(t, err, ErrSubmitVetoed)
+	assert.Contains(t, err.Error(), "at most one branch allowed")
+
+	tx2, err := client.StartTransaction(ctx, ModeSaga, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, tx2.AddBranch(ctx, "b1", server.URL+"/ok"))
+	assert.NoError(t, tx2.Submit(ctx))
+	assert.Equal(t, int32(2), policyCalls.Load())
+
+	policy.Close()
+	assert.ErrorIs(t, tx.Submit(ctx), ErrSubmitVetoed)
+}
+
+func TestRequireCompensationsSaga(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.PreSubmitValidator = RequireCompensations
+	client := NewClient(config)
+	defer client.Close()
+
+	// Compensations the orchestrator calls itself satisfy the validator
+	ctx := context.Background()
+	options := DefaultExecutionOptions()
+	options.Timeout = 5 * time.Second
+	workflow := &SagaWorkflow{}
+	workflow.AddStep("reserve", server.URL+"/ok", server.URL+"/release")
+	workflow.AddStep("charge", server.URL+"/ok", server.URL+"/refund")
+	assert.NoError(t, NewSagaManager(client).ExecuteSaga(ctx, workflow, nil, options))
+	assert.Len(t, server.Requests("/api/submit"), 1)
+
+	workflow.AddStep("notify", server.URL+"/ok", "")
+	err := NewSagaManager(client).ExecuteSaga(ctx, workflow, nil, options)
+	assert.ErrorIs(t, err, ErrSubmitVetoed)
+	assert.Contains(t, err.Error(), "notify")
+	assert.NotContains(t, err.Error(), "reserve")
+	assert.Len(t, server.Requests("/api/submit"), 1)
+}
+
+func TestOPAAuthorizer(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	// Only the checkout service may start TCC transactions; nobody aborts
+	var inputs []AuthorizationRequest
+	var mu sync.Mutex
+	opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input AuthorizationRequest `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		inputs = append(inputs, body.Input)
+		mu.Unlock()
+		in := body.Input
+		switch {
+		case in.Operation == AuthorizeAbort:
+			w.Write([]byte(`{"result": {"allow": false, "reason": "aborts go through the ops console"}}`))
+		case in.Mode == ModeTCC && in.Caller != "checkout":
+			w.Write([]byte(`{"result": false}`))
+		case in.Tags["team"] == "":
+			w.Write([]byte(`{}`))
+		default:
+			w.Write([]byte(`{"result": true}`))
+		}
+	}))
+	defer opa.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.Authorizer = NewOPAAuthorizer(opa.URL+"/v1/data/seata/authz", time.Second)
+	config.CallerIdentity = "reports"
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := WithAuthorizationTags(context.Background(), map[string]string{"team": "payments"})
+	_, err := client.StartTransaction(ctx, ModeTCC, []byte("order"))
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Empty(t, server.Requests("/api/start"))
+
+	checkout := WithCaller(ctx, "checkout")
+	tx, err := client.StartTransaction(checkout, ModeTCC, []byte("order"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, tx.Submit(checkout))
+	err = tx.Abort(checkout)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Contains(t, err.Error(), "ops console")
+
+	// An undefined decision denies
+	_, err = client.StartTransaction(context.Background(), ModeSaga, nil)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, inputs, 5) {
+		assert.Equal(t, "reports", inputs[0].Caller)
+		assert.Equal(t, 5, inputs[0].PayloadSize)
+		assert.NotEmpty(t, inputs[0].PayloadSHA256)
+		assert.Equal(t, AuthorizeSubmit, inputs[2].Operation)
+		assert.Equal(t, tx.GetGID(), inputs[2].GID)
+	}
+}
+
+func TestDuplicateAddBranch(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, tx.AddBranchWithCompensation(ctx, "b1", server.URL+"/ok", server.URL+"/undo"))
+
+	// An identical registration is a no-op
+	assert.NoError(t, tx.AddBranchWithCompensation(ctx, "b1", server.URL+"/ok", server.URL+"/undo"))
+	assert.Len(t, server.Requests("/api/branch/add"), 1)
+	assert.Len(t, tx.GetBranches(), 1)
+
+	assert.ErrorIs(t, tx.AddBranch(ctx, "b1", server.URL+"/ok"), ErrDuplicateBranch)
+	assert.ErrorIs(t, tx.AddBranchWithCompensation(ctx, "b1", server.URL+"/other", server.URL+"/undo"), ErrDuplicateBranch)
+	assert.Len(t, server.Requests("/api/branch/add"), 1)
+}
+
+func TestWaitForBranch(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, tx.AddBranch(ctx, "pay", server.URL+"/ok"))
+	assert.NoError(t, tx.AddBranch(ctx, "reserve", server.URL+"/fail"))
+
+	_, err = tx.WaitForBranch(ctx, "pay", BranchStatusSucceed, 100*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		tx.Submit(ctx)
+	}()
+	branch, err := tx.WaitForBranch(ctx, "pay", BranchStatusSucceed, 5*time.Second)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "pay", branch.BranchID)
+	}
+	_, err = tx.WaitForBranch(ctx, "reserve", BranchStatusSucceed, 5*time.Second)
+	assert.ErrorIs(t, err, ErrBranchStatusUnreachable)
+}
+
+func TestStepConditions(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	isMember := func(ctx context.Context, payload []byte, prior map[string]bool) bool {
+		return bytes.Contains(payload, []byte(`"member":true`))
+	}
+	afterLoyalty := func(ctx context.Context, payload []byte, prior map[string]bool) bool {
+		return prior["loyalty"]
+	}
+	ctx := context.Background()
+
+	saga := CreateSagaWorkflow([]SagaStep{
+		{BranchID: "order", Action: server.URL + "/ok", Compensate: server.URL + "/undo"},
+		{BranchID: "loyalty", Action: server.URL + "/ok", Compensate: server.URL + "/undo", Condition: isMember},
+		{BranchID: "badge", Action: server.URL + "/ok", Condition: afterLoyalty},
+	})
+	result, err := NewSagaManager(client).ExecuteSagaWithResult(ctx, saga, []byte(`{"member":false}`), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"loyalty", "badge"}, result.Skipped)
+	assert.Equal(t, SagaPathPrimary, result.Saga[0].Path)
+	assert.Equal(t, SagaPathSkipped, result.Saga[1].Path)
+	info, _ := server.Transaction(result.GID)
+	assert.Len(t, info.Branches, 1)
+
+	// A failing TCC never tries or cancels the skipped step
+	tcc := CreateTCCWorkflow(nil)
+	tcc.AddStep("order", server.URL+"/tcc/order/try", server.URL+"/tcc/order/confirm", server.URL+"/tcc/order/cancel")
+	tcc.Steps = append(tcc.Steps, TCCStep{BranchID: "loyalty", Try: server.URL + "/tcc/loyalty/try",
+		Confirm: server.URL + "/tcc/loyalty/confirm", Cancel: server.URL + "/tcc/loyalty/cancel", Condition: isMember})
+	tcc.AddStep("stock", server.URL+"/fail", server.URL+"/tcc/stock/confirm", server.URL+"/tcc/stock/cancel")
+	options := DefaultExecutionOptions()
+	options.ParallelBranches = false
+	tccResult, err := NewTCCManager(client).ExecuteTCCWithResult(ctx, tcc, []byte(`{"member":false}`), options)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"loyalty"}, tccResult.Skipped)
+	assert.Empty(t, server.Requests("/tcc/loyalty/"))
+	assert.NotEmpty(t, server.Requests("/tcc/order/cancel"))
+}
+
+func TestWorkflowIncludes(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	registry := NewWorkflowRegistry()
+	assert.NoError(t, registry.RegisterSaga("billing", CreateSagaWorkflow([]SagaStep{
+		{BranchID: "charge", Action: server.URL + "/ok", Compensate: server.URL + "/refund"},
+		{BranchID: "invoice", Action: server.URL + "/ok"},
+	})))
+	assert.NoError(t, registry.RegisterSaga("order", CreateSagaWorkflow([]SagaStep{
+		{BranchID: "reserve", Action: server.URL + "/ok"},
+		{BranchID: "billing", Include: "billing"},
+		{BranchID: "shipping", Include: "billing", IncludeAsChild: true},
+	})))
+
+	workflow, err := registry.ExpandSaga("order")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var branchIDs []string
+	for _, step := range workflow.Steps {
+		branchIDs = append(branchIDs, step.BranchID)
+	}
+	assert.Equal(t, []string{"reserve", "billing.charge", "billing.invoice", "shipping"}, branchIDs)
+
+	result, err := NewSagaManager(client).ExecuteSagaWithResult(context.Background(), workflow, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	parent, _ := server.Transaction(result.GID)
+	assert.Len(t, parent.Branches, 3)
+	tree, err := client.GetTransactionTree(context.Background(), result.GID)
+	if assert.NoError(t, err) && assert.Len(t, tree.Children, 1) {
+		assert.Equal(t, StatusCommitted, tree.Children[0].Status)
+		assert.Len(t, tree.Children[0].Branches, 2)
+	}
+
+	assert.NoError(t, registry.RegisterTCC("a", CreateTCCWorkflow([]TCCStep{{BranchID: "b", Include: "b"}})))
+	assert.NoError(t, registry.RegisterTCC("b", CreateTCCWorkflow([]TCCStep{{BranchID: "a", Include: "a"}})))
+	_, err = registry.ExpandTCC("a")
+	assert.ErrorContains(t, err, "cycle")
+}
+
+func TestPreflightCheck(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	options := DefaultExecutionOptions()
+	options.PreflightCheck = &PreflightConfig{Timeout: time.Second}
+	saga := CreateSagaWorkflow([]SagaStep{
+		{BranchID: "b1", Action: server.URL + "/ok"},
+		{BranchID: "b2", Action: down.URL + "/ship"},
+	})
+	err := NewSagaManager(client).ExecuteSaga(ctx, saga, nil, options)
+	assert.ErrorIs(t, err, ErrParticipantsUnreachable)
+	assert.Contains(t, err.Error(), down.URL+"/ship")
+	assert.NotContains(t, err.Error(), server.URL+"/ok")
+	assert.Empty(t, server.Requests("/api/start"))
+
+	tcc := CreateTCCWorkflow(nil)
+	tcc.AddStep("stock", server.URL+"/tcc/stock/try", server.URL+"/tcc/stock/confirm", server.URL+"/tcc/stock/cancel")
+	options.PreflightCheck = &PreflightConfig{HealthPath: "/health"}
+	_, err = NewTCCManager(client).ExecuteTCCWithResult(ctx, tcc, nil, options)
+	assert.NoError(t, err)
+
+	tcc.AddStep("pay", down.URL+"/try", down.URL+"/confirm", down.URL+"/cancel")
+	_, err = NewTCCManager(client).ExecuteTCCWithResult(ctx, tcc, nil, options)
+	assert.ErrorIs(t, err, ErrParticipantsUnreachable)
+	assert.Len(t, server.Requests("/api/start"), 1)
+}
+
+func TestDerivedBranchIDs(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	assert.Equal(t, DeriveBranchID("g1", 0, "http://svc/a"), DeriveBranchID("g1", 0, "http://svc/a"))
+	assert.NotEqual(t, DeriveBranchID("g1", 0, "http://svc/a"), DeriveBranchID("g1", 1, "http://svc/a"))
+	assert.NotEqual(t, DeriveBranchID("g1", 0, "http://svc/a"), DeriveBranchID("g2", 0, "http://svc/a"))
+
+	saga := CreateSagaWorkflow([]SagaStep{{Action: server.URL + "/ok"}, {Action: server.URL + "/ok"}})
+	assert.Error(t, saga.Validate())
+	saga.DeriveBranchIDs = true
+	assert.NoError(t, saga.Validate())
+
+	ctx := context.Background()
+	result, err := NewSagaManager(client).ExecuteSagaWithResult(ctx, saga, nil, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	info, _ := server.Transaction(result.GID)
+	if assert.Len(t, info.Branches, 2) {
+		for i, branch := range info.Branches {
+			assert.Equal(t, DeriveBranchID(result.GID, i, server.URL+"/ok"), branch.BranchID)
+			assert.Equal(t, branch.BranchID, result.Saga[i].BranchID)
+			assert.Equal(t, BranchStatusSucceed, result.Saga[i].Status)
+		}
+	}
+
+	tcc := &TCCWorkflow{DeriveBranchIDs: true}
+	tcc.AddStep("", server.URL+"/tcc/stock/try", server.URL+"/tcc/stock/confirm", server.URL+"/tcc/stock/cancel")
+	tcc.AddStep("pay", server.URL+"/tcc/pay/try", server.URL+"/tcc/pay/confirm", server.URL+"/tcc/pay/cancel")
+	tccResult, err := NewTCCManager(client).ExecuteTCCWithResult(ctx, tcc, nil, nil)
+	if assert.NoError(t, err) {
+		var tried []string
+		for _, timing := range tccResult.Timings {
+			if timing.Phase == PhaseTry {
+				tried = append(tried, timing.BranchID)
+			}
+		}
+		assert.ElementsMatch(t, []string{DeriveBranchID(tccResult.GID, 0, server.URL+"/tcc/stock/try"), "pay"}, tried)
+	}
+}
+
+func TestRequestErrorBody(t *testing.T) {
+	page := "<html>\n\t<body>" + strings.Repeat("Bad Gateway ", 1000) + "\xff\x00</body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	config.MaxErrorBody = 64
+	client := NewClient(config)
+	defer client.Close()
+
+	_, err := client.GetTransaction(context.Background(), "gid-1")
+	var reqErr *RequestError
+	if !assert.ErrorAs(t, err, &reqErr) {
+		return
+	}
+	assert.Equal(t, http.StatusBadGateway, reqErr.StatusCode)
+	assert.Equal(t, http.MethodGet, reqErr.Method)
+	assert.Contains(t, reqErr.URL, "/api/tx/gid-1")
+	assert.Equal(t, page, string(reqErr.Body()))
+	assert.Less(t, len(err.Error()), 200)
+	assert.Contains(t, err.Error(), "body: <html> <body>Bad Gateway")
+	assert.Contains(t, err.Error(), "more bytes)")
+
+	assert.Equal(t, "a b �", excerptBody([]byte("a\n\tb\x00\xff"), 64))
+	assert.Equal(t, "héé… (4 more bytes)", excerptBody([]byte("héééé"), 6))
+}
+
+func TestExecutionResultCodes(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	options := DefaultExecutionOptions()
+	options.ParallelBranches = false
+	options.RetryConfig = &RetryConfig{MaxRetries: 0}
+
+	tcc := CreateTCCWorkflow(nil)
+	tcc.AddStep("stock", server.URL+"/tcc/stock/try", server.URL+"/tcc/stock/confirm", server.URL+"/tcc/stock/cancel")
+	tcc.AddStep("pay", server.URL+"/fail", server.URL+"/tcc/pay/confirm", server.URL+"/tcc/pay/cancel")
+	err := NewTCCManager(client).ExecuteTCC(ctx, tcc, nil, options)
+	assert.ErrorIs(t, err, ErrTryPhaseFailed)
+	assert.NotErrorIs(t, err, ErrConfirmPhaseFailed)
+	assert.NotErrorIs(t, err, ErrCompensationFailed)
+	cancelFailed := &TCCError{Err: err, Result: &TCCResult{CancelFailures: map[string]error{"stock": errors.New("down")}}}
+	assert.ErrorIs(t, cancelFailed, ErrCompensationFailed)
+	assert.ErrorIs(t, cancelFailed, ErrTryPhaseFailed)
+
+	saga := CreateSagaWorkflow([]SagaStep{{BranchID: "b1", Action: server.URL + "/fail"}})
+	err = NewSagaManager(client).ExecuteSaga(ctx, saga, nil, options)
+	assert.ErrorIs(t, err, ErrSagaAborted)
+
+	compensate := func(ctx context.Context, step *SagaStep) error { return errors.New("refund service down") }
+	err = NewSagaManager(client).ExecuteSagaWithCompensation(ctx, saga, nil, compensate, options)
+	assert.ErrorIs(t, err, ErrCompensationFailed)
+
+	options.Timeout = 10 * time.Millisecond
+	saga = CreateSagaWorkflow([]SagaStep{{BranchID: "b1", Action: server.URL + "/ok"}})
+	err = NewSagaManager(client).ExecuteSaga(ctx, saga, nil, options)
+	assert.ErrorIs(t, err, ErrExecutionTimeout)
+}
+
+type traceIDKey struct{}
+
+func TestLatencyHistogramExemplars(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	var observed []string
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.LatencyHistograms = &HistogramConfig{
+		Buckets: []float64{60},
+		TraceID: func(ctx context.Context) string {
+			id, _ := ctx.Value(traceIDKey{}).(string)
+			return id
+		},
+		Observe: func(operation string, seconds float64, traceID string) {
+			observed = append(observed, operation+"/"+traceID)
+		},
+	}
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.WithValue(context.Background(), traceIDKey{}, "4bf92f3577b34da6a3ce929d0e0e4736")
+	tx, err := client.StartTransaction(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.AddBranch(ctx, "b1", server.URL+"/ok"))
+	assert.NoError(t, tx.Submit(ctx))
+	assert.Equal(t, []string{
+		"start/4bf92f3577b34da6a3ce929d0e0e4736",
+		"branch_add/4bf92f3577b34da6a3ce929d0e0e4736",
+		"submit/4bf92f3577b34da6a3ce929d0e0e4736",
+	}, observed)
+
+	recorder := httptest.NewRecorder()
+	client.MetricsHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, OpenMetricsContentType, recorder.Header().Get("Content-Type"))
+	body := recorder.Body.String()
+	assert.Contains(t, body, `seata_client_operation_duration_seconds_bucket{operation="start",le="60"} 1 # {trace_id="4bf92f3577b34da6a3ce929d0e0e4736"} `)
+	assert.Contains(t, body, `seata_client_operation_duration_seconds_bucket{operation="submit",le="+Inf"} 1`+"\n")
+	assert.Contains(t, body, `seata_client_operation_duration_seconds_count{operation="branch_add"} 1`)
+	assert.True(t, strings.HasSuffix(body, "# EOF\n"))
+}
+
+func TestGrpcVerifyService(t *testing.T) {
+	serve := func(register func(*grpc.Server)) string {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		server := grpc.NewServer()
+		register(server)
+		go server.Serve(listener)
+		t.Cleanup(server.Stop)
+		return listener.Addr().String()
+	}
+	config := DefaultGrpcConfig()
+	config.VerifyService = true
+	config.DialTimeout = 2 * time.Second
+
+	// Health reports the service as not serving
+	notServing := serve(func(s *grpc.Server) {
+		h := health.NewServer()
+		h.SetServingStatus(grpcServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+		healthpb.RegisterHealthServer(s, h)
+	})
+	client := &GrpcClient{config: config}
+	err := client.Connect(notServing)
+	assert.ErrorIs(t, err, ErrServiceNotServed)
+	assert.Contains(t, err.Error(), "NOT_SERVING")
+	_, err = client.Submit(context.Background(), "gid")
+	assert.ErrorIs(t, err, ErrServiceNotServed)
+
+	// Reflection lists other services only
+	otherService := serve(func(s *grpc.Server) {
+		healthpb.RegisterHealthServer(s, health.NewServer())
+		reflection.Register(s)
+	})
+	err = (&GrpcClient{config: config}).Connect(otherService)
+	assert.ErrorIs(t, err, ErrServiceNotServed)
+	assert.Contains(t, err.Error(), "grpc.health.v1.Health")
+
+	// Health reports it as serving
+	serving := serve(func(s *grpc.Server) {
+		h := health.NewServer()
+		h.SetServingStatus(grpcServiceName, healthpb.HealthCheckResponse_SERVING)
+		healthpb.RegisterHealthServer(s, h)
+	})
+	client = &GrpcClient{config: config}
+	assert.NoError(t, client.Connect(serving))
+	client.Close()
+}
+
+type sessionTestServer struct {
+	seata_proto.UnimplementedTransactionServiceServer
+	unary atomic.Int32
+}
+
+func (s *sessionTestServer) StartGlobal(ctx context.Context, req *seata_proto.StartGlobalRequest) (*seata_proto.StartGlobalResponse, error) {
+	s.unary.Add(1)
+	return &seata_proto.StartGlobalResponse{Gid: req.Gid}, nil
+}
+
+func (s *sessionTestServer) Submit(ctx context.Context, req *seata_proto.SubmitRequest) (*seata_proto.SubmitResponse, error) {
+	s.unary.Add(1)
+	return &seata_proto.SubmitResponse{}, nil
+}
+
+func TestGrpcStreamSession(t *testing.T) {
+	var streamed atomic.Int32
+	session := func(srv any, stream grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(stream)
+		if method != "/seata.txn.v1.TransactionService/Session" {
+			return status.Error(codes.Unimplemented, method)
+		}
+		for {
+			frame := new(anypb.Any)
+			if err := stream.RecvMsg(frame); err != nil {
+				return nil
+			}
+			streamed.Add(1)
+			message, _ := frame.UnmarshalNew()
+			var reply proto.Message = &seata_proto.SubmitResponse{}
+			switch req := message.(type) {
+			case *seata_proto.StartGlobalRequest:
+				reply = &seata_proto.StartGlobalResponse{Gid: req.Gid}
+			case *seata_proto.AbortRequest:
+				reply = status.New(codes.NotFound, "no transaction "+req.Gid).Proto()
+			}
+			out, _ := anypb.New(reply)
+			if err := stream.SendMsg(out); err != nil {
+				return err
+			}
+		}
+	}
+	serve := func(options ...grpc.ServerOption) (string, *sessionTestServer) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		impl := &sessionTestServer{}
+		server := grpc.NewServer(options...)
+		seata_proto.RegisterTransactionServiceServer(server, impl)
+		go server.Serve(listener)
+		t.Cleanup(server.Stop)
+		return listener.Addr().String(), impl
+	}
+	config := DefaultGrpcConfig()
+	config.StreamSession = true
+	ctx := context.Background()
+
+	addr, impl := serve(grpc.UnknownServiceHandler(session))
+	client := NewGrpcClientWithConfig(addr, config)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			gid := "gid-" + strconv.Itoa(i)
+			resp, err := client.StartGlobal(ctx, gid, ModeSaga, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, gid, resp.GetGid())
+		}(i)
+	}
+	wg.Wait()
+	_, err := client.Submit(ctx, "gid-1")
+	assert.NoError(t, err)
+	_, err = client.Abort(ctx, "missing")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, int32(22), streamed.Load())
+	assert.Equal(t, int32(0), impl.unary.Load())
+	client.Close()
+
+	// A TC without the session stream is called unary
+	addr, impl = serve()
+	client = NewGrpcClientWithConfig(addr, config)
+	defer client.Close()
+	resp, err := client.StartGlobal(ctx, "gid-1", ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "gid-1", resp.GetGid())
+	_, err = client.Submit(ctx, "gid-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), impl.unary.Load())
+}
+
+func TestTransactionHandoff(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	api := NewClient(config)
+	defer api.Close()
+	worker := NewClient(config)
+	defer worker.Close()
+
+	ctx := WithCorrelationID(context.Background(), "order-42")
+	tx, err := api.StartTransaction(ctx, ModeSaga, []byte(`{"order":42}`))
+	assert.NoError(t, err)
+	assert.NoError(t, tx.AddBranchWithCompensation(ctx, "stock", server.URL+"/ok", server.URL+"/ok?undo"))
+	data, err := tx.MarshalBinary()
+	assert.NoError(t, err)
+
+	restored, err := worker.RestoreTransaction(data)
+	assert.NoError(t, err)
+	assert.Equal(t, tx.GetGID(), restored.GetGID())
+	assert.Equal(t, tx.payload, restored.payload)
+	assert.Equal(t, "order-42", restored.correlationID)
+	// The handed-over branch is known, so adding it again is a no-op
+	assert.NoError(t, restored.AddBranchWithCompensation(ctx, "stock", server.URL+"/ok", server.URL+"/ok?undo"))
+	assert.NoError(t, restored.AddBranch(ctx, "pay", server.URL+"/ok"))
+	assert.NoError(t, restored.Submit(ctx))
+
+	stored, ok := server.Transaction(tx.GetGID())
+	assert.True(t, ok)
+	assert.Len(t, stored.Branches, 2)
+
+	_, err = worker.RestoreTransaction([]byte(`{"v":99,"gid":"x"}`))
+	assert.ErrorContains(t, err, "unsupported version")
+}
+
+func TestTCCStepTimeout(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/try" {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(500 * time.Millisecond):
+		}
+	}))
+	defer slow.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := NewClient(config)
+	defer client.Close()
+
+	workflow := CreateTCCWorkflow([]TCCStep{
+		{BranchID: "stock", Try: server.URL + "/tcc/stock/try", Confirm: server.URL + "/tcc/stock/confirm", Cancel: server.URL + "/tcc/stock/cancel"},
+		{BranchID: "pay", Try: slow.URL + "/try", Confirm: slow.URL + "/confirm", Cancel: slow.URL + "/cancel", Timeout: 50 * time.Millisecond},
+	})
+	options := DefaultExecutionOptions()
+	options.ParallelBranches = false
+
+	started := time.Now()
+	result, err := NewTCCManager(client).ExecuteTCCWithResult(context.Background(), workflow, nil, options)
+	assert.Less(t, time.Since(started), 400*time.Millisecond)
+	assert.ErrorIs(t, err, ErrStepTimeout)
+	assert.ErrorIs(t, err, ErrTryPhaseFailed)
+	assert.Equal(t, []string{"pay"}, result.TCC.TimedOut)
+	assert.ErrorIs(t, result.TCC.TryFailures["pay"], ErrStepTimeout)
+	assert.Contains(t, result.TCC.Cancelled, "stock")
+	assert.Contains(t, result.TCC.Cancelled, "pay")
+	assert.Empty(t, result.TCC.CancelFailures)
+	assert.Len(t, server.Requests("/api/branch/fail"), 2, "each branch is failed once, by the cancel phase")
+
+	stored, _ := server.Transaction(result.GID)
+	for _, branch := range stored.Branches {
+		if branch.BranchID == "pay" {
+			assert.Equal(t, "FAILED", branch.Status)
+		}
+	}
+}
+
+func TestConfigProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seata.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{
+		"default": "dev",
+		"common": {"request_timeout": "5s", "gid_prefix": "orders-"},
+		"profiles": {
+			"dev": {"http_endpoint": "http://localhost:36789"},
+			"prod": {
+				"http_endpoint": "https://tc.prod:36789",
+				"grpc_endpoint": "tc.prod:36790",
+				"request_timeout": "2s",
+				"max_retries": 0,
+				"auth_token": "${SEATA_TEST_TOKEN}",
+				"tls": {"server_name": "tc.prod"}
+			}
+		}
+	}`), 0o600))
+	t.Setenv("SEATA_TEST_TOKEN", "secret")
+
+	t.Setenv(ProfileEnvVar, "")
+	config, err := LoadProfile(path, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:36789", config.HTTPEndpoint)
+	assert.Equal(t, 5*time.Second, config.RequestTimeout)
+	assert.Equal(t, "orders-", config.GIDPrefix)
+	assert.Equal(t, 3, config.MaxRetries)
+	assert.Nil(t, config.TLS)
+
+	t.Setenv(ProfileEnvVar, "prod")
+	config, err = LoadProfile(path, "", func(c *Config) { c.MaxConnsPerHost = 8 })
+	assert.NoError(t, err)
+	assert.Equal(t, "tc.prod:36790", config.GrpcEndpoint)
+	assert.Equal(t, 2*time.Second, config.RequestTimeout)
+	assert.Equal(t, 0, config.MaxRetries)
+	assert.Equal(t, "secret", config.AuthToken)
+	assert.Equal(t, "tc.prod", config.TLS.ServerName)
+	assert.Equal(t, 8, config.MaxConnsPerHost)
+
+	_, err = LoadProfile(path, "staging")
+	assert.ErrorIs(t, err, ErrProfileNotFound)
+	assert.ErrorContains(t, err, "have dev, prod")
+
+	_, err = ParseProfiles([]byte(`{"profiles": {"dev": {"http_endpont": "x"}}}`))
+	assert.ErrorContains(t, err, "http_endpont")
+}
+
+func TestTransactionLogs(t *testing.T) {
+	more := make(chan struct{})
+	tc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tx/g1/logs" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		io.WriteString(w, `{"time":"2024-05-01T10:00:00Z","level":"info","message":"branch added","branch_id":"b1"}`+"\n")
+		if r.URL.Query().Get("follow") != "true" {
+			io.WriteString(w, `{"time":"2024-05-01T10:00:01Z","level":"error","message":"branch failed","branch_id":"b1","fields":{"status":500}}`+"\n")
+			return
+		}
+		w.(http.Flusher).Flush()
+		<-more
+		io.WriteString(w, `{"time":"2024-05-01T10:00:02Z","message":"aborted"}`+"\n")
+	}))
+	defer tc.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = tc.URL
+	config.GrpcEndpoint = ""
+	config.RequestTimeout = 50 * time.Millisecond
+	client := NewClient(config)
+	defer client.Close()
+	ctx := context.Background()
+
+	logs, err := client.TransactionLogs(ctx, "g1", false)
+	assert.NoError(t, err)
+	var messages []string
+	for logs.Next() {
+		messages = append(messages, logs.Entry().BranchID+":"+logs.Entry().Message)
+	}
+	assert.NoError(t, logs.Err())
+	assert.NoError(t, logs.Close())
+	assert.Equal(t, []string{"b1:branch added", "b1:branch failed"}, messages)
+
+	// Following outlives RequestTimeout
+	logs, err = client.TransactionLogs(ctx, "g1", true)
+	assert.NoError(t, err)
+	assert.True(t, logs.Next())
+	assert.Equal(t, "branch added", logs.Entry().Message)
+	time.Sleep(100 * time.Millisecond)
+	close(more)
+	assert.True(t, logs.Next())
+	assert.Equal(t, "aborted", logs.Entry().Message)
+	assert.False(t, logs.Next())
+	assert.NoError(t, logs.Err())
+	logs.Close()
+
+	_, err = client.TransactionLogs(ctx, "other", false)
+	assert.ErrorIs(t, err, ErrLogsUnsupported)
+	var reqErr *RequestError
+	assert.ErrorAs(t, err, &reqErr)
+	assert.Equal(t, http.StatusNotFound, reqErr.StatusCode)
+}
+
+func TestSimulator(t *testing.T) {
+	ctx := context.Background()
+	options := DefaultExecutionOptions()
+	options.RetryConfig = &RetryConfig{MaxRetries: 2, RetryInterval: time.Second, BackoffFactor: 2}
+
+	saga := CreateSagaWorkflow([]SagaStep{
+		{BranchID: "stock", Action: "http://stock/reserve", Compensate: "http://stock/release"},
+		{BranchID: "notify", Action: "http://notify/send"},
+		{BranchID: "pay", Action: "http://gw-a/pay", Compensate: "http://gw-a/refund", FallbackAction: "http://gw-b/pay"},
+		{BranchID: "ship", Action: "http://ship/create", Compensate: "http://ship/cancel"},
+	})
+	result, err := NewSimulator(SimulationScript{
+		"pay":  {Action: []string{ResultOngoing}, Fallback: []string{ResultSuccess}},
+		"ship": {Action: []string{ResultFailure}},
+	}, options).SimulateSaga(ctx, saga, nil)
+	assert.NoError(t, err)
+	assert.False(t, result.Committed)
+	assert.Equal(t, []string{"pay", "stock"}, result.Compensated)
+	assert.Equal(t, 2, result.Retries)
+	assert.Equal(t, `action stock #1: SUCCESS -> continue
+action notify #1: SUCCESS -> continue
+action pay #1: ONGOING -> retry in 1s
+action pay #2: ONGOING -> retry in 2s
+action pay #3: ONGOING -> fallback
+fallback pay #1: SUCCESS -> continue
+action ship #1: FAILURE -> rollback
+compensate pay #1: SUCCESS -> continue
+compensate notify: nothing to compensate
+compensate stock #1: SUCCESS -> continue
+outcome: rolled back
+`, result.Report())
+
+	tcc := CreateTCCWorkflow([]TCCStep{
+		{BranchID: "stock", Try: "t1", Confirm: "c1", Cancel: "x1"},
+		{BranchID: "pay", Try: "t2", Confirm: "c2", Cancel: "x2"},
+	})
+	options.RetryBudget = &RetryBudget{MaxRetries: 1}
+	result, err = NewSimulator(SimulationScript{
+		"pay":   {Try: []string{ResultOngoing}},
+		"stock": {Cancel: []string{ResultFailure}},
+	}, options).SimulateTCC(ctx, tcc, nil)
+	assert.NoError(t, err)
+	assert.False(t, result.Committed)
+	assert.True(t, result.BudgetExhausted)
+	assert.Equal(t, []string{"pay"}, result.Compensated)
+	assert.Equal(t, []string{"stock"}, result.Failed)
+	assert.Equal(t, DecisionRollback, result.Decisions[2].Decision)
+	assert.Contains(t, result.Report(), "outcome: rolled back, compensation failed for stock")
+
+	result, err = NewSimulator(nil, nil).SimulateTCC(ctx, tcc, nil)
+	assert.NoError(t, err)
+	assert.True(t, result.Committed)
+	assert.Len(t, result.Decisions, 4)
+}
+
+func TestCompensationTemplates(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+	var mu sync.Mutex
+	var undo []string
+	orders := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			io.WriteString(w, `{"id": 1234567, "ref": "a/b"}`)
+			return
+		}
+		mu.Lock()
+		undo = append(undo, r.Method+" "+r.URL.EscapedPath())
+		mu.Unlock()
+	}))
+	defer orders.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	workflow := CreateSagaWorkflow([]SagaStep{
+		{BranchID: "order", Action: orders.URL + "/orders", Options: &BranchOptions{
+			Compensate:       orders.URL + "/orders/{{.resp.id}}/{{.resp.ref | path}}",
+			CompensateMethod: http.MethodDelete,
+		}},
+		{BranchID: "pay", Action: server.URL + "/fail"},
+	})
+	err := NewSagaManager(client).ExecuteSaga(context.Background(), workflow, nil, nil)
+	assert.ErrorIs(t, err, ErrSagaAborted)
+	mu.Lock()
+	assert.Equal(t, []string{"DELETE /orders/1234567/a%2Fb"}, undo)
+	mu.Unlock()
+
+	rendered, err := RenderCompensateURL("http://svc/refunds?charge={{.resp.charge.id | query}}&gid={{.gid}}", []byte(`{"charge":{"id":"ch 1"}}`), "g1", "pay")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://svc/refunds?charge=ch+1&gid=g1", rendered)
+	_, err = RenderCompensateURL("http://svc/{{.resp.missing}}", []byte(`{"id":1}`), "g1", "pay")
+	assert.Error(t, err)
+
+	tx, err := client.StartTransaction(context.Background(), ModeSaga, nil)
+	assert.NoError(t, err)
+	err = tx.AddBranchWithOptions(context.Background(), "b1", orders.URL, &BranchOptions{CompensateMethod: "PURGE"})
+	assert.ErrorContains(t, err, "unsupported HTTP method")
+	err = tx.AddBranchWithOptions(context.Background(), "b1", orders.URL, &BranchOptions{Compensate: "http://svc/{{.resp.id"})
+	assert.ErrorContains(t, err, "invalid compensation template")
+}
+
+func TestEscapeHatches(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+
+	resp, err := client.HTTP().R().SetContext(context.Background()).Get("/health")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Nil(t, client.GRPCConn())
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	t.Setenv(EnvHTTPEndpoint, server.URL)
+	t.Setenv(EnvGrpcEndpoint, "")
+	t.Setenv(EnvRequestTimeout, "3s")
+	t.Setenv(EnvMaxRetries, "5")
+	t.Setenv(EnvGIDPrefix, "env-")
+
+	config, err := ConfigFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL, config.HTTPEndpoint)
+	assert.Empty(t, config.GrpcEndpoint)
+	assert.Equal(t, 3*time.Second, config.RequestTimeout)
+	assert.Equal(t, 5, config.MaxRetries)
+	assert.Nil(t, config.TLS)
+	assert.Nil(t, config.Discovery)
+
+	client, err := NewClientFromEnv()
+	assert.NoError(t, err)
+	defer client.Close()
+	tx, err := client.StartTransaction(context.Background(), ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(tx.GetGID(), "env-"))
+
+	t.Setenv(EnvRequestTimeout, "soon")
+	t.Setenv(EnvMaxRetries, "many")
+	_, err = NewClientFromEnv()
+	var configErr *ConfigError
+	assert.ErrorAs(t, err, &configErr)
+	assert.Len(t, configErr.Problems, 2)
+	assert.ErrorContains(t, err, EnvRequestTimeout)
+
+	t.Setenv(EnvRequestTimeout, "")
+	t.Setenv(EnvMaxRetries, "")
+	t.Setenv(EnvTransport, "carrier-pigeon")
+	_, err = NewClientFromEnv()
+	assert.ErrorContains(t, err, "Transport")
+	httpOnly, err := NewClientFromEnv(func(c *Config) { c.Transport = TransportHTTPOnly })
+	assert.NoError(t, err)
+	httpOnly.Close()
+}
+
+func TestBranchPoolPerHostCap(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	var inFlight, peak atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/try" {
+			return
+		}
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer slow.Close()
+	var fastDone atomic.Int64
+	started := time.Now()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/try" {
+			fastDone.Store(int64(time.Since(started)))
+		}
+	}))
+	defer fast.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.BranchPool = &BranchPoolConfig{MaxConcurrency: 4, MaxPerHost: 1}
+	client := NewClient(config)
+	defer client.Close()
+
+	var steps []TCCStep
+	for i := 0; i < 3; i++ {
+		steps = append(steps, TCCStep{BranchID: "slow" + strconv.Itoa(i), Try: slow.URL + "/try", Confirm: slow.URL + "/confirm", Cancel: slow.URL + "/cancel"})
+	}
+	steps = append(steps, TCCStep{BranchID: "fast", Try: fast.URL + "/try", Confirm: fast.URL + "/confirm", Cancel: fast.URL + "/cancel"})
+
+	err := NewTCCManager(client).ExecuteTCC(context.Background(), CreateTCCWorkflow(steps), nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), peak.Load())
+	assert.GreaterOrEqual(t, time.Since(started), 300*time.Millisecond)
+	assert.Less(t, time.Duration(fastDone.Load()), 100*time.Millisecond, "the fast participant must not queue behind the slow one")
+
+	stats := client.BranchPoolStats()
+	assert.Equal(t, 0, stats.InFlight)
+	assert.Empty(t, stats.Hosts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	release, _ := client.branchPool.acquire(context.Background(), "busy")
+	_, err = client.branchPool.acquire(ctx, "busy")
+	assert.ErrorIs(t, err, context.Canceled)
+	release()
+	assert.Empty(t, client.BranchPoolStats().Hosts)
+}
+
+func TestTCCFailureFailsBranchOnce(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+	participant := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/try" {
+			_, _ = w.Write([]byte("FAILURE"))
+		}
+	}))
+	defer participant.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.StrictBranchFinish = true
+	config.ResponseValidator = DTMResultValidator()
+	client := NewClient(config)
+	defer client.Close()
+
+	workflow := CreateTCCWorkflow([]TCCStep{
+		{BranchID: "pay", Try: participant.URL + "/try", Confirm: participant.URL + "/confirm", Cancel: participant.URL + "/cancel"},
+	})
+	result, err := NewTCCManager(client).ExecuteTCCWithResult(context.Background(), workflow, nil, nil)
+	assert.ErrorIs(t, err, ErrBranchFailure)
+	assert.Equal(t, []string{"pay"}, result.TCC.Cancelled)
+	assert.Empty(t, result.TCC.CancelFailures)
+	assert.Len(t, server.Requests("/api/branch/fail"), 1)
+}
+
+func TestTCCWorkflowStore(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+	ctx := context.Background()
+	tm := NewTCCManager(client)
+
+	workflow := CreateTCCWorkflow([]TCCStep{
+		{BranchID: "stock", Try: server.URL + "/tcc/stock/try", Confirm: server.URL + "/tcc/stock/confirm", Cancel: server.URL + "/tcc/stock/cancel"},
+		{BranchID: "pay", Try: server.URL + "/tcc/pay/try", Confirm: server.URL + "/tcc/pay/confirm", Cancel: server.URL + "/tcc/pay/cancel"},
+	})
+	store := NewMemoryWorkflowStore()
+	options := DefaultExecutionOptions()
+	options.ParallelBranches = false
+	options.Store = store
+	options.WorkflowName = "order"
+
+	result, err := tm.ExecuteTCCWithResult(ctx, workflow, []byte("order-1"), options)
+	assert.NoError(t, err)
+	state, err := store.Load(ctx, result.GID)
+	assert.NoError(t, err)
+	assert.Equal(t, WorkflowStatusCompleted, state.Status)
+	assert.Equal(t, "order", state.Name)
+	assert.Equal(t, []byte("order-1"), state.Payload)
+	assert.Len(t, state.Steps, 2)
+	assert.Equal(t, -1, state.NextPendingStep())
+
+	// An orchestrator stopped while confirming: resuming confirms every branch
+	tx, err := client.StartTransaction(ctx, ModeTCC, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Try(ctx, "stock", server.URL+"/tcc/stock/try", nil))
+	assert.NoError(t, tx.Try(ctx, "pay", server.URL+"/tcc/pay/try", nil))
+	assert.NoError(t, store.Save(ctx, &WorkflowState{ID: tx.GetGID(), Name: "order", Mode: ModeTCC, GID: tx.GetGID(), Status: WorkflowStatusConfirming, Steps: []WorkflowStepState{
+		{BranchID: "stock", Status: StepStatusSucceeded},
+		{BranchID: "pay", Status: StepStatusSucceeded},
+	}}))
+	pending, err := store.ListPending(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	_, err = tm.ResumeTCC(ctx, pending[0], workflow, options)
+	assert.NoError(t, err)
+	stored, _ := server.Transaction(tx.GetGID())
+	for _, branch := range stored.Branches {
+		assert.Equal(t, "SUCCEED", branch.Status, branch.BranchID)
+	}
+	state, _ = store.Load(ctx, tx.GetGID())
+	assert.Equal(t, WorkflowStatusCompleted, state.Status)
+
+	// An orchestrator stopped during the try phase: resuming cancels
+	tx, err = client.StartTransaction(ctx, ModeTCC, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Try(ctx, "stock", server.URL+"/tcc/stock/try", nil))
+	assert.NoError(t, store.Save(ctx, &WorkflowState{ID: tx.GetGID(), Name: "order", Mode: ModeTCC, GID: tx.GetGID(), Status: WorkflowStatusRunning, Steps: []WorkflowStepState{
+		{BranchID: "stock", Status: StepStatusSucceeded},
+		{BranchID: "pay", Status: StepStatusPending},
+	}}))
+	state, _ = store.Load(ctx, tx.GetGID())
+	result, err = tm.ResumeTCC(ctx, state, workflow, options)
+	assert.ErrorIs(t, err, ErrTryPhaseFailed)
+	assert.ElementsMatch(t, []string{"stock", "pay"}, result.TCC.Cancelled)
+	assert.Equal(t, "cancel", server.BarrierState("stock", tx.GetGID(), "stock"))
+	state, _ = store.Load(ctx, tx.GetGID())
+	assert.Equal(t, WorkflowStatusFailed, state.Status)
+
+	// A failed try is recorded before the cancel phase
+	server.FailNext("/tcc/pay/try", seatatest.Response{Status: 500})
+	options.RetryConfig = &RetryConfig{MaxRetries: 0}
+	result, err = tm.ExecuteTCCWithResult(ctx, workflow, nil, options)
+	assert.ErrorIs(t, err, ErrTryPhaseFailed)
+	state, _ = store.Load(ctx, result.GID)
+	assert.Equal(t, WorkflowStatusFailed, state.Status)
+	assert.Equal(t, StepStatusSucceeded, state.Steps[0].Status)
+	assert.Equal(t, StepStatusFailed, state.Steps[1].Status)
+	assert.NotEmpty(t, state.Steps[1].Error)
+}
+
+// fakeSQL is a database/sql driver answering scripted queries in order, in
+// the manner of sqlmock: each expectation matches a query by substring
+type fakeSQL struct {
+	mu      sync.Mutex
+	expects []fakeSQLExpect
+	calls   []fakeSQLCall
+}
+
+type fakeSQLExpect struct {
+	query string
+	rows  [][]driver.Value
+	err   error
+}
+
+type fakeSQLCall struct {
+	query string
+	args  []driver.Value
+}
+
+// newFakeSQL returns a database backed by a new fakeSQL
+func newFakeSQL() (*sql.DB, *fakeSQL) {
+	f := &fakeSQL{}
+	return sql.OpenDB(f), f
+}
+
+// expect scripts the next statement containing query; queries answer rows
+func (f *fakeSQL) expect(query string, err error, rows ...[]driver.Value) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expects = append(f.expects, fakeSQLExpect{query: query, rows: rows, err: err})
+}
+
+// remaining returns the scripted statements not run yet
+func (f *fakeSQL) remaining() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var queries []string
+	for _, e := range f.expects {
+		queries = append(queries, e.query)
+	}
+	return queries
+}
+
+func (f *fakeSQL) next(query string, args []driver.NamedValue) (fakeSQLExpect, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	call := fakeSQLCall{query: query}
+	for _, arg := range args {
+		call.args = append(call.args, arg.Value)
+	}
+	f.calls = append(f.calls, call)
+	if len(f.expects) == 0 || !strings.Contains(query, f.expects[0].query) {
+		return fakeSQLExpect{}, errors.New("unexpected statement: " + query)
+	}
+	e := f.expects[0]
+	f.expects = f.expects[1:]
+	return e, e.err
+}
+
+func (f *fakeSQL) Connect(context.Context) (driver.Conn, error) { return fakeSQLConn{f}, nil }
+func (f *fakeSQL) Driver() driver.Driver                        { return nil }
+
+type fakeSQLConn struct{ f *fakeSQL }
+
+func (c fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+func (c fakeSQLConn) Close() error { return nil }
+func (c fakeSQLConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+func (c fakeSQLConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	if _, err := c.f.next("BEGIN", nil); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+func (c fakeSQLConn) Commit() error {
+	_, err := c.f.next("COMMIT", nil)
+	return err
+}
+func (c fakeSQLConn) Rollback() error {
+	_, err := c.f.next("ROLLBACK", nil)
+	return err
+}
+
+func (c fakeSQLConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if _, err := c.f.next(query, args); err != nil {
+		return nil, err
+	}
+	return fakeSQLResult(len(c.f.calls)), nil
+}
+
+// fakeSQLResult affects one row; its insert ID is the statement count
+type fakeSQLResult int64
+
+func (r fakeSQLResult) LastInsertId() (int64, error) { return int64(r), nil }
+func (r fakeSQLResult) RowsAffected() (int64, error) { return 1, nil }
+
+func (c fakeSQLConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	e, err := c.f.next(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeSQLRows{rows: e.rows}, nil
+}
+
+type fakeSQLRows struct{ rows [][]driver.Value }
+
+func (r *fakeSQLRows) Columns() []string {
+	n := 1
+	if len(r.rows) > 0 {
+		n = len(r.rows[0])
+	}
+	return make([]string, n)
+}
+func (r *fakeSQLRows) Close() error { return nil }
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSQLWorkflowStore(t *testing.T) {
+	ctx := context.Background()
+	db, fake := newFakeSQL()
+	defer db.Close()
+	store, err := NewSQLWorkflowStore(db, SQLDialectPostgres, "")
+	assert.NoError(t, err)
+
+	state := &WorkflowState{ID: "wf-1", Mode: ModeTCC, Status: WorkflowStatusRunning, Steps: []WorkflowStepState{{BranchID: "b1", Status: StepStatusPending}}}
+	fake.expect("INSERT INTO seata_workflow", nil)
+	assert.NoError(t, store.Save(ctx, state))
+	assert.Contains(t, fake.calls[0].query, "ON CONFLICT (id) DO UPDATE")
+	assert.Contains(t, fake.calls[0].query, "VALUES ($1, $2, $3, $4, $5)")
+	assert.Equal(t, "wf-1", fake.calls[0].args[0])
+	assert.Equal(t, WorkflowStatusRunning, fake.calls[0].args[1])
+	data, _ := fake.calls[0].args[2].(string)
+
+	fake.expect("SELECT data FROM seata_workflow WHERE id = $1", nil, []driver.Value{data})
+	loaded, err := store.Load(ctx, "wf-1")
+	assert.NoError(t, err)
+	assert.Equal(t, state.Steps, loaded.Steps)
+
+	fake.expect("SELECT data FROM seata_workflow WHERE id", nil)
+	_, err = store.Load(ctx, "missing")
+	assert.ErrorIs(t, err, ErrWorkflowNotFound)
+
+	fake.expect("WHERE status NOT IN ($1, $2) ORDER BY created_unix", nil, []driver.Value{data}, []driver.Value{data})
+	pending, err := store.ListPending(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+	assert.Equal(t, []driver.Value{WorkflowStatusCompleted, WorkflowStatusFailed}, fake.calls[len(fake.calls)-1].args)
+
+	fake.expect("BEGIN", nil)
+	fake.expect("FOR UPDATE", nil, []driver.Value{data})
+	fake.expect("UPDATE seata_workflow SET data = $1, updated_unix = $2 WHERE id = $3", nil)
+	fake.expect("COMMIT", nil)
+	assert.NoError(t, store.MarkStep(ctx, "wf-1", "b1", StepStatusSucceeded, nil))
+	var updated WorkflowState
+	assert.NoError(t, json.Unmarshal([]byte(fake.calls[len(fake.calls)-2].args[0].(string)), &updated))
+	assert.Equal(t, StepStatusSucceeded, updated.Steps[0].Status)
+
+	// A failed update rolls the row lock back
+	fake.expect("BEGIN", nil)
+	fake.expect("FOR UPDATE", nil, []driver.Value{data})
+	fake.expect("UPDATE seata_workflow", errors.New("deadlock"))
+	fake.expect("ROLLBACK", nil)
+	err = store.MarkStep(ctx, "wf-1", "b1", StepStatusFailed, errors.New("boom"))
+	assert.ErrorContains(t, err, "deadlock")
+	assert.Empty(t, fake.remaining())
+
+	mysql, err := NewSQLWorkflowStore(db, SQLDialectMySQL, "workflows")
+	assert.NoError(t, err)
+	fake.expect("INSERT INTO workflows", nil)
+	assert.NoError(t, mysql.Save(ctx, &WorkflowState{ID: "wf-2"}))
+	assert.Contains(t, fake.calls[len(fake.calls)-1].query, "ON DUPLICATE KEY UPDATE")
+	assert.Contains(t, fake.calls[len(fake.calls)-1].query, "VALUES (?, ?, ?, ?, ?)")
+
+	_, err = NewSQLWorkflowStore(db, "sqlite", "")
+	assert.Error(t, err)
+}
+
+// fakeEtcdKV is an in-memory etcd KV with revisions, for the Put, Get and
+// Txn calls of the etcd stores
+type fakeEtcdKV struct {
+	clientv3.KV
+	mu       sync.Mutex
+	data     map[string]*mvccpb.KeyValue
+	revision int64
+	// conflicts makes the next compare-and-swaps fail
+	conflicts int
+}
+
+func newFakeEtcd() (*clientv3.Client, *fakeEtcdKV) {
+	kv := &fakeEtcdKV{data: make(map[string]*mvccpb.KeyValue)}
+	return &clientv3.Client{KV: kv}, kv
+}
+
+func (kv *fakeEtcdKV) Put(ctx context.Context, key, value string, opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	kv.put(key, value)
+	return &clientv3.PutResponse{}, nil
+}
+
+func (kv *fakeEtcdKV) put(key, value string) {
+	kv.revision++
+	kv.data[key] = &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value), ModRevision: kv.revision}
+}
+
+func (kv *fakeEtcdKV) Get(ctx context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	end := string(clientv3.OpGet(key, opts...).RangeBytes())
+	resp := &clientv3.GetResponse{}
+	for k, v := range kv.data {
+		if k == key || (end != "" && k >= key && k < end) {
+			resp.Kvs = append(resp.Kvs, v)
+		}
+	}
+	return resp, nil
+}
+
+func (kv *fakeEtcdKV) Txn(ctx context.Context) clientv3.Txn {
+	return &fakeEtcdTxn{kv: kv}
+}
+
+type fakeEtcdTxn struct {
+	kv       *fakeEtcdKV
+	cmps     []clientv3.Cmp
+	then, el []clientv3.Op
+}
+
+func (txn *fakeEtcdTxn) If(cs ...clientv3.Cmp) clientv3.Txn   { txn.cmps = cs; return txn }
+func (txn *fakeEtcdTxn) Then(ops ...clientv3.Op) clientv3.Txn { txn.then = ops; return txn }
+func (txn *fakeEtcdTxn) Else(ops ...clientv3.Op) clientv3.Txn { txn.el = ops; return txn }
+
+func (txn *fakeEtcdTxn) Commit() (*clientv3.TxnResponse, error) {
+	kv := txn.kv
+	kv.mu.Lock()
+	defer kv.mu.Unlock()
+	succeeded := true
+	for _, cmp := range txn.cmps {
+		current := int64(0)
+		if v := kv.data[string(cmp.KeyBytes())]; v != nil {
+			current = v.ModRevision
+		}
+		target, _ := (*etcdserverpb.Compare)(&cmp).TargetUnion.(*etcdserverpb.Compare_ModRevision)
+		if target == nil || cmp.Result != etcdserverpb.Compare_EQUAL || target.ModRevision != current {
+			succeeded = false
+		}
+	}
+	if kv.conflicts > 0 {
+		kv.conflicts--
+		succeeded = false
+	}
+	ops := txn.el
+	if succeeded {
+		ops = txn.then
+	}
+	for _, op := range ops {
+		if op.IsPut() {
+			kv.put(string(op.KeyBytes()), string(op.ValueBytes()))
+		}
+	}
+	return &clientv3.TxnResponse{Succeeded: succeeded}, nil
+}
+
+func TestEtcdWorkflowStore(t *testing.T) {
+	ctx := context.Background()
+	cli, kv := newFakeEtcd()
+	store := NewEtcdWorkflowStore(cli, "")
+
+	assert.NoError(t, store.Save(ctx, &WorkflowState{ID: "wf-1", Mode: ModeTCC, Steps: []WorkflowStepState{{BranchID: "b1", Status: StepStatusPending}}}))
+	assert.NoError(t, store.Save(ctx, &WorkflowState{ID: "wf-2", Mode: ModeTCC, Status: WorkflowStatusCompleted}))
+	assert.Contains(t, kv.data, "/seata/workflows/wf-1")
+	kv.put("/seata/other", "not a workflow")
+
+	kv.conflicts = 2
+	assert.NoError(t, store.MarkStep(ctx, "wf-1", "b1", StepStatusSucceeded, nil))
+	loaded, err := store.Load(ctx, "wf-1")
+	assert.NoError(t, err)
+	assert.Equal(t, StepStatusSucceeded, loaded.Steps[0].Status)
+
+	kv.conflicts = 5
+	assert.ErrorContains(t, store.MarkStep(ctx, "wf-1", "b1", StepStatusFailed, nil), "too many concurrent updates")
+	loaded, _ = store.Load(ctx, "wf-1")
+	assert.Equal(t, StepStatusSucceeded, loaded.Steps[0].Status)
+
+	pending, err := store.ListPending(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "wf-1", pending[0].ID)
+
+	_, err = store.Load(ctx, "missing")
+	assert.ErrorIs(t, err, ErrWorkflowNotFound)
+	assert.ErrorIs(t, store.MarkStep(ctx, "missing", "b1", StepStatusSucceeded, nil), ErrWorkflowNotFound)
+}
+
+// fakeRedis implements RedisCommands over maps
+type fakeRedis struct {
+	mu      sync.Mutex
+	strings map[string]string
+	sets    map[string]map[string]bool
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{strings: make(map[string]string), sets: make(map[string]map[string]bool)}
+}
+
+func (r *fakeRedis) Get(ctx context.Context, key string) (string, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	value, found := r.strings[key]
+	return value, found, nil
+}
+
+func (r *fakeRedis) Set(ctx context.Context, key, value string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.strings[key] = value
+	return nil
+}
+
+func (r *fakeRedis) SAdd(ctx context.Context, key, member string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sets[key] == nil {
+		r.sets[key] = make(map[string]bool)
+	}
+	r.sets[key][member] = true
+	return nil
+}
+
+func (r *fakeRedis) SRem(ctx context.Context, key, member string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sets[key], member)
+	return nil
+}
+
+func (r *fakeRedis) SMembers(ctx context.Context, key string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var members []string
+	for member := range r.sets[key] {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func TestRedisWorkflowStore(t *testing.T) {
+	ctx := context.Background()
+	redis := newFakeRedis()
+	store := NewRedisWorkflowStore(redis, "")
+
+	assert.NoError(t, store.Save(ctx, &WorkflowState{ID: "wf-1", Mode: ModeTCC, Steps: []WorkflowStepState{{BranchID: "b1", Status: StepStatusPending}}}))
+	assert.NoError(t, store.Save(ctx, &WorkflowState{ID: "wf-2", Mode: ModeTCC}))
+	assert.Len(t, redis.sets["seata:workflow:pending"], 2)
+
+	var wg sync.WaitGroup
+	for _, branchID := range []string{"b1", "b2", "b3"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, store.MarkStep(ctx, "wf-1", branchID, StepStatusSucceeded, nil))
+		}()
+	}
+	wg.Wait()
+	loaded, err := store.Load(ctx, "wf-1")
+	assert.NoError(t, err)
+	assert.Len(t, loaded.Steps, 3)
+	assert.Equal(t, -1, loaded.NextPendingStep())
+
+	loaded.Status = WorkflowStatusCompleted
+	assert.NoError(t, store.Save(ctx, loaded))
+	assert.Equal(t, map[string]bool{"wf-2": true}, redis.sets["seata:workflow:pending"])
+
+	// An id left in the pending set without its document is ignored
+	assert.NoError(t, redis.SAdd(ctx, "seata:workflow:pending", "gone"))
+	pending, err := store.ListPending(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "wf-2", pending[0].ID)
+
+	_, err = store.Load(ctx, "missing")
+	assert.ErrorIs(t, err, ErrWorkflowNotFound)
+}
+
+// fakeElection is an electionSession won at once
+type fakeElection struct{ done chan struct{} }
+
+func (e *fakeElection) Campaign(ctx context.Context, identity string) error { return nil }
+func (e *fakeElection) Resign(ctx context.Context) error                    { return nil }
+func (e *fakeElection) Done() <-chan struct{}                               { return e.done }
+func (e *fakeElection) Close() error                                        { return nil }
+
+// newFakeElector returns an elector campaigning on fake elections
+func newFakeElector(config *LeaderElectorConfig, campaigns *atomic.Int32) *LeaderElector {
+	elector := NewLeaderElector(nil, "test", config)
+	elector.newSession = func(ctx context.Context) (electionSession, error) {
+		campaigns.Add(1)
+		return &fakeElection{done: make(chan struct{})}, nil
+	}
+	return elector
+}
+
+func TestLeaderElectorResignBackoff(t *testing.T) {
+	var campaigns, elected, resigned atomic.Int32
+	elector := newFakeElector(&LeaderElectorConfig{
+		Identity:      "replica-1",
+		ResignBackoff: 300 * time.Millisecond,
+		OnElected:     func(ctx context.Context) { elected.Add(1) },
+		OnResigned:    func() { resigned.Add(1) },
+	}, &campaigns)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- elector.Run(ctx) }()
+	assert.Eventually(t, func() bool { return elected.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	elector.Resign()
+	assert.Eventually(t, func() bool { return resigned.Load() == 1 }, time.Second, 5*time.Millisecond)
+	assert.False(t, elector.IsLeader())
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(1), campaigns.Load(), "a resigned elector must not campaign again at once")
+	// OnElected runs after leadership is recorded, so wait on the callback
+	assert.Eventually(t, func() bool { return elected.Load() == 2 }, time.Second, 5*time.Millisecond)
+	assert.True(t, elector.IsLeader())
+	assert.Equal(t, int32(2), campaigns.Load())
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.False(t, elector.IsLeader())
+}
+
+// agedWorkflowStore reports the workflows it lists as last updated an hour
+// ago, except those whose ID starts with "fresh"
+type agedWorkflowStore struct {
+	*MemoryWorkflowStore
+}
+
+func (s agedWorkflowStore) ListPending(ctx context.Context) ([]*WorkflowState, error) {
+	pending, err := s.MemoryWorkflowStore.ListPending(ctx)
+	for _, state := range pending {
+		if !strings.HasPrefix(state.ID, "fresh") {
+			state.UpdatedUnix -= 3600
+		}
+	}
+	return pending, err
+}
+
+func TestRecoveryManager(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+	ctx := context.Background()
+
+	workflow := CreateTCCWorkflow([]TCCStep{
+		{BranchID: "stock", Try: server.URL + "/tcc/stock/try", Confirm: server.URL + "/tcc/stock/confirm", Cancel: server.URL + "/tcc/stock/cancel"},
+		{BranchID: "pay", Try: server.URL + "/tcc/pay/try", Confirm: server.URL + "/tcc/pay/confirm", Cancel: server.URL + "/tcc/pay/cancel"},
+	})
+	registry := NewWorkflowRegistry()
+	assert.NoError(t, registry.RegisterTCC("order", workflow))
+	store := agedWorkflowStore{NewMemoryWorkflowStore()}
+
+	// interrupted saves a TCC execution of order left in status after its tries
+	interrupted := func(id, status string) string {
+		tx, err := client.StartTransaction(ctx, ModeTCC, nil)
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Try(ctx, "stock", server.URL+"/tcc/stock/try", nil))
+		assert.NoError(t, tx.Try(ctx, "pay", server.URL+"/tcc/pay/try", nil))
+		if id == "" {
+			id = tx.GetGID()
+		}
+		assert.NoError(t, store.Save(ctx, &WorkflowState{ID: id, Name: "order", Mode: ModeTCC, GID: tx.GetGID(), Status: status, Steps: []WorkflowStepState{
+			{BranchID: "stock", Status: StepStatusSucceeded},
+			{BranchID: "pay", Status: StepStatusSucceeded},
+		}}))
+		return tx.GetGID()
+	}
+	branchStatuses := func(gid string) []string {
+		stored, _ := server.Transaction(gid)
+		var statuses []string
+		for _, branch := range stored.Branches {
+			statuses = append(statuses, branch.Status)
+		}
+		return statuses
+	}
+
+	confirming := interrupted("", WorkflowStatusConfirming)
+	running := interrupted("", WorkflowStatusRunning)
+	fresh := interrupted("fresh", WorkflowStatusRunning)
+	assert.NoError(t, store.Save(ctx, &WorkflowState{ID: "abort/g1", Mode: ModeTCC, GID: "g1", Status: WorkflowStatusPending}))
+	assert.NoError(t, store.Save(ctx, &WorkflowState{ID: "unknown", Name: "refund", Mode: ModeTCC, GID: "g2", Status: WorkflowStatusRunning}))
+
+	manager := NewRecoveryManager(NewTCCManager(client), store, &RecoveryConfig{Registry: registry})
+	recovered, err := manager.RecoverOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, recovered)
+	assert.Equal(t, []string{"SUCCEED", "SUCCEED"}, branchStatuses(confirming))
+	assert.Equal(t, []string{"FAILED", "FAILED"}, branchStatuses(running))
+	assert.Empty(t, branchStatuses(fresh)[0], "an execution still making progress is left alone")
+
+	pending, err := store.MemoryWorkflowStore.ListPending(ctx)
+	assert.NoError(t, err)
+	var ids []string
+	for _, state := range pending {
+		ids = append(ids, state.ID)
+	}
+	assert.ElementsMatch(t, []string{"fresh", "abort/g1", "unknown"}, ids)
+
+	// Only the leader recovers
+	var campaigns atomic.Int32
+	elector := newFakeElector(&LeaderElectorConfig{Identity: "replica-1"}, &campaigns)
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	manager = NewRecoveryManager(NewTCCManager(client), store, &RecoveryConfig{Registry: registry, Interval: 10 * time.Millisecond, Elector: elector})
+	go manager.Run(runCtx)
+	late := interrupted("", WorkflowStatusConfirming)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []string{"", ""}, branchStatuses(late))
+
+	go elector.Run(runCtx)
+	assert.Eventually(t, func() bool {
+		statuses := branchStatuses(late)
+		return len(statuses) == 2 && statuses[0] == "SUCCEED" && statuses[1] == "SUCCEED"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestResumeTCCMigration(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+	ctx := context.Background()
+	tm := NewTCCManager(client)
+
+	// v1 ran stock and notify; v2 drops notify and adds pay
+	v2 := CreateTCCWorkflow([]TCCStep{
+		{BranchID: "stock", Try: server.URL + "/tcc/stock/try", Confirm: server.URL + "/tcc/stock/confirm", Cancel: server.URL + "/tcc/stock/cancel"},
+		{BranchID: "pay", Try: server.URL + "/tcc/pay/try", Confirm: server.URL + "/tcc/pay/confirm", Cancel: server.URL + "/tcc/pay/cancel"},
+	})
+	v2.Version = 2
+	migrator := NewWorkflowMigrator()
+	assert.NoError(t, migrator.Register(WorkflowMigration{From: 1, To: 2, Removed: []string{"notify"}}))
+	store := NewMemoryWorkflowStore()
+	options := DefaultExecutionOptions()
+	options.Store = store
+
+	tx, err := client.StartTransaction(ctx, ModeTCC, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Try(ctx, "stock", server.URL+"/tcc/stock/try", nil))
+	state := &WorkflowState{ID: tx.GetGID(), Name: "order", Mode: ModeTCC, Version: 1, GID: tx.GetGID(), Status: WorkflowStatusConfirming, Steps: []WorkflowStepState{
+		{BranchID: "stock", Status: StepStatusSucceeded},
+		{BranchID: "notify", Status: StepStatusSucceeded},
+	}}
+	assert.NoError(t, store.Save(ctx, state))
+
+	_, err = tm.ResumeTCC(ctx, state, v2, options)
+	assert.ErrorContains(t, err, "needs ExecutionOptions.Migrator")
+
+	options.Migrator = migrator
+	_, err = tm.ResumeTCC(ctx, state, v2, options)
+	assert.NoError(t, err)
+	assert.Equal(t, "confirm", server.BarrierState("stock", tx.GetGID(), "stock"))
+	assert.Empty(t, server.BarrierState("pay", tx.GetGID(), "pay"), "a step added by the migration was never tried and is not confirmed")
+
+	stored, err := store.Load(ctx, tx.GetGID())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stored.Version)
+	assert.Equal(t, WorkflowStatusCompleted, stored.Status)
+	assert.Equal(t, []string{"stock", "pay", "notify"}, []string{stored.Steps[0].BranchID, stored.Steps[1].BranchID, stored.Steps[2].BranchID})
+	assert.Equal(t, StepStatusSkipped, stored.Steps[1].Status)
+}
+
+func TestSQLOutbox(t *testing.T) {
+	ctx := context.Background()
+	db, fake := newFakeSQL()
+	defer db.Close()
+	outbox, err := NewSQLOutbox(db, SQLDialectPostgres, "")
+	assert.NoError(t, err)
+
+	entry := &OutboxEntry{GID: "g1", Kind: OutboxSubmit, Mode: ModeSaga, Payload: []byte("order-1")}
+	fake.expect("INSERT INTO seata_outbox (gid, branch_id, kind, status, data, mode, payload, state) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id", nil, []driver.Value{int64(7)})
+	assert.NoError(t, outbox.Enqueue(ctx, entry))
+	assert.Equal(t, int64(7), entry.ID)
+	args := fake.calls[0].args
+	assert.Equal(t, ModeSaga, args[5])
+	assert.Equal(t, "b3JkZXItMQ==", args[6])
+	assert.Equal(t, "pending", args[7])
+
+	created := time.Now().Truncate(time.Second)
+	fake.expect("FROM seata_outbox WHERE state = $1 ORDER BY id LIMIT $2", nil,
+		[]driver.Value{int64(7), "g1", "", OutboxSubmit, "", "", ModeSaga, "b3JkZXItMQ==", int64(0), nil, created},
+		[]driver.Value{int64(8), "g1", "b1", OutboxBranchReport, BranchStatusPrepared, "ZGF0YQ==", "", nil, int64(2), "timeout", created},
+	)
+	pending, err := outbox.Pending(ctx, 10)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+	assert.Equal(t, &OutboxEntry{ID: 7, GID: "g1", Kind: OutboxSubmit, Mode: ModeSaga, Payload: []byte("order-1"), Data: []byte{}, CreatedAt: created}, pending[0])
+	assert.Equal(t, []byte("data"), pending[1].Data)
+	assert.Equal(t, 2, pending[1].Attempts)
+	assert.Equal(t, "timeout", pending[1].LastError)
+	assert.Equal(t, []driver.Value{"pending", int64(10)}, fake.calls[1].args)
+
+	fake.expect("UPDATE seata_outbox SET state = $1 WHERE id = $2", nil)
+	assert.NoError(t, outbox.MarkDone(ctx, 7))
+	fake.expect("UPDATE seata_outbox SET attempts = attempts + 1, last_error = $1, state = $2 WHERE id = $3", nil)
+	assert.NoError(t, outbox.MarkFailed(ctx, 8, errors.New("boom"), true))
+	assert.Equal(t, []driver.Value{"boom", "failed", int64(8)}, fake.calls[3].args)
+	fake.expect("UPDATE seata_outbox", errors.New("connection reset"))
+	assert.ErrorContains(t, outbox.MarkDone(ctx, 9), "connection reset")
+
+	mysql, err := NewSQLOutbox(db, SQLDialectMySQL, "outbox")
+	assert.NoError(t, err)
+	entry = &OutboxEntry{GID: "g2", Kind: OutboxAbort}
+	fake.expect("INSERT INTO outbox (gid, branch_id, kind, status, data, mode, payload, state) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", nil)
+	assert.NoError(t, mysql.Enqueue(ctx, entry))
+	assert.Equal(t, int64(len(fake.calls)), entry.ID)
+	assert.Empty(t, fake.remaining())
+}
+
+func TestOutboxRelayValidation(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	var mu sync.Mutex
+	var submitted []*SubmitRequest
+	var authorized []*AuthorizationRequest
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	config.PreSubmitValidator = func(ctx context.Context, req *SubmitRequest) error {
+		mu.Lock()
+		defer mu.Unlock()
+		submitted = append(submitted, req)
+		if req.Mode != ModeSaga || len(req.Payload) == 0 {
+			return errors.New("incomplete transaction")
+		}
+		return nil
+	}
+	config.Authorizer = AuthorizerFunc(func(ctx context.Context, req *AuthorizationRequest) error {
+		mu.Lock()
+		defer mu.Unlock()
+		authorized = append(authorized, req)
+		if req.Operation == AuthorizeAbort {
+			return errors.New("aborts are not allowed")
+		}
+		return nil
+	})
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	tx, err := client.StartTransaction(ctx, ModeSaga, []byte("order-1"))
+	assert.NoError(t, err)
+	assert.NoError(t, tx.AddBranch(ctx, "b1", server.URL+"/ok"))
+
+	outbox := NewMemoryOutbox()
+	assert.NoError(t, outbox.Enqueue(ctx, &OutboxEntry{GID: tx.GetGID(), Kind: OutboxSubmit, Mode: ModeSaga, Payload: []byte("order-1")}))
+	assert.NoError(t, outbox.Enqueue(ctx, &OutboxEntry{GID: "legacy", Kind: OutboxSubmit}))
+
+	var failures []error
+	relay := NewOutboxRelay(client, outbox, &OutboxRelayConfig{OnError: func(_ *OutboxEntry, err error) { failures = append(failures, err) }})
+	delivered, err := relay.RelayOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, []byte("order-1"), submitted[0].Payload)
+	assert.Equal(t, ModeSaga, authorized[len(authorized)-1].Mode)
+	assert.Equal(t, 7, authorized[len(authorized)-1].PayloadSize)
+
+	// A vetoed submit is given up at once instead of retried forever
+	assert.Len(t, failures, 1)
+	assert.ErrorIs(t, failures[0], ErrSubmitVetoed)
+	assert.Len(t, outbox.Failed(), 1)
+	pending, _ := outbox.Pending(ctx, 10)
+	assert.Empty(t, pending)
+
+	// Relayed aborts are authorized like direct ones
+	aborted, err := client.StartTransaction(ctx, ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, outbox.Enqueue(ctx, &OutboxEntry{GID: aborted.GetGID(), Kind: OutboxAbort, Mode: ModeSaga}))
+	delivered, err = relay.RelayOnce(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, delivered)
+	assert.Len(t, failures, 2)
+	assert.ErrorIs(t, failures[1], ErrUnauthorized)
+	assert.Equal(t, AuthorizeAbort, authorized[len(authorized)-1].Operation)
+	assert.Empty(t, server.Requests("/api/abort"))
+}
+
+func TestAbortEscalationOutcomes(t *testing.T) {
+	var mu sync.Mutex
+	answers := map[string]int{"gone": http.StatusNotFound, "aborted": http.StatusConflict, "bad": http.StatusBadRequest}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			GID string `json:"gid"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		defer mu.Unlock()
+		if code, ok := answers[req.GID]; ok {
+			w.WriteHeader(code)
+		}
+	}))
+	defer server.Close()
+
+	escalated := make(chan PendingAbort, 4)
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	config.AbortEscalation = &AbortEscalationConfig{
+		RetryInterval:     10 * time.Millisecond,
+		EscalateAfter:     100,
+		OnAbortEscalation: func(p PendingAbort) { escalated <- p },
+	}
+	client := NewClient(config)
+	defer client.Close()
+	ctx := context.Background()
+
+	// Refusals are not queued
+	for _, gid := range []string{"gone", "aborted", "bad"} {
+		err := (&Transaction{client: client, gid: gid, mode: ModeSaga}).Abort(ctx)
+		assert.Error(t, err, gid)
+		assert.NotErrorIs(t, err, ErrAbortQueued, gid)
+	}
+	assert.Empty(t, client.PendingAborts())
+
+	// Queued aborts end once the TC answers, whatever it answers
+	for _, gid := range []string{"gone", "aborted", "bad"} {
+		mu.Lock()
+		answers[gid+"-later"] = http.StatusServiceUnavailable
+		mu.Unlock()
+		err := (&Transaction{client: client, gid: gid + "-later", mode: ModeSaga}).Abort(ctx)
+		assert.ErrorIs(t, err, ErrAbortQueued, gid)
+	}
+	assert.Len(t, client.PendingAborts(), 3)
+	mu.Lock()
+	for _, gid := range []string{"gone", "aborted", "bad"} {
+		answers[gid+"-later"] = answers[gid]
+	}
+	mu.Unlock()
+	assert.Eventually(t, func() bool { return len(client.PendingAborts()) == 0 }, 2*time.Second, 10*time.Millisecond)
+
+	select {
+	case p := <-escalated:
+		assert.Equal(t, "bad-later", p.GID)
+		var reqErr *RequestError
+		assert.ErrorAs(t, p.LastError, &reqErr)
+		assert.Equal(t, http.StatusBadRequest, reqErr.StatusCode)
+	case <-time.After(time.Second):
+		t.Fatal("the refused abort was not escalated")
+	}
+	assert.Empty(t, escalated)
+	assert.True(t, abortRetryable(status.Error(codes.Unavailable, "down")))
+	assert.False(t, abortRetryable(status.Error(codes.InvalidArgument, "bad gid")))
+	assert.True(t, abortSettled(status.Error(codes.NotFound, "unknown gid")))
+}
+
+func TestCompressedRequestBodies(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.Compressor = NewGzipCompressor()
+	config.CompressionThreshold = 256
+	client := NewClient(config)
+	defer client.Close()
+
+	ctx := context.Background()
+	// Random enough that the compressed payload still fills the body
+	noise := make([]byte, 1000)
+	for i := range noise {
+		noise[i] = "0123456789abcdef"[(i*7919+i*i)%16]
+	}
+	large := []byte(`{"items":"` + string(noise) + `"}`)
+	parent, err := client.StartTransaction(ctx, ModeSaga, []byte(`{}`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	child, err := parent.Fork(ctx, ModeSaga, large)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, child.SetPayload(ctx, large))
+
+	for _, path := range []string{"/api/start", "/api/payload"} {
+		requests := server.Requests(path)
+		if assert.NotEmpty(t, requests, path) {
+			assert.Equal(t, "gzip", requests[len(requests)-1].Header.Get("Content-Encoding"), path)
+		}
+	}
+	// Small bodies are sent as they are
+	assert.Empty(t, server.Requests("/api/start")[0].Header.Get("Content-Encoding"))
+
+	info, err := client.GetTransaction(ctx, child.GetGID())
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, large, info.Payload)
+	assert.Equal(t, parent.GetGID(), info.ParentGID)
+}
+
+func TestPurgeTransactions(t *testing.T) {
+	old := time.Now().Add(-2 * time.Hour).Unix()
+	var mu sync.Mutex
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			mu.Lock()
+			deleted = append(deleted, r.URL.EscapedPath())
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.URL.Query().Get("status") != StatusCommitted || r.URL.Query().Get("offset") != "" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `[{"gid":"old/1","status":"COMMITTED","updated_unix":%d},{"gid":"new","status":"COMMITTED","updated_unix":%d}]`, old, time.Now().Unix())
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+	ctx := context.Background()
+
+	var progress int
+	result, err := client.PurgeTransactions(ctx, time.Hour, nil, &PurgeOptions{DryRun: true, OnProgress: func(*PurgeResult) { progress++ }})
+	assert.NoError(t, err)
+	assert.Equal(t, &PurgeResult{Scanned: 2, Matched: 1, DryRun: true}, result)
+	assert.Equal(t, 1, progress)
+	assert.Empty(t, deleted)
+
+	var gids []string
+	result, err = client.PurgeTransactions(ctx, time.Hour, []string{StatusCommitted}, &PurgeOptions{OnDelete: func(gid string) { gids = append(gids, gid) }})
+	assert.NoError(t, err)
+	assert.Equal(t, &PurgeResult{Scanned: 2, Matched: 1, Deleted: 1}, result)
+	assert.Equal(t, []string{"old/1"}, gids)
+	// The gid is escaped into a single path segment
+	assert.Equal(t, []string{"/api/tx/old%2F1"}, deleted)
+
+	// Transactions are deleted on the TC they were listed on, whatever
+	// region their gid names
+	var remoteCalls atomic.Int32
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteCalls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer remote.Close()
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			mu.Lock()
+			deleted = append(deleted, r.URL.EscapedPath())
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if r.URL.Query().Get("offset") != "" {
+			_, _ = w.Write([]byte(`[]`))
+			return
+		}
+		_, _ = fmt.Fprintf(w, `[{"gid":"eu-migrated","status":"COMMITTED","updated_unix":%d}]`, old)
+	}))
+	defer local.Close()
+
+	config = DefaultConfig()
+	config.GrpcEndpoint = ""
+	config.Regions = []RegionConfig{{Name: "us", HTTPEndpoint: local.URL}, {Name: "eu", HTTPEndpoint: remote.URL}}
+	config.LocalRegion = "us"
+	regional := NewClient(config)
+	defer regional.Close()
+	deleted = nil
+	result, err = regional.PurgeTransactions(ctx, time.Hour, []string{StatusCommitted}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Deleted)
+	assert.Equal(t, []string{"/api/tx/eu-migrated"}, deleted)
+	assert.Zero(t, remoteCalls.Load())
+}
+
+func TestStartTransactionIfAbsentPerKeyLock(t *testing.T) {
+	arrived := make(chan struct{})
+	release := make(chan struct{})
+	var lookups atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/start":
+			var req map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			_, _ = w.Write([]byte(`{"gid":"` + req["gid"].(string) + `"}`))
+		case "/api/tx":
+			if r.URL.Query().Get("business_key") == "slow" && lookups.Add(1) == 1 {
+				close(arrived)
+				<-release
+			}
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	client := NewClient(config)
+	defer client.Close()
+	ctx := context.Background()
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := client.StartTransactionIfAbsent(ctx, "slow", ModeSaga, nil)
+		done <- err
+	}()
+	<-arrived
+
+	// Another key does not wait for the slow lookup
+	_, created, err := client.StartTransactionIfAbsent(ctx, "fast", ModeSaga, nil)
+	assert.NoError(t, err)
+	assert.True(t, created)
+
+	// The same key waits, until its context gives up
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, _, err = client.StartTransactionIfAbsent(waitCtx, "slow", ModeSaga, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	assert.NoError(t, <-done)
+	client.dedupMu.Lock()
+	assert.Empty(t, client.dedupLocks)
+	client.dedupMu.Unlock()
+}
+
+func TestWatchdogEviction(t *testing.T) {
+	deadline := time.Now().Add(time.Hour).Unix()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch gid := strings.TrimPrefix(r.URL.Path, "/api/tx/"); gid {
+		case "purged":
+			http.Error(w, "transaction not found", http.StatusNotFound)
+		case "late":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"gid": gid, "status": StatusSubmitted, "deadline_unix": deadline})
+		default:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"gid": gid, "status": StatusSubmitted})
+		}
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	config.Watchdog = &WatchdogConfig{SLA: time.Millisecond, CheckInterval: time.Hour, MaxFailures: 2, Grace: 30 * time.Millisecond}
+	client := NewClient(config)
+	defer client.Close()
+	ctx := context.Background()
+
+	watchdog := client.Watchdog()
+	for _, gid := range []string{"purged", "late", "stuck"} {
+		watchdog.Track(&Transaction{client: client, gid: gid, mode: ModeSaga})
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// Failed lookups evict after MaxFailures in a row
+	watchdog.check(ctx)
+	assert.Equal(t, 3, watchdog.Tracked())
+	watchdog.check(ctx)
+	assert.Equal(t, 2, watchdog.Tracked())
+
+	// Reported transactions are dropped Grace after their deadline; the TC
+	// deadline of the late one is still ahead
+	time.Sleep(40 * time.Millisecond)
+	watchdog.check(ctx)
+	assert.Equal(t, 1, watchdog.Tracked())
+	assert.Contains(t, watchdog.tracked, "late")
+	assert.Equal(t, int64(2), watchdog.Alerts())
+}
+
+func TestGrpcsEndpointDialsTLS(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer listener.Close()
+	first := make(chan byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 1)
+		if _, err := io.ReadFull(conn, buf); err == nil {
+			first <- buf[0]
+		}
+	}()
+
+	gc := NewGrpcClient("grpcs://" + listener.Addr().String())
+	defer gc.Close()
+	gc.conn.Connect()
+	select {
+	case b := <-first:
+		// A TLS handshake record, not the HTTP/2 preface
+		assert.Equal(t, byte(0x16), b)
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not connect")
+	}
+}
+
+func TestReportBranch(t *testing.T) {
+	var mu sync.Mutex
+	var reports []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/api/branch/report" {
+			return
+		}
+		if req["branch_id"] == "gone" {
+			http.Error(w, "branch not found", http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		reports = append(reports, req)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := NewClient(config)
+	defer client.Close()
+	ctx := context.Background()
+
+	tx := &Transaction{client: client, gid: "g1", mode: ModeTCC, branches: []*Branch{{BranchID: "stock", Status: BranchStatusPrepared}}}
+	assert.NoError(t, tx.ReportBranch(ctx, "stock", BranchStatusSucceed, []byte(`{"reservation":"r-7"}`)))
+	if assert.Len(t, reports, 1) {
+		assert.Equal(t, "g1", reports[0]["gid"])
+		assert.Equal(t, "stock", reports[0]["branch_id"])
+		assert.Equal(t, BranchStatusSucceed, reports[0]["status"])
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(`{"reservation":"r-7"}`)), reports[0]["application_data"])
+	}
+	// The local branch list follows the report
+	assert.Equal(t, BranchStatusSucceed, tx.branches[0].Status)
+	assert.Equal(t, []byte(`{"reservation":"r-7"}`), tx.branches[0].ApplicationData)
+
+	assert.Error(t, tx.ReportBranch(ctx, "stock", "DONE", nil))
+	var reqErr *RequestError
+	assert.ErrorAs(t, tx.ReportBranch(ctx, "gone", BranchStatusFailed, nil), &reqErr)
+	assert.Len(t, reports, 1)
+}
+
+// fakeLease grants leases whose keep-alive channels the test closes to
+// simulate a lost lease
+type fakeLease struct {
+	clientv3.Lease
+	mu         sync.Mutex
+	ttls       []int64
+	keepAlives []chan *clientv3.LeaseKeepAliveResponse
+	revoked    []clientv3.LeaseID
+}
+
+func (l *fakeLease) Grant(ctx context.Context, ttl int64) (*clientv3.LeaseGrantResponse, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.ttls = append(l.ttls, ttl)
+	return &clientv3.LeaseGrantResponse{ID: clientv3.LeaseID(len(l.ttls)), TTL: ttl}, nil
+}
+
+func (l *fakeLease) KeepAlive(ctx context.Context, id clientv3.LeaseID) (<-chan *clientv3.LeaseKeepAliveResponse, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ch := make(chan *clientv3.LeaseKeepAliveResponse)
+	l.keepAlives = append(l.keepAlives, ch)
+	return ch, nil
+}
+
+func (l *fakeLease) Revoke(ctx context.Context, id clientv3.LeaseID) (*clientv3.LeaseRevokeResponse, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.revoked = append(l.revoked, id)
+	return &clientv3.LeaseRevokeResponse{}, nil
+}
+
+func (l *fakeLease) Close() error { return nil }
+
+func (l *fakeLease) grants() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.keepAlives)
+}
+
+func TestEtcdRegistrar(t *testing.T) {
+	discovery := &DiscoveryConfig{EtcdEndpoints: []string{"127.0.0.1:2379"}, Namespace: "/prod"}
+	_, err := NewEtcdRegistrar(&DiscoveryConfig{}, &RegistrationConfig{Address: "http://10.0.0.5:8081"})
+	assert.Error(t, err)
+	_, err = NewEtcdRegistrar(discovery, &RegistrationConfig{})
+	assert.Error(t, err)
+
+	registrar, err := NewEtcdRegistrar(discovery, &RegistrationConfig{
+		InstanceID:   "orders-1",
+		Address:      "http://10.0.0.5:8081",
+		Capabilities: []string{CapabilityBranchCommit, CapabilityBranchRollback},
+		TTL:          100 * time.Millisecond,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "/prod/clients/orders-1", registrar.Key())
+
+	kv := &fakeEtcdKV{data: make(map[string]*mvccpb.KeyValue)}
+	lease := &fakeLease{}
+	registrar.newClient = func() (*clientv3.Client, error) {
+		cli := clientv3.NewCtxClient(context.Background())
+		cli.KV, cli.Lease = kv, lease
+		return cli, nil
+	}
+	go registrar.Run(context.Background())
+	assert.Eventually(t, func() bool { return lease.grants() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	resp, _ := kv.Get(context.Background(), registrar.Key())
+	if assert.Len(t, resp.Kvs, 1) {
+		var registration ClientRegistration
+		assert.NoError(t, json.Unmarshal(resp.Kvs[0].Value, &registration))
+		assert.Equal(t, "orders-1", registration.InstanceID)
+		assert.Equal(t, "http://10.0.0.5:8081", registration.Address)
+		assert.Equal(t, []string{CapabilityBranchCommit, CapabilityBranchRollback}, registration.Capabilities)
+	}
+
+	// A lost lease is re-granted; TTLs are rounded up to etcd's one second
+	lease.mu.Lock()
+	close(lease.keepAlives[0])
+	lease.mu.Unlock()
+	assert.Eventually(t, func() bool { return lease.grants() == 2 }, 2*time.Second, 10*time.Millisecond)
+
+	// Stopping revokes the current lease right away
+	registrar.Stop()
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+	assert.Equal(t, []int64{1, 1}, lease.ttls)
+	assert.Equal(t, []clientv3.LeaseID{2}, lease.revoked)
+}
+
+// fakeWatcher hands out watch channels the test sends events on
+type fakeWatcher struct {
+	clientv3.Watcher
+	mu    sync.Mutex
+	chans []chan clientv3.WatchResponse
+}
+
+func (w *fakeWatcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ch := make(chan clientv3.WatchResponse, 1)
+	w.chans = append(w.chans, ch)
+	return ch
+}
+
+func (w *fakeWatcher) Close() error { return nil }
+
+func TestEtcdDiscovery(t *testing.T) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	config := &DiscoveryConfig{
+		EtcdEndpoints:  []string{"127.0.0.1:2379"},
+		Namespace:      "/prod",
+		Username:       "seata",
+		Password:       "secret",
+		TLS:            tlsConfig,
+		RequireLease:   true,
+		ResyncInterval: 20 * time.Millisecond,
+	}
+	etcdConfig := config.etcdConfig()
+	assert.Equal(t, "seata", etcdConfig.Username)
+	assert.Equal(t, "secret", etcdConfig.Password)
+	assert.Same(t, tlsConfig, etcdConfig.TLS)
+	assert.Equal(t, 5*time.Second, etcdConfig.DialTimeout)
+
+	kv := &fakeEtcdKV{data: make(map[string]*mvccpb.KeyValue)}
+	set := func(key, value string, lease int64) {
+		kv.mu.Lock()
+		defer kv.mu.Unlock()
+		kv.data["/prod/endpoints/"+key] = &mvccpb.KeyValue{Key: []byte("/prod/endpoints/" + key), Value: []byte(value), Lease: lease}
+	}
+	set("http/a", "http://10.0.0.1:36789", 1)
+	set("http/b", discoveryTombstone, 1)
+	set("http/c", "http://10.0.0.3:36789", 0)
+	set("grpc/a", "10.0.0.1:36790", 1)
+
+	updates := make(chan [2][]string, 16)
+	d := NewEtcdDiscoveryWithConfig(config, func(httpAddrs, grpcAddrs []string) {
+		updates <- [2][]string{append([]string(nil), httpAddrs...), append([]string(nil), grpcAddrs...)}
+	})
+	watcher := &fakeWatcher{}
+	d.newClient = func() (*clientv3.Client, error) {
+		cli := clientv3.NewCtxClient(context.Background())
+		cli.KV, cli.Watcher = kv, watcher
+		return cli, nil
+	}
+	next := func() [2][]string {
+		select {
+		case update := <-updates:
+			return update
+		case <-time.After(2 * time.Second):
+			t.Fatal("no discovery update")
+			return [2][]string{}
+		}
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(context.Background())
+	}()
+	defer func() {
+		d.Stop()
+		<-done
+	}()
+
+	// Tombstoned keys and keys without a lease are not endpoints
+	assert.Equal(t, [2][]string{{"http://10.0.0.1:36789"}, {"10.0.0.1:36790"}}, next())
+
+	// Resyncs pick up changes without a watch event
+	set("http/d", "http://10.0.0.4:36789", 2)
+	for update := next(); len(update[0]) != 2; update = next() {
+	}
+
+	// Expired instances disappear
+	kv.mu.Lock()
+	delete(kv.data, "/prod/endpoints/grpc/a")
+	kv.mu.Unlock()
+	for update := next(); len(update[1]) != 0; update = next() {
+	}
+}
+
+func TestHTTPTransportSettings(t *testing.T) {
+	config := DefaultConfig()
+	config.TLSHandshakeTimeout = 3 * time.Second
+	config.ResponseHeaderTimeout = 4 * time.Second
+	config.ExpectContinueTimeout = 500 * time.Millisecond
+	config.DisableKeepAlives = true
+	transport := newHTTPTransport(config)
+	assert.Equal(t, 3*time.Second, transport.TLSHandshakeTimeout)
+	assert.Equal(t, 4*time.Second, transport.ResponseHeaderTimeout)
+	assert.Equal(t, 500*time.Millisecond, transport.ExpectContinueTimeout)
+	assert.True(t, transport.DisableKeepAlives)
+	assert.Equal(t, 90*time.Second, transport.IdleConnTimeout)
+
+	var mu sync.Mutex
+	protos := map[string]bool{}
+	remotes := map[string]bool{}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		protos[r.Proto] = true
+		remotes[r.RemoteAddr] = true
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"status":"healthy"}`))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	// HTTP/2 over TLS when enabled
+	config = DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.TLS = server.Client().Transport.(*http.Transport).TLSClientConfig
+	config.EnableHTTP2 = true
+	client := NewClient(config)
+	_, err := client.Health(context.Background())
+	assert.NoError(t, err)
+	client.Close()
+	assert.Equal(t, map[string]bool{"HTTP/2.0": true}, protos)
+
+	// Without keep-alives every request opens a new connection
+	config.EnableHTTP2 = false
+	config.DisableKeepAlives = true
+	config.TLS = config.TLS.Clone()
+	config.TLS.NextProtos = nil
+	client = NewClient(config)
+	defer client.Close()
+	remotes = map[string]bool{}
+	for i := 0; i < 3; i++ {
+		_, err := client.Health(context.Background())
+		assert.NoError(t, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.True(t, protos["HTTP/1.1"])
+	assert.Len(t, remotes, 3)
+}
+
+func TestStatsDPackets(t *testing.T) {
+	var lines bytes.Buffer
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&lines, "seata.client.requests:%d|c|#protocol:http,endpoint:http://tc-%02d.example.com:36789\n", i, i)
+	}
+	long := strings.Repeat("x", statsdMaxPacket+10) + "\n"
+	lines.WriteString(long)
+	lines.WriteString("seata.client.stuck_transactions:1|c\n")
+
+	packets := statsdPackets(lines.Bytes(), statsdMaxPacket)
+	assert.Greater(t, len(packets), 2)
+	var joined []byte
+	for _, packet := range packets {
+		// Every datagram holds whole lines and fits the limit, except a
+		// line that is longer on its own
+		assert.True(t, bytes.HasSuffix(packet, []byte("\n")))
+		if len(packet) > statsdMaxPacket {
+			assert.Equal(t, long, string(packet))
+		}
+		joined = append(joined, packet...)
+	}
+	assert.Equal(t, lines.Bytes(), joined)
+	assert.Nil(t, statsdPackets(nil, statsdMaxPacket))
+}
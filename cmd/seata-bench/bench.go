@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/seata-team/seata-go-client"
+)
+
+// benchConfig describes one load run
+type benchConfig struct {
+	// SagaRatio is the share of saga transactions, the rest are TCC
+	SagaRatio   float64
+	Branches    int
+	PayloadSize int
+	TPS         int
+	Duration    time.Duration
+	Concurrency int
+	// Participant is the base URL of the branch endpoints: sagas use
+	// /saga/{i} and /saga/{i}/compensate, TCC uses /tcc/bench{i}/{phase}
+	Participant string
+	// Wait makes sagas wait for the TC to finish them instead of returning
+	// once submitted
+	Wait bool
+	// Soak enables invariant checking; the run stops at the first violation
+	Soak *soakConfig
+}
+
+// parseMix parses "saga=70,tcc=30" into the saga share
+func parseMix(mix string) (float64, error) {
+	var saga, tcc float64
+	for _, part := range strings.Split(mix, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return 0, fmt.Errorf("invalid mix entry %q", part)
+		}
+		weight, err := strconv.ParseFloat(value, 64)
+		if err != nil || weight < 0 {
+			return 0, fmt.Errorf("invalid weight in mix entry %q", part)
+		}
+		switch name {
+		case seata.ModeSaga:
+			saga = weight
+		case seata.ModeTCC:
+			tcc = weight
+		default:
+			return 0, fmt.Errorf("unknown mode %q in mix", name)
+		}
+	}
+	if saga+tcc == 0 {
+		return 0, fmt.Errorf("mix has no weight")
+	}
+	return saga / (saga + tcc), nil
+}
+
+// modeStats collects the outcomes of one transaction mode
+type modeStats struct {
+	latencies []time.Duration
+	errors    map[string]int
+}
+
+// report is the result of a run
+type report struct {
+	Elapsed    time.Duration          `json:"elapsed"`
+	Skipped    int                    `json:"skipped"` // ticks dropped while all workers were busy
+	Modes      map[string]*modeReport `json:"modes"`
+	Checks     int                    `json:"checks,omitempty"` // soak invariant checks run
+	Violations []violation            `json:"violations,omitempty"`
+}
+
+// modeReport summarizes one transaction mode
+type modeReport struct {
+	Count  int            `json:"count"`
+	Errors map[string]int `json:"errors,omitempty"`
+	TPS    float64        `json:"tps"`
+	P50    time.Duration  `json:"p50"`
+	P90    time.Duration  `json:"p90"`
+	P99    time.Duration  `json:"p99"`
+	Max    time.Duration  `json:"max"`
+}
+
+// runner drives transactions against a client
+type runner struct {
+	config   benchConfig
+	client   *seata.Client
+	payload  []byte
+	saga     *seata.SagaWorkflow
+	tcc      *seata.TCCWorkflow
+	sagas    *seata.SagaManager
+	tccs     *seata.TCCManager
+	options  *seata.ExecutionOptions
+	mu       sync.Mutex
+	stats    map[string]*modeStats
+	skipped  int
+	randMu   sync.Mutex
+	rand     *rand.Rand
+	finished time.Duration
+	soak     *soakChecker
+}
+
+func newRunner(client *seata.Client, config benchConfig) *runner {
+	r := &runner{
+		config:  config,
+		client:  client,
+		payload: make([]byte, config.PayloadSize),
+		saga:    seata.CreateSagaWorkflow(nil),
+		tcc:     seata.CreateTCCWorkflow(nil),
+		sagas:   seata.NewSagaManager(client),
+		tccs:    seata.NewTCCManager(client),
+		options: seata.DefaultExecutionOptions(),
+		stats:   make(map[string]*modeStats),
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for i := range r.payload {
+		r.payload[i] = 'x'
+	}
+	base := strings.TrimSuffix(config.Participant, "/")
+	for i := 0; i < config.Branches; i++ {
+		id := fmt.Sprintf("b%d", i)
+		r.saga.AddStep(id, fmt.Sprintf("%s/saga/%d", base, i), fmt.Sprintf("%s/saga/%d/compensate", base, i))
+		tcc := fmt.Sprintf("%s/tcc/bench%d/", base, i)
+		r.tcc.AddStep(id, tcc+"try", tcc+"confirm", tcc+"cancel")
+	}
+	return r
+}
+
+// run issues transactions at the configured rate until Duration elapsed
+func (r *runner) run(ctx context.Context) *report {
+	loadCtx, cancel := context.WithTimeout(ctx, r.config.Duration)
+	defer cancel()
+	// Calls in flight finish after the load stopped, unless a soak
+	// violation aborts them
+	callCtx, abortCalls := context.WithCancel(context.WithoutCancel(ctx))
+	defer abortCalls()
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	if r.config.Soak != nil {
+		r.soak = newSoakChecker(r.client, *r.config.Soak, func() {
+			cancel()
+			abortCalls()
+		})
+		go r.soak.watch(watchCtx)
+	}
+
+	ticks := make(chan struct{}, r.config.Concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < r.config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range ticks {
+				r.once(callCtx)
+			}
+		}()
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(time.Second / time.Duration(r.config.TPS))
+	defer ticker.Stop()
+loop:
+	for {
+		select {
+		case <-loadCtx.Done():
+			break loop
+		case <-ticker.C:
+			select {
+			case ticks <- struct{}{}:
+			default:
+				r.mu.Lock()
+				r.skipped++
+				r.mu.Unlock()
+			}
+		}
+	}
+	close(ticks)
+	wg.Wait()
+	r.finished = time.Since(start)
+	stopWatch()
+	if r.soak != nil {
+		if violations, _ := r.soak.results(); len(violations) == 0 {
+			drainCtx, cancelDrain := context.WithTimeout(ctx, r.config.Soak.StuckAfter+r.config.Soak.CheckInterval)
+			r.soak.drain(drainCtx)
+			cancelDrain()
+		}
+	}
+	return r.report()
+}
+
+// once runs one transaction of a mode picked by the mix
+func (r *runner) once(ctx context.Context) {
+	r.randMu.Lock()
+	mode := seata.ModeTCC
+	if r.rand.Float64() < r.config.SagaRatio {
+		mode = seata.ModeSaga
+	}
+	r.randMu.Unlock()
+
+	if r.soak != nil {
+		defer r.soak.end(r.soak.begin(mode))
+	}
+	start := time.Now()
+	var err error
+	if mode == seata.ModeSaga {
+		err = r.runSaga(ctx)
+	} else {
+		var result *seata.ExecutionResult
+		result, err = r.tccs.ExecuteTCCWithResult(ctx, r.tcc, r.payload, r.options)
+		if r.soak != nil {
+			r.soak.tccResult(result)
+		}
+	}
+	r.record(mode, time.Since(start), err)
+}
+
+// runSaga executes a saga; without Wait it returns once submitted, since
+// waiting polls the TC every second
+func (r *runner) runSaga(ctx context.Context) error {
+	if r.config.Wait {
+		return r.sagas.ExecuteSaga(ctx, r.saga, r.payload, r.options)
+	}
+	tx, err := r.client.StartTransaction(ctx, seata.ModeSaga, r.payload)
+	if err != nil {
+		return err
+	}
+	for _, step := range r.saga.Steps {
+		if err := tx.AddBranchWithOptions(ctx, step.BranchID, step.Action, &seata.BranchOptions{Compensate: step.Compensate}); err != nil {
+			_ = tx.Abort(ctx)
+			return err
+		}
+	}
+	if err := tx.Submit(ctx); err != nil {
+		return err
+	}
+	if r.soak != nil {
+		r.soak.trackSaga(tx.GetGID())
+	}
+	return nil
+}
+
+func (r *runner) record(mode string, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats, ok := r.stats[mode]
+	if !ok {
+		stats = &modeStats{errors: make(map[string]int)}
+		r.stats[mode] = stats
+	}
+	if err != nil {
+		stats.errors[errorKind(err)]++
+		return
+	}
+	stats.latencies = append(stats.latencies, latency)
+}
+
+// errorKind groups errors by TC error code, timeout or leading message
+func errorKind(err error) string {
+	var seataErr *seata.SeataError
+	switch {
+	case errors.As(err, &seataErr):
+		return seataErr.Code
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	}
+	kind, _, _ := strings.Cut(err.Error(), ":")
+	return kind
+}
+
+func (r *runner) report() *report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := &report{Elapsed: r.finished, Skipped: r.skipped, Modes: make(map[string]*modeReport)}
+	if r.soak != nil {
+		out.Violations, out.Checks = r.soak.results()
+	}
+	for mode, stats := range r.stats {
+		latencies := append([]time.Duration(nil), stats.latencies...)
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		mr := &modeReport{Count: len(latencies), Errors: stats.errors}
+		for _, n := range stats.errors {
+			mr.Count += n
+		}
+		if r.finished > 0 {
+			mr.TPS = float64(len(latencies)) / r.finished.Seconds()
+		}
+		if len(latencies) > 0 {
+			mr.P50 = percentile(latencies, 0.50)
+			mr.P90 = percentile(latencies, 0.90)
+			mr.P99 = percentile(latencies, 0.99)
+			mr.Max = latencies[len(latencies)-1]
+		}
+		if len(mr.Errors) == 0 {
+			mr.Errors = nil
+		}
+		out.Modes[mode] = mr
+	}
+	return out
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,163 @@
+// Command seata-bench generates load against a TC with a mix of saga and TCC
+// transactions and reports latency percentiles and errors per mode.
+//
+//	seata-bench -http http://localhost:36789 -participant http://localhost:8080 \
+//	    -mix saga=70,tcc=30 -branches 3 -payload 512 -tps 200 -duration 1m
+//
+// The participant must answer the branch URLs: /saga/{i}, /saga/{i}/compensate
+// and /tcc/bench{i}/{try,confirm,cancel}. With -fake the run targets an
+// in-process fake TC and participant, which measures the client's own
+// overhead.
+//
+// With -soak the run also checks invariants every -check-interval: no call
+// runs and no submitted saga stays unfinished longer than -stuck-after, and
+// every failed TCC transaction cancels all of its branches. The first
+// violation stops the run, which then exits with status 1.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"github.com/seata-team/seata-go-client"
+	"github.com/seata-team/seata-go-client/seatatest"
+)
+
+func main() {
+	var (
+		httpEndpoint = flag.String("http", "http://localhost:36789", "TC HTTP endpoint")
+		grpcEndpoint = flag.String("grpc", "", "TC gRPC endpoint; empty uses HTTP only")
+		participant  = flag.String("participant", "http://localhost:8080", "base URL of the branch endpoints")
+		fake         = flag.Bool("fake", false, "run against an in-process fake TC and participant")
+		mix          = flag.String("mix", "saga=50,tcc=50", "weights of the transaction modes")
+		branches     = flag.Int("branches", 3, "branches per transaction")
+		payload      = flag.Int("payload", 256, "payload size in bytes")
+		tps          = flag.Int("tps", 100, "target transactions per second")
+		duration     = flag.Duration("duration", 30*time.Second, "length of the run")
+		concurrency  = flag.Int("concurrency", 64, "maximum transactions in flight")
+		wait         = flag.Bool("wait", false, "wait for sagas to finish instead of returning once submitted")
+		asJSON       = flag.Bool("json", false, "print the report as JSON")
+		soak         = flag.Bool("soak", false, "check invariants and exit 1 on a violation")
+		interval     = flag.Duration("check-interval", time.Minute, "time between soak invariant checks")
+		stuckAfter   = flag.Duration("stuck-after", 5*time.Minute, "age at which an unfinished transaction violates the soak invariants")
+	)
+	flag.Parse()
+
+	sagaRatio, err := parseMix(*mix)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *tps <= 0 || *concurrency <= 0 || *branches <= 0 || *duration <= 0 {
+		fmt.Fprintln(os.Stderr, "tps, concurrency, branches and duration must be positive")
+		os.Exit(2)
+	}
+
+	config := seata.DefaultConfig()
+	config.HTTPEndpoint = *httpEndpoint
+	config.GrpcEndpoint = *grpcEndpoint
+	config.MaxIdleConns = *concurrency
+	config.MaxConnsPerHost = *concurrency
+	if *fake {
+		server := seatatest.NewServer()
+		defer server.Close()
+		config.HTTPEndpoint = server.URL
+		config.GrpcEndpoint = ""
+		*participant = server.URL
+	}
+	client := seata.NewClient(config)
+	defer client.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	bench := benchConfig{
+		SagaRatio:   sagaRatio,
+		Branches:    *branches,
+		PayloadSize: *payload,
+		TPS:         *tps,
+		Duration:    *duration,
+		Concurrency: *concurrency,
+		Participant: *participant,
+		Wait:        *wait,
+	}
+	if *soak {
+		if *interval <= 0 || *stuckAfter <= 0 {
+			fmt.Fprintln(os.Stderr, "check-interval and stuck-after must be positive")
+			os.Exit(2)
+		}
+		bench.Soak = &soakConfig{CheckInterval: *interval, StuckAfter: *stuckAfter}
+	}
+	rep := newRunner(client, bench).run(ctx)
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(rep)
+	} else {
+		printReport(os.Stdout, rep)
+	}
+	if len(rep.Violations) > 0 {
+		client.Close()
+		os.Exit(1)
+	}
+}
+
+// printReport writes rep as a table
+func printReport(w io.Writer, rep *report) {
+	fmt.Fprintf(w, "elapsed %s, skipped %d ticks\n\n", rep.Elapsed.Round(time.Millisecond), rep.Skipped)
+	fmt.Fprintf(w, "%-6s %8s %8s %10s %10s %10s %10s %8s\n", "mode", "count", "tps", "p50", "p90", "p99", "max", "errors")
+
+	modes := make([]string, 0, len(rep.Modes))
+	for mode := range rep.Modes {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	for _, mode := range modes {
+		m := rep.Modes[mode]
+		failed := 0
+		for _, n := range m.Errors {
+			failed += n
+		}
+		fmt.Fprintf(w, "%-6s %8d %8.1f %10s %10s %10s %10s %8d\n", mode, m.Count, m.TPS,
+			m.P50.Round(time.Microsecond), m.P90.Round(time.Microsecond), m.P99.Round(time.Microsecond), m.Max.Round(time.Microsecond), failed)
+	}
+	for _, mode := range modes {
+		kinds := make([]string, 0, len(rep.Modes[mode].Errors))
+		for kind := range rep.Modes[mode].Errors {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			fmt.Fprintf(w, "  %s error %q: %d\n", mode, kind, rep.Modes[mode].Errors[kind])
+		}
+	}
+
+	if rep.Checks == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nsoak: %d checks, %d violations\n", rep.Checks, len(rep.Violations))
+	for _, v := range rep.Violations {
+		fmt.Fprintf(w, "  VIOLATION %s %s", v.Mode, v.Reason)
+		if v.GID != "" {
+			fmt.Fprintf(w, " gid=%s", v.GID)
+		}
+		if v.Status != "" {
+			fmt.Fprintf(w, " status=%s", v.Status)
+		}
+		if v.Age > 0 {
+			fmt.Fprintf(w, " age=%s", v.Age.Round(time.Millisecond))
+		}
+		if v.Detail != "" {
+			fmt.Fprintf(w, " (%s)", v.Detail)
+		}
+		fmt.Fprintln(w)
+	}
+}
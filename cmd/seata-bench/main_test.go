@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/seata-team/seata-go-client"
+	"github.com/seata-team/seata-go-client/seatatest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMix(t *testing.T) {
+	ratio, err := parseMix("saga=3,tcc=1")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.75, ratio)
+
+	for _, mix := range []string{"saga", "xa=1", "saga=-1", "saga=0,tcc=0"} {
+		_, err := parseMix(mix)
+		assert.Error(t, err, mix)
+	}
+}
+
+func TestRunAgainstFake(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+	server.FailNext("/api/start", seatatest.Response{Status: 500, Body: `{"code":"INTERNAL","error":"boom"}`})
+
+	config := seata.DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := seata.NewClient(config)
+	defer client.Close()
+
+	r := newRunner(client, benchConfig{
+		SagaRatio:   0.5,
+		Branches:    2,
+		PayloadSize: 16,
+		TPS:         100,
+		Duration:    300 * time.Millisecond,
+		Concurrency: 4,
+		Participant: server.URL,
+	})
+	rep := r.run(context.Background())
+
+	total, failed := 0, 0
+	for _, m := range rep.Modes {
+		total += m.Count
+		for _, n := range m.Errors {
+			failed += n
+		}
+		assert.LessOrEqual(t, m.P50, m.P99)
+		assert.LessOrEqual(t, m.P99, m.Max)
+	}
+	assert.Greater(t, total, 10)
+	assert.Equal(t, 1, failed)
+
+	var out bytes.Buffer
+	printReport(&out, rep)
+	assert.Contains(t, out.String(), "p99")
+}
+
+func TestErrorKind(t *testing.T) {
+	assert.Equal(t, "timeout", errorKind(context.DeadlineExceeded))
+	assert.Equal(t, "failed to start transaction", errorKind(errors.New("failed to start transaction: status 500")))
+}
+
+func TestSoakInvariants(t *testing.T) {
+	server := seatatest.NewServer()
+	defer server.Close()
+
+	config := seata.DefaultConfig()
+	config.HTTPEndpoint = server.URL
+	config.GrpcEndpoint = ""
+	config.MaxRetries = 0
+	client := seata.NewClient(config)
+	defer client.Close()
+
+	bench := benchConfig{
+		SagaRatio:   1,
+		Branches:    1,
+		TPS:         50,
+		Duration:    300 * time.Millisecond,
+		Concurrency: 2,
+		Participant: server.URL,
+		Soak:        &soakConfig{CheckInterval: 20 * time.Millisecond, StuckAfter: 200 * time.Millisecond},
+	}
+	rep := newRunner(client, bench).run(context.Background())
+	assert.Empty(t, rep.Violations)
+	assert.Greater(t, rep.Checks, 0)
+
+	// A submit the TC acknowledges but never executes leaves the saga stuck
+	server.FailNext("/api/submit", seatatest.Response{Status: 200})
+	bench.Duration = 10 * time.Second
+	start := time.Now()
+	rep = newRunner(client, bench).run(context.Background())
+	assert.Less(t, time.Since(start), 5*time.Second)
+	if assert.Len(t, rep.Violations, 1) {
+		assert.Equal(t, reasonStuck, rep.Violations[0].Reason)
+		assert.Equal(t, seatatest.StatusSubmitted, rep.Violations[0].Status)
+	}
+
+	var out bytes.Buffer
+	printReport(&out, rep)
+	assert.Contains(t, out.String(), "VIOLATION saga transaction stuck")
+}
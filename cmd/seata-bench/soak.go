@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/seata-team/seata-go-client"
+)
+
+// Violation reasons
+const (
+	reasonHung         = "call has not returned"
+	reasonStuck        = "transaction stuck"
+	reasonCompensation = "compensation not complete"
+	reasonCancel       = "TCC cancel failed"
+)
+
+// violation is a broken soak invariant
+type violation struct {
+	GID    string        `json:"gid,omitempty"`
+	Mode   string        `json:"mode"`
+	Status string        `json:"status,omitempty"`
+	Reason string        `json:"reason"`
+	Age    time.Duration `json:"age,omitempty"`
+	Detail string        `json:"detail,omitempty"`
+}
+
+// soakConfig configures invariant checking
+type soakConfig struct {
+	CheckInterval time.Duration
+	// StuckAfter is how long a call may run or a submitted saga may stay
+	// unfinished before it counts as stuck
+	StuckAfter time.Duration
+}
+
+// inflight is a transaction call that has not returned yet
+type inflight struct {
+	mode  string
+	start time.Time
+}
+
+// soakChecker tracks transactions of a run and checks that none gets stuck
+// and every compensation completes
+type soakChecker struct {
+	client *seata.Client
+	config soakConfig
+	// stop ends the run on the first violation
+	stop context.CancelFunc
+
+	mu         sync.Mutex
+	nextID     int
+	running    map[int]inflight
+	submitted  map[string]time.Time // sagas the TC has not finished yet
+	violations []violation
+	checks     int
+}
+
+func newSoakChecker(client *seata.Client, config soakConfig, stop context.CancelFunc) *soakChecker {
+	return &soakChecker{
+		client:    client,
+		config:    config,
+		stop:      stop,
+		running:   make(map[int]inflight),
+		submitted: make(map[string]time.Time),
+	}
+}
+
+// begin records a call in flight; pass the id to end
+func (s *soakChecker) begin(mode string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	s.running[s.nextID] = inflight{mode: mode, start: time.Now()}
+	return s.nextID
+}
+
+func (s *soakChecker) end(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, id)
+}
+
+// trackSaga watches a submitted saga until the TC finishes it
+func (s *soakChecker) trackSaga(gid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.submitted[gid] = time.Now()
+}
+
+// tccResult checks that a failed TCC execution cancelled every branch
+func (s *soakChecker) tccResult(result *seata.ExecutionResult) {
+	if result == nil || result.TCC == nil || result.TCC.CleanupComplete() {
+		return
+	}
+	detail := ""
+	for branchID, err := range result.TCC.CancelFailures {
+		detail = branchID + ": " + err.Error()
+		break
+	}
+	s.violate(violation{GID: result.GID, Mode: seata.ModeTCC, Reason: reasonCancel, Detail: detail})
+}
+
+func (s *soakChecker) violate(v violation) {
+	s.mu.Lock()
+	s.violations = append(s.violations, v)
+	s.mu.Unlock()
+	s.stop()
+}
+
+// watch checks the invariants every CheckInterval until ctx is done
+func (s *soakChecker) watch(ctx context.Context) {
+	ticker := time.NewTicker(s.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.check(ctx)
+		}
+	}
+}
+
+// drain keeps checking after the load stopped until every submitted saga
+// finished or was reported
+func (s *soakChecker) drain(ctx context.Context) {
+	for {
+		s.check(ctx)
+		s.mu.Lock()
+		remaining := len(s.submitted)
+		s.mu.Unlock()
+		if remaining == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.config.CheckInterval):
+		}
+	}
+}
+
+// check reports calls and sagas older than StuckAfter
+func (s *soakChecker) check(ctx context.Context) {
+	now := time.Now()
+	s.mu.Lock()
+	s.checks++
+	var found []violation
+	for id, call := range s.running {
+		if age := now.Sub(call.start); age > s.config.StuckAfter {
+			found = append(found, violation{Mode: call.mode, Reason: reasonHung, Age: age})
+			delete(s.running, id)
+		}
+	}
+	gids := make([]string, 0, len(s.submitted))
+	for gid := range s.submitted {
+		gids = append(gids, gid)
+	}
+	s.mu.Unlock()
+
+	if len(gids) > 0 {
+		// When the TC is briefly unavailable, sagas are checked next time
+		if infos, err := s.client.GetTransactions(ctx, gids); err == nil {
+			s.mu.Lock()
+			for _, gid := range gids {
+				info, ok := infos[gid]
+				if !ok || seata.IsTerminal(info.Status) {
+					delete(s.submitted, gid)
+					continue
+				}
+				age := now.Sub(s.submitted[gid])
+				if age <= s.config.StuckAfter {
+					continue
+				}
+				v := violation{GID: gid, Mode: seata.ModeSaga, Status: info.Status, Reason: reasonStuck, Age: age}
+				if isCompensating(info.Status) {
+					v.Reason = reasonCompensation
+				}
+				found = append(found, v)
+				delete(s.submitted, gid)
+			}
+			s.mu.Unlock()
+		}
+	}
+
+	for _, v := range found {
+		s.violate(v)
+	}
+}
+
+func isCompensating(status string) bool {
+	return status == seata.StatusRollbacking || status == seata.StatusCancelling || status == seata.StatusSuspended
+}
+
+// results returns the violations found and the number of checks run
+func (s *soakChecker) results() ([]violation, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]violation(nil), s.violations...), s.checks
+}
@@ -0,0 +1,56 @@
+package seata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// compensateFuncs escape captured values for the part of the URL they go in
+var compensateFuncs = template.FuncMap{
+	"path":  url.PathEscape,
+	"query": url.QueryEscape,
+}
+
+// parseCompensateTemplate parses a compensation URL template; URLs without
+// "{{" are not templates and yield nil
+func parseCompensateTemplate(rawURL string) (*template.Template, error) {
+	if !strings.Contains(rawURL, "{{") {
+		return nil, nil
+	}
+	tmpl, err := template.New("compensate").Funcs(compensateFuncs).Option("missingkey=error").Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compensation template %q: %w", rawURL, err)
+	}
+	return tmpl, nil
+}
+
+// RenderCompensateURL fills a compensation URL template with values
+// captured from the branch action's JSON response, available as .resp, e.g.
+// "http://orders/orders/{{.resp.id | path}}". .gid and .branch_id hold the
+// branch identifiers, and the path and query functions escape a value. The
+// TC renders registered templates itself; this is for custom compensation
+// and Go participants. URLs without "{{" are returned unchanged.
+func RenderCompensateURL(rawURL string, actionResponse []byte, gid, branchID string) (string, error) {
+	tmpl, err := parseCompensateTemplate(rawURL)
+	if tmpl == nil || err != nil {
+		return rawURL, err
+	}
+	var resp interface{}
+	if len(bytes.TrimSpace(actionResponse)) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(actionResponse))
+		decoder.UseNumber() // keep ids like 1234567 out of float notation
+		if err := decoder.Decode(&resp); err != nil {
+			return "", fmt.Errorf("failed to parse action response: %w", err)
+		}
+	}
+	var out strings.Builder
+	data := map[string]interface{}{"resp": resp, "gid": gid, "branch_id": branchID}
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render compensation URL: %w", err)
+	}
+	return out.String(), nil
+}
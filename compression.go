@@ -0,0 +1,165 @@
+package seata
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/go-resty/resty/v2"
+	"google.golang.org/grpc"
+	grpcgzip "google.golang.org/grpc/encoding/gzip"
+)
+
+// PayloadCompressor compresses transaction payloads before they are sent to the TC
+type PayloadCompressor interface {
+	// Name returns the encoding name, e.g. "gzip"
+	Name() string
+	// Compress compresses the payload
+	Compress(data []byte) ([]byte, error)
+	// Decompress reverses Compress
+	Decompress(data []byte) ([]byte, error)
+}
+
+// DefaultCompressionThreshold is the payload size above which payloads are compressed
+const DefaultCompressionThreshold = 4096
+
+// compressedPayloadMagic prefixes every compressed payload, so payloads that
+// merely look compressed are never decompressed.
+//
+// Layout: magic | encoding name length (1 byte) | encoding name | data
+var compressedPayloadMagic = []byte("SCZ1")
+
+// GzipCompressor compresses payloads with gzip
+type GzipCompressor struct {
+	Level int
+}
+
+// NewGzipCompressor creates a gzip compressor with the default compression level
+func NewGzipCompressor() *GzipCompressor {
+	return &GzipCompressor{Level: gzip.DefaultCompression}
+}
+
+// Name returns the encoding name
+func (g *GzipCompressor) Name() string {
+	return grpcgzip.Name
+}
+
+// Compress compresses data with gzip
+func (g *GzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, g.Level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses gzip data
+func (g *GzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// compressPayload compresses the payload if it exceeds the configured threshold
+func (c *Client) compressPayload(payload []byte) ([]byte, error) {
+	compressor := c.config.Compressor
+	if compressor == nil {
+		return payload, nil
+	}
+
+	threshold := c.config.CompressionThreshold
+	if threshold <= 0 {
+		threshold = DefaultCompressionThreshold
+	}
+	if len(payload) < threshold {
+		return payload, nil
+	}
+
+	compressed, err := compressor.Compress(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress payload: %w", err)
+	}
+	name := compressor.Name()
+	if name == "" || len(name) > 255 {
+		return nil, fmt.Errorf("invalid compressor name: %q", name)
+	}
+	marked := make([]byte, 0, len(compressedPayloadMagic)+1+len(name)+len(compressed))
+	marked = append(marked, compressedPayloadMagic...)
+	marked = append(marked, byte(len(name)))
+	marked = append(marked, name...)
+	return append(marked, compressed...), nil
+}
+
+// decompressPayload transparently decompresses a payload read back from the
+// TC. Payloads without the compression marker are returned unchanged; gzip
+// payloads are readable without a configured Compressor.
+func (c *Client) decompressPayload(payload []byte) ([]byte, error) {
+	if !bytes.HasPrefix(payload, compressedPayloadMagic) {
+		return payload, nil
+	}
+	rest := payload[len(compressedPayloadMagic):]
+	if len(rest) == 0 || len(rest) < 1+int(rest[0]) {
+		return nil, fmt.Errorf("failed to decompress payload: truncated header")
+	}
+	name, data := string(rest[1:1+int(rest[0])]), rest[1+int(rest[0]):]
+
+	var compressor PayloadCompressor
+	switch {
+	case c.config.Compressor != nil && c.config.Compressor.Name() == name:
+		compressor = c.config.Compressor
+	case name == grpcgzip.Name:
+		compressor = NewGzipCompressor()
+	default:
+		return nil, fmt.Errorf("failed to decompress payload: no %s compressor configured", name)
+	}
+	decompressed, err := compressor.Decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress payload: %w", err)
+	}
+	return decompressed, nil
+}
+
+// setJSONBody sets body as the JSON body of a TC request. With a Compressor,
+// bodies of at least CompressionThreshold bytes, i.e. those carrying a large
+// payload, are compressed and marked with Content-Encoding, as gRPC calls
+// use the compressor.
+func (c *Client) setJSONBody(req *resty.Request, body interface{}) (*resty.Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	req.SetHeader("Content-Type", "application/json")
+	compressor := c.config.Compressor
+	threshold := c.config.CompressionThreshold
+	if threshold <= 0 {
+		threshold = DefaultCompressionThreshold
+	}
+	if compressor != nil && len(data) >= threshold {
+		if data, err = compressor.Compress(data); err != nil {
+			return nil, fmt.Errorf("failed to compress request: %w", err)
+		}
+		req.SetHeader("Content-Encoding", compressor.Name())
+	}
+	return req.SetBody(data), nil
+}
+
+// grpcCallOptions returns per-call options derived from the client configuration
+func (c *Client) grpcCallOptions() []grpc.CallOption {
+	var opts []grpc.CallOption
+	if c.config.Compressor != nil && c.config.Compressor.Name() == grpcgzip.Name {
+		opts = append(opts, grpc.UseCompressor(grpcgzip.Name))
+	}
+	return opts
+}
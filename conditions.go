@@ -0,0 +1,50 @@
+package seata
+
+import "context"
+
+// StepCondition decides whether a workflow step runs. Conditions are
+// evaluated in step order before the transaction starts; prior maps the
+// branch ID of every earlier step to whether it runs. Skipped steps are
+// never registered with the TC, so they are never confirmed, cancelled or
+// compensated.
+type StepCondition func(ctx context.Context, payload []byte, prior map[string]bool) bool
+
+// selectSagaSteps returns the saga steps whose condition holds and the
+// indexes of the skipped ones
+func selectSagaSteps(ctx context.Context, workflow *SagaWorkflow, payload []byte) (*SagaWorkflow, []int) {
+	prior := make(map[string]bool, len(workflow.Steps))
+	var skipped []int
+	selected := *workflow
+	selected.Steps = make([]SagaStep, 0, len(workflow.Steps))
+	for i, step := range workflow.Steps {
+		runs := step.Condition == nil || step.Condition(ctx, payload, prior)
+		prior[step.BranchID] = runs
+		if !runs {
+			skipped = append(skipped, i)
+			continue
+		}
+		step.index = i
+		selected.Steps = append(selected.Steps, step)
+	}
+	return &selected, skipped
+}
+
+// selectTCCSteps returns the TCC steps whose condition holds and the
+// indexes of the skipped ones
+func selectTCCSteps(ctx context.Context, workflow *TCCWorkflow, payload []byte) (*TCCWorkflow, []int) {
+	prior := make(map[string]bool, len(workflow.Steps))
+	var skipped []int
+	selected := *workflow
+	selected.Steps = make([]TCCStep, 0, len(workflow.Steps))
+	for i, step := range workflow.Steps {
+		runs := step.Condition == nil || step.Condition(ctx, payload, prior)
+		prior[step.BranchID] = runs
+		if !runs {
+			skipped = append(skipped, i)
+			continue
+		}
+		step.index = i
+		selected.Steps = append(selected.Steps, step)
+	}
+	return &selected, skipped
+}
@@ -0,0 +1,215 @@
+package seata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigError lists every problem found by Config.Validate
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid seata config: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the configuration and returns a *ConfigError describing
+// every problem found, or nil
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	hasDiscovery := c.Discovery != nil && len(c.Discovery.EtcdEndpoints) > 0
+	switch {
+	case c.HTTPEndpoint == "" && !hasDiscovery:
+		add("HTTPEndpoint is empty; set it to the TC address (e.g. http://localhost:36789) or configure Discovery")
+	case c.HTTPEndpoint != "":
+		if _, err := NormalizeHTTPEndpoint(c.HTTPEndpoint, c.TLS != nil); err != nil {
+			add("HTTPEndpoint: %v; use the form http://host:port", err)
+		}
+	}
+	if c.GrpcEndpoint != "" {
+		if _, err := NormalizeGrpcEndpoint(c.GrpcEndpoint); err != nil {
+			add("GrpcEndpoint: %v; use the form host:port or unix:///path", err)
+		}
+	}
+
+	for _, field := range []struct {
+		name  string
+		value int64
+	}{
+		{"RequestTimeout", int64(c.RequestTimeout)},
+		{"RetryInterval", int64(c.RetryInterval)},
+		{"MaxRetries", int64(c.MaxRetries)},
+		{"MaxIdleConns", int64(c.MaxIdleConns)},
+		{"MaxConnsPerHost", int64(c.MaxConnsPerHost)},
+		{"IdleConnTimeout", int64(c.IdleConnTimeout)},
+		{"TLSHandshakeTimeout", int64(c.TLSHandshakeTimeout)},
+		{"ResponseHeaderTimeout", int64(c.ResponseHeaderTimeout)},
+		{"ExpectContinueTimeout", int64(c.ExpectContinueTimeout)},
+		{"CompressionThreshold", int64(c.CompressionThreshold)},
+	} {
+		if field.value < 0 {
+			add("%s cannot be negative (use 0 for the default or no limit)", field.name)
+		}
+	}
+	if c.MaxRetries > 0 && c.RetryInterval == 0 {
+		add("RetryInterval is 0 while MaxRetries is %d; retries would hammer the TC, set e.g. 1s", c.MaxRetries)
+	}
+
+	switch c.PreferredTransport {
+	case "", TransportPreferGRPC, TransportPreferHTTP:
+	default:
+		add("PreferredTransport %q is unknown; use %q or %q", c.PreferredTransport, TransportPreferGRPC, TransportPreferHTTP)
+	}
+
+	switch c.Transport {
+	case TransportAuto:
+	case TransportGRPCOnly:
+		if c.GrpcEndpoint == "" && !hasDiscovery {
+			add("Transport is %q but GrpcEndpoint is empty", TransportGRPCOnly)
+		}
+		if c.PreferredTransport == TransportPreferHTTP {
+			add("PreferredTransport %q conflicts with Transport %q", TransportPreferHTTP, TransportGRPCOnly)
+		}
+		if len(c.ReadEndpoints) > 0 || c.ReadDiscovery != nil {
+			add("ReadEndpoints and ReadDiscovery are served over HTTP, which Transport %q rules out", TransportGRPCOnly)
+		}
+	case TransportHTTPOnly:
+		if c.PreferredTransport == TransportPreferGRPC {
+			add("PreferredTransport %q conflicts with Transport %q", TransportPreferGRPC, TransportHTTPOnly)
+		}
+	case TransportConnect:
+		if c.GrpcEndpoint == "" && !hasDiscovery {
+			add("Transport is %q but GrpcEndpoint (the Connect URL) is empty", TransportConnect)
+		}
+	default:
+		add("Transport %q is unknown; use %q, %q, %q or leave it empty", c.Transport, TransportGRPCOnly, TransportHTTPOnly, TransportConnect)
+	}
+
+	switch c.LBRotation {
+	case "", LBRotateOnFailure:
+	case LBRotateInterval:
+		if c.LBRotateInterval <= 0 {
+			add("LBRotateInterval must be positive when LBRotation is %q", LBRotateInterval)
+		}
+	default:
+		add("LBRotation %q is unknown; use %q or %q", c.LBRotation, LBRotateOnFailure, LBRotateInterval)
+	}
+	switch c.LBStrategy {
+	case "", LBStrategyRoundRobin, LBStrategyLeastLatency:
+	default:
+		add("LBStrategy %q is unknown; use %q or %q", c.LBStrategy, LBStrategyRoundRobin, LBStrategyLeastLatency)
+	}
+
+	if c.Discovery != nil && len(c.Discovery.EtcdEndpoints) == 0 {
+		add("Discovery is set but has no EtcdEndpoints")
+	}
+	if c.Registration != nil {
+		if !hasDiscovery {
+			add("Registration requires Discovery with EtcdEndpoints")
+		}
+		if c.Registration.Address == "" {
+			add("Registration.Address is empty; set the URL the TC should call back")
+		}
+	}
+
+	if g := c.Grpc; g != nil {
+		if g.MaxRecvMsgSize < 0 || g.MaxSendMsgSize < 0 {
+			add("Grpc message size limits cannot be negative")
+		}
+		if g.DialTimeout < 0 || g.KeepaliveTime < 0 || g.KeepaliveTimeout < 0 {
+			add("Grpc timeouts cannot be negative")
+		}
+		if _, err := g.serviceConfig(); err != nil {
+			add("Grpc %v", err)
+		}
+	}
+
+	if p := c.BranchPool; p != nil && (p.MaxConcurrency < 0 || p.MaxPerHost < 0) {
+		add("BranchPool limits cannot be negative (use 0 for the default or no limit)")
+	}
+
+	if m := c.MetricsPush; m != nil {
+		switch m.Protocol {
+		case MetricsPushOTLP, MetricsPushStatsD:
+		default:
+			add("MetricsPush.Protocol %q is unknown; use %q or %q", m.Protocol, MetricsPushOTLP, MetricsPushStatsD)
+		}
+		if m.Endpoint == "" {
+			add("MetricsPush.Endpoint is empty")
+		}
+		if m.Interval < 0 {
+			add("MetricsPush.Interval cannot be negative")
+		}
+	}
+
+	if w := c.Watchdog; w != nil && w.SLA <= 0 {
+		add("Watchdog.SLA must be positive")
+	}
+
+	if d := c.Debug; d != nil && (d.Capacity < 0 || d.MaxBody < 0) {
+		add("Debug.Capacity and Debug.MaxBody cannot be negative")
+	}
+
+	if a := c.AbortEscalation; a != nil && (a.RetryInterval < 0 || a.EscalateAfter < 0) {
+		add("AbortEscalation.RetryInterval and EscalateAfter cannot be negative")
+	}
+
+	if e := c.Events; e != nil && (e.Sink == nil || e.BufferSize < 0) {
+		add("Events requires a Sink and a non-negative BufferSize")
+	}
+
+	if n := c.NATS; n != nil && (n.Conn == nil || n.Timeout < 0) {
+		add("NATS requires a Conn and a non-negative Timeout")
+	}
+
+	if strings.ContainsAny(c.GIDPrefix, "/?#% \t") {
+		add("GIDPrefix %q cannot contain '/', '?', '#', '%%' or whitespace; gids appear in URL paths", c.GIDPrefix)
+	}
+	if c.EnforceGIDPrefix && c.GIDPrefix == "" {
+		add("EnforceGIDPrefix is set but GIDPrefix is empty")
+	}
+
+	for _, endpoint := range c.ReadEndpoints {
+		if _, err := NormalizeHTTPEndpoint(endpoint, c.TLS != nil); err != nil {
+			add("ReadEndpoints: %v; use the form http://host:port", err)
+		}
+	}
+	if c.ReadDiscovery != nil && len(c.ReadDiscovery.EtcdEndpoints) == 0 {
+		add("ReadDiscovery is set but has no EtcdEndpoints")
+	}
+
+	if len(c.Regions) > 0 {
+		names := make(map[string]bool, len(c.Regions))
+		for _, r := range c.Regions {
+			switch {
+			case r.Name == "" || strings.ContainsAny(r.Name, "-/?#% \t"):
+				add("Regions: name %q must be non-empty without '-', '/', '?', '#', '%%' or whitespace", r.Name)
+			case names[r.Name]:
+				add("Regions: duplicate region %q", r.Name)
+			}
+			names[r.Name] = true
+			if r.HTTPEndpoint == "" && (r.Discovery == nil || len(r.Discovery.EtcdEndpoints) == 0) {
+				add("Regions: region %q needs an HTTPEndpoint or Discovery", r.Name)
+			}
+		}
+		if !names[c.LocalRegion] {
+			add("LocalRegion %q is not one of Regions", c.LocalRegion)
+		}
+	}
+
+	for _, r := range c.SuccessStatus {
+		if r.Min < 100 || r.Max > 599 || r.Min > r.Max {
+			add("SuccessStatus range %d-%d is invalid; use HTTP statuses with Min <= Max", r.Min, r.Max)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
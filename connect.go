@@ -0,0 +1,146 @@
+package seata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	seata_proto "github.com/seata-team/seata-go-client/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// connectCodes maps Connect error codes to gRPC codes
+var connectCodes = map[string]codes.Code{
+	"canceled":            codes.Canceled,
+	"unknown":             codes.Unknown,
+	"invalid_argument":    codes.InvalidArgument,
+	"deadline_exceeded":   codes.DeadlineExceeded,
+	"not_found":           codes.NotFound,
+	"already_exists":      codes.AlreadyExists,
+	"permission_denied":   codes.PermissionDenied,
+	"resource_exhausted":  codes.ResourceExhausted,
+	"failed_precondition": codes.FailedPrecondition,
+	"aborted":             codes.Aborted,
+	"out_of_range":        codes.OutOfRange,
+	"unimplemented":       codes.Unimplemented,
+	"internal":            codes.Internal,
+	"unavailable":         codes.Unavailable,
+	"data_loss":           codes.DataLoss,
+	"unauthenticated":     codes.Unauthenticated,
+}
+
+// connectConn speaks the Connect unary protocol over HTTP in place of a gRPC
+// connection, so the generated TransactionService client runs on it.
+// Errors are converted to gRPC status errors and handled like gRPC ones.
+type connectConn struct {
+	client  *Client
+	baseURL string
+}
+
+// newConnectClient returns a GrpcClient whose calls use the Connect protocol
+func (c *Client) newConnectClient(endpoint string) *GrpcClient {
+	baseURL := strings.TrimSuffix(endpoint, "/")
+	if strings.HasPrefix(baseURL, schemeGrpcs) {
+		baseURL = "https://" + strings.TrimPrefix(baseURL, schemeGrpcs)
+	}
+	if !strings.Contains(baseURL, "://") {
+		scheme := "http://"
+		if c.config.TLS != nil {
+			scheme = "https://"
+		}
+		baseURL = scheme + baseURL
+	}
+	conn := &connectConn{client: c, baseURL: baseURL}
+	return &GrpcClient{client: seata_proto.NewTransactionServiceClient(conn), config: DefaultGrpcConfig(), connect: conn}
+}
+
+// Invoke performs a unary call
+func (cc *connectConn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := cc.invoke(ctx, method, args, reply)
+	cc.client.stats.record("connect", cc.baseURL, time.Since(start), err)
+	return err
+}
+
+func (cc *connectConn) invoke(ctx context.Context, method string, args, reply interface{}) error {
+	body, err := proto.Marshal(args.(proto.Message))
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to marshal %s request: %v", method, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cc.baseURL+method, bytes.NewReader(body))
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to build %s request: %v", method, err)
+	}
+	req.Header.Set("Content-Type", "application/proto")
+	req.Header.Set("Connect-Protocol-Version", "1")
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Header.Set("Connect-Timeout-Ms", strconv.FormatInt(max(time.Until(deadline).Milliseconds(), 1), 10))
+	}
+	HeadersFromContext(ctx, req.Header.Set)
+
+	resp, err := cc.client.httpClient.GetClient().Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		// Keep dial errors visible to transportUnavailable
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to read %s response: %v", method, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return connectError(resp.StatusCode, data)
+	}
+	if err := proto.Unmarshal(data, reply.(proto.Message)); err != nil {
+		return status.Errorf(codes.Internal, "failed to unmarshal %s response: %v", method, err)
+	}
+	return nil
+}
+
+// NewStream is not supported; the TransactionService API is unary
+func (cc *connectConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, status.Errorf(codes.Unimplemented, "streaming %s is not supported over Connect", method)
+}
+
+// connectError converts a Connect error response into a gRPC status error
+func connectError(httpStatus int, body []byte) error {
+	var wire struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &wire) == nil && wire.Code != "" {
+		code, ok := connectCodes[wire.Code]
+		if !ok {
+			code = codes.Unknown
+		}
+		return status.Error(code, wire.Message)
+	}
+
+	// Responses of proxies without a Connect error body
+	code := codes.Unknown
+	switch httpStatus {
+	case http.StatusBadRequest:
+		code = codes.Internal
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		code = codes.Unavailable
+	}
+	return status.Error(code, fmt.Sprintf("HTTP status %d", httpStatus))
+}
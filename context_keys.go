@@ -0,0 +1,96 @@
+package seata
+
+import (
+	"context"
+	"net/http"
+)
+
+// Headers carrying transaction identifiers between services. gRPC metadata
+// uses the same names lower-cased, e.g. seata-gid.
+const (
+	HeaderGID           = "Seata-Gid"
+	HeaderBranchID      = "Seata-Branch-Id"
+	HeaderMode          = "Seata-Mode"
+	HeaderCorrelationID = CorrelationIDHeader
+)
+
+// WithGID returns a context carrying gid
+func WithGID(ctx context.Context, gid string) context.Context {
+	fields := fieldsFrom(ctx)
+	fields.gid = gid
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// GIDFromContext returns the gid carried by ctx, or ""
+func GIDFromContext(ctx context.Context) string {
+	return fieldsFrom(ctx).gid
+}
+
+// WithBranchID returns a context carrying branchID
+func WithBranchID(ctx context.Context, branchID string) context.Context {
+	fields := fieldsFrom(ctx)
+	fields.branchID = branchID
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// BranchIDFromContext returns the branch ID carried by ctx, or ""
+func BranchIDFromContext(ctx context.Context) string {
+	return fieldsFrom(ctx).branchID
+}
+
+// WithMode returns a context carrying the transaction mode
+func WithMode(ctx context.Context, mode string) context.Context {
+	fields := fieldsFrom(ctx)
+	fields.mode = mode
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// ModeFromContext returns the transaction mode carried by ctx, or ""
+func ModeFromContext(ctx context.Context) string {
+	return fieldsFrom(ctx).mode
+}
+
+// ContextFromHeaders returns ctx carrying the identifiers read with get,
+// which looks up a header by name: r.Header.Get for net/http and chi,
+// c.Get for fiber, or a metadata lookup for gRPC and connect-go. Missing
+// headers leave the context's values unchanged.
+func ContextFromHeaders(ctx context.Context, get func(name string) string) context.Context {
+	fields := fieldsFrom(ctx)
+	if v := get(HeaderGID); v != "" {
+		fields.gid = v
+	}
+	if v := get(HeaderBranchID); v != "" {
+		fields.branchID = v
+	}
+	if v := get(HeaderMode); v != "" {
+		fields.mode = v
+	}
+	if v := get(HeaderCorrelationID); v != "" {
+		fields.correlationID = v
+	}
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// HeadersFromContext calls set for every identifier carried by ctx, e.g.
+// with req.Header.Set on an outgoing request
+func HeadersFromContext(ctx context.Context, set func(name, value string)) {
+	fields := fieldsFrom(ctx)
+	for _, header := range [][2]string{
+		{HeaderGID, fields.gid},
+		{HeaderBranchID, fields.branchID},
+		{HeaderMode, fields.mode},
+		{HeaderCorrelationID, fields.correlationID},
+	} {
+		if header[1] != "" {
+			set(header[0], header[1])
+		}
+	}
+}
+
+// Middleware attaches the identifiers of incoming request headers to the
+// request context, for net/http compatible routers such as chi
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(ContextFromHeaders(r.Context(), r.Header.Get)))
+	})
+}
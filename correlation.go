@@ -0,0 +1,126 @@
+package seata
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// CorrelationIDHeader carries the correlation ID on HTTP calls; gRPC calls
+// carry it in the x-correlation-id metadata key
+const CorrelationIDHeader = "X-Correlation-ID"
+
+type logFieldsKey struct{}
+
+// logFields are the identifiers attached to a context
+type logFields struct {
+	correlationID string
+	gid           string
+	branchID      string
+	mode          string
+}
+
+func fieldsFrom(ctx context.Context) logFields {
+	fields, _ := ctx.Value(logFieldsKey{}).(logFields)
+	return fields
+}
+
+// WithCorrelationID returns a context carrying id. Transactions started
+// with it use id as their correlation ID instead of generating one.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	fields := fieldsFrom(ctx)
+	fields.correlationID = id
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// CorrelationIDFromContext returns the correlation ID carried by ctx, or ""
+func CorrelationIDFromContext(ctx context.Context) string {
+	return fieldsFrom(ctx).correlationID
+}
+
+// ContextLogger returns base with the correlation ID, gid and branch ID
+// carried by ctx attached, so every line logged with it identifies the
+// transaction. A nil base uses slog.Default().
+func ContextLogger(ctx context.Context, base *slog.Logger) *slog.Logger {
+	if base == nil {
+		base = slog.Default()
+	}
+	fields := fieldsFrom(ctx)
+	var attrs []any
+	if fields.correlationID != "" {
+		attrs = append(attrs, slog.String("correlation_id", fields.correlationID))
+	}
+	if fields.gid != "" {
+		attrs = append(attrs, slog.String("gid", fields.gid))
+	}
+	if fields.branchID != "" {
+		attrs = append(attrs, slog.String("branch_id", fields.branchID))
+	}
+	if len(attrs) == 0 {
+		return base
+	}
+	return base.With(attrs...)
+}
+
+// CorrelationID returns the correlation ID sent with every call of the transaction
+func (tx *Transaction) CorrelationID() string {
+	return tx.correlationID
+}
+
+// Context returns ctx carrying the transaction's correlation ID, gid and mode
+func (tx *Transaction) Context(ctx context.Context) context.Context {
+	fields := fieldsFrom(ctx)
+	if fields.gid == tx.gid && fields.mode == tx.mode && (tx.correlationID == "" || fields.correlationID == tx.correlationID) {
+		return ctx
+	}
+	fields.gid = tx.gid
+	fields.mode = tx.mode
+	if tx.correlationID != "" {
+		fields.correlationID = tx.correlationID
+	}
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// BranchContext is Context with branchID attached as well
+func (tx *Transaction) BranchContext(ctx context.Context, branchID string) context.Context {
+	fields := fieldsFrom(tx.Context(ctx))
+	fields.branchID = branchID
+	return context.WithValue(ctx, logFieldsKey{}, fields)
+}
+
+// newCorrelationID returns the correlation ID carried by ctx or a new one
+func newCorrelationID(ctx context.Context) string {
+	if id := CorrelationIDFromContext(ctx); id != "" {
+		return id
+	}
+	return uuid.New().String()
+}
+
+// installCorrelationHook sends the identifiers of the request context as headers
+func (c *Client) installCorrelationHook() {
+	c.httpClient.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+		HeadersFromContext(req.Context(), func(name, value string) {
+			if req.Header.Get(name) == "" {
+				req.SetHeader(name, value)
+			}
+		})
+		return nil
+	})
+}
+
+// correlationInterceptor sends the identifiers of the call context as metadata
+func correlationInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	var pairs []string
+	HeadersFromContext(ctx, func(name, value string) {
+		pairs = append(pairs, strings.ToLower(name), value)
+	})
+	if len(pairs) > 0 {
+		ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,198 @@
+package seata
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-resty/resty/v2"
+	"google.golang.org/grpc"
+)
+
+const (
+	defaultDebugCapacity = 100
+	defaultDebugMaxBody  = 2048
+)
+
+// DebugConfig enables capturing request/response dumps of every TC and
+// branch call, retrievable with Client.DebugTrace. Bodies pass through the
+// configured Redactor and sensitive headers are masked.
+type DebugConfig struct {
+	Capacity int // dumps kept; zero means 100
+	MaxBody  int // bytes kept of each body; zero means 2048
+}
+
+// DebugEntry is one captured call
+type DebugEntry struct {
+	Time            time.Time
+	Method          string // HTTP method, or "GRPC"
+	URL             string // request URL, or target plus gRPC method
+	RequestHeaders  http.Header
+	RequestBody     string
+	StatusCode      int
+	ResponseHeaders http.Header
+	ResponseBody    string
+	Latency         time.Duration
+	Error           string
+}
+
+type debugKey struct{}
+
+// WithDebugTrace returns a context whose calls are captured into the debug
+// trace even when Config.Debug is not set
+func WithDebugTrace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugKey{}, true)
+}
+
+// debugTrace is a ring buffer of captured calls
+type debugTrace struct {
+	maxBody int
+
+	mu      sync.Mutex
+	entries []DebugEntry
+	next    int
+	full    bool
+}
+
+func newDebugTrace(config *DebugConfig) *debugTrace {
+	capacity, maxBody := defaultDebugCapacity, defaultDebugMaxBody
+	if config != nil {
+		if config.Capacity > 0 {
+			capacity = config.Capacity
+		}
+		if config.MaxBody > 0 {
+			maxBody = config.MaxBody
+		}
+	}
+	return &debugTrace{maxBody: maxBody, entries: make([]DebugEntry, capacity)}
+}
+
+func (t *debugTrace) add(entry DebugEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries[t.next] = entry
+	t.next = (t.next + 1) % len(t.entries)
+	if t.next == 0 {
+		t.full = true
+	}
+}
+
+// snapshot returns the entries oldest first
+func (t *debugTrace) snapshot() []DebugEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.full {
+		return append([]DebugEntry(nil), t.entries[:t.next]...)
+	}
+	return append(append([]DebugEntry(nil), t.entries[t.next:]...), t.entries[:t.next]...)
+}
+
+// DebugTrace returns the captured calls, oldest first. It is empty unless
+// Config.Debug is set or calls were made with WithDebugTrace.
+func (c *Client) DebugTrace() []DebugEntry {
+	return c.debug.snapshot()
+}
+
+// debugEnabled reports whether calls made with ctx are captured
+func (c *Client) debugEnabled(ctx context.Context) bool {
+	if c.config.Debug != nil {
+		return true
+	}
+	forced, _ := ctx.Value(debugKey{}).(bool)
+	return forced
+}
+
+// installDebugHooks captures HTTP calls into the debug trace
+func (c *Client) installDebugHooks() {
+	c.httpClient.OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+		req := resp.Request
+		if !c.debugEnabled(req.Context()) {
+			return nil
+		}
+		entry := c.debugRequest(req)
+		entry.StatusCode = resp.StatusCode()
+		entry.ResponseHeaders = sanitizeHeaders(resp.Header())
+		entry.ResponseBody = c.debugBody(resp.String())
+		entry.Latency = resp.Time()
+		c.debug.add(entry)
+		return nil
+	})
+	c.httpClient.OnError(func(req *resty.Request, err error) {
+		if !c.debugEnabled(req.Context()) {
+			return
+		}
+		entry := c.debugRequest(req)
+		entry.Latency = time.Since(req.Time)
+		entry.Error = err.Error()
+		c.debug.add(entry)
+	})
+}
+
+// debugRequest dumps the request side of a call
+func (c *Client) debugRequest(req *resty.Request) DebugEntry {
+	var body string
+	switch b := req.Body.(type) {
+	case nil:
+	case string:
+		body = b
+	case []byte:
+		body = string(b)
+	default:
+		if data, err := json.Marshal(b); err == nil {
+			body = string(data)
+		}
+	}
+	return DebugEntry{
+		Time:           req.Time,
+		Method:         req.Method,
+		URL:            req.URL,
+		RequestHeaders: sanitizeHeaders(req.Header),
+		RequestBody:    c.debugBody(body),
+	}
+}
+
+// debugBody redacts and truncates a body on a rune boundary
+func (c *Client) debugBody(body string) string {
+	body = c.RedactBody(body)
+	if len(body) > c.debug.maxBody {
+		cut := c.debug.maxBody
+		for cut > 0 && !utf8.RuneStart(body[cut]) {
+			cut--
+		}
+		return body[:cut] + "...(truncated)"
+	}
+	return body
+}
+
+// debugInterceptor captures gRPC calls to target into the debug trace
+func (c *Client) debugInterceptor(target string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !c.debugEnabled(ctx) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		entry := DebugEntry{Time: start, Method: "GRPC", URL: target + method, Latency: time.Since(start)}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		c.debug.add(entry)
+		return err
+	}
+}
+
+// sanitizeHeaders copies h with credentials masked
+func sanitizeHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for name := range out {
+		switch strings.ToLower(name) {
+		case "authorization", "proxy-authorization", "cookie", "set-cookie", "x-api-key":
+			out[name] = []string{redactedPlaceholder}
+		}
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+)
+
+// StartTransactionIfAbsent starts a transaction tagged with businessKey unless
+// an unfinished transaction with the same key already exists, in which case
+// that transaction is returned and created is false, so sagas triggered by
+// retried webhooks or redelivered messages are started once.
+//
+// Deduplication is best-effort: calls with the same key are serialized
+// within this client only. Clients in other processes, or a TC that lists a
+// new transaction late, can still start duplicates; use a unique constraint
+// on the business key where that matters.
+//
+// Keyed transactions are always looked up and started over HTTP since the
+// gRPC API has no business key field. An existing transaction is returned
+// bound to the client of the region that started it.
+func (c *Client) StartTransactionIfAbsent(ctx context.Context, businessKey, mode string, payload []byte) (tx *Transaction, created bool, err error) {
+	if businessKey == "" {
+		return nil, false, fmt.Errorf("business key cannot be empty")
+	}
+	correlationID := newCorrelationID(ctx)
+	ctx = WithCorrelationID(ctx, correlationID)
+
+	unlock, err := c.lockBusinessKey(ctx, businessKey)
+	if err != nil {
+		return nil, false, err
+	}
+	defer unlock()
+
+	existing, err := c.findByBusinessKey(ctx, businessKey)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		return &Transaction{
+			client:   c.ForGID(existing.GID),
+			gid:      existing.GID,
+			mode:     existing.Mode,
+			payload:  existing.Payload,
+			branches: make([]*Branch, 0),
+			// The original correlation ID is not known to the client
+			correlationID: correlationID,
+		}, false, nil
+	}
+
+	encoded, err := c.encodePayload(payload)
+	if err != nil {
+		return nil, false, err
+	}
+	httpBase, gc := c.currentTargets()
+	gid := c.newGID()
+	if err := c.ValidateGID(gid); err != nil {
+		return nil, false, err
+	}
+	if err := c.authorize(ctx, AuthorizeStart, mode, gid, payload); err != nil {
+		return nil, false, err
+	}
+	tx, err = c.startTransactionHTTP(ctx, httpBase, gid, mode, encoded, map[string]string{"business_key": businessKey})
+	if err != nil {
+		return nil, false, err
+	}
+
+	tx.payload = payload
+	tx.correlationID = correlationID
+	if c.config.StickySessions {
+		tx.httpBase = httpBase
+		tx.grpc = gc
+	}
+	if c.watchdog != nil {
+		c.watchdog.Track(tx)
+	}
+	c.emit(EventTransactionStarted, tx, "")
+	return tx, true, nil
+}
+
+// businessKeyLock serializes the StartTransactionIfAbsent calls of one
+// business key, dropped once unused
+type businessKeyLock struct {
+	slot  chan struct{}
+	users int
+}
+
+// lockBusinessKey waits until no other call of this client holds key and
+// returns the unlock
+func (c *Client) lockBusinessKey(ctx context.Context, key string) (func(), error) {
+	c.dedupMu.Lock()
+	if c.dedupLocks == nil {
+		c.dedupLocks = make(map[string]*businessKeyLock)
+	}
+	l := c.dedupLocks[key]
+	if l == nil {
+		l = &businessKeyLock{slot: make(chan struct{}, 1)}
+		c.dedupLocks[key] = l
+	}
+	l.users++
+	c.dedupMu.Unlock()
+
+	release := func() {
+		c.dedupMu.Lock()
+		defer c.dedupMu.Unlock()
+		if l.users--; l.users == 0 {
+			delete(c.dedupLocks, key)
+		}
+	}
+	select {
+	case l.slot <- struct{}{}:
+	case <-ctx.Done():
+		release()
+		return nil, fmt.Errorf("waiting for business key %s: %w", key, ctx.Err())
+	}
+	return func() {
+		<-l.slot
+		release()
+	}, nil
+}
+
+// findByBusinessKey returns the unfinished transaction tagged with businessKey, or nil
+func (c *Client) findByBusinessKey(ctx context.Context, businessKey string) (*TransactionInfo, error) {
+	_, gc := c.currentTargets()
+	var found *TransactionInfo
+	err := c.withFailover(gc, false, func() error {
+		return fmt.Errorf("%w: the gRPC API cannot look up business keys", ErrTransportDisabled)
+	}, func() (err error) {
+		found, err = c.findByBusinessKeyHTTP(ctx, businessKey)
+		return err
+	})
+	return found, err
+}
+
+// findByBusinessKeyHTTP looks businessKey up via HTTP
+func (c *Client) findByBusinessKeyHTTP(ctx context.Context, businessKey string) (*TransactionInfo, error) {
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		SetQueryParam("business_key", businessKey).
+		Get("/api/tx")
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up business key %s: %w", businessKey, err)
+	}
+	if resp.StatusCode() != 200 {
+		return nil, c.statusError(fmt.Sprintf("failed to look up business key %s", businessKey), resp)
+	}
+
+	var transactions []*TransactionInfo
+	if err := decodeJSON(resp.Body(), &transactions); err != nil {
+		return nil, fmt.Errorf("failed to parse transactions list: %w", err)
+	}
+	for _, info := range transactions {
+		// Filter again in case the TC ignores the query parameter
+		if info.BusinessKey != businessKey || IsTerminal(info.Status) {
+			continue
+		}
+		if err := c.decodeTransactionInfo(info); err != nil {
+			return nil, err
+		}
+		return info, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,209 @@
+package seata
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// PayloadEncrypter encrypts transaction payloads before they are sent to the TC
+type PayloadEncrypter interface {
+	// Encrypt encrypts the payload with the active key
+	Encrypt(plaintext []byte) ([]byte, error)
+	// Decrypt decrypts a payload produced by Encrypt, using the key it was sealed with
+	Decrypt(ciphertext []byte) ([]byte, error)
+	// IsEncrypted reports whether data was produced by Encrypt
+	IsEncrypted(data []byte) bool
+}
+
+// KeyProvider resolves a key by id, e.g. by unwrapping it through a KMS
+type KeyProvider func(keyID string) ([]byte, error)
+
+// encryptedPayloadMagic prefixes every payload sealed by AESGCMEncrypter
+var encryptedPayloadMagic = []byte("SEC1")
+
+// AESGCMEncrypter encrypts payloads with AES-GCM.
+// Sealed payloads carry the id of the key used, so old payloads remain
+// readable after the active key is rotated.
+//
+// Layout: magic | key id length (1 byte) | key id | nonce | ciphertext
+type AESGCMEncrypter struct {
+	mu          sync.RWMutex
+	activeKeyID string
+	keys        map[string][]byte
+	provider    KeyProvider
+}
+
+// NewAESGCMEncrypter creates an encrypter sealing new payloads with the given key.
+// The key must be 16, 24 or 32 bytes long.
+func NewAESGCMEncrypter(keyID string, key []byte) (*AESGCMEncrypter, error) {
+	e := &AESGCMEncrypter{keys: make(map[string][]byte)}
+	if err := e.AddKey(keyID, key); err != nil {
+		return nil, err
+	}
+	e.activeKeyID = keyID
+	return e, nil
+}
+
+// NewAESGCMEncrypterWithProvider creates an encrypter that resolves keys through provider
+func NewAESGCMEncrypterWithProvider(activeKeyID string, provider KeyProvider) (*AESGCMEncrypter, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("key provider cannot be nil")
+	}
+	return &AESGCMEncrypter{
+		activeKeyID: activeKeyID,
+		keys:        make(map[string][]byte),
+		provider:    provider,
+	}, nil
+}
+
+// AddKey registers a key that can be used for decryption
+func (e *AESGCMEncrypter) AddKey(keyID string, key []byte) error {
+	if keyID == "" || len(keyID) > 255 {
+		return fmt.Errorf("invalid key id: %q", keyID)
+	}
+	if _, err := aes.NewCipher(key); err != nil {
+		return fmt.Errorf("invalid key %s: %w", keyID, err)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.keys[keyID] = key
+	return nil
+}
+
+// Rotate makes keyID the key used for new payloads
+func (e *AESGCMEncrypter) Rotate(keyID string, key []byte) error {
+	if err := e.AddKey(keyID, key); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.activeKeyID = keyID
+	return nil
+}
+
+// ActiveKeyID returns the id of the key used for new payloads
+func (e *AESGCMEncrypter) ActiveKeyID() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.activeKeyID
+}
+
+// Encrypt seals plaintext with the active key
+func (e *AESGCMEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	keyID := e.ActiveKeyID()
+	gcm, err := e.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	header := make([]byte, 0, len(encryptedPayloadMagic)+1+len(keyID)+len(nonce))
+	header = append(header, encryptedPayloadMagic...)
+	header = append(header, byte(len(keyID)))
+	header = append(header, keyID...)
+	header = append(header, nonce...)
+
+	// The header (minus the nonce) is authenticated as additional data
+	aad := append([]byte(nil), header[:len(header)-len(nonce)]...)
+	return gcm.Seal(header, nonce, plaintext, aad), nil
+}
+
+// Decrypt opens a payload sealed by Encrypt
+func (e *AESGCMEncrypter) Decrypt(ciphertext []byte) ([]byte, error) {
+	if !e.IsEncrypted(ciphertext) {
+		return nil, fmt.Errorf("payload is not encrypted")
+	}
+
+	rest := ciphertext[len(encryptedPayloadMagic):]
+	idLen := int(rest[0])
+	if len(rest) < 1+idLen {
+		return nil, fmt.Errorf("malformed encrypted payload")
+	}
+	keyID := string(rest[1 : 1+idLen])
+	aad := ciphertext[:len(encryptedPayloadMagic)+1+idLen]
+
+	gcm, err := e.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	body := rest[1+idLen:]
+	if len(body) < gcm.NonceSize() {
+		return nil, fmt.Errorf("malformed encrypted payload")
+	}
+	nonce, sealed := body[:gcm.NonceSize()], body[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, sealed, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload with key %s: %w", keyID, err)
+	}
+	return plaintext, nil
+}
+
+// IsEncrypted checks for the encrypted payload header
+func (e *AESGCMEncrypter) IsEncrypted(data []byte) bool {
+	return len(data) > len(encryptedPayloadMagic) && bytes.HasPrefix(data, encryptedPayloadMagic)
+}
+
+// aead returns the AES-GCM cipher for keyID, consulting the key provider if needed
+func (e *AESGCMEncrypter) aead(keyID string) (cipher.AEAD, error) {
+	e.mu.RLock()
+	key, ok := e.keys[keyID]
+	provider := e.provider
+	e.mu.RUnlock()
+
+	if !ok {
+		if provider == nil {
+			return nil, fmt.Errorf("unknown encryption key: %s", keyID)
+		}
+		resolved, err := provider(keyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve encryption key %s: %w", keyID, err)
+		}
+		if err := e.AddKey(keyID, resolved); err != nil {
+			return nil, err
+		}
+		key = resolved
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key %s: %w", keyID, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptPayload encrypts the payload if an encrypter is configured
+func (c *Client) encryptPayload(payload []byte) ([]byte, error) {
+	if c.config.Encrypter == nil {
+		return payload, nil
+	}
+	sealed, err := c.config.Encrypter.Encrypt(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt payload: %w", err)
+	}
+	return sealed, nil
+}
+
+// decryptPayload decrypts a payload read back from the TC.
+// Payloads that were stored before encryption was enabled are returned as-is.
+func (c *Client) decryptPayload(payload []byte) ([]byte, error) {
+	encrypter := c.config.Encrypter
+	if encrypter == nil || !encrypter.IsEncrypted(payload) {
+		return payload, nil
+	}
+	plaintext, err := encrypter.Decrypt(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	return plaintext, nil
+}
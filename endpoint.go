@@ -0,0 +1,107 @@
+package seata
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// schemeGrpcs marks a gRPC endpoint dialed with TLS
+const schemeGrpcs = "grpcs://"
+
+// NormalizeHTTPEndpoint turns host:port, http:// or https:// endpoints, with
+// or without trailing slashes, into a canonical base URL. A missing scheme
+// becomes http://, or https:// when useTLS is set; useTLS also upgrades an
+// explicit http:// scheme. Unix socket endpoints are returned unchanged.
+func NormalizeHTTPEndpoint(endpoint string, useTLS bool) (string, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return "", fmt.Errorf("endpoint is empty")
+	}
+	if _, _, ok := parseUnixHTTPEndpoint(endpoint); ok {
+		return endpoint, nil
+	}
+
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid endpoint %q: %w", endpoint, err)
+	}
+	switch u.Scheme {
+	case "http", "https":
+	default:
+		return "", fmt.Errorf("invalid endpoint %q: scheme must be http or https", endpoint)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid endpoint %q: missing host", endpoint)
+	}
+	if useTLS {
+		u.Scheme = "https"
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+// NormalizeGrpcEndpoint turns grpc://, http:// or bare host:port endpoints
+// into a host:port dial target and verifies a port is present. grpcs://
+// endpoints keep their scheme, which makes the client dial with TLS. Unix
+// socket and dns: resolver targets are returned unchanged.
+func NormalizeGrpcEndpoint(endpoint string) (string, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return "", fmt.Errorf("endpoint is empty")
+	}
+	if strings.HasPrefix(endpoint, schemeUnix) || strings.HasPrefix(endpoint, "unix:") || strings.HasPrefix(endpoint, schemeHTTPUnix) {
+		return endpoint, nil
+	}
+
+	scheme := ""
+	if i := strings.Index(endpoint, "://"); i >= 0 {
+		scheme = endpoint[:i]
+		switch scheme {
+		case "dns":
+			// dns:[//authority]/host:port, resolved by gRPC itself
+			_, target, _ := strings.Cut(endpoint[i+3:], "/")
+			if _, port, err := net.SplitHostPort(target); err != nil || port == "" {
+				return "", fmt.Errorf("invalid gRPC endpoint %q: expected dns:///host:port", endpoint)
+			}
+			return endpoint, nil
+		case "grpc", "grpcs", "http", "https":
+		default:
+			return "", fmt.Errorf("invalid gRPC endpoint %q: unsupported scheme %q", endpoint, scheme)
+		}
+		endpoint = endpoint[i+3:]
+	}
+	endpoint = strings.TrimRight(endpoint, "/")
+
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil || port == "" {
+		return "", fmt.Errorf("invalid gRPC endpoint %q: expected host:port", endpoint)
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	if scheme == "grpcs" {
+		return schemeGrpcs + net.JoinHostPort(host, port), nil
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// normalizeEndpoints rewrites the configured endpoints into canonical form,
+// leaving any it cannot parse for Validate to report
+func (c *Config) normalizeEndpoints() {
+	if c.HTTPEndpoint != "" {
+		if normalized, err := NormalizeHTTPEndpoint(c.HTTPEndpoint, c.TLS != nil); err == nil {
+			c.HTTPEndpoint = normalized
+		}
+	}
+	if c.GrpcEndpoint != "" {
+		if normalized, err := NormalizeGrpcEndpoint(c.GrpcEndpoint); err == nil {
+			c.GrpcEndpoint = normalized
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"google.golang.org/grpc"
+)
+
+// endpointStatsAlpha is the smoothing factor of the rolling averages
+const endpointStatsAlpha = 0.2
+
+// EndpointStat is a snapshot of the observed behaviour of one endpoint
+type EndpointStat struct {
+	Endpoint    string        `json:"endpoint"`
+	Protocol    string        `json:"protocol"`
+	Requests    int64         `json:"requests"`
+	Errors      int64         `json:"errors"`
+	AvgLatency  time.Duration `json:"avg_latency"` // exponentially weighted moving average
+	ErrorRate   float64       `json:"error_rate"`  // exponentially weighted moving average in [0, 1]
+	LastLatency time.Duration `json:"last_latency"`
+	LastError   string        `json:"last_error,omitempty"`
+	LastSeen    time.Time     `json:"last_seen"`
+}
+
+// endpointLatencySamples is the number of recent latencies kept per endpoint
+// for percentile estimates
+const endpointLatencySamples = 128
+
+// endpointStats tracks rolling latency and error rates per endpoint
+type endpointStats struct {
+	mu      sync.RWMutex
+	stats   map[string]*EndpointStat
+	samples map[string][]time.Duration // ring of recent successful latencies
+	next    map[string]int
+}
+
+func newEndpointStats() *endpointStats {
+	return &endpointStats{
+		stats:   make(map[string]*EndpointStat),
+		samples: make(map[string][]time.Duration),
+		next:    make(map[string]int),
+	}
+}
+
+// record adds one observation for endpoint
+func (es *endpointStats) record(protocol, endpoint string, latency time.Duration, err error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	key := protocol + "|" + endpoint
+	stat, ok := es.stats[key]
+	if !ok {
+		stat = &EndpointStat{Endpoint: endpoint, Protocol: protocol, AvgLatency: latency}
+		es.stats[key] = stat
+	}
+
+	failed := 0.0
+	if err != nil {
+		failed = 1.0
+		stat.Errors++
+		stat.LastError = err.Error()
+	}
+	stat.Requests++
+	stat.AvgLatency = time.Duration(endpointStatsAlpha*float64(latency) + (1-endpointStatsAlpha)*float64(stat.AvgLatency))
+	stat.ErrorRate = endpointStatsAlpha*failed + (1-endpointStatsAlpha)*stat.ErrorRate
+	stat.LastLatency = latency
+	stat.LastSeen = time.Now()
+
+	if err == nil {
+		ring := es.samples[key]
+		if len(ring) < endpointLatencySamples {
+			es.samples[key] = append(ring, latency)
+		} else {
+			ring[es.next[key]] = latency
+			es.next[key] = (es.next[key] + 1) % endpointLatencySamples
+		}
+	}
+}
+
+// percentile returns the p-th percentile (0-100) of recent successful
+// latencies of endpoint, or false when there are no samples
+func (es *endpointStats) percentile(protocol, endpoint string, p float64) (time.Duration, bool) {
+	es.mu.RLock()
+	sorted := append([]time.Duration(nil), es.samples[protocol+"|"+endpoint]...)
+	es.mu.RUnlock()
+	if len(sorted) == 0 {
+		return 0, false
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(float64(len(sorted)-1) * p / 100)
+	return sorted[idx], true
+}
+
+// get returns the stat for endpoint, if any
+func (es *endpointStats) get(protocol, endpoint string) (EndpointStat, bool) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	stat, ok := es.stats[protocol+"|"+endpoint]
+	if !ok {
+		return EndpointStat{}, false
+	}
+	return *stat, true
+}
+
+// snapshot returns a copy of all stats sorted by protocol and endpoint
+func (es *endpointStats) snapshot() []EndpointStat {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	result := make([]EndpointStat, 0, len(es.stats))
+	for _, stat := range es.stats {
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Protocol != result[j].Protocol {
+			return result[i].Protocol < result[j].Protocol
+		}
+		return result[i].Endpoint < result[j].Endpoint
+	})
+	return result
+}
+
+// score ranks endpoints for the least-latency strategy; lower is better.
+// Endpoints that have not been observed yet score zero so they get probed.
+func (es *endpointStats) score(protocol, endpoint string) float64 {
+	stat, ok := es.get(protocol, endpoint)
+	if !ok {
+		return 0
+	}
+	// Penalize errors: an endpoint failing every call looks 10x slower
+	return float64(stat.AvgLatency) * (1 + 9*stat.ErrorRate)
+}
+
+// EndpointStats returns the observed latency and error rate of every endpoint
+// the client has talked to
+func (c *Client) EndpointStats() []EndpointStat {
+	return c.stats.snapshot()
+}
+
+// installStatsHooks records latency and errors of every HTTP request,
+// feeding the latency histograms when they are enabled
+func (c *Client) installStatsHooks() {
+	c.httpClient.OnError(func(req *resty.Request, err error) {
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		c.stats.record("http", endpointOf(req.URL), time.Since(req.Time), err)
+		c.histograms.observe(req.Context(), httpOperation(req.URL), time.Since(req.Time))
+	})
+	c.httpClient.OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+		var err error
+		if resp.StatusCode() >= 500 {
+			err = &SeataError{Code: ErrCodeServerError, Message: resp.Status()}
+		}
+		c.stats.record("http", endpointOf(resp.Request.URL), resp.Time(), err)
+		c.histograms.observe(resp.Request.Context(), httpOperation(resp.Request.URL), resp.Time())
+		return nil
+	})
+}
+
+// statsInterceptor records latency and errors of every gRPC call to target
+func (c *Client) statsInterceptor(target string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		c.stats.record("grpc", target, time.Since(start), err)
+		c.histograms.observe(ctx, grpcOperation(method), time.Since(start))
+		return err
+	}
+}
+
+// newGrpcClient dials addr with the configured settings and stats collection
+func (c *Client) newGrpcClient(addr string) *GrpcClient {
+	switch c.config.Transport {
+	case TransportHTTPOnly:
+		return &GrpcClient{}
+	case TransportConnect:
+		if addr == "" {
+			return &GrpcClient{}
+		}
+		return c.newConnectClient(addr)
+	}
+	config := DefaultGrpcConfig()
+	if c.config.Grpc != nil {
+		copied := *c.config.Grpc
+		config = &copied
+	}
+	if config.TLS == nil {
+		config.TLS = c.config.TLS
+	}
+	config.DialOptions = append(append([]grpc.DialOption(nil), config.DialOptions...),
+		grpc.WithChainUnaryInterceptor(c.statsInterceptor(addr), correlationInterceptor, c.debugInterceptor(addr)))
+	return NewGrpcClientWithConfig(addr, config)
+}
+
+// endpointOf reduces a request URL to scheme://host
+func endpointOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Scheme + "://" + u.Host
+}
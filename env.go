@@ -0,0 +1,131 @@
+package seata
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by ConfigFromEnv
+const (
+	EnvHTTPEndpoint          = "SEATA_HTTP_ENDPOINT"
+	EnvGrpcEndpoint          = "SEATA_GRPC_ENDPOINT" // set but empty disables gRPC
+	EnvTransport             = "SEATA_TRANSPORT"
+	EnvPreferredTransport    = "SEATA_PREFERRED_TRANSPORT"
+	EnvRequestTimeout        = "SEATA_REQUEST_TIMEOUT" // a duration such as "5s"
+	EnvRetryInterval         = "SEATA_RETRY_INTERVAL"
+	EnvMaxRetries            = "SEATA_MAX_RETRIES"
+	EnvMaxIdleConns          = "SEATA_MAX_IDLE_CONNS"
+	EnvMaxConnsPerHost       = "SEATA_MAX_CONNS_PER_HOST"
+	EnvAuthToken             = "SEATA_AUTH_TOKEN"
+	EnvGIDPrefix             = "SEATA_GID_PREFIX"
+	EnvTLSCAFile             = "SEATA_TLS_CA_FILE"
+	EnvTLSCertFile           = "SEATA_TLS_CERT_FILE"
+	EnvTLSKeyFile            = "SEATA_TLS_KEY_FILE"
+	EnvTLSServerName         = "SEATA_TLS_SERVER_NAME"
+	EnvTLSInsecureSkipVerify = "SEATA_TLS_INSECURE_SKIP_VERIFY"
+	EnvEtcdEndpoints         = "SEATA_ETCD_ENDPOINTS" // comma-separated
+	EnvDiscoveryNamespace    = "SEATA_DISCOVERY_NAMESPACE"
+)
+
+// ConfigFromEnv returns DefaultConfig with the SEATA_* environment variables
+// applied; unset variables keep the defaults. TLS is enabled when any
+// SEATA_TLS_* variable is set and discovery when SEATA_ETCD_ENDPOINTS is.
+// Malformed values are reported together as a *ConfigError.
+func ConfigFromEnv() (*Config, error) {
+	var problems []string
+	env := func(name string) string {
+		return strings.TrimSpace(os.Getenv(name))
+	}
+	duration := func(name string) ProfileDuration {
+		v := env(name)
+		if v == "" {
+			return 0
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %q is not a duration such as \"5s\"", name, v))
+		}
+		return ProfileDuration(d)
+	}
+	integer := func(name string) *int {
+		v := env(name)
+		if v == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %q is not an integer", name, v))
+			return nil
+		}
+		return &n
+	}
+
+	profile := Profile{
+		HTTPEndpoint:       env(EnvHTTPEndpoint),
+		GrpcEndpoint:       env(EnvGrpcEndpoint),
+		Transport:          env(EnvTransport),
+		PreferredTransport: env(EnvPreferredTransport),
+		RequestTimeout:     duration(EnvRequestTimeout),
+		RetryInterval:      duration(EnvRetryInterval),
+		MaxRetries:         integer(EnvMaxRetries),
+		MaxIdleConns:       integer(EnvMaxIdleConns),
+		MaxConnsPerHost:    integer(EnvMaxConnsPerHost),
+		AuthToken:          env(EnvAuthToken),
+		GIDPrefix:          env(EnvGIDPrefix),
+	}
+
+	tls := ProfileTLS{
+		CAFile:     env(EnvTLSCAFile),
+		CertFile:   env(EnvTLSCertFile),
+		KeyFile:    env(EnvTLSKeyFile),
+		ServerName: env(EnvTLSServerName),
+	}
+	if v := env(EnvTLSInsecureSkipVerify); v != "" {
+		skip, err := strconv.ParseBool(v)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %q is not a boolean", EnvTLSInsecureSkipVerify, v))
+		}
+		tls.InsecureSkipVerify = skip
+	}
+	if tls != (ProfileTLS{}) {
+		profile.TLS = &tls
+	}
+
+	if v := env(EnvEtcdEndpoints); v != "" {
+		discovery := &ProfileDiscovery{Namespace: env(EnvDiscoveryNamespace)}
+		for _, endpoint := range strings.Split(v, ",") {
+			if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+				discovery.EtcdEndpoints = append(discovery.EtcdEndpoints, endpoint)
+			}
+		}
+		profile.Discovery = discovery
+	}
+
+	if len(problems) > 0 {
+		return nil, &ConfigError{Problems: problems}
+	}
+	config := DefaultConfig()
+	if err := profile.apply(config); err != nil {
+		return nil, fmt.Errorf("invalid SEATA_TLS_* settings: %w", err)
+	}
+	if v, ok := os.LookupEnv(EnvGrpcEndpoint); ok && strings.TrimSpace(v) == "" {
+		config.GrpcEndpoint = ""
+	}
+	return config, nil
+}
+
+// NewClientFromEnv creates a client configured by ConfigFromEnv, then each
+// override in order, failing when the result does not pass Config.Validate
+func NewClientFromEnv(overrides ...func(*Config)) (*Client, error) {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	for _, override := range overrides {
+		override(config)
+	}
+	return NewClientE(config)
+}
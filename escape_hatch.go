@@ -0,0 +1,30 @@
+package seata
+
+import (
+	"github.com/go-resty/resty/v2"
+	"google.golang.org/grpc"
+)
+
+// HTTP returns the resty client the Client sends TC requests with, for TC
+// endpoints this package does not wrap yet. Requests made with it share the
+// connection pool, TLS, base URL, correlation headers, stats and debug
+// hooks of the Client.
+//
+// Advanced: changing its settings or hooks changes them for every call the
+// Client makes.
+func (c *Client) HTTP() *resty.Client {
+	return c.httpClient
+}
+
+// GRPCConn returns the connection of the current gRPC endpoint, for TC
+// services and methods this package does not wrap yet, e.g.
+// pb.NewAdminServiceClient(client.GRPCConn()). Calls made on it go through
+// the Client's interceptors. With TransportConnect it is the Connect
+// protocol adapter, which supports unary calls only. It is nil when gRPC is
+// disabled or not connected.
+//
+// Advanced: do not close it; Client.Close does.
+func (c *Client) GRPCConn() grpc.ClientConnInterface {
+	_, gc := c.currentTargets()
+	return gc.Conn()
+}
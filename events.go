@@ -0,0 +1,175 @@
+package seata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/google/uuid"
+)
+
+// CloudEvents types emitted for the transaction lifecycle
+const (
+	EventTransactionStarted   = "io.seata.transaction.started"
+	EventTransactionSubmitted = "io.seata.transaction.submitted"
+	EventTransactionCommitted = "io.seata.transaction.committed"
+	EventTransactionAborted   = "io.seata.transaction.aborted"
+	EventBranchRegistered     = "io.seata.branch.registered"
+	EventBranchSucceeded      = "io.seata.branch.succeeded"
+	EventBranchFailed         = "io.seata.branch.failed"
+)
+
+// CloudEvent is a CloudEvents 1.0 event in structured JSON form
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"` // the gid
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// EventData is the data of lifecycle events
+type EventData struct {
+	GID           string `json:"gid"`
+	Mode          string `json:"mode,omitempty"`
+	BranchID      string `json:"branch_id,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// EventSink delivers events, e.g. to an HTTP endpoint, a Kafka topic or a
+// NATS subject
+type EventSink interface {
+	Send(ctx context.Context, event CloudEvent) error
+}
+
+// EventSinkFunc adapts a function to EventSink. Kafka and NATS producers can
+// publish json.Marshal(event) with it.
+type EventSinkFunc func(ctx context.Context, event CloudEvent) error
+
+// Send calls f
+func (f EventSinkFunc) Send(ctx context.Context, event CloudEvent) error {
+	return f(ctx, event)
+}
+
+// HTTPEventSink posts events in structured content mode
+type HTTPEventSink struct {
+	URL     string
+	Headers map[string]string
+	client  *resty.Client
+}
+
+// NewHTTPEventSink creates a sink posting to url
+func NewHTTPEventSink(url string) *HTTPEventSink {
+	return &HTTPEventSink{URL: url, client: resty.New().SetTimeout(10 * time.Second)}
+}
+
+// Send posts event and expects a 2xx answer
+func (s *HTTPEventSink) Send(ctx context.Context, event CloudEvent) error {
+	resp, err := s.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/cloudevents+json").
+		SetHeaders(s.Headers).
+		SetBody(event).
+		Post(s.URL)
+	if err != nil {
+		return fmt.Errorf("failed to send event: %w", err)
+	}
+	if resp.StatusCode() < http.StatusOK || resp.StatusCode() >= http.StatusMultipleChoices {
+		return fmt.Errorf("failed to send event: status %d", resp.StatusCode())
+	}
+	return nil
+}
+
+// EventsConfig enables emitting lifecycle CloudEvents. Events are delivered
+// in the background in order; when the buffer is full they are dropped with
+// a warning rather than slowing down transactions.
+type EventsConfig struct {
+	Sink EventSink
+	// Source is the CloudEvents source attribute; empty means "seata-go-client"
+	Source string
+	// BufferSize is the number of undelivered events kept; zero means 1000
+	BufferSize int
+}
+
+// eventEmitter delivers events to the sink
+type eventEmitter struct {
+	config EventsConfig
+	queue  chan CloudEvent
+	done   chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newEventEmitter(config *EventsConfig) *eventEmitter {
+	copied := *config
+	if copied.Source == "" {
+		copied.Source = "seata-go-client"
+	}
+	if copied.BufferSize <= 0 {
+		copied.BufferSize = 1000
+	}
+	return &eventEmitter{
+		config: copied,
+		queue:  make(chan CloudEvent, copied.BufferSize),
+		done:   make(chan struct{}),
+	}
+}
+
+func (e *eventEmitter) run() {
+	defer close(e.done)
+	for event := range e.queue {
+		if err := e.config.Sink.Send(context.Background(), event); err != nil {
+			fmt.Printf("Warning: failed to emit %s for %s: %v\n", event.Type, event.Subject, err)
+		}
+	}
+}
+
+// close delivers the queued events and stops
+func (e *eventEmitter) close() {
+	e.mu.Lock()
+	e.closed = true
+	close(e.queue)
+	e.mu.Unlock()
+	<-e.done
+}
+
+// enqueue queues event unless the buffer is full or the emitter is closed
+func (e *eventEmitter) enqueue(event CloudEvent) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return
+	}
+	select {
+	case e.queue <- event:
+	default:
+		fmt.Printf("Warning: event buffer full, dropping %s for %s\n", event.Type, event.Subject)
+	}
+}
+
+// emit queues an event of tx; it is a no-op without Config.Events
+func (c *Client) emit(eventType string, tx *Transaction, branchID string) {
+	if c.events == nil {
+		return
+	}
+	data, _ := json.Marshal(EventData{GID: tx.gid, Mode: tx.mode, BranchID: branchID, CorrelationID: tx.correlationID})
+	event := CloudEvent{
+		SpecVersion:     "1.0",
+		ID:              uuid.New().String(),
+		Source:          c.events.config.Source,
+		Type:            eventType,
+		Subject:         tx.gid,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+	c.events.enqueue(event)
+}
@@ -115,37 +115,42 @@ func manualTCCTransaction() {
 	ctx := context.Background()
 	payload := []byte(`{"order_id": "12345", "amount": 100.00}`)
 
+	if err := runManualTCC(ctx, client, payload); err != nil {
+		log.Fatalf("TCC transaction failed: %v", err)
+	}
+
+	fmt.Println("TCC transaction completed successfully!")
+}
+
+// runManualTCC drives the TCC phases by hand; the guard aborts the
+// transaction on error or panic and submits it on success
+func runManualTCC(ctx context.Context, client *seata.Client, payload []byte) (err error) {
 	// Start TCC transaction
 	tx, err := client.StartTransaction(ctx, seata.ModeTCC, payload)
 	if err != nil {
-		log.Fatalf("Failed to start transaction: %v", err)
+		return fmt.Errorf("failed to start transaction: %w", err)
 	}
+	done := tx.Guard(ctx)
+	defer done(&err)
 
 	fmt.Printf("Started TCC transaction: %s\n", tx.GetGID())
 
 	// Try phase
 	fmt.Println("Executing try phase...")
-	err = tx.Try(ctx, "order-service", "http://order-service:8080/api/orders/try", payload)
-	if err != nil {
-		log.Fatalf("Try phase failed: %v", err)
+	if err := tx.Try(ctx, "order-service", "http://order-service:8080/api/orders/try", payload); err != nil {
+		return fmt.Errorf("try phase failed: %w", err)
 	}
-
-	err = tx.Try(ctx, "payment-service", "http://payment-service:8080/api/payments/try", payload)
-	if err != nil {
-		log.Fatalf("Try phase failed: %v", err)
+	if err := tx.Try(ctx, "payment-service", "http://payment-service:8080/api/payments/try", payload); err != nil {
+		return fmt.Errorf("try phase failed: %w", err)
 	}
 
 	// Confirm phase
 	fmt.Println("Executing confirm phase...")
-	err = tx.Confirm(ctx, "order-service")
-	if err != nil {
-		log.Fatalf("Confirm phase failed: %v", err)
+	if err := tx.Confirm(ctx, "order-service"); err != nil {
+		return fmt.Errorf("confirm phase failed: %w", err)
 	}
-
-	err = tx.Confirm(ctx, "payment-service")
-	if err != nil {
-		log.Fatalf("Confirm phase failed: %v", err)
+	if err := tx.Confirm(ctx, "payment-service"); err != nil {
+		return fmt.Errorf("confirm phase failed: %w", err)
 	}
-
-	fmt.Println("TCC transaction completed successfully!")
+	return nil
 }
@@ -0,0 +1,25 @@
+package seata
+
+import "errors"
+
+// Result codes of SagaManager and TCCManager executions. Execution errors
+// wrap them, so callers can branch with errors.Is.
+var (
+	// ErrTryPhaseFailed means a TCC try failed; the tried branches were
+	// cancelled
+	ErrTryPhaseFailed = errors.New("TCC try phase failed")
+	// ErrConfirmPhaseFailed means a TCC confirm failed after every try
+	// succeeded
+	ErrConfirmPhaseFailed = errors.New("TCC confirm phase failed")
+	// ErrCompensationFailed means a TCC cancel or a custom saga
+	// compensation failed, so a branch may still hold its effects
+	ErrCompensationFailed = errors.New("compensation failed")
+	// ErrExecutionTimeout means the execution did not finish within
+	// ExecutionOptions.Timeout, or the TC timed the transaction out
+	ErrExecutionTimeout = errors.New("execution timed out")
+	// ErrStepTimeout means a TCC try ran longer than its step's Timeout;
+	// the branch was marked failed and the tried branches cancelled
+	ErrStepTimeout = errors.New("step timed out")
+	// ErrSagaAborted means the TC rolled the saga back
+	ErrSagaAborted = errors.New("saga transaction aborted")
+)
@@ -0,0 +1,121 @@
+package seata
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Export formats
+const (
+	ExportFormatJSONL = "jsonl"
+	ExportFormatCSV   = "csv"
+)
+
+// exportCSVHeader lists the CSV columns written by ExportTransactions
+var exportCSVHeader = []string{"gid", "mode", "status", "branches", "created_unix", "updated_unix", "payload"}
+
+// ExportFilter controls which transactions are exported
+type ExportFilter struct {
+	Status   string // only export transactions with this status (empty means all)
+	PageSize int    // number of transactions fetched per ListTransactions call
+	Limit    int    // maximum number of transactions to export (0 means no limit)
+
+	GIDPrefix string // only export transactions whose gid starts with this prefix
+}
+
+// DefaultExportFilter returns a filter exporting every transaction
+func DefaultExportFilter() *ExportFilter {
+	return &ExportFilter{
+		PageSize: 100,
+	}
+}
+
+// ExportTransactions streams transactions to w as JSONL or CSV.
+// Pages are fetched one at a time and written before the next page is
+// requested, so a slow writer naturally throttles the export.
+// It returns the number of transactions written.
+func (c *Client) ExportTransactions(ctx context.Context, w io.Writer, format string, filter *ExportFilter) (int, error) {
+	if filter == nil {
+		filter = DefaultExportFilter()
+	}
+	pageSize := filter.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultExportFilter().PageSize
+	}
+
+	var writeRow func(*TransactionInfo) error
+	var flush func() error
+
+	switch format {
+	case ExportFormatJSONL:
+		enc := json.NewEncoder(w)
+		writeRow = func(info *TransactionInfo) error { return enc.Encode(info) }
+		flush = func() error { return nil }
+	case ExportFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(exportCSVHeader); err != nil {
+			return 0, fmt.Errorf("failed to write CSV header: %w", err)
+		}
+		writeRow = func(info *TransactionInfo) error { return cw.Write(transactionCSVRecord(info)) }
+		flush = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		return 0, fmt.Errorf("unsupported export format: %s", format)
+	}
+
+	written := 0
+	for offset := 0; ; offset += pageSize {
+		select {
+		case <-ctx.Done():
+			return written, ctx.Err()
+		default:
+		}
+
+		page, err := c.ListTransactions(ctx, pageSize, offset, filter.Status)
+		if err != nil {
+			return written, fmt.Errorf("failed to export transactions: %w", err)
+		}
+
+		for _, info := range page {
+			if !strings.HasPrefix(info.GID, filter.GIDPrefix) {
+				continue
+			}
+			if filter.Limit > 0 && written >= filter.Limit {
+				return written, flush()
+			}
+			if err := writeRow(info); err != nil {
+				return written, fmt.Errorf("failed to write transaction %s: %w", info.GID, err)
+			}
+			written++
+		}
+
+		if err := flush(); err != nil {
+			return written, fmt.Errorf("failed to flush export: %w", err)
+		}
+
+		if len(page) < pageSize {
+			return written, nil
+		}
+	}
+}
+
+// transactionCSVRecord converts a transaction into a CSV record
+func transactionCSVRecord(info *TransactionInfo) []string {
+	return []string{
+		info.GID,
+		info.Mode,
+		info.Status,
+		strconv.Itoa(len(info.Branches)),
+		strconv.FormatInt(info.CreatedUnix, 10),
+		strconv.FormatInt(info.UpdatedUnix, 10),
+		base64.StdEncoding.EncodeToString(info.Payload),
+	}
+}
@@ -0,0 +1,47 @@
+package seata
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// forEachStep runs fn for the steps 0..n-1 on at most limit workers. Steps
+// are handed out one at a time, so goroutines and buffers are bounded by
+// limit instead of the workflow size. Once fn fails no further steps are
+// started and the first error is returned.
+func forEachStep(n, limit int, fn func(i int) error) error {
+	if limit <= 0 {
+		limit = 1
+	}
+	if limit > n {
+		limit = n
+	}
+
+	var (
+		wg       sync.WaitGroup
+		next     atomic.Int64
+		failed   atomic.Bool
+		once     sync.Once
+		firstErr error
+	)
+	for w := 0; w < limit; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				if err := fn(i); err != nil {
+					once.Do(func() {
+						firstErr = err
+						failed.Store(true)
+					})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,164 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// maxTreeDepth bounds GetTransactionTree against cyclic parent links
+const maxTreeDepth = 32
+
+// ForkOptions configures a child transaction
+type ForkOptions struct {
+	// CascadeAbort aborts the child when the parent is aborted through this
+	// client
+	CascadeAbort bool
+}
+
+// TransactionTree is a transaction with the children forked from it
+type TransactionTree struct {
+	*TransactionInfo
+	Children []*TransactionTree
+}
+
+// forkedChild is a child started with Fork
+type forkedChild struct {
+	tx      *Transaction
+	cascade bool
+}
+
+// Fork starts a child transaction linked to tx by its parent gid, e.g. a
+// sub-saga spawned by a running saga. Children are started over HTTP since
+// the gRPC API has no parent field.
+func (tx *Transaction) Fork(ctx context.Context, mode string, payload []byte) (*Transaction, error) {
+	return tx.ForkWithOptions(ctx, mode, payload, nil)
+}
+
+// ForkWithOptions starts a child transaction like Fork
+func (tx *Transaction) ForkWithOptions(ctx context.Context, mode string, payload []byte, options *ForkOptions) (*Transaction, error) {
+	c := tx.client
+	ctx = WithCorrelationID(tx.Context(ctx), tx.correlationID)
+	encoded, err := c.encodePayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	httpBase, gc := c.currentTargets()
+	gid := c.newGID()
+	if err := c.ValidateGID(gid); err != nil {
+		return nil, err
+	}
+	if err := c.authorize(ctx, AuthorizeStart, mode, gid, payload); err != nil {
+		return nil, err
+	}
+	child, err := c.startTransactionHTTP(ctx, httpBase, gid, mode, encoded, map[string]string{"parent_gid": tx.gid})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fork child of %s: %w", tx.gid, err)
+	}
+
+	child.payload = payload
+	child.parentGID = tx.gid
+	child.correlationID = tx.correlationID
+	if c.config.StickySessions {
+		child.httpBase = httpBase
+		child.grpc = gc
+	}
+	if c.watchdog != nil {
+		c.watchdog.Track(child)
+	}
+	c.emit(EventTransactionStarted, child, "")
+
+	tx.forkMu.Lock()
+	tx.children = append(tx.children, forkedChild{tx: child, cascade: options != nil && options.CascadeAbort})
+	tx.forkMu.Unlock()
+	return child, nil
+}
+
+// ParentGID returns the gid of the transaction tx was forked from, if any
+func (tx *Transaction) ParentGID() string {
+	return tx.parentGID
+}
+
+// abortChildren aborts the children forked with CascadeAbort
+func (tx *Transaction) abortChildren(ctx context.Context) error {
+	tx.forkMu.Lock()
+	children := append([]forkedChild(nil), tx.children...)
+	tx.forkMu.Unlock()
+
+	var errs []error
+	for _, child := range children {
+		if !child.cascade {
+			continue
+		}
+		if err := child.tx.Abort(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to abort child %s: %w", child.tx.gid, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetTransactionTree returns the transaction gid with its forked children,
+// recursively
+func (c *Client) GetTransactionTree(ctx context.Context, gid string) (*TransactionTree, error) {
+	return c.transactionTree(ctx, gid, 0)
+}
+
+// transactionTree builds the tree of gid on the client of its region; the
+// children of each node are looked up where that node lives
+func (c *Client) transactionTree(ctx context.Context, gid string, depth int) (*TransactionTree, error) {
+	if depth > maxTreeDepth {
+		return nil, fmt.Errorf("transaction tree of %s is deeper than %d levels", gid, maxTreeDepth)
+	}
+	if regional := c.ForGID(gid); regional != c {
+		return regional.transactionTree(ctx, gid, depth)
+	}
+	info, err := c.GetTransaction(ctx, gid)
+	if err != nil {
+		return nil, err
+	}
+	children, err := c.listChildren(ctx, gid)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := &TransactionTree{TransactionInfo: info}
+	for _, child := range children {
+		subtree, err := c.transactionTree(ctx, child.GID, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		tree.Children = append(tree.Children, subtree)
+	}
+	return tree, nil
+}
+
+// listChildren returns the transactions forked from gid
+func (c *Client) listChildren(ctx context.Context, gid string) ([]*TransactionInfo, error) {
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		SetQueryParam("parent_gid", gid).
+		Get("/api/tx")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list children of %s: %w", gid, err)
+	}
+	if resp.StatusCode() != 200 {
+		return nil, c.statusError(fmt.Sprintf("failed to list children of %s", gid), resp)
+	}
+
+	var children []*TransactionInfo
+	if err := decodeJSON(resp.Body(), &children); err != nil {
+		return nil, fmt.Errorf("failed to parse children of %s: %w", gid, err)
+	}
+	// A TC without parent filtering returns every transaction
+	linked := children[:0]
+	for _, child := range children {
+		if child.ParentGID != gid {
+			continue
+		}
+		if err := c.decodeTransactionInfo(child); err != nil {
+			return nil, err
+		}
+		linked = append(linked, child)
+	}
+	return linked, nil
+}
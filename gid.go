@@ -0,0 +1,62 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidGID is returned when a gid fails the client's format checks
+var ErrInvalidGID = errors.New("invalid gid")
+
+// newGID generates a gid in the client's namespace
+func (c *Client) newGID() string {
+	if c.region != "" {
+		return c.config.GIDPrefix + c.region + "-" + uuid.New().String()
+	}
+	return c.config.GIDPrefix + uuid.New().String()
+}
+
+// ValidateGID checks gid against the configured prefix, when enforced, and
+// GIDValidator
+func (c *Client) ValidateGID(gid string) error {
+	if gid == "" {
+		return fmt.Errorf("%w: gid cannot be empty", ErrInvalidGID)
+	}
+	if c.config.EnforceGIDPrefix && !strings.HasPrefix(gid, c.config.GIDPrefix) {
+		return fmt.Errorf("%w: %q does not start with %q", ErrInvalidGID, gid, c.config.GIDPrefix)
+	}
+	if c.config.GIDValidator != nil {
+		if err := c.config.GIDValidator(gid); err != nil {
+			return fmt.Errorf("%w: %q: %v", ErrInvalidGID, gid, err)
+		}
+	}
+	return nil
+}
+
+// AttachTransaction returns a Transaction for an existing gid, e.g. one
+// received from another service, after checking it with ValidateGID and
+// reading its mode and payload from the TC
+func (c *Client) AttachTransaction(ctx context.Context, gid string) (*Transaction, error) {
+	if regional := c.ForGID(gid); regional != c {
+		return regional.AttachTransaction(ctx, gid)
+	}
+	if err := c.ValidateGID(gid); err != nil {
+		return nil, err
+	}
+	info, err := c.GetTransaction(ctx, gid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach transaction %s: %w", gid, err)
+	}
+	return &Transaction{
+		client:        c,
+		gid:           info.GID,
+		mode:          info.Mode,
+		payload:       info.Payload,
+		branches:      make([]*Branch, 0),
+		correlationID: newCorrelationID(ctx),
+	}, nil
+}
@@ -2,13 +2,13 @@ package seata
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	seata_proto "github.com/seata-team/seata-go-client/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
@@ -16,11 +16,26 @@ import (
 type GrpcClient struct {
 	conn   *grpc.ClientConn
 	client seata_proto.TransactionServiceClient
+	config *GrpcConfig
+	// why the last Connect failed, reported by calls made without a connection
+	connectErr error
+	// carries writes when GrpcConfig.StreamSession is set
+	session *streamSession
+	// set in place of conn with TransportConnect
+	connect *connectConn
 }
 
 // NewGrpcClient creates a new gRPC client
 func NewGrpcClient(endpoint string) *GrpcClient {
-	client := &GrpcClient{}
+	return NewGrpcClientWithConfig(endpoint, nil)
+}
+
+// NewGrpcClientWithConfig creates a new gRPC client with the given connection settings
+func NewGrpcClientWithConfig(endpoint string, config *GrpcConfig) *GrpcClient {
+	if config == nil {
+		config = DefaultGrpcConfig()
+	}
+	client := &GrpcClient{config: config}
 	if err := client.Connect(endpoint); err != nil {
 		// Log error but don't fail - connection will be established on first use
 		fmt.Printf("Warning: Failed to connect to gRPC server: %v\n", err)
@@ -30,30 +45,82 @@ func NewGrpcClient(endpoint string) *GrpcClient {
 
 // Connect establishes a connection to the gRPC server
 func (gc *GrpcClient) Connect(endpoint string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if gc.config == nil {
+		gc.config = DefaultGrpcConfig()
+	}
+	dialTimeout := gc.config.DialTimeout
+	if dialTimeout <= 0 {
+		dialTimeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
 	defer cancel()
 
-	target := endpoint
-	if strings.HasPrefix(target, "grpc://") {
-		target = strings.TrimPrefix(target, "grpc://")
-	}
+	target := grpcTarget(endpoint)
 	if target == "" {
 		return fmt.Errorf("invalid gRPC endpoint")
 	}
 
-	conn, err := grpc.DialContext(ctx, target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	extraOpts, err := gc.config.dialOptions()
+	if err != nil {
+		return fmt.Errorf("invalid gRPC config: %w", err)
+	}
+	creds := insecure.NewCredentials()
+	if gc.config.TLS != nil || strings.HasPrefix(endpoint, schemeGrpcs) {
+		// grpcs:// without Config.TLS verifies against the system roots
+		creds = credentials.NewTLS(gc.config.TLS)
+	}
+	opts := append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, extraOpts...)
+	if gc.config.StreamSession {
+		// innermost, so the other interceptors see session calls as unary ones
+		gc.session = newStreamSession()
+		opts = append(opts, grpc.WithChainUnaryInterceptor(gc.session.intercept))
+	}
+
+	conn, err := grpc.DialContext(ctx, target, opts...)
 	if err != nil {
+		gc.connectErr = err
 		return fmt.Errorf("failed to connect to gRPC server: %w", err)
 	}
+	if gc.config.VerifyService {
+		if err := verifyService(ctx, conn, endpoint); err != nil {
+			conn.Close()
+			gc.connectErr = err
+			return err
+		}
+	}
 
 	gc.conn = conn
 	gc.client = seata_proto.NewTransactionServiceClient(conn)
+	gc.connectErr = nil
+
+	return nil
+}
 
+// notConnected is the error of a call made without a connection
+func (gc *GrpcClient) notConnected() error {
+	if gc.connectErr != nil {
+		return fmt.Errorf("gRPC client not connected: %w", gc.connectErr)
+	}
+	return fmt.Errorf("gRPC client not connected")
+}
+
+// Conn returns the connection calls are made on: the gRPC connection, the
+// Connect protocol adapter with TransportConnect, or nil when not connected
+func (gc *GrpcClient) Conn() grpc.ClientConnInterface {
+	switch {
+	case gc == nil:
+		return nil
+	case gc.conn != nil:
+		return gc.conn
+	case gc.connect != nil:
+		return gc.connect
+	}
 	return nil
 }
 
 // Close closes the gRPC connection
 func (gc *GrpcClient) Close() error {
+	gc.session.close()
 	if gc.conn != nil {
 		return gc.conn.Close()
 	}
@@ -61,9 +128,9 @@ func (gc *GrpcClient) Close() error {
 }
 
 // StartGlobal starts a global transaction via gRPC
-func (gc *GrpcClient) StartGlobal(ctx context.Context, gid, mode string, payload []byte) (*seata_proto.StartGlobalResponse, error) {
+func (gc *GrpcClient) StartGlobal(ctx context.Context, gid, mode string, payload []byte, opts ...grpc.CallOption) (*seata_proto.StartGlobalResponse, error) {
 	if gc.client == nil {
-		return nil, fmt.Errorf("gRPC client not connected")
+		return nil, gc.notConnected()
 	}
 
 	req := &seata_proto.StartGlobalRequest{
@@ -72,13 +139,13 @@ func (gc *GrpcClient) StartGlobal(ctx context.Context, gid, mode string, payload
 		Payload: payload,
 	}
 
-	return gc.client.StartGlobal(ctx, req)
+	return gc.client.StartGlobal(ctx, req, opts...)
 }
 
 // Submit submits a transaction via gRPC
 func (gc *GrpcClient) Submit(ctx context.Context, gid string) (*seata_proto.SubmitResponse, error) {
 	if gc.client == nil {
-		return nil, fmt.Errorf("gRPC client not connected")
+		return nil, gc.notConnected()
 	}
 
 	req := &seata_proto.SubmitRequest{
@@ -91,7 +158,7 @@ func (gc *GrpcClient) Submit(ctx context.Context, gid string) (*seata_proto.Subm
 // Abort aborts a transaction via gRPC
 func (gc *GrpcClient) Abort(ctx context.Context, gid string) (*seata_proto.AbortResponse, error) {
 	if gc.client == nil {
-		return nil, fmt.Errorf("gRPC client not connected")
+		return nil, gc.notConnected()
 	}
 
 	req := &seata_proto.AbortRequest{
@@ -104,7 +171,7 @@ func (gc *GrpcClient) Abort(ctx context.Context, gid string) (*seata_proto.Abort
 // AddBranch adds a branch via gRPC
 func (gc *GrpcClient) AddBranch(ctx context.Context, gid, branchID, action string) (*seata_proto.AddBranchResponse, error) {
 	if gc.client == nil {
-		return nil, fmt.Errorf("gRPC client not connected")
+		return nil, gc.notConnected()
 	}
 
 	req := &seata_proto.AddBranchRequest{
@@ -119,7 +186,7 @@ func (gc *GrpcClient) AddBranch(ctx context.Context, gid, branchID, action strin
 // BranchTry executes try phase via gRPC
 func (gc *GrpcClient) BranchTry(ctx context.Context, gid, branchID, action string) (*seata_proto.BranchTryResponse, error) {
 	if gc.client == nil {
-		return nil, fmt.Errorf("gRPC client not connected")
+		return nil, gc.notConnected()
 	}
 
 	req := &seata_proto.BranchTryRequest{
@@ -134,7 +201,7 @@ func (gc *GrpcClient) BranchTry(ctx context.Context, gid, branchID, action strin
 // BranchSucceed marks branch as successful via gRPC
 func (gc *GrpcClient) BranchSucceed(ctx context.Context, gid, branchID string) (*seata_proto.BranchStateResponse, error) {
 	if gc.client == nil {
-		return nil, fmt.Errorf("gRPC client not connected")
+		return nil, gc.notConnected()
 	}
 
 	req := &seata_proto.BranchStateRequest{
@@ -148,7 +215,7 @@ func (gc *GrpcClient) BranchSucceed(ctx context.Context, gid, branchID string) (
 // BranchFail marks branch as failed via gRPC
 func (gc *GrpcClient) BranchFail(ctx context.Context, gid, branchID string) (*seata_proto.BranchStateResponse, error) {
 	if gc.client == nil {
-		return nil, fmt.Errorf("gRPC client not connected")
+		return nil, gc.notConnected()
 	}
 
 	req := &seata_proto.BranchStateRequest{
@@ -162,7 +229,7 @@ func (gc *GrpcClient) BranchFail(ctx context.Context, gid, branchID string) (*se
 // Get retrieves a transaction via gRPC
 func (gc *GrpcClient) Get(ctx context.Context, gid string) (*TransactionInfo, error) {
 	if gc.client == nil {
-		return nil, fmt.Errorf("gRPC client not connected")
+		return nil, gc.notConnected()
 	}
 
 	req := &seata_proto.GetRequest{
@@ -176,7 +243,7 @@ func (gc *GrpcClient) Get(ctx context.Context, gid string) (*TransactionInfo, er
 
 	// Parse the JSON response
 	var txInfo TransactionInfo
-	if err := json.Unmarshal(resp.TxnJson, &txInfo); err != nil {
+	if err := decodeJSON(resp.TxnJson, &txInfo); err != nil {
 		return nil, fmt.Errorf("failed to parse transaction JSON: %w", err)
 	}
 
@@ -186,7 +253,7 @@ func (gc *GrpcClient) Get(ctx context.Context, gid string) (*TransactionInfo, er
 // List retrieves transactions via gRPC
 func (gc *GrpcClient) List(ctx context.Context, limit, offset int, status string) ([]*TransactionInfo, error) {
 	if gc.client == nil {
-		return nil, fmt.Errorf("gRPC client not connected")
+		return nil, gc.notConnected()
 	}
 
 	req := &seata_proto.ListRequest{
@@ -204,7 +271,7 @@ func (gc *GrpcClient) List(ctx context.Context, limit, offset int, status string
 	var transactions []*TransactionInfo
 	for _, txnJson := range resp.TxnJson {
 		var txInfo TransactionInfo
-		if err := json.Unmarshal(txnJson, &txInfo); err != nil {
+		if err := decodeJSON(txnJson, &txInfo); err != nil {
 			return nil, fmt.Errorf("failed to parse transaction JSON: %w", err)
 		}
 		transactions = append(transactions, &txInfo)
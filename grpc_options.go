@@ -0,0 +1,176 @@
+package seata
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/keepalive"
+)
+
+// grpcServiceName is the fully-qualified name of the TC transaction service
+const grpcServiceName = "seata.txn.v1.TransactionService"
+
+// GrpcConfig holds gRPC connection settings
+type GrpcConfig struct {
+	// Keepalive settings (zero values disable client keepalive pings)
+	KeepaliveTime       time.Duration
+	KeepaliveTimeout    time.Duration
+	PermitWithoutStream bool
+
+	// Service config JSON passed to the channel; takes precedence over RetryPolicy
+	ServiceConfigJSON string
+	// Retry policy applied to every TransactionService method
+	RetryPolicy *GrpcRetryPolicy
+
+	// Message size limits in bytes (0 keeps the gRPC defaults)
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+
+	// Block makes Connect wait until the connection is ready or DialTimeout elapses
+	Block       bool
+	DialTimeout time.Duration
+
+	// VerifyService makes Connect check with the gRPC health service or
+	// server reflection that the endpoint serves the TransactionService
+	VerifyService bool
+
+	// StreamSession sends start, branch, submit and abort calls over one
+	// long-lived bidirectional stream instead of a unary call each, falling
+	// back to unary calls when the TC does not support the stream
+	StreamSession bool
+
+	// Connection backoff settings (zero values keep the gRPC defaults)
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	MinConnectTimeout time.Duration
+
+	// TLS enables transport security; nil dials without TLS. Client copies
+	// Config.TLS here when it is unset.
+	TLS *tls.Config
+
+	// Additional dial options appended after the ones derived above
+	DialOptions []grpc.DialOption
+}
+
+// GrpcRetryPolicy describes a gRPC service-config retry policy
+type GrpcRetryPolicy struct {
+	MaxAttempts          int
+	InitialBackoff       time.Duration
+	MaxBackoff           time.Duration
+	BackoffMultiplier    float64
+	RetryableStatusCodes []string
+}
+
+// DefaultGrpcConfig returns the default gRPC configuration
+func DefaultGrpcConfig() *GrpcConfig {
+	return &GrpcConfig{
+		DialTimeout: 10 * time.Second,
+	}
+}
+
+// DefaultGrpcRetryPolicy returns a retry policy for transient failures
+func DefaultGrpcRetryPolicy() *GrpcRetryPolicy {
+	return &GrpcRetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       100 * time.Millisecond,
+		MaxBackoff:           2 * time.Second,
+		BackoffMultiplier:    2.0,
+		RetryableStatusCodes: []string{"UNAVAILABLE", "RESOURCE_EXHAUSTED"},
+	}
+}
+
+// serviceConfig returns the service config JSON for the configured retry policy
+func (gc *GrpcConfig) serviceConfig() (string, error) {
+	if gc.ServiceConfigJSON != "" {
+		return gc.ServiceConfigJSON, nil
+	}
+	if gc.RetryPolicy == nil {
+		return "", nil
+	}
+
+	rp := gc.RetryPolicy
+	if rp.MaxAttempts < 2 {
+		return "", fmt.Errorf("gRPC retry policy requires MaxAttempts >= 2")
+	}
+	cfg := map[string]interface{}{
+		"methodConfig": []interface{}{
+			map[string]interface{}{
+				"name": []interface{}{map[string]string{"service": grpcServiceName}},
+				"retryPolicy": map[string]interface{}{
+					"maxAttempts":          rp.MaxAttempts,
+					"initialBackoff":       formatGrpcDuration(rp.InitialBackoff),
+					"maxBackoff":           formatGrpcDuration(rp.MaxBackoff),
+					"backoffMultiplier":    rp.BackoffMultiplier,
+					"retryableStatusCodes": rp.RetryableStatusCodes,
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// dialOptions converts the configuration into gRPC dial options
+func (gc *GrpcConfig) dialOptions() ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+
+	if gc.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                gc.KeepaliveTime,
+			Timeout:             gc.KeepaliveTimeout,
+			PermitWithoutStream: gc.PermitWithoutStream,
+		}))
+	}
+
+	serviceConfig, err := gc.serviceConfig()
+	if err != nil {
+		return nil, err
+	}
+	if serviceConfig != "" {
+		opts = append(opts, grpc.WithDefaultServiceConfig(serviceConfig))
+	}
+
+	var callOpts []grpc.CallOption
+	if gc.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(gc.MaxRecvMsgSize))
+	}
+	if gc.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(gc.MaxSendMsgSize))
+	}
+	if len(callOpts) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+
+	if gc.InitialBackoff > 0 || gc.MaxBackoff > 0 || gc.MinConnectTimeout > 0 {
+		bc := backoff.DefaultConfig
+		if gc.InitialBackoff > 0 {
+			bc.BaseDelay = gc.InitialBackoff
+		}
+		if gc.MaxBackoff > 0 {
+			bc.MaxDelay = gc.MaxBackoff
+		}
+		params := grpc.ConnectParams{Backoff: bc}
+		if gc.MinConnectTimeout > 0 {
+			params.MinConnectTimeout = gc.MinConnectTimeout
+		}
+		opts = append(opts, grpc.WithConnectParams(params))
+	}
+
+	if gc.Block {
+		opts = append(opts, grpc.WithBlock())
+	}
+
+	return append(opts, gc.DialOptions...), nil
+}
+
+// formatGrpcDuration formats a duration the way service config expects ("0.1s")
+func formatGrpcDuration(d time.Duration) string {
+	return fmt.Sprintf("%gs", d.Seconds())
+}
@@ -0,0 +1,79 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+)
+
+// ErrServiceNotServed is returned by Connect when GrpcConfig.VerifyService
+// is set and the endpoint does not serve the TransactionService
+var ErrServiceNotServed = errors.New("transaction service not served")
+
+// verifyService checks that conn serves the TransactionService, first with
+// the gRPC health checking protocol and, when that has no status for it,
+// with server reflection. A server offering neither cannot be checked and
+// is accepted.
+func verifyService(ctx context.Context, conn *grpc.ClientConn, endpoint string) error {
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: grpcServiceName})
+	switch status.Code(err) {
+	case codes.OK:
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("%w: %s reports %s as %s", ErrServiceNotServed, endpoint, grpcServiceName, resp.GetStatus())
+		}
+		return nil
+	case codes.NotFound, codes.Unimplemented:
+		// No health status for the service, try reflection
+	default:
+		return fmt.Errorf("failed to check gRPC health of %s: %w", endpoint, err)
+	}
+
+	services, err := listServices(ctx, conn)
+	if status.Code(err) == codes.Unimplemented {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list gRPC services of %s: %w", endpoint, err)
+	}
+	for _, service := range services {
+		if service == grpcServiceName {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s serves [%s] but not %s", ErrServiceNotServed, endpoint, strings.Join(services, ", "), grpcServiceName)
+}
+
+// listServices asks the server reflection service for the services it serves
+func listServices(ctx context.Context, conn *grpc.ClientConn) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, status.Error(codes.Code(e.GetErrorCode()), e.GetErrorMessage())
+	}
+	var services []string
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		services = append(services, service.GetName())
+	}
+	return services, nil
+}
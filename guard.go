@@ -0,0 +1,61 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// guardAbortTimeout bounds the abort sent after a panic or when ctx is done
+const guardAbortTimeout = 10 * time.Second
+
+// Guard ensures a hand-written transaction never stays open. Use it as
+//
+//	done := tx.Guard(ctx)
+//	defer done(&err)
+//
+// where err is the enclosing function's named error result. When the
+// function returns nil the transaction is submitted and a submit failure is
+// stored in err; when it returns an error, or ctx is done, the transaction is
+// aborted; when it panics the transaction is aborted and the panic continues.
+// Aborts use a context detached from ctx so they are still sent after ctx is
+// cancelled.
+//
+// done(nil) only aborts on a panic or a done ctx and otherwise leaves
+// completing the transaction to the caller.
+func (tx *Transaction) Guard(ctx context.Context) func(errp *error) {
+	return func(errp *error) {
+		if r := recover(); r != nil {
+			_ = tx.abortDetached(ctx)
+			panic(r)
+		}
+		if errp == nil {
+			if ctx.Err() != nil {
+				_ = tx.abortDetached(ctx)
+			}
+			return
+		}
+
+		if *errp == nil && ctx.Err() != nil {
+			*errp = fmt.Errorf("transaction %s abandoned: %w", tx.gid, ctx.Err())
+		}
+		if *errp != nil {
+			if abortErr := tx.abortDetached(ctx); abortErr != nil {
+				*errp = errors.Join(*errp, abortErr)
+			}
+			return
+		}
+		if err := tx.Submit(ctx); err != nil {
+			*errp = fmt.Errorf("failed to complete transaction %s: %w", tx.gid, err)
+		}
+	}
+}
+
+// abortDetached aborts the transaction with a bounded context that ignores
+// the cancellation of ctx
+func (tx *Transaction) abortDetached(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), guardAbortTimeout)
+	defer cancel()
+	return tx.Abort(ctx)
+}
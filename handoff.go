@@ -0,0 +1,81 @@
+package seata
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// handoffVersion is the version of the MarshalBinary encoding
+const handoffVersion = 1
+
+// handoff is the encoded state of a Transaction
+type handoff struct {
+	Version       int       `json:"v"`
+	GID           string    `json:"gid"`
+	Mode          string    `json:"mode"`
+	Payload       []byte    `json:"payload,omitempty"`
+	Branches      []*Branch `json:"branches,omitempty"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	ParentGID     string    `json:"parent_gid,omitempty"`
+	Submitted     bool      `json:"submitted,omitempty"`
+}
+
+// MarshalBinary encodes the transaction's gid, mode, payload, added
+// branches and correlation ID, so another process can resume it with
+// Client.RestoreTransaction. Endpoints pinned by sticky sessions and state
+// of a running workflow execution are not included.
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	return json.Marshal(handoff{
+		Version:       handoffVersion,
+		GID:           tx.gid,
+		Mode:          tx.mode,
+		Payload:       tx.payload,
+		Branches:      tx.branches,
+		CorrelationID: tx.correlationID,
+		ParentGID:     tx.parentGID,
+		Submitted:     tx.submitted,
+	})
+}
+
+// UnmarshalBinary decodes data produced by MarshalBinary into tx. A
+// transaction decoded this way has no client; use Client.RestoreTransaction
+// to get one that can make calls.
+func (tx *Transaction) UnmarshalBinary(data []byte) error {
+	var h handoff
+	if err := json.Unmarshal(data, &h); err != nil {
+		return fmt.Errorf("failed to decode transaction: %w", err)
+	}
+	if h.Version != handoffVersion {
+		return fmt.Errorf("failed to decode transaction: unsupported version %d", h.Version)
+	}
+	if h.GID == "" {
+		return fmt.Errorf("failed to decode transaction: missing gid")
+	}
+	tx.gid = h.GID
+	tx.mode = h.Mode
+	tx.payload = h.Payload
+	tx.branches = h.Branches
+	if tx.branches == nil {
+		tx.branches = make([]*Branch, 0)
+	}
+	tx.correlationID = h.CorrelationID
+	tx.parentGID = h.ParentGID
+	tx.submitted = h.Submitted
+	return nil
+}
+
+// RestoreTransaction resumes a transaction handed over from another process
+// with Transaction.MarshalBinary, e.g. through a queue, without a call to
+// the TC. The gid is checked with ValidateGID.
+func (c *Client) RestoreTransaction(data []byte) (*Transaction, error) {
+	tx := &Transaction{}
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	regional := c.ForGID(tx.gid)
+	if err := regional.ValidateGID(tx.gid); err != nil {
+		return nil, err
+	}
+	tx.client = regional
+	return tx, nil
+}
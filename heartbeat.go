@@ -0,0 +1,97 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// HeartbeatConfig controls the keep-alives sent for a long-running
+// transaction so the TC does not time it out while the orchestrator works
+type HeartbeatConfig struct {
+	Interval time.Duration // time between keep-alives
+	Extend   time.Duration // deadline extension requested by each keep-alive
+	// OnError is called when a keep-alive fails; nil prints a warning.
+	// The heartbeat keeps running after errors.
+	OnError func(gid string, err error)
+}
+
+// DefaultHeartbeatConfig returns a heartbeat extending the deadline by 30s
+// every 10s
+func DefaultHeartbeatConfig() *HeartbeatConfig {
+	return &HeartbeatConfig{
+		Interval: 10 * time.Second,
+		Extend:   30 * time.Second,
+	}
+}
+
+// KeepAlive asks the TC to push the transaction's deadline to at least
+// extend from now and returns the new deadline
+func (tx *Transaction) KeepAlive(ctx context.Context, extend time.Duration) (time.Time, error) {
+	if extend <= 0 {
+		return time.Time{}, fmt.Errorf("extension must be positive")
+	}
+	resp, err := tx.client.httpClient.R().
+		SetContext(tx.Context(ctx)).
+		SetHeader("Content-Type", "application/json").
+		SetBody(map[string]int64{"extend_ms": extend.Milliseconds()}).
+		Post(tx.url(fmt.Sprintf("/api/tx/%s/keepalive", url.PathEscape(tx.gid))))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to keep transaction alive: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return time.Time{}, tx.client.statusError("failed to keep transaction alive", resp)
+	}
+
+	var result struct {
+		DeadlineUnix int64 `json:"deadline_unix"`
+	}
+	if err := decodeJSON(resp.Body(), &result); err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse keep-alive response: %w", err)
+	}
+	return time.Unix(result.DeadlineUnix, 0), nil
+}
+
+// StartHeartbeat sends keep-alives until stop is called or ctx is done.
+// A nil config returns a no-op stop, so callers can always
+//
+//	defer tx.StartHeartbeat(ctx, options.Heartbeat)()
+func (tx *Transaction) StartHeartbeat(ctx context.Context, config *HeartbeatConfig) (stop func()) {
+	if config == nil {
+		return func() {}
+	}
+	interval, extend := config.Interval, config.Extend
+	if interval <= 0 {
+		interval = DefaultHeartbeatConfig().Interval
+	}
+	if extend <= 0 {
+		extend = 3 * interval
+	}
+	onError := config.OnError
+	if onError == nil {
+		onError = func(gid string, err error) { fmt.Printf("Warning: heartbeat of %s: %v\n", gid, err) }
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := tx.KeepAlive(ctx, extend); err != nil && ctx.Err() == nil {
+					onError(tx.gid, err)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
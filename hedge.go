@@ -0,0 +1,127 @@
+package seata
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// defaultHedgeMinDelay bounds the P95-derived hedge delay from below
+const defaultHedgeMinDelay = 10 * time.Millisecond
+
+// HedgeConfig enables hedged requests for idempotent reads (GetTransaction,
+// ListTransactions, Health): when the current endpoint has not answered
+// after the hedge delay, the same request is sent to a second discovered
+// endpoint and the first successful answer wins. Hedging needs at least two
+// discovered HTTP endpoints; otherwise reads are sent once as usual.
+type HedgeConfig struct {
+	// Delay before the hedge is sent; zero uses the P95 latency observed for
+	// the current endpoint, or MaxDelay until enough samples exist
+	Delay time.Duration
+	// MinDelay and MaxDelay bound the P95-derived delay; zero means 10ms and
+	// the client request timeout
+	MinDelay time.Duration
+	MaxDelay time.Duration
+}
+
+// hedgedGet sends an idempotent GET to the primary TC, hedging it when
+// configured
+func (c *Client) hedgedGet(ctx context.Context, path string, query url.Values) (*resty.Response, error) {
+	primary, alternate := c.hedgeTargets()
+	if c.config.Hedging == nil || alternate == "" {
+		return c.httpClient.R().SetContext(ctx).SetQueryParamsFromValues(query).Get(path)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		resp *resty.Response
+		err  error
+	}
+	results := make(chan result, 2)
+	send := func(base string) {
+		go func() {
+			resp, err := c.httpClient.R().SetContext(ctx).SetQueryParamsFromValues(query).Get(joinURL(base, path))
+			results <- result{resp, err}
+		}()
+	}
+
+	send(primary)
+	timer := time.NewTimer(c.hedgeDelay(primary))
+	defer timer.Stop()
+	pending, hedged := 1, false
+	for {
+		select {
+		case <-timer.C:
+			if !hedged {
+				hedged = true
+				pending++
+				send(alternate)
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil && r.resp.StatusCode() < 500 {
+				return r.resp, nil
+			}
+			// A fast failure of the primary triggers the hedge right away
+			if !hedged {
+				hedged = true
+				pending++
+				send(alternate)
+				continue
+			}
+			if pending == 0 {
+				return r.resp, r.err
+			}
+		}
+	}
+}
+
+// hedgeTargets returns the current HTTP endpoint and the next discovered
+// one, or "" when there is no second endpoint
+func (c *Client) hedgeTargets() (primary, alternate string) {
+	c.lbMu.RLock()
+	defer c.lbMu.RUnlock()
+	primary = c.currentHTTP
+	if primary == "" || len(c.httpAddrs) < 2 {
+		return primary, ""
+	}
+	for i, addr := range c.httpAddrs {
+		if addr == primary {
+			return primary, c.httpAddrs[(i+1)%len(c.httpAddrs)]
+		}
+	}
+	return primary, ""
+}
+
+// hedgeDelay returns how long to wait for primary before hedging
+func (c *Client) hedgeDelay(primary string) time.Duration {
+	h := c.config.Hedging
+	if h.Delay > 0 {
+		return h.Delay
+	}
+	minDelay, maxDelay := h.MinDelay, h.MaxDelay
+	if minDelay <= 0 {
+		minDelay = defaultHedgeMinDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = c.config.RequestTimeout
+	}
+	p95, ok := c.stats.percentile("http", endpointOf(primary), 95)
+	if !ok {
+		if maxDelay <= 0 {
+			return minDelay
+		}
+		return maxDelay
+	}
+	if p95 < minDelay {
+		return minDelay
+	}
+	if maxDelay > 0 && p95 > maxDelay {
+		return maxDelay
+	}
+	return p95
+}
@@ -0,0 +1,227 @@
+package seata
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operations measured by the latency histograms
+const (
+	OperationStart     = "start"
+	OperationSubmit    = "submit"
+	OperationAbort     = "abort"
+	OperationBranchAdd = "branch_add"
+	OperationTry       = "try"
+	OperationConfirm   = "confirm"
+	OperationCancel    = "cancel"
+)
+
+// OpenMetricsContentType is the content type served by MetricsHandler
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// HistogramConfig enables per-operation latency histograms of the calls the
+// client makes to the TC. When TraceID returns an id for a call's context,
+// the observation is kept as the exemplar of its bucket, so a latency spike
+// on a dashboard links straight to the trace that caused it.
+type HistogramConfig struct {
+	// Buckets are the upper bounds in seconds; empty uses DefaultLatencyBuckets
+	Buckets []float64
+	// TraceID extracts the trace id of the call's context, e.g. from the
+	// active OpenTelemetry span; nil or "" records no exemplar
+	TraceID func(ctx context.Context) string
+	// Observe is optionally called with every observation, to feed an
+	// existing Prometheus histogram with ObserveWithExemplar
+	Observe func(operation string, seconds float64, traceID string)
+}
+
+// DefaultLatencyBuckets returns the default histogram upper bounds in seconds
+func DefaultLatencyBuckets() []float64 {
+	return []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+}
+
+// exemplar is the last traced observation of a bucket
+type exemplar struct {
+	traceID string
+	value   float64
+	at      time.Time
+}
+
+// histogram is one operation's cumulative latency distribution
+type histogram struct {
+	counts    []uint64 // per bucket, the last one is +Inf
+	exemplars []*exemplar
+	sum       float64
+	count     uint64
+}
+
+// latencyHistograms keeps a histogram per operation
+type latencyHistograms struct {
+	config  HistogramConfig
+	buckets []float64
+
+	mu   sync.Mutex
+	byOp map[string]*histogram
+}
+
+func newLatencyHistograms(config *HistogramConfig) *latencyHistograms {
+	if config == nil {
+		return nil
+	}
+	buckets := append([]float64(nil), config.Buckets...)
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets()
+	}
+	sort.Float64s(buckets)
+	return &latencyHistograms{config: *config, buckets: buckets, byOp: make(map[string]*histogram)}
+}
+
+// observe records one call of operation made with ctx. Nil-safe.
+func (h *latencyHistograms) observe(ctx context.Context, operation string, latency time.Duration) {
+	if h == nil || operation == "" {
+		return
+	}
+	seconds := latency.Seconds()
+	var traceID string
+	if h.config.TraceID != nil && ctx != nil {
+		traceID = h.config.TraceID(ctx)
+	}
+	if h.config.Observe != nil {
+		h.config.Observe(operation, seconds, traceID)
+	}
+
+	idx := sort.SearchFloat64s(h.buckets, seconds)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hist, ok := h.byOp[operation]
+	if !ok {
+		hist = &histogram{
+			counts:    make([]uint64, len(h.buckets)+1),
+			exemplars: make([]*exemplar, len(h.buckets)+1),
+		}
+		h.byOp[operation] = hist
+	}
+	hist.counts[idx]++
+	hist.sum += seconds
+	hist.count++
+	if traceID != "" {
+		hist.exemplars[idx] = &exemplar{traceID: traceID, value: seconds, at: time.Now()}
+	}
+}
+
+// write renders the histograms in the OpenMetrics text format
+func (h *latencyHistograms) write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	const name = "seata_client_operation_duration_seconds"
+	fmt.Fprintf(out, "# TYPE %s histogram\n", name)
+	fmt.Fprintf(out, "# UNIT %s seconds\n", name)
+	fmt.Fprintf(out, "# HELP %s Latency of calls to the transaction coordinator.\n", name)
+
+	if h != nil {
+		h.mu.Lock()
+		operations := make([]string, 0, len(h.byOp))
+		for op := range h.byOp {
+			operations = append(operations, op)
+		}
+		sort.Strings(operations)
+		for _, op := range operations {
+			hist := h.byOp[op]
+			var cumulative uint64
+			for i, count := range hist.counts {
+				cumulative += count
+				le := "+Inf"
+				if i < len(h.buckets) {
+					le = formatFloat(h.buckets[i])
+				}
+				fmt.Fprintf(out, "%s_bucket{operation=%q,le=%q} %d", name, op, le, cumulative)
+				if e := hist.exemplars[i]; e != nil {
+					fmt.Fprintf(out, " # {trace_id=%q} %s %s", e.traceID, formatFloat(e.value),
+						formatFloat(float64(e.at.UnixNano())/1e9))
+				}
+				out.WriteByte('\n')
+			}
+			fmt.Fprintf(out, "%s_count{operation=%q} %d\n", name, op, hist.count)
+			fmt.Fprintf(out, "%s_sum{operation=%q} %s\n", name, op, formatFloat(hist.sum))
+		}
+		h.mu.Unlock()
+	}
+	out.WriteString("# EOF\n")
+	return out.Flush()
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// httpOperation maps a TC API path to the operation it measures
+func httpOperation(rawURL string) string {
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+	switch {
+	case strings.HasSuffix(path, "/api/start"):
+		return OperationStart
+	case strings.HasSuffix(path, "/api/submit"):
+		return OperationSubmit
+	case strings.HasSuffix(path, "/api/abort"):
+		return OperationAbort
+	case strings.HasSuffix(path, "/api/branch/add"):
+		return OperationBranchAdd
+	case strings.HasSuffix(path, "/api/branch/try"):
+		return OperationTry
+	case strings.HasSuffix(path, "/api/branch/succeed"):
+		return OperationConfirm
+	case strings.HasSuffix(path, "/api/branch/fail"):
+		return OperationCancel
+	}
+	return ""
+}
+
+// grpcOperation maps a full gRPC method name to the operation it measures
+func grpcOperation(method string) string {
+	switch method[strings.LastIndex(method, "/")+1:] {
+	case "StartGlobal":
+		return OperationStart
+	case "Submit":
+		return OperationSubmit
+	case "Abort":
+		return OperationAbort
+	case "AddBranch":
+		return OperationBranchAdd
+	case "BranchTry":
+		return OperationTry
+	case "BranchSucceed":
+		return OperationConfirm
+	case "BranchFail":
+		return OperationCancel
+	}
+	return ""
+}
+
+// WriteOpenMetrics writes the latency histograms enabled by
+// Config.LatencyHistograms in the OpenMetrics text format, with exemplars
+func (c *Client) WriteOpenMetrics(w io.Writer) error {
+	return c.histograms.write(w)
+}
+
+// MetricsHandler serves WriteOpenMetrics for a Prometheus scrape. Prometheus
+// only ingests exemplars when run with --enable-feature=exemplar-storage.
+func (c *Client) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", OpenMetricsContentType)
+		c.WriteOpenMetrics(w)
+	})
+}
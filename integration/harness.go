@@ -0,0 +1,166 @@
+// Package integration starts a real Seata server in Docker for end-to-end
+// tests of the client. It drives the docker CLI directly, so it adds no
+// dependencies to the client module; docker must be on PATH.
+//
+// Tests using it are guarded by the "integration" build tag:
+//
+//	go test -tags integration ./integration/
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	seata "github.com/seata-team/seata-go-client"
+)
+
+// Default image and ports of the Seata server. The image is pinned so test
+// runs are reproducible; set SEATA_IMAGE to test against another release.
+const (
+	DefaultImage    = "seata/seata-server:2.0.0"
+	DefaultHTTPPort = "36789/tcp"
+	DefaultGrpcPort = "36790/tcp"
+)
+
+// Options configures the server container
+type Options struct {
+	// Image of the server; SEATA_IMAGE overrides the default
+	Image string
+	// Env passed to the container as KEY=VALUE
+	Env []string
+	// ReadyTimeout bounds waiting for the health endpoint
+	ReadyTimeout time.Duration
+	// Config tweaks the client configuration before it is created
+	Config func(*seata.Config)
+}
+
+// Harness is a running Seata server with a client connected to it
+type Harness struct {
+	Client       *seata.Client
+	HTTPEndpoint string
+	GrpcEndpoint string
+
+	containerID string
+}
+
+// Start launches the server container, waits until it reports healthy and
+// returns a ready client. Call Close to remove the container.
+func Start(options *Options) (*Harness, error) {
+	opts := Options{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.Image == "" {
+		opts.Image = envOr("SEATA_IMAGE", DefaultImage)
+	}
+	if opts.ReadyTimeout <= 0 {
+		opts.ReadyTimeout = 2 * time.Minute
+	}
+
+	args := []string{"run", "-d", "--rm", "-p", "127.0.0.1::" + DefaultHTTPPort, "-p", "127.0.0.1::" + DefaultGrpcPort}
+	for _, env := range opts.Env {
+		args = append(args, "-e", env)
+	}
+	args = append(args, opts.Image)
+	containerID, err := docker(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start seata container: %w", err)
+	}
+	h := &Harness{containerID: containerID}
+
+	httpAddr, err := docker("port", containerID, DefaultHTTPPort)
+	if err == nil {
+		h.HTTPEndpoint = "http://" + firstLine(httpAddr)
+		var grpcAddr string
+		grpcAddr, err = docker("port", containerID, DefaultGrpcPort)
+		h.GrpcEndpoint = firstLine(grpcAddr)
+	}
+	if err != nil {
+		h.Close()
+		return nil, fmt.Errorf("failed to resolve seata container ports: %w", err)
+	}
+
+	config := seata.DefaultConfig()
+	config.HTTPEndpoint = h.HTTPEndpoint
+	config.GrpcEndpoint = h.GrpcEndpoint
+	if opts.Config != nil {
+		opts.Config(config)
+	}
+
+	if err := waitHealthy(config, opts.ReadyTimeout); err != nil {
+		h.Close()
+		return nil, fmt.Errorf("seata server did not become healthy: %w", err)
+	}
+
+	h.Client = seata.NewClient(config)
+	return h, nil
+}
+
+// Logs returns the container output, useful when a test fails
+func (h *Harness) Logs() string {
+	out, _ := exec.Command("docker", "logs", h.containerID).CombinedOutput()
+	return string(out)
+}
+
+// Close closes the client and removes the container
+func (h *Harness) Close() error {
+	if h.Client != nil {
+		h.Client.Close()
+	}
+	if _, err := docker("rm", "-f", h.containerID); err != nil {
+		return fmt.Errorf("failed to remove seata container: %w", err)
+	}
+	return nil
+}
+
+// waitHealthy polls the health endpoint until it succeeds or timeout elapses
+func waitHealthy(config *seata.Config, timeout time.Duration) error {
+	probe := seata.NewClient(config)
+	defer probe.Close()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := probe.Health(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// docker runs a docker CLI command and returns its trimmed stdout
+func docker(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// firstLine returns the first line of s; docker port may list IPv4 and IPv6
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
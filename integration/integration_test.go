@@ -0,0 +1,82 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	seata "github.com/seata-team/seata-go-client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var harness *Harness
+
+func TestMain(m *testing.M) {
+	var err error
+	harness, err = Start(nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	harness.Close()
+	os.Exit(code)
+}
+
+func TestHealth(t *testing.T) {
+	health, err := harness.Client.Health(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "healthy", health.Status)
+}
+
+func TestSagaSubmit(t *testing.T) {
+	ctx := context.Background()
+	tx, err := harness.Client.StartTransaction(ctx, seata.ModeSaga, []byte(`{"order":1}`))
+	require.NoError(t, err)
+	require.NoError(t, tx.AddBranch(ctx, "b1", harness.HTTPEndpoint+"/health"))
+	require.NoError(t, tx.Submit(ctx))
+
+	info := waitFinished(t, tx)
+	assert.Equal(t, seata.StatusCommitted, info.Status)
+}
+
+func TestSagaAbort(t *testing.T) {
+	ctx := context.Background()
+	tx, err := harness.Client.StartTransaction(ctx, seata.ModeSaga, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Abort(ctx))
+
+	info := waitFinished(t, tx)
+	assert.Equal(t, seata.StatusAborted, info.Status)
+}
+
+func TestTCCTryConfirm(t *testing.T) {
+	ctx := context.Background()
+	tx, err := harness.Client.StartTransaction(ctx, seata.ModeTCC, nil)
+	require.NoError(t, err)
+	require.NoError(t, tx.Try(ctx, "b1", harness.HTTPEndpoint+"/health", nil))
+	assert.NoError(t, tx.Confirm(ctx, "b1"))
+}
+
+// waitFinished polls until the transaction reaches a final status
+func waitFinished(t *testing.T, tx *seata.Transaction) *seata.TransactionInfo {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for {
+		info, err := tx.GetInfo(ctx)
+		if err == nil && seata.IsTerminal(info.Status) {
+			return info
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("transaction %s did not finish: %v", tx.GetGID(), err)
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
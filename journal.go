@@ -0,0 +1,290 @@
+package seata
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNonDeterministic is returned when a replayed execution calls a step
+// other than the one its journal recorded at that position
+var ErrNonDeterministic = errors.New("execution diverged from its journal")
+
+// journalStartStep is the step name StartTransaction records
+const journalStartStep = "seata.start"
+
+// JournalEntry records one completed step of an execution
+type JournalEntry struct {
+	Seq          int    `json:"seq"`
+	Step         string `json:"step"`
+	InputHash    string `json:"input_hash"`
+	Output       []byte `json:"output,omitempty"`
+	RecordedUnix int64  `json:"recorded_unix"`
+}
+
+// Journal persists the steps of client-orchestrated executions so a
+// restarted orchestrator can replay them
+type Journal interface {
+	// Load returns the entries of an execution in order; an unknown
+	// execution has none
+	Load(ctx context.Context, executionID string) ([]JournalEntry, error)
+	// Append records the next entry of an execution
+	Append(ctx context.Context, executionID string, entry JournalEntry) error
+}
+
+// Execution runs the steps of one orchestration through a journal. Steps
+// the journal already holds return their recorded output without running;
+// the first step past the end of the journal runs and is recorded. Steps
+// must be called in the same order on every run, one at a time.
+type Execution struct {
+	journal Journal
+	id      string
+
+	mu      sync.Mutex
+	entries []JournalEntry
+	next    int
+}
+
+// NewExecution loads the journal of executionID, replaying it on the steps
+// that follow
+func NewExecution(ctx context.Context, journal Journal, executionID string) (*Execution, error) {
+	if journal == nil {
+		return nil, fmt.Errorf("journal cannot be nil")
+	}
+	if executionID == "" {
+		return nil, fmt.Errorf("execution ID cannot be empty")
+	}
+	entries, err := journal.Load(ctx, executionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load journal: %w", err)
+	}
+	return &Execution{journal: journal, id: executionID, entries: entries}, nil
+}
+
+// ID returns the execution ID
+func (e *Execution) ID() string {
+	return e.id
+}
+
+// Replaying reports whether the next step will be served from the journal
+func (e *Execution) Replaying() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.next < len(e.entries)
+}
+
+// Step runs fn, or returns the output recorded for this position when
+// replaying. A recorded step with another name or input fails with
+// ErrNonDeterministic. Only successful steps are recorded, so a failed
+// step runs again on the next run.
+func (e *Execution) Step(ctx context.Context, name string, input []byte, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	hash := sha256.Sum256(input)
+	inputHash := hex.EncodeToString(hash[:])
+	if e.next < len(e.entries) {
+		entry := e.entries[e.next]
+		if entry.Step != name || entry.InputHash != inputHash {
+			return nil, fmt.Errorf("%w: step %d is %q in the journal, got %q", ErrNonDeterministic, e.next, entry.Step, name)
+		}
+		e.next++
+		return entry.Output, nil
+	}
+
+	output, err := fn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entry := JournalEntry{
+		Seq:          e.next,
+		Step:         name,
+		InputHash:    inputHash,
+		Output:       output,
+		RecordedUnix: time.Now().Unix(),
+	}
+	if err := e.journal.Append(ctx, e.id, entry); err != nil {
+		return nil, fmt.Errorf("failed to record step %q: %w", name, err)
+	}
+	e.entries = append(e.entries, entry)
+	e.next++
+	return output, nil
+}
+
+// StartTransaction starts a transaction as a journaled step. On replay the
+// transaction recorded by the journal is reattached instead of starting a
+// new one.
+func (e *Execution) StartTransaction(ctx context.Context, c *Client, mode string, payload []byte) (*Transaction, error) {
+	type started struct {
+		GID           string `json:"gid"`
+		CorrelationID string `json:"correlation_id"`
+	}
+
+	input := append([]byte(mode+"\x00"), payload...)
+	var tx *Transaction
+	output, err := e.Step(ctx, journalStartStep, input, func(ctx context.Context) ([]byte, error) {
+		var err error
+		if tx, err = c.StartTransaction(ctx, mode, payload); err != nil {
+			return nil, err
+		}
+		return json.Marshal(started{GID: tx.gid, CorrelationID: tx.correlationID})
+	})
+	if err != nil || tx != nil {
+		return tx, err
+	}
+
+	var s started
+	if err := json.Unmarshal(output, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode journaled transaction: %w", err)
+	}
+	return &Transaction{
+		client:        c,
+		gid:           s.GID,
+		mode:          mode,
+		payload:       payload,
+		branches:      make([]*Branch, 0),
+		correlationID: s.CorrelationID,
+	}, nil
+}
+
+// MemoryJournal keeps journals in process memory; they are lost on restart
+type MemoryJournal struct {
+	mu      sync.RWMutex
+	entries map[string][]JournalEntry
+}
+
+// NewMemoryJournal creates an empty in-memory journal
+func NewMemoryJournal() *MemoryJournal {
+	return &MemoryJournal{entries: make(map[string][]JournalEntry)}
+}
+
+// Load returns the entries of an execution in order
+func (j *MemoryJournal) Load(ctx context.Context, executionID string) ([]JournalEntry, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return append([]JournalEntry(nil), j.entries[executionID]...), nil
+}
+
+// Append records the next entry of an execution
+func (j *MemoryJournal) Append(ctx context.Context, executionID string, entry JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries[executionID] = append(j.entries[executionID], entry)
+	return nil
+}
+
+// FileJournal keeps one JSON-lines file per execution in Dir. Entries are
+// synced to disk before Append returns; a partial last line left by a crash
+// is ignored on Load.
+type FileJournal struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+// NewFileJournal creates a journal in dir, creating the directory if needed
+func NewFileJournal(dir string) (*FileJournal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	return &FileJournal{Dir: dir}, nil
+}
+
+// Load returns the entries of an execution in order
+func (j *FileJournal) Load(ctx context.Context, executionID string) ([]JournalEntry, error) {
+	path, err := j.path(executionID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	// A last line without its newline was cut short by a crash
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+	var entries []JournalEntry
+	for _, line := range bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode journal entry %d: %w", len(entries), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Append records the next entry of an execution
+func (j *FileJournal) Append(ctx context.Context, executionID string, entry JournalEntry) error {
+	path, err := j.path(executionID)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+	end, err := dropPartialLine(f)
+	if err != nil {
+		return fmt.Errorf("failed to repair journal: %w", err)
+	}
+	if _, err := f.WriteAt(append(line, '\n'), end); err != nil {
+		return fmt.Errorf("failed to append journal entry: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	return nil
+}
+
+func (j *FileJournal) path(executionID string) (string, error) {
+	if executionID == "" || strings.ContainsAny(executionID, `/\`) || executionID == "." || executionID == ".." {
+		return "", fmt.Errorf("invalid execution ID %q", executionID)
+	}
+	return filepath.Join(j.Dir, executionID+".jsonl"), nil
+}
+
+// dropPartialLine truncates a partial last line left by a crash and returns
+// the new end of f
+func dropPartialLine(f *os.File) (int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return 0, nil
+	}
+	data := make([]byte, size)
+	if _, err := f.ReadAt(data, 0); err != nil {
+		return 0, err
+	}
+	if data[size-1] == '\n' {
+		return size, nil
+	}
+	end := int64(bytes.LastIndexByte(data, '\n') + 1)
+	return end, f.Truncate(end)
+}
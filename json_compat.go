@@ -0,0 +1,113 @@
+package seata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// decodeJSON decodes a TC response keeping numbers as json.Number, so values
+// decoded into interface{} do not lose precision through float64
+func decodeJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// flexString decodes a JSON string or number into its exact decimal text.
+// Some TC versions return numeric gids such as 1782654321987654321.
+type flexString string
+
+func (s *flexString) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		*s = flexString(str)
+		return nil
+	}
+
+	var num json.Number
+	if err := decodeJSON(data, &num); err != nil {
+		return fmt.Errorf("expected string or number, got %s", data)
+	}
+	*s = flexString(num.String())
+	return nil
+}
+
+// flexInt64 decodes a JSON integer, an integral float in exponent form
+// (1.7e12) or a numeric string into an int64 without going through float64
+type flexInt64 int64
+
+func (n *flexInt64) UnmarshalJSON(data []byte) error {
+	var text flexString
+	if err := text.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	if text == "" {
+		return nil
+	}
+
+	if v, err := strconv.ParseInt(string(text), 10, 64); err == nil {
+		*n = flexInt64(v)
+		return nil
+	}
+	f, _, err := big.ParseFloat(string(text), 10, 128, big.ToNearestEven)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", text)
+	}
+	v, accuracy := f.Int64()
+	if accuracy != big.Exact {
+		return fmt.Errorf("integer %s is out of range or not integral", text)
+	}
+	*n = flexInt64(v)
+	return nil
+}
+
+// UnmarshalJSON accepts gids as strings or numbers and timestamps as
+// integers, exponent-form numbers or strings
+func (ti *TransactionInfo) UnmarshalJSON(data []byte) error {
+	type plain TransactionInfo
+	aux := struct {
+		*plain
+		GID          flexString `json:"gid"`
+		ParentGID    flexString `json:"parent_gid"`
+		UpdatedUnix  flexInt64  `json:"updated_unix"`
+		CreatedUnix  flexInt64  `json:"created_unix"`
+		DeadlineUnix flexInt64  `json:"deadline_unix"`
+	}{plain: (*plain)(ti)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	ti.GID = string(aux.GID)
+	ti.ParentGID = string(aux.ParentGID)
+	ti.UpdatedUnix = int64(aux.UpdatedUnix)
+	ti.CreatedUnix = int64(aux.CreatedUnix)
+	ti.DeadlineUnix = int64(aux.DeadlineUnix)
+	return nil
+}
+
+// UnmarshalJSON accepts gids and branch IDs as strings or numbers
+func (cmd *BranchCommand) UnmarshalJSON(data []byte) error {
+	type plain BranchCommand
+	aux := struct {
+		*plain
+		GID      flexString `json:"gid"`
+		BranchID flexString `json:"branch_id"`
+	}{plain: (*plain)(cmd)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	cmd.GID = string(aux.GID)
+	cmd.BranchID = string(aux.BranchID)
+	return nil
+}
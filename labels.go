@@ -0,0 +1,88 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// SetLabels adds or updates free-form labels on a transaction, e.g.
+// {"investigation": "ticket-1234"}. Labels not listed are kept.
+func (c *Client) SetLabels(ctx context.Context, gid string, labels map[string]string) error {
+	for key := range labels {
+		if key == "" {
+			return fmt.Errorf("label key cannot be empty")
+		}
+	}
+	if regional := c.ForGID(gid); regional != c {
+		return regional.SetLabels(ctx, gid, labels)
+	}
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(labels).
+		Patch(labelsPath(gid))
+	if err != nil {
+		return fmt.Errorf("failed to set labels: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return c.statusError("failed to set labels", resp)
+	}
+	return nil
+}
+
+// DeleteLabel removes a label from a transaction
+func (c *Client) DeleteLabel(ctx context.Context, gid, key string) error {
+	if regional := c.ForGID(gid); regional != c {
+		return regional.DeleteLabel(ctx, gid, key)
+	}
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		Delete(labelsPath(gid) + "/" + url.PathEscape(key))
+	if err != nil {
+		return fmt.Errorf("failed to delete label: %w", err)
+	}
+	if resp.StatusCode() != 200 && resp.StatusCode() != 404 {
+		return c.statusError("failed to delete label", resp)
+	}
+	return nil
+}
+
+// GetLabels returns the labels of a transaction; they are also reported in
+// TransactionInfo.Labels
+func (c *Client) GetLabels(ctx context.Context, gid string) (map[string]string, error) {
+	if regional := c.ForGID(gid); regional != c {
+		return regional.GetLabels(ctx, gid)
+	}
+	resp, err := c.readGet(ctx, labelsPath(gid), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get labels: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return nil, c.statusError("failed to get labels", resp)
+	}
+	labels := map[string]string{}
+	if err := decodeJSON(resp.Body(), &labels); err != nil {
+		return nil, fmt.Errorf("failed to parse labels: %w", err)
+	}
+	return labels, nil
+}
+
+// SetLabel adds or updates one label on the transaction
+func (tx *Transaction) SetLabel(ctx context.Context, key, value string) error {
+	return tx.client.SetLabels(tx.Context(ctx), tx.gid, map[string]string{key: value})
+}
+
+// DeleteLabel removes a label from the transaction
+func (tx *Transaction) DeleteLabel(ctx context.Context, key string) error {
+	return tx.client.DeleteLabel(tx.Context(ctx), tx.gid, key)
+}
+
+// Labels returns the labels of the transaction
+func (tx *Transaction) Labels(ctx context.Context) (map[string]string, error) {
+	return tx.client.GetLabels(tx.Context(ctx), tx.gid)
+}
+
+func labelsPath(gid string) string {
+	return "/api/tx/" + url.PathEscape(gid) + "/labels"
+}
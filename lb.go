@@ -0,0 +1,147 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Load balancer rotation triggers
+const (
+	// LBRotateOnFailure moves to the next endpoint when the current one fails
+	LBRotateOnFailure = "on_failure"
+	// LBRotateInterval rotates endpoints every LBRotateInterval
+	LBRotateInterval = "interval"
+)
+
+// Load balancer endpoint selection strategies
+const (
+	LBStrategyRoundRobin   = "round_robin"
+	LBStrategyLeastLatency = "least_latency"
+)
+
+// selectIndex picks the endpoint to use from addrs. The caller must hold lbMu.
+func (c *Client) selectIndex(protocol string, addrs []string, key func(string) string) int {
+	if c.config.LBStrategy == LBStrategyLeastLatency && c.stats != nil {
+		best, bestScore := 0, c.stats.score(protocol, key(addrs[0]))
+		for i := 1; i < len(addrs); i++ {
+			if score := c.stats.score(protocol, key(addrs[i])); score < bestScore {
+				best, bestScore = i, score
+			}
+		}
+		return best
+	}
+	idx := c.lbIndex % len(addrs)
+	if idx < 0 {
+		idx = 0
+	}
+	return idx
+}
+
+// currentTargets returns the HTTP base URL and gRPC client currently selected.
+// The HTTP base is empty when no endpoints have been discovered.
+func (c *Client) currentTargets() (string, *GrpcClient) {
+	c.lbMu.RLock()
+	defer c.lbMu.RUnlock()
+	return c.currentHTTP, c.grpcClient
+}
+
+// rotate advances to the next discovered endpoint
+func (c *Client) rotate() {
+	c.lbMu.Lock()
+	defer c.lbMu.Unlock()
+	if len(c.httpAddrs) == 0 && len(c.grpcAddrs) == 0 {
+		return
+	}
+	c.lbIndex++
+	c.applyTargets()
+}
+
+// pooledGrpcClient returns the connection for addr, dialing it on first use.
+// Connections are kept so transactions pinned to an endpoint keep working
+// after the client rotates away from it. The caller must hold lbMu.
+func (c *Client) pooledGrpcClient(addr string) *GrpcClient {
+	if gc, ok := c.grpcPool[addr]; ok {
+		return gc
+	}
+	gc := c.newGrpcClient(addr)
+	c.grpcPool[addr] = gc
+	return gc
+}
+
+// pruneGrpcPool closes connections to endpoints that are no longer discovered.
+// The caller must hold lbMu.
+func (c *Client) pruneGrpcPool() {
+	if len(c.grpcAddrs) == 0 {
+		return
+	}
+	alive := make(map[string]bool, len(c.grpcAddrs))
+	for _, addr := range c.grpcAddrs {
+		alive[addr] = true
+	}
+	for addr, gc := range c.grpcPool {
+		if !alive[addr] {
+			_ = gc.Close()
+			delete(c.grpcPool, addr)
+		}
+	}
+}
+
+// installFailureHooks rotates endpoints when HTTP requests fail
+func (c *Client) installFailureHooks() {
+	c.httpClient.OnError(func(req *resty.Request, err error) {
+		// Requests cancelled by the caller, e.g. the losing side of a hedged
+		// read, say nothing about the endpoint
+		if errors.Is(err, context.Canceled) {
+			return
+		}
+		c.reportHTTPFailure(req.URL)
+	})
+	c.httpClient.OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+		if resp.StatusCode() >= 500 {
+			c.reportHTTPFailure(resp.Request.URL)
+		}
+		return nil
+	})
+}
+
+// reportHTTPFailure rotates away from the current HTTP endpoint if requestURL targeted it
+func (c *Client) reportHTTPFailure(requestURL string) {
+	if c.config.LBRotation != LBRotateOnFailure {
+		return
+	}
+	c.lbMu.Lock()
+	defer c.lbMu.Unlock()
+	// Only the first failure against an endpoint triggers a rotation
+	if c.currentHTTP == "" || !strings.HasPrefix(requestURL, strings.TrimSuffix(c.currentHTTP, "/")+"/") {
+		return
+	}
+	c.lbIndex++
+	c.applyTargets()
+}
+
+// reportGrpcFailure rotates away from gc if it is the current endpoint and is unavailable
+func (c *Client) reportGrpcFailure(gc *GrpcClient, err error) {
+	if c.config.LBRotation != LBRotateOnFailure || status.Code(err) != codes.Unavailable {
+		return
+	}
+	c.lbMu.Lock()
+	defer c.lbMu.Unlock()
+	if len(c.grpcAddrs) == 0 || gc != c.grpcClient {
+		return
+	}
+	c.lbIndex++
+	c.applyTargets()
+}
+
+// joinURL prefixes path with base; an empty base keeps path relative to the client BaseURL
+func joinURL(base, path string) string {
+	if base == "" {
+		return path
+	}
+	return strings.TrimSuffix(base, "/") + path
+}
@@ -0,0 +1,219 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// LeaderElectorConfig configures an etcd-based leader election
+type LeaderElectorConfig struct {
+	// Prefix of the election keys; defaults to "/seata/election/<name>"
+	Prefix string
+	// Identity published by the leader; defaults to hostname plus a random suffix
+	Identity string
+	// TTL of the session lease; leadership is lost this long after the leader dies
+	TTL time.Duration
+	// OnElected is called when this instance becomes leader. ctx is cancelled
+	// when leadership is lost, so long-running work should watch it.
+	OnElected func(ctx context.Context)
+	// OnResigned is called after leadership is lost or given up
+	OnResigned func()
+	// ResignBackoff is how long Run waits after Resign before campaigning
+	// again, so another replica can take over; zero means TTL
+	ResignBackoff time.Duration
+}
+
+// LeaderElector makes sure only one replica of a singleton orchestrator
+// (recovery, workflow resume, cron launchers) is active at a time
+type LeaderElector struct {
+	cli    *clientv3.Client
+	config LeaderElectorConfig
+
+	mu       sync.Mutex
+	leader   bool
+	resignCh chan struct{}
+
+	// newSession opens an election session; replaced in tests
+	newSession func(ctx context.Context) (electionSession, error)
+}
+
+// electionSession is one campaign of the elector over an etcd lease
+type electionSession interface {
+	Campaign(ctx context.Context, identity string) error
+	Resign(ctx context.Context) error
+	// Done is closed when the lease expires
+	Done() <-chan struct{}
+	Close() error
+}
+
+// etcdElection is an electionSession backed by etcd
+type etcdElection struct {
+	*concurrency.Election
+	session *concurrency.Session
+}
+
+func (e *etcdElection) Campaign(ctx context.Context, identity string) error {
+	return e.Election.Campaign(ctx, identity)
+}
+
+func (e *etcdElection) Done() <-chan struct{} {
+	return e.session.Done()
+}
+
+func (e *etcdElection) Close() error {
+	return e.session.Close()
+}
+
+// NewLeaderElector creates an elector for the named election
+func NewLeaderElector(cli *clientv3.Client, name string, config *LeaderElectorConfig) *LeaderElector {
+	var cfg LeaderElectorConfig
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "/seata/election/" + name
+	}
+	if cfg.Identity == "" {
+		host, _ := os.Hostname()
+		cfg.Identity = fmt.Sprintf("%s-%s", host, uuid.New().String()[:8])
+	}
+	if cfg.TTL < time.Second {
+		cfg.TTL = 10 * time.Second
+	}
+	if cfg.ResignBackoff <= 0 {
+		cfg.ResignBackoff = cfg.TTL
+	}
+	le := &LeaderElector{cli: cli, config: cfg}
+	le.newSession = le.etcdSession
+	return le
+}
+
+// etcdSession opens a session on the etcd election of the elector
+func (le *LeaderElector) etcdSession(ctx context.Context) (electionSession, error) {
+	session, err := concurrency.NewSession(le.cli, concurrency.WithTTL(int(le.config.TTL/time.Second)), concurrency.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &etcdElection{Election: concurrency.NewElection(session, le.config.Prefix), session: session}, nil
+}
+
+// Identity returns the identity this instance campaigns with
+func (le *LeaderElector) Identity() string {
+	return le.config.Identity
+}
+
+// IsLeader reports whether this instance currently holds leadership
+func (le *LeaderElector) IsLeader() bool {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	return le.leader
+}
+
+// Leader returns the identity of the current leader
+func (le *LeaderElector) Leader(ctx context.Context) (string, error) {
+	resp, err := le.cli.Get(ctx, le.config.Prefix, clientv3.WithFirstCreate()...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get leader: %w", err)
+	}
+	if len(resp.Kvs) == 0 {
+		return "", concurrency.ErrElectionNoLeader
+	}
+	return string(resp.Kvs[0].Value), nil
+}
+
+// Run campaigns for leadership until ctx is done. Whenever leadership is
+// won OnElected is invoked; when it is lost (session expiry, ctx done)
+// OnResigned is invoked and the elector campaigns again, after
+// ResignBackoff when leadership was given up with Resign.
+func (le *LeaderElector) Run(ctx context.Context) error {
+	for {
+		resigned, err := le.campaignOnce(ctx)
+		backoff := time.Duration(0)
+		if resigned {
+			backoff = le.config.ResignBackoff
+		} else if err != nil {
+			// Back off briefly before campaigning again
+			backoff = time.Second
+		}
+		if backoff > 0 && ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(backoff):
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// campaignOnce runs one session: campaign, lead until the session ends,
+// resign. It reports whether leadership was given up with Resign.
+func (le *LeaderElector) campaignOnce(ctx context.Context) (bool, error) {
+	election, err := le.newSession(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to create election session: %w", err)
+	}
+	defer election.Close()
+
+	if err := election.Campaign(ctx, le.config.Identity); err != nil {
+		return false, fmt.Errorf("failed to campaign: %w", err)
+	}
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	resignCh := make(chan struct{})
+	le.setLeader(true, resignCh)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if le.config.OnElected != nil {
+			le.config.OnElected(leaderCtx)
+		}
+	}()
+
+	resigned := false
+	select {
+	case <-election.Done():
+	case <-ctx.Done():
+	case <-resignCh:
+		resigned = true
+	}
+	cancel()
+	<-done
+
+	le.setLeader(false, nil)
+	resignCtx, resignCancel := context.WithTimeout(context.Background(), 3*time.Second)
+	_ = election.Resign(resignCtx)
+	resignCancel()
+
+	if le.config.OnResigned != nil {
+		le.config.OnResigned()
+	}
+	return resigned, nil
+}
+
+// Resign gives up leadership if held; Run campaigns again after
+// ResignBackoff
+func (le *LeaderElector) Resign() {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	if le.resignCh != nil {
+		close(le.resignCh)
+		le.resignCh = nil
+	}
+}
+
+func (le *LeaderElector) setLeader(leader bool, resignCh chan struct{}) {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	le.leader = leader
+	le.resignCh = resignCh
+}
@@ -0,0 +1,133 @@
+package seata
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ErrLogsUnsupported is returned by TransactionLogs when the TC does not
+// serve transaction logs
+var ErrLogsUnsupported = errors.New("transaction logs not supported by the TC")
+
+// maxLogLine is the longest log entry a LogStream accepts
+const maxLogLine = 1 << 20
+
+// LogEntry is one server-side log line about a transaction
+type LogEntry struct {
+	Time     time.Time              `json:"time"`
+	Level    string                 `json:"level,omitempty"`
+	Message  string                 `json:"message"`
+	BranchID string                 `json:"branch_id,omitempty"`
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+}
+
+// LogStream reads the entries returned by TransactionLogs
+type LogStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	entry   LogEntry
+	err     error
+}
+
+// Next advances to the next entry, blocking while a followed stream waits
+// for new ones. It returns false at the end of the logs or on an error.
+func (s *LogStream) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	for s.scanner.Scan() {
+		line := s.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry LogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			s.err = fmt.Errorf("failed to parse log entry: %w", err)
+			return false
+		}
+		s.entry = entry
+		return true
+	}
+	s.err = s.scanner.Err()
+	return false
+}
+
+// Entry returns the entry read by the last call to Next
+func (s *LogStream) Entry() LogEntry {
+	return s.entry
+}
+
+// Err returns the error that stopped Next, or nil at the end of the logs.
+// Following a stream until its context is cancelled ends with that error.
+func (s *LogStream) Err() error {
+	return s.err
+}
+
+// Close stops reading the logs
+func (s *LogStream) Close() error {
+	return s.body.Close()
+}
+
+// TransactionLogs fetches the TC's log entries for gid from
+// GET /api/tx/{gid}/logs, which answers newline-delimited JSON entries.
+// With follow, the TC keeps the response open and streams new entries until
+// ctx is cancelled or the transaction ends; the client's RequestTimeout
+// does not apply. A TC without the endpoint fails with ErrLogsUnsupported.
+func (c *Client) TransactionLogs(ctx context.Context, gid string, follow bool) (*LogStream, error) {
+	if regional := c.ForGID(gid); regional != c {
+		return regional.TransactionLogs(ctx, gid, follow)
+	}
+	base, _ := c.currentTargets()
+	if base == "" {
+		base = c.httpClient.BaseURL
+	}
+	target := joinURL(base, "/api/tx/"+url.PathEscape(gid)+"/logs")
+	if follow {
+		target += "?follow=true"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction logs: %w", err)
+	}
+	for name, values := range c.httpClient.Header {
+		req.Header[name] = values
+	}
+	HeadersFromContext(ctx, req.Header.Set)
+	req.Header.Set("Accept", "application/x-ndjson")
+
+	// The client's http.Client carries RequestTimeout, which would cut a
+	// followed stream, so only its transport is shared
+	started := time.Now()
+	resp, err := (&http.Client{Transport: c.httpClient.GetClient().Transport}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction logs: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxLogLine))
+		reqErr := &RequestError{
+			Op:         "failed to fetch transaction logs",
+			StatusCode: resp.StatusCode,
+			Method:     http.MethodGet,
+			URL:        target,
+			Elapsed:    time.Since(started),
+		}
+		c.setErrorBody(reqErr, body)
+		switch resp.StatusCode {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			return nil, fmt.Errorf("%w: %w", ErrLogsUnsupported, reqErr)
+		}
+		return nil, reqErr
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogLine)
+	return &LogStream{body: resp.Body, scanner: scanner}, nil
+}
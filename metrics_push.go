@@ -0,0 +1,297 @@
+package seata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics push protocols
+const (
+	MetricsPushOTLP   = "otlp"   // OTLP/HTTP with JSON encoding
+	MetricsPushStatsD = "statsd" // StatsD over UDP with DogStatsD-style tags
+)
+
+// MetricsPushConfig enables pushing client metrics for deployments without a
+// Prometheus scrape path, such as short-lived batch jobs. The per-endpoint
+// request counts, error counts and average latencies of EndpointStats are
+// pushed every Interval and once more when the client is closed.
+type MetricsPushConfig struct {
+	Protocol string // MetricsPushOTLP or MetricsPushStatsD
+	// OTLP collector base URL (e.g. http://localhost:4318; /v1/metrics is
+	// appended) or StatsD host:port
+	Endpoint string
+	Interval time.Duration // zero means 10s
+	Prefix   string        // metric name prefix; empty means "seata_client"
+	// Tags added to every metric (OTLP resource attributes for OTLP)
+	Tags map[string]string
+}
+
+// metricsPusher periodically pushes endpoint stats
+type metricsPusher struct {
+	config  MetricsPushConfig
+	stats   *endpointStats
+	client  *http.Client
+	started time.Time
+	stop    chan struct{}
+	done    chan struct{}
+
+	// optional source of the stuck transaction count
+	watchdog *Watchdog
+
+	mu         sync.Mutex
+	sent       map[string]EndpointStat // last pushed values, for StatsD deltas
+	sentAlerts int64
+}
+
+func newMetricsPusher(config *MetricsPushConfig, stats *endpointStats) *metricsPusher {
+	copied := *config
+	if copied.Interval <= 0 {
+		copied.Interval = 10 * time.Second
+	}
+	if copied.Prefix == "" {
+		copied.Prefix = "seata_client"
+	}
+	return &metricsPusher{
+		config:  copied,
+		stats:   stats,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		started: time.Now(),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		sent:    make(map[string]EndpointStat),
+	}
+}
+
+// run pushes on every tick until stopped, then pushes a final time
+func (p *metricsPusher) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.push(context.Background()); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		case <-p.stop:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := p.push(ctx); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+			cancel()
+			return
+		}
+	}
+}
+
+// close stops the pusher after a final push
+func (p *metricsPusher) close() {
+	close(p.stop)
+	<-p.done
+}
+
+// push sends the current stats
+func (p *metricsPusher) push(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	snapshot := p.stats.snapshot()
+	var err error
+	switch p.config.Protocol {
+	case MetricsPushStatsD:
+		err = p.pushStatsD(snapshot)
+	default:
+		err = p.pushOTLP(ctx, snapshot)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	return nil
+}
+
+// pushStatsD sends counter deltas since the last push and latency gauges
+func (p *metricsPusher) pushStatsD(snapshot []EndpointStat) error {
+	var buf bytes.Buffer
+	for _, stat := range snapshot {
+		key := stat.Protocol + "|" + stat.Endpoint
+		last := p.sent[key]
+		tags := p.statsdTags("protocol:"+stat.Protocol, "endpoint:"+stat.Endpoint)
+		if d := stat.Requests - last.Requests; d > 0 {
+			fmt.Fprintf(&buf, "%s.requests:%d|c%s\n", p.config.Prefix, d, tags)
+		}
+		if d := stat.Errors - last.Errors; d > 0 {
+			fmt.Fprintf(&buf, "%s.errors:%d|c%s\n", p.config.Prefix, d, tags)
+		}
+		fmt.Fprintf(&buf, "%s.latency_avg_ms:%s|g%s\n", p.config.Prefix, formatMillis(stat.AvgLatency), tags)
+	}
+	var alerts int64
+	if p.watchdog != nil {
+		alerts = p.watchdog.Alerts()
+		if d := alerts - p.sentAlerts; d > 0 {
+			fmt.Fprintf(&buf, "%s.stuck_transactions:%d|c%s\n", p.config.Prefix, d, p.statsdTags())
+		}
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	conn, err := net.Dial("udp", p.config.Endpoint)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	for _, packet := range statsdPackets(buf.Bytes(), statsdMaxPacket) {
+		if _, err := conn.Write(packet); err != nil {
+			return err
+		}
+	}
+	for _, stat := range snapshot {
+		p.sent[stat.Protocol+"|"+stat.Endpoint] = stat
+	}
+	p.sentAlerts = alerts
+	return nil
+}
+
+// statsdMaxPacket keeps StatsD datagrams within a 1500 byte Ethernet MTU
+// after IPv6 and UDP headers, so they are not fragmented or dropped
+const statsdMaxPacket = 1432
+
+// statsdPackets splits newline-terminated metric lines into datagrams of at
+// most max bytes; a longer line is sent on its own
+func statsdPackets(lines []byte, max int) [][]byte {
+	var packets [][]byte
+	start, end := 0, 0
+	for end < len(lines) {
+		next := end + bytes.IndexByte(lines[end:], '\n') + 1
+		if next == end {
+			next = len(lines)
+		}
+		if next-start > max && end > start {
+			packets = append(packets, lines[start:end])
+			start = end
+		}
+		end = next
+	}
+	if end > start {
+		packets = append(packets, lines[start:end])
+	}
+	return packets
+}
+
+// statsdTags renders the given and configured tags as |#k:v,...
+func (p *metricsPusher) statsdTags(tags ...string) string {
+	for _, k := range sortedKeys(p.config.Tags) {
+		tags = append(tags, k+":"+p.config.Tags[k])
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return "|#" + strings.Join(tags, ",")
+}
+
+// pushOTLP posts cumulative sums and latency gauges to an OTLP/HTTP collector
+func (p *metricsPusher) pushOTLP(ctx context.Context, snapshot []EndpointStat) error {
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+	start := strconv.FormatInt(p.started.UnixNano(), 10)
+
+	var requests, errors, latency []map[string]interface{}
+	for _, stat := range snapshot {
+		attrs := []map[string]interface{}{otlpAttr("protocol", stat.Protocol), otlpAttr("endpoint", stat.Endpoint)}
+		requests = append(requests, map[string]interface{}{
+			"attributes": attrs, "startTimeUnixNano": start, "timeUnixNano": now, "asInt": strconv.FormatInt(stat.Requests, 10),
+		})
+		errors = append(errors, map[string]interface{}{
+			"attributes": attrs, "startTimeUnixNano": start, "timeUnixNano": now, "asInt": strconv.FormatInt(stat.Errors, 10),
+		})
+		latency = append(latency, map[string]interface{}{
+			"attributes": attrs, "timeUnixNano": now, "asDouble": float64(stat.AvgLatency) / float64(time.Millisecond),
+		})
+	}
+	sum := func(name string, points []map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"name": p.config.Prefix + "_" + name,
+			"sum":  map[string]interface{}{"aggregationTemporality": 2, "isMonotonic": true, "dataPoints": points},
+		}
+	}
+
+	metrics := []map[string]interface{}{
+		sum("requests_total", requests),
+		sum("errors_total", errors),
+		{
+			"name":  p.config.Prefix + "_latency_avg_ms",
+			"gauge": map[string]interface{}{"dataPoints": latency},
+		},
+	}
+	if p.watchdog != nil {
+		metrics = append(metrics, sum("stuck_transactions_total", []map[string]interface{}{{
+			"startTimeUnixNano": start, "timeUnixNano": now, "asInt": strconv.FormatInt(p.watchdog.Alerts(), 10),
+		}}))
+	}
+
+	resource := []map[string]interface{}{}
+	for _, k := range sortedKeys(p.config.Tags) {
+		resource = append(resource, otlpAttr(k, p.config.Tags[k]))
+	}
+	body := map[string]interface{}{
+		"resourceMetrics": []map[string]interface{}{{
+			"resource": map[string]interface{}{"attributes": resource},
+			"scopeMetrics": []map[string]interface{}{{
+				"scope":   map[string]interface{}{"name": "seata-go-client"},
+				"metrics": metrics,
+			}},
+		}},
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, joinURL(p.config.Endpoint, "/v1/metrics"), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// PushMetrics pushes client metrics immediately when MetricsPush is configured
+func (c *Client) PushMetrics(ctx context.Context) error {
+	if c.metricsPusher == nil {
+		return fmt.Errorf("metrics push is not configured")
+	}
+	return c.metricsPusher.push(ctx)
+}
+
+func otlpAttr(key, value string) map[string]interface{} {
+	return map[string]interface{}{"key": key, "value": map[string]interface{}{"stringValue": value}}
+}
+
+func formatMillis(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,175 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers of messages exchanged with nats:// branches
+const (
+	NATSHeaderGID      = HeaderGID
+	NATSHeaderBranchID = HeaderBranchID
+	NATSHeaderPhase    = "Seata-Phase" // try, confirm or cancel
+	NATSHeaderReplyTo  = "Seata-Reply-To"
+)
+
+// ErrNATSNotConfigured is returned for nats:// branches without Config.NATS
+var ErrNATSNotConfigured = errors.New("nats:// branch used but Config.NATS is not set")
+
+// NATSConn is the part of a NATS JetStream connection used by nats://
+// branches. An adapter typically wraps jetstream.JetStream.PublishMsg and a
+// core subscription on the result subject.
+type NATSConn interface {
+	// Publish sends data with header to subject and returns once JetStream
+	// acknowledged it
+	Publish(ctx context.Context, subject string, header map[string]string, data []byte) error
+	// Subscribe calls handler for each message on subject until unsubscribe
+	// is called
+	Subscribe(subject string, handler func(header map[string]string, data []byte)) (unsubscribe func(), err error)
+}
+
+// NATSConfig enables TCC branches with nats://subject URLs. The client
+// publishes the payload to the subject and waits for a result message on
+// ResultSubject carrying the same gid, branch id and phase headers. Result
+// bodies follow the branch result convention: SUCCESS, FAILURE or ONGOING
+// (keep waiting), plain or as {"dtm_result": ...}.
+type NATSConfig struct {
+	Conn NATSConn
+	// ResultSubject receives branch results; empty means "seata.results"
+	ResultSubject string
+	// Timeout for a result; zero means 30s. A ?timeout=5s query on the
+	// branch URL overrides it.
+	Timeout time.Duration
+}
+
+// natsBranches correlates published branch calls with their results
+type natsBranches struct {
+	config      NATSConfig
+	unsubscribe func()
+
+	mu      sync.Mutex
+	waiters map[string]chan error
+}
+
+func newNATSBranches(config *NATSConfig) (*natsBranches, error) {
+	copied := *config
+	if copied.ResultSubject == "" {
+		copied.ResultSubject = "seata.results"
+	}
+	if copied.Timeout <= 0 {
+		copied.Timeout = 30 * time.Second
+	}
+	n := &natsBranches{config: copied, waiters: make(map[string]chan error)}
+	unsubscribe, err := copied.Conn.Subscribe(copied.ResultSubject, n.deliver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", copied.ResultSubject, err)
+	}
+	n.unsubscribe = unsubscribe
+	return n, nil
+}
+
+func (n *natsBranches) close() {
+	n.unsubscribe()
+}
+
+func natsKey(gid, branchID, phase string) string {
+	return gid + "/" + branchID + "/" + phase
+}
+
+// deliver hands a result message to the call waiting for it
+func (n *natsBranches) deliver(header map[string]string, data []byte) {
+	var err error
+	switch ParseBranchResult(http.StatusOK, data) {
+	case ResultOngoing:
+		return
+	case ResultFailure:
+		err = ErrBranchFailure
+	}
+	key := natsKey(header[NATSHeaderGID], header[NATSHeaderBranchID], header[NATSHeaderPhase])
+	n.mu.Lock()
+	waiter, ok := n.waiters[key]
+	delete(n.waiters, key)
+	n.mu.Unlock()
+	if ok {
+		waiter <- err
+	}
+}
+
+// isNATSURL reports whether a branch URL is served over NATS
+func isNATSURL(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "nats://")
+}
+
+// callNATS publishes payload for one phase of a nats:// branch and waits for
+// its result
+func (tx *Transaction) callNATS(ctx context.Context, branchID, rawURL, phase string, payload []byte) error {
+	n := tx.client.nats
+	if n == nil {
+		return ErrNATSNotConfigured
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid NATS URL %q: %w", rawURL, err)
+	}
+	subject := strings.Trim(u.Host+u.Path, "/")
+	timeout := n.config.Timeout
+	if t := u.Query().Get("timeout"); t != "" {
+		if timeout, err = time.ParseDuration(t); err != nil {
+			return fmt.Errorf("invalid timeout in NATS URL %q: %w", rawURL, err)
+		}
+	}
+
+	key := natsKey(tx.gid, branchID, phase)
+	waiter := make(chan error, 1)
+	n.mu.Lock()
+	n.waiters[key] = waiter
+	n.mu.Unlock()
+	defer func() {
+		n.mu.Lock()
+		delete(n.waiters, key)
+		n.mu.Unlock()
+	}()
+
+	header := map[string]string{
+		NATSHeaderGID:       tx.gid,
+		NATSHeaderBranchID:  branchID,
+		NATSHeaderPhase:     phase,
+		NATSHeaderReplyTo:   n.config.ResultSubject,
+		CorrelationIDHeader: tx.correlationID,
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := n.config.Conn.Publish(ctx, subject, header, payload); err != nil {
+		return fmt.Errorf("failed to publish %s of branch %s to %s: %w", phase, branchID, subject, err)
+	}
+
+	select {
+	case err := <-waiter:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("no %s result for branch %s from %s: %w", phase, branchID, subject, ctx.Err())
+	}
+}
+
+// tryNATS runs the try phase of a nats:// branch and reports it to the TC
+func (tx *Transaction) tryNATS(ctx context.Context, branchID, action string, payload []byte) error {
+	if err := tx.callNATS(ctx, branchID, action, "try", payload); err != nil {
+		return fmt.Errorf("failed to execute try phase: %w", err)
+	}
+	return tx.ReportBranch(ctx, branchID, BranchStatusPrepared, nil)
+}
+
+// finishNATS confirms or cancels a nats:// branch and reports the final
+// status to the TC
+func (tx *Transaction) finishNATS(ctx context.Context, branchID, rawURL, phase, status string) error {
+	if err := tx.callNATS(ctx, branchID, rawURL, phase, tx.payload); err != nil {
+		return fmt.Errorf("failed to execute %s phase: %w", phase, err)
+	}
+	return tx.ReportBranch(ctx, branchID, status, nil)
+}
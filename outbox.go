@@ -0,0 +1,389 @@
+package seata
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outbox entry kinds
+const (
+	OutboxSubmit       = "submit"        // submit the global transaction
+	OutboxAbort        = "abort"         // abort the global transaction
+	OutboxBranchReport = "branch_report" // ReportBranch with Status and Data
+)
+
+// Outbox entry states
+const (
+	outboxPending = "pending"
+	outboxDone    = "done"
+	outboxFailed  = "failed"
+)
+
+// OutboxEntry is a TC call recorded in the local database, committed
+// together with the business change and barrier that require it
+type OutboxEntry struct {
+	ID       int64
+	GID      string
+	BranchID string // branch reports only
+	Kind     string
+	Status   string // branch status of a report
+	Data     []byte // application data of a report
+	// Mode and Payload of the transaction, handed to the PreSubmitValidator
+	// and Authorizer when a submit is relayed
+	Mode      string
+	Payload   []byte
+	Attempts  int
+	LastError string
+	CreatedAt time.Time
+}
+
+// OutboxStore persists outbox entries until the relay delivered them
+type OutboxStore interface {
+	// Enqueue records a new entry and sets its ID
+	Enqueue(ctx context.Context, entry *OutboxEntry) error
+	// Pending returns up to limit undelivered entries, oldest first
+	Pending(ctx context.Context, limit int) ([]*OutboxEntry, error)
+	// MarkDone records that the entry was delivered
+	MarkDone(ctx context.Context, id int64) error
+	// MarkFailed counts a failed attempt; final stops further attempts
+	MarkFailed(ctx context.Context, id int64, cause error, final bool) error
+}
+
+// MemoryOutbox keeps entries in memory, for tests
+type MemoryOutbox struct {
+	mu      sync.Mutex
+	nextID  int64
+	entries map[int64]*OutboxEntry
+	states  map[int64]string
+}
+
+// NewMemoryOutbox creates an empty in-memory outbox
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{entries: make(map[int64]*OutboxEntry), states: make(map[int64]string)}
+}
+
+// Enqueue records a new entry
+func (o *MemoryOutbox) Enqueue(ctx context.Context, entry *OutboxEntry) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.nextID++
+	entry.ID = o.nextID
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	copied := *entry
+	o.entries[entry.ID] = &copied
+	o.states[entry.ID] = outboxPending
+	return nil
+}
+
+// Pending returns undelivered entries, oldest first
+func (o *MemoryOutbox) Pending(ctx context.Context, limit int) ([]*OutboxEntry, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var pending []*OutboxEntry
+	for id, entry := range o.entries {
+		if o.states[id] == outboxPending {
+			copied := *entry
+			pending = append(pending, &copied)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].ID < pending[j].ID })
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending, nil
+}
+
+// MarkDone records that the entry was delivered
+func (o *MemoryOutbox) MarkDone(ctx context.Context, id int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.states[id] = outboxDone
+	return nil
+}
+
+// MarkFailed counts a failed attempt
+func (o *MemoryOutbox) MarkFailed(ctx context.Context, id int64, cause error, final bool) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if entry, ok := o.entries[id]; ok {
+		entry.Attempts++
+		entry.LastError = cause.Error()
+	}
+	if final {
+		o.states[id] = outboxFailed
+	}
+	return nil
+}
+
+// Failed returns the entries given up on
+func (o *MemoryOutbox) Failed() []*OutboxEntry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var failed []*OutboxEntry
+	for id, entry := range o.entries {
+		if o.states[id] == outboxFailed {
+			copied := *entry
+			failed = append(failed, &copied)
+		}
+	}
+	return failed
+}
+
+// SQLOutbox stores entries in a SQL table next to the barrier table, e.g.:
+//
+//	CREATE TABLE seata_outbox (
+//	  id BIGINT AUTO_INCREMENT PRIMARY KEY, -- BIGSERIAL on PostgreSQL
+//	  gid VARCHAR(128) NOT NULL,
+//	  branch_id VARCHAR(128) NOT NULL DEFAULT '',
+//	  kind VARCHAR(32) NOT NULL,
+//	  status VARCHAR(32) NOT NULL DEFAULT '',
+//	  data TEXT,
+//	  mode VARCHAR(32) NOT NULL DEFAULT '',
+//	  payload TEXT,
+//	  state VARCHAR(16) NOT NULL DEFAULT 'pending',
+//	  attempts INT NOT NULL DEFAULT 0,
+//	  last_error TEXT,
+//	  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+//	);
+//	CREATE INDEX seata_outbox_state ON seata_outbox (state, id);
+//
+// Use EnqueueTx in the same database transaction as the business change and
+// SQLBarrierStore.InsertTx, so the TC call is recorded exactly when the local
+// work commits.
+type SQLOutbox struct {
+	db      *sql.DB
+	table   string
+	dialect string
+}
+
+// NewSQLOutbox creates an outbox using table (default "seata_outbox")
+func NewSQLOutbox(db *sql.DB, dialect, table string) (*SQLOutbox, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database cannot be nil")
+	}
+	if dialect != SQLDialectMySQL && dialect != SQLDialectPostgres {
+		return nil, fmt.Errorf("unsupported SQL dialect: %s", dialect)
+	}
+	if table == "" {
+		table = "seata_outbox"
+	}
+	return &SQLOutbox{db: db, table: table, dialect: dialect}, nil
+}
+
+// sqlQueryExecer is implemented by *sql.DB and *sql.Tx
+type sqlQueryExecer interface {
+	sqlExecer
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Enqueue records a new entry
+func (o *SQLOutbox) Enqueue(ctx context.Context, entry *OutboxEntry) error {
+	return o.enqueue(ctx, o.db, entry)
+}
+
+// EnqueueTx records a new entry inside tx
+func (o *SQLOutbox) EnqueueTx(ctx context.Context, tx *sql.Tx, entry *OutboxEntry) error {
+	return o.enqueue(ctx, tx, entry)
+}
+
+func (o *SQLOutbox) enqueue(ctx context.Context, db sqlQueryExecer, entry *OutboxEntry) error {
+	query := fmt.Sprintf("INSERT INTO %s (gid, branch_id, kind, status, data, mode, payload, state) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", o.table)
+	args := []interface{}{entry.GID, entry.BranchID, entry.Kind, entry.Status, base64.StdEncoding.EncodeToString(entry.Data),
+		entry.Mode, base64.StdEncoding.EncodeToString(entry.Payload), outboxPending}
+	if o.dialect == SQLDialectPostgres {
+		if err := db.QueryRowContext(ctx, o.rebind(query)+" RETURNING id", args...).Scan(&entry.ID); err != nil {
+			return fmt.Errorf("failed to enqueue %s of %s: %w", entry.Kind, entry.GID, err)
+		}
+		return nil
+	}
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s of %s: %w", entry.Kind, entry.GID, err)
+	}
+	entry.ID, err = result.LastInsertId()
+	return err
+}
+
+// Pending returns up to limit undelivered entries, oldest first
+func (o *SQLOutbox) Pending(ctx context.Context, limit int) ([]*OutboxEntry, error) {
+	query := fmt.Sprintf("SELECT id, gid, branch_id, kind, status, data, mode, payload, attempts, last_error, created_at FROM %s WHERE state = ? ORDER BY id LIMIT ?", o.table)
+	rows, err := o.db.QueryContext(ctx, o.rebind(query), outboxPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outbox entries: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []*OutboxEntry
+	for rows.Next() {
+		var entry OutboxEntry
+		var data, payload, lastError sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.GID, &entry.BranchID, &entry.Kind, &entry.Status, &data, &entry.Mode, &payload, &entry.Attempts, &lastError, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to list outbox entries: %w", err)
+		}
+		if entry.Data, err = base64.StdEncoding.DecodeString(data.String); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox entry %d: %w", entry.ID, err)
+		}
+		if entry.Payload, err = base64.StdEncoding.DecodeString(payload.String); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox entry %d: %w", entry.ID, err)
+		}
+		entry.LastError = lastError.String
+		pending = append(pending, &entry)
+	}
+	return pending, rows.Err()
+}
+
+// MarkDone records that the entry was delivered
+func (o *SQLOutbox) MarkDone(ctx context.Context, id int64) error {
+	query := fmt.Sprintf("UPDATE %s SET state = ? WHERE id = ?", o.table)
+	if _, err := o.db.ExecContext(ctx, o.rebind(query), outboxDone, id); err != nil {
+		return fmt.Errorf("failed to mark outbox entry %d done: %w", id, err)
+	}
+	return nil
+}
+
+// MarkFailed counts a failed attempt
+func (o *SQLOutbox) MarkFailed(ctx context.Context, id int64, cause error, final bool) error {
+	state := outboxPending
+	if final {
+		state = outboxFailed
+	}
+	query := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = ?, state = ? WHERE id = ?", o.table)
+	if _, err := o.db.ExecContext(ctx, o.rebind(query), cause.Error(), state, id); err != nil {
+		return fmt.Errorf("failed to mark outbox entry %d failed: %w", id, err)
+	}
+	return nil
+}
+
+// rebind converts ? placeholders into the dialect's placeholder syntax
+func (o *SQLOutbox) rebind(query string) string {
+	return rebindSQL(o.dialect, query)
+}
+
+// OutboxRelayConfig configures an OutboxRelay
+type OutboxRelayConfig struct {
+	// BatchSize is the number of entries relayed per poll; zero means 100
+	BatchSize int
+	// PollInterval between scans; zero means 1s
+	PollInterval time.Duration
+	// MaxAttempts after which an entry is given up; zero retries forever.
+	// Calls vetoed by the PreSubmitValidator or denied by the Authorizer
+	// are given up at once.
+	MaxAttempts int
+	// Elector, when set, restricts relaying to the instance holding leadership
+	Elector *LeaderElector
+	// OnError is called for every failed delivery
+	OnError func(entry *OutboxEntry, err error)
+}
+
+// OutboxRelay delivers outbox entries to the TC, closing the gap between a
+// committed local transaction and the TC calls it requires. Entries are
+// delivered at least once, so the TC calls must be idempotent.
+type OutboxRelay struct {
+	client *Client
+	store  OutboxStore
+	config OutboxRelayConfig
+}
+
+// NewOutboxRelay creates a relay delivering entries of store through client
+func NewOutboxRelay(client *Client, store OutboxStore, config *OutboxRelayConfig) *OutboxRelay {
+	var cfg OutboxRelayConfig
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	return &OutboxRelay{client: client, store: store, config: cfg}
+}
+
+// Run relays entries every PollInterval until ctx is done
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		if r.config.Elector == nil || r.config.Elector.IsLeader() {
+			if _, err := r.RelayOnce(ctx); err != nil {
+				fmt.Printf("Warning: outbox relay: %v\n", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce delivers one batch and returns the number of delivered entries.
+// Entries of a transaction whose earlier entry failed are left for the next
+// poll, so a submit never overtakes its branch reports.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	entries, err := r.store.Pending(ctx, r.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	blocked := make(map[string]bool)
+	var errs []string
+	for _, entry := range entries {
+		if blocked[entry.GID] {
+			continue
+		}
+		if err := r.deliver(ctx, entry); err != nil {
+			blocked[entry.GID] = true
+			// A vetoed or unauthorized call fails the same way every time
+			final := r.config.MaxAttempts > 0 && entry.Attempts+1 >= r.config.MaxAttempts ||
+				errors.Is(err, ErrSubmitVetoed) || errors.Is(err, ErrUnauthorized)
+			if markErr := r.store.MarkFailed(ctx, entry.ID, err, final); markErr != nil {
+				errs = append(errs, markErr.Error())
+			}
+			if r.config.OnError != nil {
+				r.config.OnError(entry, err)
+			}
+			continue
+		}
+		if err := r.store.MarkDone(ctx, entry.ID); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		delivered++
+	}
+	if len(errs) > 0 {
+		return delivered, fmt.Errorf("failed to update outbox: %s", strings.Join(errs, "; "))
+	}
+	return delivered, nil
+}
+
+// deliver performs the TC call of entry on the client of its region. The
+// relayed transaction has the entry's Mode and Payload but no branches,
+// which the TC holds.
+func (r *OutboxRelay) deliver(ctx context.Context, entry *OutboxEntry) error {
+	tx := &Transaction{client: r.client.ForGID(entry.GID), gid: entry.GID, mode: entry.Mode, payload: entry.Payload, branches: make([]*Branch, 0)}
+	switch entry.Kind {
+	case OutboxSubmit:
+		return tx.Submit(ctx)
+	case OutboxAbort:
+		// The relay retries failed entries itself, so the abort is not
+		// handed to the abort escalator as Abort would
+		if err := tx.client.authorize(ctx, AuthorizeAbort, tx.mode, tx.gid, tx.payload); err != nil {
+			return err
+		}
+		return tx.abort(ctx, nil)
+	case OutboxBranchReport:
+		return tx.ReportBranch(ctx, entry.BranchID, entry.Status, entry.Data)
+	}
+	return fmt.Errorf("unknown outbox entry kind: %s", entry.Kind)
+}
@@ -0,0 +1,16 @@
+package seata
+
+import "github.com/go-resty/resty/v2"
+
+// newParticipantClient returns the client for calls the client makes to
+// participants directly, such as polls of ONGOING branches. It has its own
+// connection pool and none of the TC client's base URL, retries, TLS
+// settings or hooks, so these calls stay out of TC stats and histograms.
+func newParticipantClient(config *Config) *resty.Client {
+	transport := newHTTPTransport(config)
+	transport.TLSClientConfig = nil
+	client := resty.New()
+	client.SetTimeout(config.RequestTimeout)
+	client.GetClient().Transport = transport
+	return client
+}
@@ -0,0 +1,83 @@
+package seata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// GetPayload returns the global payload as last sent to the TC
+func (tx *Transaction) GetPayload() []byte {
+	return tx.payload
+}
+
+// SetPayload replaces the global payload, e.g. with totals computed while
+// registering branches. It must be called before Submit; the local payload
+// only changes once the TC accepted the new one.
+func (tx *Transaction) SetPayload(ctx context.Context, payload []byte) error {
+	if tx.submitted {
+		return fmt.Errorf("cannot set payload of transaction %s: already submitted", tx.gid)
+	}
+	encoded, err := tx.client.encodePayload(payload)
+	if err != nil {
+		return err
+	}
+	req := map[string]interface{}{
+		"gid":     tx.gid,
+		"payload": bytesToIntArray(encoded),
+	}
+
+	request, err := tx.client.setJSONBody(tx.client.httpClient.R().SetContext(tx.Context(ctx)), req)
+	if err != nil {
+		return fmt.Errorf("failed to set payload: %w", err)
+	}
+	resp, err := request.Post(tx.url("/api/payload"))
+	if err != nil {
+		return fmt.Errorf("failed to set payload: %w", err)
+	}
+	if resp.StatusCode() != 200 {
+		return tx.client.statusError("failed to set payload", resp)
+	}
+
+	tx.payload = payload
+	return nil
+}
+
+// PatchPayload applies a JSON merge patch (RFC 7386) to a JSON payload and
+// sends the result with SetPayload
+func (tx *Transaction) PatchPayload(ctx context.Context, patch []byte) error {
+	var current, changes interface{}
+	if len(tx.payload) > 0 {
+		if err := json.Unmarshal(tx.payload, &current); err != nil {
+			return fmt.Errorf("failed to patch payload: payload is not JSON: %w", err)
+		}
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return fmt.Errorf("failed to patch payload: invalid patch: %w", err)
+	}
+	merged, err := json.Marshal(mergePatch(current, changes))
+	if err != nil {
+		return fmt.Errorf("failed to patch payload: %w", err)
+	}
+	return tx.SetPayload(ctx, merged)
+}
+
+// mergePatch applies patch to target following RFC 7386
+func mergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
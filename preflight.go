@@ -0,0 +1,125 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrParticipantsUnreachable is returned when a pre-flight check finds
+// participants that cannot be reached; no transaction is started
+var ErrParticipantsUnreachable = errors.New("participants unreachable")
+
+// PreflightConfig probes the participants of a workflow before its
+// transaction starts, so a run that is bound to be compensated is not
+// started at all
+type PreflightConfig struct {
+	// HealthPath is probed with a GET on every participant host, e.g.
+	// "/health", and must answer 2xx. Empty sends a HEAD to each action
+	// URL instead, which passes on any response below 500.
+	HealthPath string
+	// Timeout bounds each probe; zero means 2s
+	Timeout time.Duration
+}
+
+// preflight probes every distinct participant of actions concurrently and
+// lists the unreachable ones in the error. nats:// actions are not probed.
+func (c *Client) preflight(ctx context.Context, config *PreflightConfig, actions []string) error {
+	if config == nil {
+		return nil
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+
+	targets := make(map[string]string) // probe URL by participant
+	for _, action := range actions {
+		if action == "" || isNATSURL(action) {
+			continue
+		}
+		u, err := url.Parse(action)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		if config.HealthPath == "" {
+			targets[action] = action
+			continue
+		}
+		targets[u.Host] = u.Scheme + "://" + u.Host + "/" + strings.TrimPrefix(config.HealthPath, "/")
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var failures []string
+	for participant, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.probe(ctx, target, config.HealthPath == "", timeout); err != nil {
+				mu.Lock()
+				failures = append(failures, fmt.Sprintf("%s (%v)", participant, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("%w: %s", ErrParticipantsUnreachable, strings.Join(failures, ", "))
+	}
+	return nil
+}
+
+// probe checks one participant URL
+func (c *Client) probe(ctx context.Context, target string, head bool, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req := c.httpClient.R().SetContext(ctx)
+	if head {
+		resp, err := req.Execute(http.MethodHead, target)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode() >= 500 {
+			return fmt.Errorf("status %d", resp.StatusCode())
+		}
+		return nil
+	}
+	resp, err := req.Get(target)
+	if err != nil {
+		return err
+	}
+	if !resp.IsSuccess() {
+		return fmt.Errorf("status %d", resp.StatusCode())
+	}
+	return nil
+}
+
+// sagaActions lists the actions a saga runs
+func sagaActions(steps []SagaStep) []string {
+	actions := make([]string, 0, len(steps))
+	for _, step := range steps {
+		actions = append(actions, step.Action, step.FallbackAction)
+		if step.child != nil {
+			actions = append(actions, sagaActions(step.child.Steps)...)
+		}
+	}
+	return actions
+}
+
+// tccActions lists the try actions of a TCC workflow
+func tccActions(steps []TCCStep) []string {
+	actions := make([]string, 0, len(steps))
+	for _, step := range steps {
+		actions = append(actions, step.Try)
+	}
+	return actions
+}
@@ -0,0 +1,105 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// ErrSubmitVetoed is returned when a PreSubmitValidator rejects a
+// transaction; it stays unsubmitted and can be aborted
+var ErrSubmitVetoed = errors.New("transaction submission vetoed")
+
+// SubmitRequest is the assembled transaction handed to a PreSubmitValidator
+type SubmitRequest struct {
+	GID      string   `json:"gid"`
+	Mode     string   `json:"mode"`
+	Branches []Branch `json:"branches"`
+	Payload  []byte   `json:"payload,omitempty"`
+	// Compensations holds the compensate URLs of saga steps that are not
+	// registered with the TC because the orchestrator calls them itself
+	// (ExecutionOptions.RegisterCompensations unset), by branch ID
+	Compensations map[string]string `json:"compensations,omitempty"`
+}
+
+// PreSubmitValidator inspects a transaction before it is submitted and
+// returns an error to veto the submission
+type PreSubmitValidator func(ctx context.Context, req *SubmitRequest) error
+
+// RequireCompensations is a PreSubmitValidator rejecting saga branches
+// with neither a registered compensate URL nor a compensation declared by
+// their saga step
+func RequireCompensations(ctx context.Context, req *SubmitRequest) error {
+	if req.Mode != ModeSaga {
+		return nil
+	}
+	var missing []string
+	for _, branch := range req.Branches {
+		if branch.Compensate == "" && req.Compensations[branch.BranchID] == "" {
+			missing = append(missing, branch.BranchID)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("saga branches without compensation: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// NewWebhookValidator returns a PreSubmitValidator that POSTs the
+// SubmitRequest as JSON to url. A 2xx response allows the submission, a 4xx
+// vetoes it with the response body as the reason; a webhook that cannot be
+// reached or fails with a 5xx blocks the submission too.
+func NewWebhookValidator(url string, timeout time.Duration) PreSubmitValidator {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	client := resty.New().SetTimeout(timeout)
+	return func(ctx context.Context, req *SubmitRequest) error {
+		resp, err := client.R().
+			SetContext(ctx).
+			SetHeader("Content-Type", "application/json").
+			SetBody(req).
+			Post(url)
+		if err != nil {
+			return fmt.Errorf("failed to call validation webhook: %w", err)
+		}
+		switch {
+		case resp.IsSuccess():
+			return nil
+		case resp.StatusCode() >= 400 && resp.StatusCode() < 500:
+			return errors.New(excerptBody(resp.Body(), defaultMaxErrorBody))
+		}
+		return fmt.Errorf("validation webhook failed with status %d", resp.StatusCode())
+	}
+}
+
+// validateSubmit runs the configured PreSubmitValidator
+func (tx *Transaction) validateSubmit(ctx context.Context) error {
+	validate := tx.client.config.PreSubmitValidator
+	if validate == nil {
+		return nil
+	}
+	req := &SubmitRequest{
+		GID:      tx.gid,
+		Mode:     tx.mode,
+		Branches: make([]Branch, len(tx.branches)),
+		Payload:  tx.payload,
+	}
+	if len(tx.compensations) > 0 {
+		req.Compensations = make(map[string]string, len(tx.compensations))
+		for id, url := range tx.compensations {
+			req.Compensations[id] = url
+		}
+	}
+	for i, branch := range tx.branches {
+		req.Branches[i] = *branch
+	}
+	if err := validate(ctx, req); err != nil {
+		return fmt.Errorf("%w: %v", ErrSubmitVetoed, err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package seata
+
+import (
+	"context"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// pprof label keys set on workflow executions with ExecutionOptions.Profile
+const (
+	ProfileLabelWorkflow = "seata.workflow"
+	ProfileLabelMode     = "seata.mode"
+	ProfileLabelGID      = "seata.gid"
+)
+
+// startProfile labels the calling goroutine with the workflow name and mode
+// and opens a runtime/trace task for the execution. Goroutines started with
+// the returned context inherit the labels; end restores the previous ones.
+func startProfile(ctx context.Context, options *ExecutionOptions, mode string) (context.Context, func()) {
+	if !options.Profile {
+		return ctx, func() {}
+	}
+	previous := ctx
+	name := options.WorkflowName
+	if name == "" {
+		name = "unnamed"
+	}
+	ctx = pprof.WithLabels(ctx, pprof.Labels(ProfileLabelWorkflow, name, ProfileLabelMode, mode))
+	pprof.SetGoroutineLabels(ctx)
+	ctx, task := trace.NewTask(ctx, "seata."+mode)
+	return ctx, func() {
+		task.End()
+		pprof.SetGoroutineLabels(previous)
+	}
+}
+
+// profileGID adds the transaction id to the labels set by startProfile
+func profileGID(ctx context.Context, options *ExecutionOptions, gid string) context.Context {
+	if !options.Profile {
+		return ctx
+	}
+	ctx = pprof.WithLabels(ctx, pprof.Labels(ProfileLabelGID, gid))
+	pprof.SetGoroutineLabels(ctx)
+	trace.Log(ctx, ProfileLabelGID, gid)
+	return ctx
+}
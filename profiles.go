@@ -0,0 +1,210 @@
+package seata
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ProfileEnvVar names the environment variable selecting the profile when
+// LoadProfile is called without a name
+const ProfileEnvVar = "SEATA_PROFILE"
+
+// ErrProfileNotFound is returned when the selected profile is not in the file
+var ErrProfileNotFound = errors.New("config profile not found")
+
+// ProfileFile is a set of named configurations, e.g. dev, staging and prod,
+// read from one JSON file. Common applies to every profile first.
+type ProfileFile struct {
+	// Default is the profile used when neither a name nor ProfileEnvVar
+	// selects one
+	Default  string             `json:"default,omitempty"`
+	Common   Profile            `json:"common"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// Profile holds the settings of one environment; unset fields keep the
+// value of Common or DefaultConfig
+type Profile struct {
+	HTTPEndpoint       string            `json:"http_endpoint,omitempty"`
+	GrpcEndpoint       string            `json:"grpc_endpoint,omitempty"`
+	Transport          string            `json:"transport,omitempty"`
+	PreferredTransport string            `json:"preferred_transport,omitempty"`
+	RequestTimeout     ProfileDuration   `json:"request_timeout,omitempty"`
+	RetryInterval      ProfileDuration   `json:"retry_interval,omitempty"`
+	MaxRetries         *int              `json:"max_retries,omitempty"`
+	MaxIdleConns       *int              `json:"max_idle_conns,omitempty"`
+	MaxConnsPerHost    *int              `json:"max_conns_per_host,omitempty"`
+	AuthToken          string            `json:"auth_token,omitempty"`
+	GIDPrefix          string            `json:"gid_prefix,omitempty"`
+	TLS                *ProfileTLS       `json:"tls,omitempty"`
+	Discovery          *ProfileDiscovery `json:"discovery,omitempty"`
+}
+
+// ProfileTLS configures TLS from PEM files
+type ProfileTLS struct {
+	CAFile             string `json:"ca_file,omitempty"`
+	CertFile           string `json:"cert_file,omitempty"`
+	KeyFile            string `json:"key_file,omitempty"`
+	ServerName         string `json:"server_name,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+// ProfileDiscovery configures etcd service discovery
+type ProfileDiscovery struct {
+	EtcdEndpoints []string `json:"etcd_endpoints"`
+	Namespace     string   `json:"namespace,omitempty"`
+}
+
+// ProfileDuration is a duration written as a string such as "5s"
+type ProfileDuration time.Duration
+
+// UnmarshalJSON parses a time.ParseDuration string
+func (d *ProfileDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = ProfileDuration(parsed)
+	return nil
+}
+
+// MarshalJSON writes the duration as a string
+func (d ProfileDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// LoadProfile reads the profile file at path and returns DefaultConfig with
+// Common and the selected profile applied, then each override in order. An
+// empty name uses $SEATA_PROFILE, then the file's Default. ${VAR}
+// references in the file are expanded from the environment.
+func LoadProfile(path, name string, overrides ...func(*Config)) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile file: %w", err)
+	}
+	file, err := ParseProfiles([]byte(os.ExpandEnv(string(data))))
+	if err != nil {
+		return nil, err
+	}
+	return file.Config(name, overrides...)
+}
+
+// ParseProfiles decodes a profile file, rejecting unknown fields
+func ParseProfiles(data []byte) (*ProfileFile, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var file ProfileFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse profile file: %w", err)
+	}
+	return &file, nil
+}
+
+// Config returns DefaultConfig with Common and the named profile applied,
+// then each override in order. An empty name uses $SEATA_PROFILE, then
+// Default.
+func (f *ProfileFile) Config(name string, overrides ...func(*Config)) (*Config, error) {
+	if name == "" {
+		name = os.Getenv(ProfileEnvVar)
+	}
+	if name == "" {
+		name = f.Default
+	}
+	profile, ok := f.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(f.Profiles))
+		for n := range f.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w: %q (have %s)", ErrProfileNotFound, name, strings.Join(names, ", "))
+	}
+
+	config := DefaultConfig()
+	if err := f.Common.apply(config); err != nil {
+		return nil, fmt.Errorf("invalid common profile settings: %w", err)
+	}
+	if err := profile.apply(config); err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %w", name, err)
+	}
+	for _, override := range overrides {
+		override(config)
+	}
+	return config, nil
+}
+
+// apply copies the set fields of p into config
+func (p *Profile) apply(config *Config) error {
+	setString := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	setString(&config.HTTPEndpoint, p.HTTPEndpoint)
+	setString(&config.GrpcEndpoint, p.GrpcEndpoint)
+	setString(&config.Transport, p.Transport)
+	setString(&config.PreferredTransport, p.PreferredTransport)
+	setString(&config.AuthToken, p.AuthToken)
+	setString(&config.GIDPrefix, p.GIDPrefix)
+	if p.RequestTimeout != 0 {
+		config.RequestTimeout = time.Duration(p.RequestTimeout)
+	}
+	if p.RetryInterval != 0 {
+		config.RetryInterval = time.Duration(p.RetryInterval)
+	}
+	if p.MaxRetries != nil {
+		config.MaxRetries = *p.MaxRetries
+	}
+	if p.MaxIdleConns != nil {
+		config.MaxIdleConns = *p.MaxIdleConns
+	}
+	if p.MaxConnsPerHost != nil {
+		config.MaxConnsPerHost = *p.MaxConnsPerHost
+	}
+	if p.Discovery != nil {
+		config.Discovery = &DiscoveryConfig{EtcdEndpoints: p.Discovery.EtcdEndpoints, Namespace: p.Discovery.Namespace}
+	}
+	if p.TLS != nil {
+		tlsConfig, err := p.TLS.config()
+		if err != nil {
+			return err
+		}
+		config.TLS = tlsConfig
+	}
+	return nil
+}
+
+// config loads the certificates named by t
+func (t *ProfileTLS) config() (*tls.Config, error) {
+	config := &tls.Config{ServerName: t.ServerName, InsecureSkipVerify: t.InsecureSkipVerify}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", t.CAFile)
+		}
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
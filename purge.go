@@ -0,0 +1,118 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// PurgeOptions controls PurgeTransactions behaviour
+type PurgeOptions struct {
+	DryRun     bool               // report matching transactions without deleting them
+	PageSize   int                // number of transactions fetched per ListTransactions call
+	OnProgress func(*PurgeResult) // invoked after every matched transaction
+	OnDelete   func(gid string)   // invoked for every transaction deleted (or matched in dry-run)
+}
+
+// DefaultPurgeOptions returns default purge options
+func DefaultPurgeOptions() *PurgeOptions {
+	return &PurgeOptions{
+		PageSize: 100,
+	}
+}
+
+// PurgeResult summarizes a purge run
+type PurgeResult struct {
+	Scanned int
+	Matched int
+	Deleted int
+	DryRun  bool
+}
+
+// PurgeTransactions deletes finished transactions whose last update is older
+// than olderThan. When statuses is empty, committed, aborted and timed out
+// transactions are purged. Transactions are deleted one by one via
+// DELETE /api/tx/{gid}. Only this client's TC is purged: in a multi-region
+// setup, transactions are listed and deleted in the local region, and
+// remote regions are purged by their own clients.
+func (c *Client) PurgeTransactions(ctx context.Context, olderThan time.Duration, statuses []string, options *PurgeOptions) (*PurgeResult, error) {
+	if options == nil {
+		options = DefaultPurgeOptions()
+	}
+	pageSize := options.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPurgeOptions().PageSize
+	}
+	if len(statuses) == 0 {
+		statuses = []string{StatusCommitted, StatusAborted, StatusTimeout}
+	}
+
+	cutoff := time.Now().Add(-olderThan).Unix()
+	result := &PurgeResult{DryRun: options.DryRun}
+
+	for _, status := range statuses {
+		offset := 0
+		for {
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			default:
+			}
+
+			page, err := c.ListTransactions(ctx, pageSize, offset, status)
+			if err != nil {
+				return result, fmt.Errorf("failed to purge transactions: %w", err)
+			}
+
+			deleted := 0
+			for _, info := range page {
+				result.Scanned++
+				if info.UpdatedUnix > cutoff {
+					continue
+				}
+				result.Matched++
+
+				if !options.DryRun {
+					if err := c.deleteTransaction(ctx, info.GID); err != nil {
+						return result, err
+					}
+					result.Deleted++
+					deleted++
+				}
+
+				if options.OnDelete != nil {
+					options.OnDelete(info.GID)
+				}
+				if options.OnProgress != nil {
+					options.OnProgress(result)
+				}
+			}
+
+			if len(page) < pageSize {
+				break
+			}
+			// Deleted transactions drop out of the listing, so only skip the ones we kept
+			offset += len(page) - deleted
+		}
+	}
+
+	return result, nil
+}
+
+// deleteTransaction removes a single transaction from this client's TC
+func (c *Client) deleteTransaction(ctx context.Context, gid string) error {
+	resp, err := c.httpClient.R().
+		SetContext(ctx).
+		Delete(fmt.Sprintf("/api/tx/%s", url.PathEscape(gid)))
+
+	if err != nil {
+		return fmt.Errorf("failed to delete transaction %s: %w", gid, err)
+	}
+
+	if resp.StatusCode() != 200 && resp.StatusCode() != 204 {
+		return c.statusError(fmt.Sprintf("failed to delete transaction %s", gid), resp)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Participant-side branch states returned by a step's Query endpoint
+const (
+	BranchStateNotFound  = "NOT_FOUND" // try never reached the participant
+	BranchStateTried     = "TRIED"
+	BranchStateConfirmed = "CONFIRMED"
+	BranchStateCancelled = "CANCELLED"
+)
+
+// QueryBranch asks the participant for the actual state of a step's branch
+// by calling its Query URL with gid and branch_id query parameters. The
+// response is either a plain state string or {"state": "..."}; 404 means
+// BranchStateNotFound.
+func (tm *TCCManager) QueryBranch(ctx context.Context, tx *Transaction, step TCCStep) (string, error) {
+	if step.Query == "" {
+		return "", fmt.Errorf("branch %s has no query endpoint", step.BranchID)
+	}
+	resp, err := tm.client.httpClient.R().
+		SetContext(tx.BranchContext(ctx, step.BranchID)).
+		SetQueryParam("gid", tx.gid).
+		SetQueryParam("branch_id", step.BranchID).
+		Get(step.Query)
+	if err != nil {
+		return "", fmt.Errorf("failed to query branch %s: %w", step.BranchID, err)
+	}
+	if resp.StatusCode() == 404 {
+		return BranchStateNotFound, nil
+	}
+	if resp.StatusCode() != 200 {
+		return "", tm.client.statusError(fmt.Sprintf("failed to query branch %s", step.BranchID), resp)
+	}
+
+	body := strings.TrimSpace(string(resp.Body()))
+	if strings.HasPrefix(body, "{") {
+		var result struct {
+			State string `json:"state"`
+		}
+		if err := decodeJSON(resp.Body(), &result); err != nil {
+			return "", fmt.Errorf("failed to parse query response of branch %s: %w", step.BranchID, err)
+		}
+		body = result.State
+	}
+	state := strings.ToUpper(strings.Trim(body, `"`))
+	switch state {
+	case BranchStateNotFound, BranchStateTried, BranchStateConfirmed, BranchStateCancelled:
+		return state, nil
+	}
+	return "", fmt.Errorf("branch %s query returned unknown state %q", step.BranchID, body)
+}
+
+// reconcileTry checks whether a try whose outcome is unknown actually took
+// effect, so a lost response does not cancel a reserved branch
+func (tm *TCCManager) reconcileTry(ctx context.Context, tx *Transaction, step TCCStep, tryErr error) error {
+	if step.Query == "" || errors.Is(tryErr, ErrBranchFailure) || errors.Is(tryErr, ErrBranchRejected) {
+		return tryErr
+	}
+	state, err := tm.QueryBranch(ctx, tx, step)
+	if err != nil {
+		return tryErr
+	}
+	switch state {
+	case BranchStateTried, BranchStateConfirmed:
+		return nil
+	}
+	return tryErr
+}
+
+// confirmBranch confirms one step holding a branch pool slot
+func (tm *TCCManager) confirmBranch(ctx context.Context, tx *Transaction, step TCCStep) error {
+	return tm.client.withBranchSlot(ctx, step.Confirm, func() error { return tm.confirmOrQuery(ctx, tx, step) })
+}
+
+// confirmOrQuery confirms one step. When the confirm fails and the step has
+// a Query endpoint, the participant state decides: already confirmed counts
+// as success and a branch still tried is confirmed once more.
+func (tm *TCCManager) confirmOrQuery(ctx context.Context, tx *Transaction, step TCCStep) error {
+	err := tx.confirmStep(ctx, step)
+	if err == nil || step.Query == "" {
+		return err
+	}
+	state, qerr := tm.QueryBranch(ctx, tx, step)
+	if qerr != nil {
+		return err
+	}
+	switch state {
+	case BranchStateConfirmed:
+		return nil
+	case BranchStateTried:
+		return tx.confirmStep(ctx, step)
+	}
+	return fmt.Errorf("%w (participant reports %s)", err, state)
+}
@@ -0,0 +1,242 @@
+package seata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Interaction is one recorded HTTP exchange with the TC
+type Interaction struct {
+	Method         string      `json:"method"`
+	Path           string      `json:"path"`
+	RequestBody    string      `json:"request_body,omitempty"`
+	Status         int         `json:"status"`
+	ResponseHeader http.Header `json:"response_header,omitempty"`
+	ResponseBody   string      `json:"response_body,omitempty"`
+}
+
+// Recorder captures every HTTP interaction of a client run. Install it with
+// Config.WrapTransport = recorder.Wrap and call Save when the run is done.
+// Only HTTP traffic is captured, so leave GrpcEndpoint empty while recording.
+// Request headers are not recorded to keep credentials out of the file.
+type Recorder struct {
+	path         string
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecorder creates a recorder that saves to path
+func NewRecorder(path string) *Recorder {
+	return &Recorder{path: path}
+}
+
+// Wrap returns a RoundTripper that records every exchange made through next
+func (r *Recorder) Wrap(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		reqBody, err := readAndRestore(&req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record request: %w", err)
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		respBody, err := readAndRestore(&resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record response: %w", err)
+		}
+
+		r.mu.Lock()
+		r.interactions = append(r.interactions, Interaction{
+			Method:         req.Method,
+			Path:           req.URL.RequestURI(),
+			RequestBody:    string(reqBody),
+			Status:         resp.StatusCode,
+			ResponseHeader: resp.Header.Clone(),
+			ResponseBody:   string(respBody),
+		})
+		r.mu.Unlock()
+		return resp, nil
+	})
+}
+
+// Interactions returns the exchanges recorded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes the recorded interactions to the recorder's file
+func (r *Recorder) Save() error {
+	data, err := json.MarshalIndent(r.Interactions(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recording: %w", err)
+	}
+	if err := os.WriteFile(r.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save recording: %w", err)
+	}
+	return nil
+}
+
+// ReplayTransport serves recorded interactions back without any server.
+// Install it with Config.WrapTransport = replay.Wrap.
+//
+// Each request is answered by the first unused interaction with the same
+// method and path. Because gids are generated per run, the gid found in a
+// request body is mapped to the recorded one, so later paths and responses
+// carrying the recorded gid are rewritten to the live gid.
+type ReplayTransport struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+	gids         map[string]string // recorded gid -> live gid
+}
+
+// LoadReplay reads a recording saved by Recorder
+func LoadReplay(path string) (*ReplayTransport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("failed to parse recording: %w", err)
+	}
+	return NewReplayTransport(interactions), nil
+}
+
+// NewReplayTransport serves the given interactions
+func NewReplayTransport(interactions []Interaction) *ReplayTransport {
+	return &ReplayTransport{
+		interactions: interactions,
+		used:         make([]bool, len(interactions)),
+		gids:         make(map[string]string),
+	}
+}
+
+// Wrap ignores next and returns the replay transport, for use as
+// Config.WrapTransport
+func (rt *ReplayTransport) Wrap(http.RoundTripper) http.RoundTripper {
+	return rt
+}
+
+// Remaining returns how many recorded interactions have not been served
+func (rt *ReplayTransport) Remaining() int {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	n := 0
+	for _, used := range rt.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+// RoundTrip implements http.RoundTripper
+func (rt *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readAndRestore(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+	liveGID := gidFromBody(reqBody)
+	path := req.URL.RequestURI()
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	for i, in := range rt.interactions {
+		if rt.used[i] || in.Method != req.Method || rt.toLive(in.Path) != path {
+			continue
+		}
+		if recorded := gidFromBody([]byte(in.RequestBody)); recorded != "" && liveGID != "" {
+			if !rt.mapGID(recorded, liveGID) {
+				continue
+			}
+		}
+		rt.used[i] = true
+
+		body := rt.toLive(in.ResponseBody)
+		header := in.ResponseHeader.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		header.Del("Content-Length")
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+			StatusCode:    in.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("no recorded interaction for %s %s", req.Method, path)
+}
+
+// mapGID records that recorded stands for live, reporting false when either
+// side is already mapped to a different gid
+func (rt *ReplayTransport) mapGID(recorded, live string) bool {
+	if mapped, ok := rt.gids[recorded]; ok {
+		return mapped == live
+	}
+	for _, mapped := range rt.gids {
+		if mapped == live {
+			return false
+		}
+	}
+	rt.gids[recorded] = live
+	return true
+}
+
+// toLive replaces recorded gids in s with their live counterparts
+func (rt *ReplayTransport) toLive(s string) string {
+	for recorded, live := range rt.gids {
+		s = strings.ReplaceAll(s, recorded, live)
+	}
+	return s
+}
+
+// gidFromBody extracts the "gid" field of a JSON request body
+func gidFromBody(body []byte) string {
+	var req struct {
+		GID string `json:"gid"`
+	}
+	if len(body) == 0 || json.Unmarshal(body, &req) != nil {
+		return ""
+	}
+	return req.GID
+}
+
+// readAndRestore reads a request or response body and replaces it with an
+// equivalent reader
+func readAndRestore(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	_ = (*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,115 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RecoveryConfig configures a RecoveryManager
+type RecoveryConfig struct {
+	// Registry resolves the WorkflowName an execution was persisted under
+	// to its TCC workflow; executions of unregistered workflows are skipped
+	Registry *WorkflowRegistry
+	// Options of the resumed executions; Store is always the manager's
+	Options *ExecutionOptions
+	// Interval between scans; zero means 10s
+	Interval time.Duration
+	// MinAge is how long an execution must have made no progress before it
+	// is resumed, so executions still running elsewhere are left alone; zero
+	// means 1m. It must exceed the longest branch call.
+	MinAge time.Duration
+	// Elector, when set, restricts recovery to the instance holding leadership
+	Elector *LeaderElector
+	// OnError is called for every execution that could not be resumed
+	OnError func(state *WorkflowState, err error)
+}
+
+// RecoveryManager finishes the TCC executions that orchestrators persisted
+// in a WorkflowStore and stopped driving, e.g. after a crash or a rollout
+type RecoveryManager struct {
+	tm     *TCCManager
+	store  WorkflowStore
+	config RecoveryConfig
+}
+
+// NewRecoveryManager creates a manager resuming the executions of store
+// through tm
+func NewRecoveryManager(tm *TCCManager, store WorkflowStore, config *RecoveryConfig) *RecoveryManager {
+	var cfg RecoveryConfig
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.MinAge <= 0 {
+		cfg.MinAge = time.Minute
+	}
+	options := DefaultExecutionOptions()
+	if cfg.Options != nil {
+		copied := *cfg.Options
+		options = &copied
+	}
+	options.Store = store
+	cfg.Options = options
+	return &RecoveryManager{tm: tm, store: store, config: cfg}
+}
+
+// Run resumes stale executions every Interval until ctx is done
+func (m *RecoveryManager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+	for {
+		if m.config.Elector == nil || m.config.Elector.IsLeader() {
+			if _, err := m.RecoverOnce(ctx); err != nil {
+				fmt.Printf("Warning: workflow recovery: %v\n", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RecoverOnce resumes the stale TCC executions of the store once and
+// returns the number it finished. Failed executions count as finished: their
+// branches were cancelled.
+func (m *RecoveryManager) RecoverOnce(ctx context.Context) (int, error) {
+	pending, err := m.store.ListPending(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	stale := time.Now().Add(-m.config.MinAge).Unix()
+	recovered := 0
+	var errs []error
+	for _, state := range pending {
+		// Pending aborts share the store and are retried by the escalator
+		if state.Mode != ModeTCC || strings.HasPrefix(state.ID, abortStatePrefix) || state.UpdatedUnix > stale {
+			continue
+		}
+		if m.config.Registry == nil {
+			continue
+		}
+		workflow, err := m.config.Registry.ExpandTCC(state.Name)
+		if err != nil {
+			continue
+		}
+		_, err = m.tm.ResumeTCC(ctx, state, workflow, m.config.Options)
+		var tccErr *TCCError
+		if err != nil && !(errors.As(err, &tccErr) && tccErr.Result.CleanupComplete()) {
+			errs = append(errs, fmt.Errorf("workflow %s: %w", state.ID, err))
+			if m.config.OnError != nil {
+				m.config.OnError(state, err)
+			}
+			continue
+		}
+		recovered++
+	}
+	return recovered, errors.Join(errs...)
+}
@@ -0,0 +1,89 @@
+package seata
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Redactor rewrites a response body before it is logged or embedded in an error
+type Redactor func(body string) string
+
+// redactedPlaceholder replaces sensitive values
+const redactedPlaceholder = "[REDACTED]"
+
+// NewJSONFieldRedactor returns a Redactor masking the values of the given JSON
+// fields, e.g. NewJSONFieldRedactor("payload", "card_number").
+// Field names are matched case-insensitively at any depth and the whole
+// value is replaced, including objects and arrays. Bodies that are not valid
+// JSON, e.g. truncated ones, only have scalar and flat array values masked.
+func NewJSONFieldRedactor(fields ...string) Redactor {
+	if len(fields) == 0 {
+		return func(body string) string { return body }
+	}
+
+	sensitive := make(map[string]bool, len(fields))
+	quoted := make([]string, len(fields))
+	for i, field := range fields {
+		sensitive[strings.ToLower(field)] = true
+		quoted[i] = regexp.QuoteMeta(field)
+	}
+	pattern := regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"|\[[^\]]*\]|[^,}\s]+)`)
+
+	masked := func(body string) string {
+		return pattern.ReplaceAllString(body, `${1}"`+redactedPlaceholder+`"`)
+	}
+	return func(body string) string {
+		decoder := json.NewDecoder(strings.NewReader(body))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil || decoder.More() {
+			return masked(body)
+		}
+
+		var out strings.Builder
+		encoder := json.NewEncoder(&out)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(redactJSON(value, sensitive)); err != nil {
+			return masked(body)
+		}
+		return strings.TrimSuffix(out.String(), "\n")
+	}
+}
+
+// redactJSON replaces the values of sensitive keys in a decoded JSON value
+func redactJSON(value interface{}, sensitive map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if sensitive[strings.ToLower(key)] {
+				v[key] = redactedPlaceholder
+			} else {
+				v[key] = redactJSON(field, sensitive)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactJSON(v[i], sensitive)
+		}
+	}
+	return value
+}
+
+// RedactBody applies the configured Redactor to a response body.
+// Use it when logging bodies returned by the TC or by branch services.
+func (c *Client) RedactBody(body string) string {
+	if c.config.Redactor != nil {
+		return c.config.Redactor(body)
+	}
+	return body
+}
+
+// statusError builds the *RequestError returned for a non-200 HTTP
+// response. The body is redacted, or omitted entirely when OmitBodyInErrors
+// is set.
+func (c *Client) statusError(msg string, resp *resty.Response) error {
+	return c.newRequestError(msg, resp)
+}
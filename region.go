@@ -0,0 +1,155 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// regionRetryAfter is how long a region that failed to start a transaction
+// is tried last
+const regionRetryAfter = 30 * time.Second
+
+// RegionConfig is one TC cluster of a multi-region setup
+type RegionConfig struct {
+	// Name tags the gids of the region's transactions; it cannot contain '-'
+	Name         string
+	HTTPEndpoint string
+	GrpcEndpoint string
+	// Discovery finds the region's TC nodes in place of the endpoints
+	Discovery *DiscoveryConfig
+}
+
+// regionRouter sends new transactions to the local region, failing over to
+// the remote ones, and gid-based calls to the region that started the gid
+type regionRouter struct {
+	local   *Client
+	remotes []*Client // in Config.Regions order
+
+	mu        sync.Mutex
+	downUntil map[string]time.Time
+}
+
+// applyLocalRegion replaces the top-level endpoints with the local region's
+func (c *Config) applyLocalRegion() {
+	for _, r := range c.Regions {
+		if r.Name == c.LocalRegion {
+			c.HTTPEndpoint, c.GrpcEndpoint, c.Discovery = r.HTTPEndpoint, r.GrpcEndpoint, r.Discovery
+			return
+		}
+	}
+}
+
+// newRegionRouter starts a client for every remote region of local's config
+func newRegionRouter(local *Client) *regionRouter {
+	rr := &regionRouter{local: local, downUntil: make(map[string]time.Time)}
+	for _, r := range local.config.Regions {
+		if r.Name == local.config.LocalRegion {
+			continue
+		}
+		config := *local.config
+		config.Regions, config.LocalRegion = nil, ""
+		config.HTTPEndpoint, config.GrpcEndpoint, config.Discovery = r.HTTPEndpoint, r.GrpcEndpoint, r.Discovery
+		// Registration, metrics push and the background components stay
+		// with the local client; remote clients share the local ones. Read
+		// replicas, hedging and latency histograms belong to the local
+		// region and are not carried over
+		config.Registration, config.MetricsPush = nil, nil
+		config.ReadEndpoints, config.ReadDiscovery = nil, nil
+		config.Hedging, config.LatencyHistograms = nil, nil
+		config.Watchdog, config.AbortEscalation, config.Events, config.NATS, config.BranchPool = nil, nil, nil, nil, nil
+		remote := NewClient(&config)
+		remote.region = r.Name
+		remote.watchdog, remote.abortEscalator, remote.events = local.watchdog, local.abortEscalator, local.events
+		remote.nats, remote.branchPool = local.nats, local.branchPool
+		rr.remotes = append(rr.remotes, remote)
+	}
+	return rr
+}
+
+// startTransaction starts a transaction in the first reachable region,
+// trying regions that recently failed last
+func (rr *regionRouter) startTransaction(ctx context.Context, mode string, payload []byte) (*Transaction, error) {
+	now := time.Now()
+	var healthy, down []*Client
+	rr.mu.Lock()
+	for _, c := range append([]*Client{rr.local}, rr.remotes...) {
+		if rr.downUntil[c.region].After(now) {
+			down = append(down, c)
+		} else {
+			healthy = append(healthy, c)
+		}
+	}
+	rr.mu.Unlock()
+
+	var lastErr error
+	for _, c := range append(healthy, down...) {
+		tx, err := c.startTransaction(ctx, mode, payload)
+		if err == nil {
+			rr.mu.Lock()
+			delete(rr.downUntil, c.region)
+			rr.mu.Unlock()
+			return tx, nil
+		}
+		if ctx.Err() != nil || !regionUnavailable(err) {
+			return nil, err
+		}
+		rr.mu.Lock()
+		rr.downUntil[c.region] = time.Now().Add(regionRetryAfter)
+		rr.mu.Unlock()
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// forGID returns the client of the region that started gid, or nil
+func (rr *regionRouter) forGID(gid string) *Client {
+	name, _, ok := strings.Cut(strings.TrimPrefix(gid, rr.local.config.GIDPrefix), "-")
+	if !ok {
+		return nil
+	}
+	for _, c := range rr.remotes {
+		if c.region == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// close closes the remote clients, leaving the components they share with
+// the local client to it
+func (rr *regionRouter) close() {
+	for _, c := range rr.remotes {
+		c.watchdog, c.abortEscalator, c.events, c.nats = nil, nil, nil, nil
+		c.Close()
+	}
+}
+
+// regionUnavailable reports whether err means the region's TC could not be
+// reached, as opposed to rejecting the request. Connections that broke on
+// a read or write count too; a start they interrupted leaves at most an
+// empty transaction the TC times out.
+func regionUnavailable(err error) bool {
+	var opErr *net.OpError
+	var netErr net.Error
+	return transportUnavailable(err) || errors.As(err, &opErr) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// ForGID returns the client of the region that started gid. Without
+// Config.Regions, and for gids of the local region, it returns c.
+func (c *Client) ForGID(gid string) *Client {
+	if c.regions != nil {
+		if remote := c.regions.forGID(gid); remote != nil {
+			return remote
+		}
+	}
+	return c
+}
+
+// Region returns the region of the client, or "" without Config.Regions
+func (c *Client) Region() string {
+	return c.region
+}
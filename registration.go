@@ -0,0 +1,205 @@
+package seata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Client capabilities advertised through registration
+const (
+	CapabilityBranchCommit   = "branch_commit"
+	CapabilityBranchRollback = "branch_rollback"
+)
+
+// RegistrationConfig describes how the client registers itself in etcd so
+// that a TC pushing branch commands can discover it
+type RegistrationConfig struct {
+	// Prefix under which instances are registered; defaults to "<namespace>/clients/"
+	Prefix string
+	// InstanceID uniquely identifies this client; defaults to hostname plus a random suffix
+	InstanceID string
+	// Address the TC should call back, e.g. "http://10.0.0.5:8081"
+	Address string
+	// Capabilities advertised to the TC
+	Capabilities []string
+	// Metadata is published verbatim alongside the registration
+	Metadata map[string]string
+	// TTL of the heartbeat lease; the registration disappears this long after the client dies
+	TTL time.Duration
+}
+
+// ClientRegistration is the value stored under the registration key
+type ClientRegistration struct {
+	InstanceID     string            `json:"instance_id"`
+	Address        string            `json:"address"`
+	Capabilities   []string          `json:"capabilities"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	RegisteredUnix int64             `json:"registered_unix"`
+}
+
+// EtcdRegistrar keeps a client registration alive in etcd using a lease
+type EtcdRegistrar struct {
+	discovery *DiscoveryConfig
+	config    *RegistrationConfig
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+
+	// newClient opens the etcd client; replaced in tests
+	newClient func() (*clientv3.Client, error)
+}
+
+// NewEtcdRegistrar creates a registrar using the etcd connection settings of discovery
+func NewEtcdRegistrar(discovery *DiscoveryConfig, config *RegistrationConfig) (*EtcdRegistrar, error) {
+	if discovery == nil || len(discovery.EtcdEndpoints) == 0 {
+		return nil, fmt.Errorf("registration requires etcd endpoints")
+	}
+	if config == nil || config.Address == "" {
+		return nil, fmt.Errorf("registration requires a callback address")
+	}
+
+	reg := *config
+	if reg.Prefix == "" {
+		namespace := discovery.Namespace
+		if namespace == "" {
+			namespace = "/seata"
+		}
+		reg.Prefix = namespace + "/clients/"
+	}
+	if reg.InstanceID == "" {
+		host, _ := os.Hostname()
+		reg.InstanceID = fmt.Sprintf("%s-%s", host, uuid.New().String()[:8])
+	}
+	if reg.TTL <= 0 {
+		reg.TTL = 10 * time.Second
+	}
+	if reg.TTL < time.Second {
+		// etcd lease TTLs have second granularity
+		reg.TTL = time.Second
+	}
+
+	r := &EtcdRegistrar{
+		discovery: discovery,
+		config:    &reg,
+		stopCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+	}
+	r.newClient = func() (*clientv3.Client, error) {
+		return clientv3.New(discovery.etcdConfig())
+	}
+	return r, nil
+}
+
+// Key returns the etcd key holding this client's registration
+func (r *EtcdRegistrar) Key() string {
+	return r.config.Prefix + r.config.InstanceID
+}
+
+// InstanceID returns the registered instance id
+func (r *EtcdRegistrar) InstanceID() string {
+	return r.config.InstanceID
+}
+
+// Run registers the client and keeps the lease alive until Stop is called or
+// ctx is done. Lost leases are re-created after a short delay.
+func (r *EtcdRegistrar) Run(ctx context.Context) {
+	defer close(r.doneCh)
+
+	// Cancel in-flight etcd calls as soon as Stop is called
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-r.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	cli, err := r.newClient()
+	if err != nil {
+		return
+	}
+	defer cli.Close()
+
+	for {
+		leaseID, keepAlive, err := r.register(ctx, cli)
+		if err == nil {
+			if !r.keepAlive(ctx, keepAlive) {
+				// Stopped: remove the registration right away instead of waiting for the TTL
+				revokeCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+				_, _ = cli.Revoke(revokeCtx, leaseID)
+				cancel()
+				return
+			}
+		}
+
+		select {
+		case <-r.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-time.After(r.config.TTL / 3):
+		}
+	}
+}
+
+// Stop deregisters the client and waits for Run to return.
+// It must only be called after Run has been started.
+func (r *EtcdRegistrar) Stop() {
+	close(r.stopCh)
+	<-r.doneCh
+}
+
+// register grants a lease and writes the registration under it
+func (r *EtcdRegistrar) register(ctx context.Context, cli *clientv3.Client) (clientv3.LeaseID, <-chan *clientv3.LeaseKeepAliveResponse, error) {
+	value, err := json.Marshal(&ClientRegistration{
+		InstanceID:     r.config.InstanceID,
+		Address:        r.config.Address,
+		Capabilities:   r.config.Capabilities,
+		Metadata:       r.config.Metadata,
+		RegisteredUnix: time.Now().Unix(),
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	opCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	lease, err := cli.Grant(opCtx, int64(r.config.TTL/time.Second))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to grant registration lease: %w", err)
+	}
+	if _, err := cli.Put(opCtx, r.Key(), string(value), clientv3.WithLease(lease.ID)); err != nil {
+		return 0, nil, fmt.Errorf("failed to write registration: %w", err)
+	}
+
+	keepAlive, err := cli.KeepAlive(ctx, lease.ID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to keep registration alive: %w", err)
+	}
+	return lease.ID, keepAlive, nil
+}
+
+// keepAlive drains heartbeat responses; it returns true if the lease was lost
+// and false if the registrar was stopped
+func (r *EtcdRegistrar) keepAlive(ctx context.Context, keepAlive <-chan *clientv3.LeaseKeepAliveResponse) bool {
+	for {
+		select {
+		case <-r.stopCh:
+			return false
+		case <-ctx.Done():
+			return false
+		case resp, ok := <-keepAlive:
+			if !ok || resp == nil {
+				return true
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package seata
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// readReplicas spreads reads over the configured or discovered replicas
+type readReplicas struct {
+	mu    sync.RWMutex
+	addrs []string
+	next  atomic.Uint32
+
+	discovery *EtcdDiscovery
+}
+
+// newReadReplicas returns the replicas of config, or nil when none are
+// configured
+func newReadReplicas(config *Config) *readReplicas {
+	d := config.ReadDiscovery
+	if len(config.ReadEndpoints) == 0 && (d == nil || len(d.EtcdEndpoints) == 0) {
+		return nil
+	}
+	r := &readReplicas{}
+	for _, endpoint := range config.ReadEndpoints {
+		if normalized, err := NormalizeHTTPEndpoint(endpoint, config.TLS != nil); err == nil {
+			r.addrs = append(r.addrs, normalized)
+		}
+	}
+	if d != nil && len(d.EtcdEndpoints) > 0 {
+		static := r.addrs
+		r.discovery = NewEtcdDiscoveryWithConfig(d, func(httpAddrs []string, _ []string) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.addrs = append(append([]string(nil), static...), httpAddrs...)
+		})
+		go r.discovery.Run(context.Background())
+	}
+	return r
+}
+
+// pick returns the next replica in round-robin order, or ""
+func (r *readReplicas) pick() string {
+	if r == nil {
+		return ""
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.addrs) == 0 {
+		return ""
+	}
+	return r.addrs[int(r.next.Add(1)-1)%len(r.addrs)]
+}
+
+// contains reports whether rawURL addresses one of the replicas
+func (r *readReplicas) contains(rawURL string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, addr := range r.addrs {
+		if strings.HasPrefix(rawURL, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *readReplicas) close() {
+	if r != nil && r.discovery != nil {
+		r.discovery.Stop()
+	}
+}
+
+// readGet sends an idempotent GET to a read replica when configured. A
+// replica that is unreachable, fails or has not replicated the resource
+// yet (404) is bypassed for the primary TC. Replicas are HTTP only, so
+// they are skipped in gRPC-only mode.
+func (c *Client) readGet(ctx context.Context, path string, query url.Values) (*resty.Response, error) {
+	if c.config.Transport == TransportGRPCOnly {
+		return c.hedgedGet(ctx, path, query)
+	}
+	if replica := c.replicas.pick(); replica != "" {
+		resp, err := c.httpClient.R().SetContext(ctx).SetQueryParamsFromValues(query).Get(joinURL(replica, path))
+		if err == nil && resp.StatusCode() < 500 && resp.StatusCode() != 404 {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return resp, err
+		}
+	}
+	return c.hedgedGet(ctx, path, query)
+}
@@ -0,0 +1,112 @@
+package seata
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// defaultMaxErrorBody is the number of body bytes kept in error strings
+// when Config.MaxErrorBody is zero
+const defaultMaxErrorBody = 512
+
+// RequestError is returned for an unexpected HTTP response from the TC or a
+// participant. Its message carries a short, printable excerpt of the body;
+// Body returns it in full.
+type RequestError struct {
+	Op         string // what failed, e.g. "failed to submit transaction"
+	StatusCode int
+	Method     string
+	URL        string
+	Elapsed    time.Duration
+
+	body    []byte // redacted; nil when Config.OmitBodyInErrors is set
+	excerpt string
+}
+
+func (e *RequestError) Error() string {
+	if e.body == nil {
+		return fmt.Sprintf("%s: status %d", e.Op, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: status %d, body: %s", e.Op, e.StatusCode, e.excerpt)
+}
+
+// Body returns the full redacted response body, or nil when bodies are
+// omitted from errors
+func (e *RequestError) Body() []byte {
+	return e.body
+}
+
+// newRequestError builds the error returned for an unexpected response
+func (c *Client) newRequestError(op string, resp *resty.Response) *RequestError {
+	e := &RequestError{
+		Op:         op,
+		StatusCode: resp.StatusCode(),
+		Elapsed:    resp.Time(),
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL
+		if resp.Request.RawRequest != nil {
+			e.URL = resp.Request.RawRequest.URL.String()
+		}
+	}
+	c.setErrorBody(e, []byte(resp.String()))
+	return e
+}
+
+// setErrorBody attaches the redacted body and its excerpt to e unless
+// bodies are omitted from errors
+func (c *Client) setErrorBody(e *RequestError, body []byte) {
+	if c.config.OmitBodyInErrors {
+		return
+	}
+	e.body = []byte(c.RedactBody(string(body)))
+	limit := c.config.MaxErrorBody
+	if limit <= 0 {
+		limit = defaultMaxErrorBody
+	}
+	e.excerpt = excerptBody(e.body, limit)
+}
+
+// excerptBody makes body printable on one line, replacing invalid UTF-8 and
+// control characters and collapsing whitespace, and cuts it to at most
+// limit bytes on a rune boundary
+func excerptBody(body []byte, limit int) string {
+	var b strings.Builder
+	space := false
+	rest := body
+	for len(rest) > 0 {
+		r, size := utf8.DecodeRune(rest)
+		if r == utf8.RuneError && size <= 1 {
+			r = unicode.ReplacementChar
+		}
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			space = true
+			rest = rest[size:]
+			continue
+		}
+		n := utf8.RuneLen(r)
+		if space && b.Len() > 0 {
+			n++
+		}
+		if b.Len()+n > limit {
+			break
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+		rest = rest[size:]
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		return fmt.Sprintf("%s… (%d more bytes)", b.String(), len(rest))
+	}
+	return b.String()
+}
@@ -0,0 +1,222 @@
+package seata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrBranchRejected is wrapped by errors returned when a branch response
+// fails validation, e.g. a 200 response carrying {"ok":false}
+var ErrBranchRejected = errors.New("branch response rejected")
+
+// ResponseValidator decides whether a branch response is a success.
+// Returning an error marks the branch as failed.
+type ResponseValidator func(statusCode int, body []byte) error
+
+// BodyMatcher checks a response body; it returns an error describing the mismatch
+type BodyMatcher func(body []byte) error
+
+// StatusRange is an inclusive range of HTTP status codes
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// SuccessCriteria describes when a branch response counts as a success
+type SuccessCriteria struct {
+	// StatusRanges accepted; empty means 200-299
+	StatusRanges []StatusRange
+	// BodyMatchers that must all pass
+	BodyMatchers []BodyMatcher
+}
+
+// Validator returns a ResponseValidator enforcing the criteria
+func (sc *SuccessCriteria) Validator() ResponseValidator {
+	ranges := sc.StatusRanges
+	if len(ranges) == 0 {
+		ranges = []StatusRange{{Min: 200, Max: 299}}
+	}
+	matchers := append([]BodyMatcher(nil), sc.BodyMatchers...)
+
+	return func(statusCode int, body []byte) error {
+		accepted := false
+		for _, r := range ranges {
+			if statusCode >= r.Min && statusCode <= r.Max {
+				accepted = true
+				break
+			}
+		}
+		if !accepted {
+			return fmt.Errorf("%w: unexpected status %d", ErrBranchRejected, statusCode)
+		}
+		for _, match := range matchers {
+			if err := match(body); err != nil {
+				return fmt.Errorf("%w: %v", ErrBranchRejected, err)
+			}
+		}
+		return nil
+	}
+}
+
+// JSONFieldEquals matches bodies whose top-level field equals want,
+// e.g. JSONFieldEquals("ok", true)
+func JSONFieldEquals(field string, want interface{}) BodyMatcher {
+	return func(body []byte) error {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return fmt.Errorf("body is not a JSON object: %v", err)
+		}
+		got, ok := doc[field]
+		if !ok {
+			return fmt.Errorf("field %q is missing", field)
+		}
+		if !jsonValuesEqual(got, want) {
+			return fmt.Errorf("field %q is %v, want %v", field, got, want)
+		}
+		return nil
+	}
+}
+
+// BodyContains matches bodies containing substr
+func BodyContains(substr string) BodyMatcher {
+	return func(body []byte) error {
+		if !bytes.Contains(body, []byte(substr)) {
+			return fmt.Errorf("body does not contain %q", substr)
+		}
+		return nil
+	}
+}
+
+// NewJSONSchemaValidator returns a validator accepting 2xx responses whose
+// body matches schema. A practical subset of JSON Schema is supported:
+// type, properties, required, items, enum, const, minimum and maximum.
+func NewJSONSchemaValidator(schema []byte) (ResponseValidator, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON schema: %w", err)
+	}
+
+	return func(statusCode int, body []byte) error {
+		if statusCode < 200 || statusCode > 299 {
+			return fmt.Errorf("%w: unexpected status %d", ErrBranchRejected, statusCode)
+		}
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return fmt.Errorf("%w: body is not valid JSON: %v", ErrBranchRejected, err)
+		}
+		if err := validateJSONSchema(root, doc, "$"); err != nil {
+			return fmt.Errorf("%w: %v", ErrBranchRejected, err)
+		}
+		return nil
+	}, nil
+}
+
+// validateJSONSchema checks value against schema; path locates value in errors
+func validateJSONSchema(schema map[string]interface{}, value interface{}, path string) error {
+	if t, ok := schema["type"].(string); ok && !jsonTypeMatches(t, value) {
+		return fmt.Errorf("%s: expected %s", path, t)
+	}
+	if want, ok := schema["const"]; ok && !jsonValuesEqual(value, want) {
+		return fmt.Errorf("%s: expected %v", path, want)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, want := range enum {
+			if jsonValuesEqual(value, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+		}
+	}
+	if n, ok := value.(float64); ok {
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			return fmt.Errorf("%s: %v is less than %v", path, n, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && n > max {
+			return fmt.Errorf("%s: %v is greater than %v", path, n, max)
+		}
+	}
+
+	if obj, ok := value.(map[string]interface{}); ok {
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if key, _ := name.(string); key != "" {
+					if _, present := obj[key]; !present {
+						return fmt.Errorf("%s: missing required field %q", path, key)
+					}
+				}
+			}
+		}
+		if props, ok := schema["properties"].(map[string]interface{}); ok {
+			for key, sub := range props {
+				subSchema, ok := sub.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if v, present := obj[key]; present {
+					if err := validateJSONSchema(subSchema, v, path+"."+key); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	if arr, ok := value.([]interface{}); ok {
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, v := range arr {
+				if err := validateJSONSchema(items, v, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// jsonTypeMatches reports whether a decoded JSON value has the schema type t
+func jsonTypeMatches(t string, value interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == float64(int64(n))
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+// jsonValuesEqual compares a decoded JSON value with a Go value, treating
+// all numeric types as float64
+func jsonValuesEqual(got, want interface{}) bool {
+	normalized, err := json.Marshal(want)
+	if err != nil {
+		return false
+	}
+	var w interface{}
+	if err := json.Unmarshal(normalized, &w); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(got, w)
+}
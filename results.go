@@ -0,0 +1,100 @@
+package seata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Branch results following the dtm convention. A participant signals a
+// result either through the status code (409 failure, 425 ongoing) or
+// through a body of "FAILURE"/"ONGOING", plain or as {"dtm_result": ...}.
+const (
+	ResultSuccess = "SUCCESS"
+	ResultFailure = "FAILURE"
+	ResultOngoing = "ONGOING"
+)
+
+var (
+	// ErrBranchFailure means the participant refused the branch; it is not retried
+	ErrBranchFailure = errors.New("branch returned FAILURE")
+	// ErrBranchOngoing means the participant has not finished; retry later
+	ErrBranchOngoing = errors.New("branch returned ONGOING")
+)
+
+// OngoingError is returned for an ONGOING result. When the participant
+// completes asynchronously it includes a poll URL reporting the final
+// result, e.g. {"dtm_result":"ONGOING","poll_url":"http://svc/status/42"}.
+type OngoingError struct {
+	PollURL string
+}
+
+func (e *OngoingError) Error() string {
+	if e.PollURL == "" {
+		return ErrBranchOngoing.Error()
+	}
+	return fmt.Sprintf("%s, poll %s", ErrBranchOngoing.Error(), e.PollURL)
+}
+
+// Is makes errors.Is(err, ErrBranchOngoing) match
+func (e *OngoingError) Is(target error) bool {
+	return target == ErrBranchOngoing
+}
+
+// ParseBranchResult classifies a participant response as ResultSuccess,
+// ResultFailure or ResultOngoing. Other non-2xx responses yield "".
+func ParseBranchResult(statusCode int, body []byte) string {
+	switch statusCode {
+	case http.StatusConflict:
+		return ResultFailure
+	case http.StatusTooEarly:
+		return ResultOngoing
+	}
+
+	result := string(bytes.TrimSpace(body))
+	var doc struct {
+		Result string `json:"dtm_result"`
+	}
+	if json.Unmarshal(body, &doc) == nil && doc.Result != "" {
+		result = doc.Result
+	}
+	switch result {
+	case ResultFailure, ResultOngoing:
+		return result
+	}
+
+	if statusCode >= 200 && statusCode <= 299 {
+		return ResultSuccess
+	}
+	return ""
+}
+
+// DTMResultValidator returns a validator mapping FAILURE to ErrBranchFailure
+// and ONGOING to ErrBranchOngoing. Other non-2xx responses are returned as
+// plain errors and retried as usual.
+func DTMResultValidator() ResponseValidator {
+	return func(statusCode int, body []byte) error {
+		switch ParseBranchResult(statusCode, body) {
+		case ResultSuccess:
+			return nil
+		case ResultFailure:
+			return ErrBranchFailure
+		case ResultOngoing:
+			var doc struct {
+				PollURL string `json:"poll_url"`
+			}
+			_ = json.Unmarshal(body, &doc)
+			return &OngoingError{PollURL: doc.PollURL}
+		}
+		return fmt.Errorf("unexpected status %d", statusCode)
+	}
+}
+
+// Bounds of the backoff between polls of an ONGOING branch
+const (
+	minPollInterval = 100 * time.Millisecond
+	maxPollInterval = 10 * time.Second
+)
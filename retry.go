@@ -2,8 +2,11 @@ package seata
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"math/rand/v2"
+	"sync"
 	"time"
 )
 
@@ -25,6 +28,7 @@ func NewRetryManager(config *RetryConfig) *RetryManager {
 // ExecuteWithRetry executes a function with retry logic
 func (rm *RetryManager) ExecuteWithRetry(ctx context.Context, operation func() error) error {
 	var lastErr error
+	var delay time.Duration
 
 	for attempt := 0; attempt <= rm.config.MaxRetries; attempt++ {
 		// Check context cancellation
@@ -48,7 +52,7 @@ func (rm *RetryManager) ExecuteWithRetry(ctx context.Context, operation func() e
 		}
 
 		// Calculate backoff delay
-		delay := rm.calculateBackoff(attempt)
+		delay = rm.calculateBackoff(attempt, delay)
 
 		// Wait with context cancellation support
 		select {
@@ -65,6 +69,7 @@ func (rm *RetryManager) ExecuteWithRetry(ctx context.Context, operation func() e
 // ExecuteWithRetryAndValidation executes a function with retry logic and validation
 func (rm *RetryManager) ExecuteWithRetryAndValidation(ctx context.Context, operation func() error, validator func() error) error {
 	var lastErr error
+	var delay time.Duration
 
 	for attempt := 0; attempt <= rm.config.MaxRetries; attempt++ {
 		// Check context cancellation
@@ -85,7 +90,7 @@ func (rm *RetryManager) ExecuteWithRetryAndValidation(ctx context.Context, opera
 			}
 
 			// Calculate backoff delay
-			delay := rm.calculateBackoff(attempt)
+			delay = rm.calculateBackoff(attempt, delay)
 
 			// Wait with context cancellation support
 			select {
@@ -108,7 +113,7 @@ func (rm *RetryManager) ExecuteWithRetryAndValidation(ctx context.Context, opera
 				}
 
 				// Calculate backoff delay
-				delay := rm.calculateBackoff(attempt)
+				delay = rm.calculateBackoff(attempt, delay)
 
 				// Wait with context cancellation support
 				select {
@@ -128,16 +133,18 @@ func (rm *RetryManager) ExecuteWithRetryAndValidation(ctx context.Context, opera
 	return fmt.Errorf("operation failed after %d retries: %w", rm.config.MaxRetries, lastErr)
 }
 
-// calculateBackoff calculates the backoff delay for the given attempt
-func (rm *RetryManager) calculateBackoff(attempt int) time.Duration {
-	// Exponential backoff with jitter
-	baseDelay := float64(rm.config.RetryInterval)
-	exponentialDelay := baseDelay * math.Pow(rm.config.BackoffFactor, float64(attempt))
+// calculateBackoff returns the delay before retry attempt+1 given the
+// previous delay, using the configured strategy or, by default, exponential
+// backoff with +/-10% jitter
+func (rm *RetryManager) calculateBackoff(attempt int, prev time.Duration) time.Duration {
+	if rm.config.Backoff != nil {
+		return rm.config.Backoff.Next(attempt, prev)
+	}
+	exponentialDelay := float64(rm.config.RetryInterval) * math.Pow(rm.config.BackoffFactor, float64(attempt))
 
 	// Add jitter to prevent thundering herd
-	jitter := time.Duration(float64(exponentialDelay) * 0.1 * (0.5 - math.Mod(float64(time.Now().UnixNano()), 1.0)))
-
-	return time.Duration(exponentialDelay) + jitter
+	jitter := exponentialDelay * 0.2 * (rand.Float64() - 0.5)
+	return time.Duration(math.Max(0, exponentialDelay+jitter))
 }
 
 // RetryableError represents an error that can be retried
@@ -191,12 +198,21 @@ func CreateRetryableOperation(operation func() error, validator func() error, is
 	}
 }
 
-// CircuitBreaker provides circuit breaker functionality
+// ErrCircuitOpen is returned while a circuit breaker rejects calls
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker provides circuit breaker functionality. It opens after
+// FailureThreshold consecutive failures; after RecoveryTimeout it admits up
+// to HalfOpenMaxCalls trial calls, closing on the first success and opening
+// again on a failure. It is safe for concurrent use.
 type CircuitBreaker struct {
 	config          *CircuitBreakerConfig
+	mu              sync.Mutex
 	failureCount    int
 	lastFailureTime time.Time
 	state           CircuitBreakerState
+	halfOpenSince   time.Time
+	halfOpenCalls   int
 }
 
 // CircuitBreakerState represents the state of the circuit breaker
@@ -208,6 +224,19 @@ const (
 	CircuitBreakerHalfOpen
 )
 
+// String returns the state name
+func (s CircuitBreakerState) String() string {
+	switch s {
+	case CircuitBreakerClosed:
+		return "closed"
+	case CircuitBreakerOpen:
+		return "open"
+	case CircuitBreakerHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitBreakerState(%d)", int(s))
+}
+
 // NewCircuitBreaker creates a new circuit breaker
 func NewCircuitBreaker(config *CircuitBreakerConfig) *CircuitBreaker {
 	if config == nil {
@@ -221,26 +250,57 @@ func NewCircuitBreaker(config *CircuitBreakerConfig) *CircuitBreaker {
 
 // Execute executes an operation through the circuit breaker
 func (cb *CircuitBreaker) Execute(operation func() error) error {
-	// Check circuit breaker state
+	if err := cb.Allow(); err != nil {
+		return err
+	}
+	err := operation()
+	cb.Record(err)
+	return err
+}
+
+// Allow reports whether a call may proceed, returning ErrCircuitOpen when
+// it may not. A nil breaker allows every call.
+func (cb *CircuitBreaker) Allow() error {
+	if cb == nil {
+		return nil
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
 	if cb.state == CircuitBreakerOpen {
-		if time.Since(cb.lastFailureTime) > cb.config.RecoveryTimeout {
-			cb.state = CircuitBreakerHalfOpen
-		} else {
-			return fmt.Errorf("circuit breaker is open")
+		if now.Sub(cb.lastFailureTime) <= cb.config.RecoveryTimeout {
+			return ErrCircuitOpen
 		}
+		cb.state = CircuitBreakerHalfOpen
+		cb.halfOpenSince, cb.halfOpenCalls = now, 0
 	}
+	if cb.state == CircuitBreakerHalfOpen {
+		// Trial calls that never reported back free their slots after
+		// another recovery period
+		if now.Sub(cb.halfOpenSince) > cb.config.RecoveryTimeout {
+			cb.halfOpenSince, cb.halfOpenCalls = now, 0
+		}
+		if cb.config.HalfOpenMaxCalls > 0 && cb.halfOpenCalls >= cb.config.HalfOpenMaxCalls {
+			return ErrCircuitOpen
+		}
+		cb.halfOpenCalls++
+	}
+	return nil
+}
 
-	// Execute operation
-	err := operation()
-
+// Record reports the outcome of a call; a nil err is a success
+func (cb *CircuitBreaker) Record(err error) {
+	if cb == nil {
+		return
+	}
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
 	if err != nil {
 		cb.recordFailure()
-		return err
+	} else {
+		cb.recordSuccess()
 	}
-
-	// Operation succeeded
-	cb.recordSuccess()
-	return nil
 }
 
 // recordFailure records a failure and updates circuit breaker state
@@ -261,11 +321,15 @@ func (cb *CircuitBreaker) recordSuccess() {
 
 // GetState returns the current circuit breaker state
 func (cb *CircuitBreaker) GetState() CircuitBreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
 	return cb.state
 }
 
 // Reset resets the circuit breaker to closed state
 func (cb *CircuitBreaker) Reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
 	cb.failureCount = 0
 	cb.state = CircuitBreakerClosed
 }
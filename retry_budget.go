@@ -0,0 +1,85 @@
+package seata
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted is returned when a workflow used up its retry budget
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudget caps retries across all steps of one workflow execution, so
+// per-step retries cannot multiply into minute-long workflows. Waits while
+// polling an ONGOING branch count as retries too. Zero fields mean no limit.
+type RetryBudget struct {
+	MaxRetries int           // total retries across all steps
+	MaxDelay   time.Duration // total time spent waiting between retries
+}
+
+// ExecutionResult reports how a workflow execution went
+type ExecutionResult struct {
+	GID string
+	// Retries and RetryDelay are the retry budget consumed by all steps
+	Retries    int
+	RetryDelay time.Duration
+	// BudgetExhausted is set when a step stopped retrying because the
+	// budget ran out
+	BudgetExhausted bool
+	// TCC has the per-branch outcomes of a failed TCC execution
+	TCC *TCCResult
+	// Saga has the per-step outcomes of a saga run by ExecuteSagaWithResult
+	Saga []SagaStepResult
+	// Timings has the duration of every phase, in start order
+	Timings []PhaseTiming
+	// Skipped lists the steps whose Condition was false
+	Skipped []string
+}
+
+// retryBudget tracks retry consumption of one execution
+type retryBudget struct {
+	limit RetryBudget
+
+	mu        sync.Mutex
+	retries   int
+	delay     time.Duration
+	exhausted bool
+}
+
+func newRetryBudget(limit *RetryBudget) *retryBudget {
+	b := &retryBudget{}
+	if limit != nil {
+		b.limit = *limit
+	}
+	return b
+}
+
+// take reserves one retry waiting delay, reporting false when that would
+// exceed the budget. A nil budget allows everything.
+func (b *retryBudget) take(delay time.Duration) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if (b.limit.MaxRetries > 0 && b.retries >= b.limit.MaxRetries) ||
+		(b.limit.MaxDelay > 0 && b.delay+delay > b.limit.MaxDelay) {
+		b.exhausted = true
+		return false
+	}
+	b.retries++
+	b.delay += delay
+	return true
+}
+
+// fill copies the consumption into result
+func (b *retryBudget) fill(result *ExecutionResult) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	result.Retries = b.retries
+	result.RetryDelay = b.delay
+	result.BudgetExhausted = b.exhausted
+}
@@ -0,0 +1,235 @@
+package seata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Branch commands pushed by the TC
+const (
+	BranchCommandCommit   = "commit"
+	BranchCommandRollback = "rollback"
+)
+
+// Branch command results reported back to the TC
+const (
+	BranchResultSuccess = "SUCCESS"
+	BranchResultFailure = "FAILURE"
+)
+
+// RM callback paths served by ResourceManager
+const (
+	rmCommitPath   = "/rm/branch/commit"
+	rmRollbackPath = "/rm/branch/rollback"
+)
+
+// BranchCommand is a second-phase instruction sent by the TC to a resource manager
+type BranchCommand struct {
+	GID             string `json:"gid"`
+	BranchID        string `json:"branch_id"`
+	ResourceID      string `json:"resource_id"`
+	Command         string `json:"command"`
+	ApplicationData []byte `json:"application_data,omitempty"`
+}
+
+// BranchCommandResult is returned to the TC for every command
+type BranchCommandResult struct {
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BranchHandler completes branches of one resource
+type BranchHandler interface {
+	Commit(ctx context.Context, cmd *BranchCommand) error
+	Rollback(ctx context.Context, cmd *BranchCommand) error
+}
+
+// BranchHandlerFuncs adapts plain functions to BranchHandler
+type BranchHandlerFuncs struct {
+	CommitFunc   func(ctx context.Context, cmd *BranchCommand) error
+	RollbackFunc func(ctx context.Context, cmd *BranchCommand) error
+}
+
+// Commit calls CommitFunc; a nil CommitFunc succeeds
+func (f BranchHandlerFuncs) Commit(ctx context.Context, cmd *BranchCommand) error {
+	if f.CommitFunc == nil {
+		return nil
+	}
+	return f.CommitFunc(ctx, cmd)
+}
+
+// Rollback calls RollbackFunc; a nil RollbackFunc succeeds
+func (f BranchHandlerFuncs) Rollback(ctx context.Context, cmd *BranchCommand) error {
+	if f.RollbackFunc == nil {
+		return nil
+	}
+	return f.RollbackFunc(ctx, cmd)
+}
+
+// ResourceManager receives branch commit/rollback callbacks from the TC and
+// dispatches them to the handler registered for the branch's resource id.
+// It implements http.Handler, so it can be mounted on an existing server or
+// run standalone with ListenAndServe.
+type ResourceManager struct {
+	mu             sync.RWMutex
+	handlers       map[string]BranchHandler
+	server         *http.Server
+	HandlerTimeout time.Duration
+}
+
+// NewResourceManager creates a resource manager with no registered resources
+func NewResourceManager() *ResourceManager {
+	return &ResourceManager{
+		handlers:       make(map[string]BranchHandler),
+		HandlerTimeout: 30 * time.Second,
+	}
+}
+
+// RegisterResource registers the handler completing branches of resourceID
+func (rm *ResourceManager) RegisterResource(resourceID string, handler BranchHandler) error {
+	if resourceID == "" {
+		return fmt.Errorf("resource ID cannot be empty")
+	}
+	if handler == nil {
+		return fmt.Errorf("handler cannot be nil")
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if _, exists := rm.handlers[resourceID]; exists {
+		return fmt.Errorf("resource already registered: %s", resourceID)
+	}
+	rm.handlers[resourceID] = handler
+	return nil
+}
+
+// UnregisterResource removes the handler of resourceID
+func (rm *ResourceManager) UnregisterResource(resourceID string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	delete(rm.handlers, resourceID)
+}
+
+// Resources returns the registered resource ids
+func (rm *ResourceManager) Resources() []string {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	ids := make([]string, 0, len(rm.handlers))
+	for id := range rm.handlers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Dispatch routes a branch command to its resource handler, with the gid and
+// branch ID attached to ctx
+func (rm *ResourceManager) Dispatch(ctx context.Context, cmd *BranchCommand) error {
+	rm.mu.RLock()
+	handler, ok := rm.handlers[cmd.ResourceID]
+	rm.mu.RUnlock()
+	if !ok {
+		return &SeataError{Code: ErrCodeBranchNotFound, Message: fmt.Sprintf("no handler registered for resource %s", cmd.ResourceID)}
+	}
+
+	ctx = WithBranchID(WithGID(ctx, cmd.GID), cmd.BranchID)
+	switch cmd.Command {
+	case BranchCommandCommit:
+		return handler.Commit(ctx, cmd)
+	case BranchCommandRollback:
+		return handler.Rollback(ctx, cmd)
+	default:
+		return &SeataError{Code: ErrCodeInvalidRequest, Message: fmt.Sprintf("unknown branch command: %s", cmd.Command)}
+	}
+}
+
+// ServeHTTP handles POST /rm/branch/commit and POST /rm/branch/rollback
+func (rm *ResourceManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var command string
+	switch r.URL.Path {
+	case rmCommitPath:
+		command = BranchCommandCommit
+	case rmRollbackPath:
+		command = BranchCommandRollback
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var cmd BranchCommand
+	if err := json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+		writeBranchResult(w, http.StatusBadRequest, fmt.Errorf("invalid branch command: %w", err))
+		return
+	}
+	cmd.Command = command
+
+	ctx := r.Context()
+	if rm.HandlerTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rm.HandlerTimeout)
+		defer cancel()
+	}
+
+	if err := rm.Dispatch(ctx, &cmd); err != nil {
+		status := http.StatusInternalServerError
+		if seataErr, ok := err.(*SeataError); ok && seataErr.Code == ErrCodeBranchNotFound {
+			status = http.StatusNotFound
+		}
+		writeBranchResult(w, status, err)
+		return
+	}
+	writeBranchResult(w, http.StatusOK, nil)
+}
+
+// ListenAndServe starts a standalone callback listener on addr
+func (rm *ResourceManager) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return rm.Serve(ln)
+}
+
+// Serve accepts TC callbacks on ln until Shutdown is called
+func (rm *ResourceManager) Serve(ln net.Listener) error {
+	rm.mu.Lock()
+	rm.server = &http.Server{Handler: rm, ReadHeaderTimeout: 10 * time.Second}
+	server := rm.server
+	rm.mu.Unlock()
+
+	if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Shutdown gracefully stops the standalone listener
+func (rm *ResourceManager) Shutdown(ctx context.Context) error {
+	rm.mu.RLock()
+	server := rm.server
+	rm.mu.RUnlock()
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
+
+// writeBranchResult writes the JSON result of a branch command
+func writeBranchResult(w http.ResponseWriter, status int, err error) {
+	result := BranchCommandResult{Result: BranchResultSuccess}
+	if err != nil {
+		result = BranchCommandResult{Result: BranchResultFailure, Error: err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(result)
+}
@@ -3,6 +3,7 @@ package seata
 import (
 	"context"
 	"fmt"
+	"runtime/trace"
 	"sync"
 	"time"
 )
@@ -21,32 +22,107 @@ func NewSagaManager(client *Client) *SagaManager {
 
 // ExecuteSaga executes a complete Saga workflow
 func (sm *SagaManager) ExecuteSaga(ctx context.Context, workflow *SagaWorkflow, payload []byte, options *ExecutionOptions) error {
+	_, err := sm.executeSaga(ctx, workflow, payload, options)
+	return err
+}
+
+// executeSaga runs a saga to completion and returns its transaction
+func (sm *SagaManager) executeSaga(ctx context.Context, workflow *SagaWorkflow, payload []byte, options *ExecutionOptions) (*Transaction, error) {
 	if options == nil {
 		options = DefaultExecutionOptions()
 	}
 
+	ctx, endProfile := startProfile(ctx, options, ModeSaga)
+	defer endProfile()
+
+	declared := workflow
+	workflow, skipped := selectSagaSteps(ctx, workflow, payload)
+	steps, err := sm.routeSagaSteps(workflow, options)
+	if err != nil {
+		return nil, err
+	}
+	if err := sm.client.preflight(ctx, options.PreflightCheck, sagaActions(steps)); err != nil {
+		return nil, err
+	}
+
 	// Start global transaction
+	started := time.Now()
 	tx, err := sm.client.StartTransaction(ctx, ModeSaga, payload)
 	if err != nil {
-		return fmt.Errorf("failed to start saga transaction: %w", err)
+		return nil, fmt.Errorf("failed to start saga transaction: %w", err)
 	}
+	tx.timings = newTimingRecorder()
+	tx.timings.record(PhaseStart, "", started, nil)
+	tx.skipped = skippedSagaSteps(declared, skipped, tx.gid)
+	deriveSagaBranchIDs(declared, steps, tx.gid)
+	ctx = profileGID(ctx, options, tx.gid)
+	defer tx.StartHeartbeat(ctx, options.Heartbeat)()
 
 	// Add all branches
-	for _, step := range workflow.Steps {
-		if err := tx.AddBranch(ctx, step.BranchID, step.Action); err != nil {
+	region := trace.StartRegion(ctx, "seata.addBranches")
+	for _, step := range steps {
+		added := time.Now()
+		err := sm.addBranch(ctx, tx, step, options)
+		tx.timings.record(PhaseBranchAdd, step.BranchID, added, err)
+		if err != nil {
 			// If adding branch fails, abort the transaction
+			region.End()
 			tx.Abort(ctx)
-			return fmt.Errorf("failed to add branch %s: %w", step.BranchID, err)
+			return tx, fmt.Errorf("failed to add branch %s: %w", step.BranchID, err)
 		}
 	}
+	region.End()
 
 	// Submit transaction for execution
-	if err := tx.Submit(ctx); err != nil {
-		return fmt.Errorf("failed to submit saga transaction: %w", err)
+	region = trace.StartRegion(ctx, "seata.submit")
+	submitted := time.Now()
+	err = tx.SubmitWithOptions(ctx, options.Submit)
+	tx.timings.record(PhaseSubmit, "", submitted, err)
+	region.End()
+	if err != nil {
+		return tx, fmt.Errorf("failed to submit saga transaction: %w", err)
 	}
 
 	// Wait for completion and handle compensation if needed
-	return sm.waitForCompletion(ctx, tx, workflow, options)
+	defer trace.StartRegion(ctx, "seata.wait").End()
+	waited := time.Now()
+	err = sm.waitForCompletion(ctx, tx, workflow, options)
+	tx.timings.record(PhaseCompletion, "", waited, err)
+	return tx, err
+}
+
+// addBranch adds a saga step with its branch options, registering its
+// compensation with the TC when options ask for it
+func (sm *SagaManager) addBranch(ctx context.Context, tx *Transaction, step SagaStep, options *ExecutionOptions) error {
+	if step.child != nil {
+		return sm.runChildSaga(ctx, tx, step, options)
+	}
+	if step.Include != "" {
+		return fmt.Errorf("step includes saga %s; expand the workflow with WorkflowRegistry.ExpandSaga", step.Include)
+	}
+	var branchOptions BranchOptions
+	if step.Options != nil {
+		branchOptions = *step.Options
+	}
+	if options.RegisterCompensations && branchOptions.Compensate == "" {
+		branchOptions.Compensate = step.Compensate
+	}
+	if branchOptions.Fallback == "" {
+		branchOptions.Fallback = step.FallbackAction
+	}
+	if branchOptions.FallbackCompensate == "" && branchOptions.Compensate != "" {
+		branchOptions.FallbackCompensate = step.FallbackCompensate
+	}
+	if err := tx.AddBranchWithOptions(ctx, step.BranchID, step.Action, &branchOptions); err != nil {
+		return err
+	}
+	if branchOptions.Compensate == "" && step.Compensate != "" {
+		if tx.compensations == nil {
+			tx.compensations = make(map[string]string)
+		}
+		tx.compensations[step.BranchID] = step.Compensate
+	}
+	return nil
 }
 
 // ExecuteSagaWithCompensation executes a Saga with custom compensation logic
@@ -55,26 +131,50 @@ func (sm *SagaManager) ExecuteSagaWithCompensation(ctx context.Context, workflow
 		options = DefaultExecutionOptions()
 	}
 
+	ctx, endProfile := startProfile(ctx, options, ModeSaga)
+	defer endProfile()
+
+	declared := workflow
+	workflow, _ = selectSagaSteps(ctx, workflow, payload)
+	steps, err := sm.routeSagaSteps(workflow, options)
+	if err != nil {
+		return err
+	}
+	if err := sm.client.preflight(ctx, options.PreflightCheck, sagaActions(steps)); err != nil {
+		return err
+	}
+
 	// Start global transaction
 	tx, err := sm.client.StartTransaction(ctx, ModeSaga, payload)
 	if err != nil {
 		return fmt.Errorf("failed to start saga transaction: %w", err)
 	}
+	deriveSagaBranchIDs(declared, steps, tx.gid)
+	workflow = &SagaWorkflow{Steps: steps, Version: workflow.Version}
+	ctx = profileGID(ctx, options, tx.gid)
+	defer tx.StartHeartbeat(ctx, options.Heartbeat)()
 
 	// Add all branches
-	for _, step := range workflow.Steps {
-		if err := tx.AddBranch(ctx, step.BranchID, step.Action); err != nil {
+	region := trace.StartRegion(ctx, "seata.addBranches")
+	for _, step := range steps {
+		if err := sm.addBranch(ctx, tx, step, options); err != nil {
+			region.End()
 			tx.Abort(ctx)
 			return fmt.Errorf("failed to add branch %s: %w", step.BranchID, err)
 		}
 	}
+	region.End()
 
 	// Submit transaction
-	if err := tx.Submit(ctx); err != nil {
+	region = trace.StartRegion(ctx, "seata.submit")
+	err = tx.SubmitWithOptions(ctx, options.Submit)
+	region.End()
+	if err != nil {
 		return fmt.Errorf("failed to submit saga transaction: %w", err)
 	}
 
 	// Monitor execution and handle compensation
+	defer trace.StartRegion(ctx, "seata.wait").End()
 	return sm.executeWithCompensation(ctx, tx, workflow, compensationFunc, options)
 }
 
@@ -91,7 +191,7 @@ func (sm *SagaManager) waitForCompletion(ctx context.Context, tx *Transaction, w
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-timeout.C:
-			return fmt.Errorf("saga execution timeout")
+			return fmt.Errorf("saga %w", ErrExecutionTimeout)
 		case <-ticker.C:
 			info, err := tx.GetInfo(ctx)
 			if err != nil {
@@ -100,14 +200,19 @@ func (sm *SagaManager) waitForCompletion(ctx context.Context, tx *Transaction, w
 
 			switch info.Status {
 			case StatusCommitted:
+				sm.recordSagaBranches(info.Branches, options)
+				sm.client.emit(EventTransactionCommitted, tx, "")
 				return nil
 			case StatusAborted:
-				return fmt.Errorf("saga transaction aborted")
-			case StatusSubmitted:
-				// Still executing, continue waiting
-				continue
+				sm.recordSagaBranches(info.Branches, options)
+				sm.client.emit(EventTransactionAborted, tx, "")
+				return ErrSagaAborted
+			case StatusTimeout:
+				return fmt.Errorf("saga %w on the server", ErrExecutionTimeout)
 			default:
-				return fmt.Errorf("unknown transaction status: %s", info.Status)
+				// Intermediate statuses, including ones added by newer
+				// servers: keep waiting
+				continue
 			}
 		}
 	}
@@ -126,7 +231,7 @@ func (sm *SagaManager) executeWithCompensation(ctx context.Context, tx *Transact
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-timeout.C:
-			return fmt.Errorf("saga execution timeout")
+			return fmt.Errorf("saga %w", ErrExecutionTimeout)
 		case <-ticker.C:
 			info, err := tx.GetInfo(ctx)
 			if err != nil {
@@ -135,59 +240,57 @@ func (sm *SagaManager) executeWithCompensation(ctx context.Context, tx *Transact
 
 			switch info.Status {
 			case StatusCommitted:
+				sm.recordSagaBranches(info.Branches, options)
+				sm.client.emit(EventTransactionCommitted, tx, "")
 				return nil
-			case StatusAborted:
+			case StatusAborted, StatusTimeout:
+				sm.recordSagaBranches(info.Branches, options)
+				sm.client.emit(EventTransactionAborted, tx, "")
 				// Find failed branches and execute compensation
-				return sm.executeCompensation(ctx, workflow, info.Branches, compensationFunc)
-			case StatusSubmitted:
-				continue
+				return sm.executeCompensation(ctx, workflow, info.Branches, compensationFunc, options)
 			default:
-				return fmt.Errorf("unknown transaction status: %s", info.Status)
+				continue
 			}
 		}
 	}
 }
 
 // executeCompensation executes compensation for failed steps
-func (sm *SagaManager) executeCompensation(ctx context.Context, workflow *SagaWorkflow, branches []Branch, compensationFunc func(ctx context.Context, failedStep *SagaStep) error) error {
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(workflow.Steps))
+func (sm *SagaManager) executeCompensation(ctx context.Context, workflow *SagaWorkflow, branches []Branch, compensationFunc func(ctx context.Context, failedStep *SagaStep) error, options *ExecutionOptions) error {
+	failedBranches := make(map[string]bool)
+	for _, branch := range branches {
+		if branch.Status == BranchStatusFailed {
+			failedBranches[branch.BranchID] = true
+		}
+	}
 
 	// Find failed branches and execute compensation in reverse order
+	var failedSteps []int
 	for i := len(workflow.Steps) - 1; i >= 0; i-- {
-		step := workflow.Steps[i]
-
-		// Check if this branch failed
-		var branchFailed bool
-		for _, branch := range branches {
-			if branch.BranchID == step.BranchID && branch.Status == BranchStatusFailed {
-				branchFailed = true
-				break
-			}
-		}
-
-		if branchFailed {
-			wg.Add(1)
-			go func(step SagaStep) {
-				defer wg.Done()
-				if err := compensationFunc(ctx, &step); err != nil {
-					errChan <- fmt.Errorf("compensation failed for branch %s: %w", step.BranchID, err)
-				}
-			}(step)
+		if failedBranches[workflow.Steps[i].BranchID] {
+			failedSteps = append(failedSteps, i)
 		}
 	}
 
-	wg.Wait()
-	close(errChan)
-
-	// Collect any compensation errors
+	var mu sync.Mutex
 	var compensationErrors []error
-	for err := range errChan {
-		compensationErrors = append(compensationErrors, err)
-	}
+	forEachStep(len(failedSteps), options.MaxConcurrency, func(i int) error {
+		step := workflow.Steps[failedSteps[i]]
+		participant := step.Compensate
+		if participant == "" {
+			participant = step.Action
+		}
+		err := sm.client.withBranchSlot(ctx, participant, func() error { return compensationFunc(ctx, &step) })
+		if err != nil {
+			mu.Lock()
+			compensationErrors = append(compensationErrors, fmt.Errorf("compensation failed for branch %s: %w", step.BranchID, err))
+			mu.Unlock()
+		}
+		return nil
+	})
 
 	if len(compensationErrors) > 0 {
-		return fmt.Errorf("compensation failed: %v", compensationErrors)
+		return fmt.Errorf("%w: %v", ErrCompensationFailed, compensationErrors)
 	}
 
 	return nil
@@ -218,13 +321,13 @@ func (sw *SagaWorkflow) Validate() error {
 
 	seen := make(map[string]bool)
 	for _, step := range sw.Steps {
-		if step.BranchID == "" {
+		if step.BranchID == "" && !sw.DeriveBranchIDs {
 			return fmt.Errorf("branch ID cannot be empty")
 		}
-		if step.Action == "" {
+		if step.Action == "" && step.Include == "" {
 			return fmt.Errorf("action cannot be empty")
 		}
-		if seen[step.BranchID] {
+		if step.BranchID != "" && seen[step.BranchID] {
 			return fmt.Errorf("duplicate branch ID: %s", step.BranchID)
 		}
 		seen[step.BranchID] = true
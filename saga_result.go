@@ -0,0 +1,81 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+)
+
+// Paths a saga step can take, reported in SagaStepResult
+const (
+	SagaPathPrimary  = "primary"
+	SagaPathFallback = "fallback"
+	SagaPathSkipped  = "skipped"
+)
+
+// SagaStepResult reports how the TC executed one saga step
+type SagaStepResult struct {
+	BranchID string
+	// Status is the branch status reported by the TC; empty when the step
+	// never ran
+	Status string
+	// Action is the URL that ran and Path tells whether it was the step's
+	// Action or its FallbackAction, or that its Condition skipped it
+	Action string
+	Path   string
+}
+
+// ExecuteSagaWithResult executes a saga like ExecuteSaga and reports the
+// outcome and path of every step. The result is returned even when the saga
+// fails after its transaction was started.
+func (sm *SagaManager) ExecuteSagaWithResult(ctx context.Context, workflow *SagaWorkflow, payload []byte, options *ExecutionOptions) (*ExecutionResult, error) {
+	tx, err := sm.executeSaga(ctx, workflow, payload, options)
+	if tx == nil {
+		return nil, err
+	}
+	result := &ExecutionResult{GID: tx.gid, Skipped: tx.skipped}
+	tx.timings.fill(result)
+	info, infoErr := tx.GetInfo(ctx)
+	if infoErr != nil {
+		if err == nil {
+			err = fmt.Errorf("failed to get transaction info: %w", infoErr)
+		}
+		return result, err
+	}
+	result.Saga = sagaStepResults(workflow, tx.gid, info.Branches, tx.skipped)
+	return result, err
+}
+
+// sagaStepResults matches the branches reported by the TC to the steps
+func sagaStepResults(workflow *SagaWorkflow, gid string, branches []Branch, skipped []string) []SagaStepResult {
+	byID := make(map[string]Branch, len(branches))
+	for _, branch := range branches {
+		byID[branch.BranchID] = branch
+	}
+	skip := make(map[string]bool, len(skipped))
+	for _, branchID := range skipped {
+		skip[branchID] = true
+	}
+
+	results := make([]SagaStepResult, len(workflow.Steps))
+	for i, step := range workflow.Steps {
+		results[i].BranchID = workflow.branchID(i, gid)
+		if skip[results[i].BranchID] {
+			results[i].Path = SagaPathSkipped
+			continue
+		}
+		branch, ok := byID[results[i].BranchID]
+		if !ok || branch.Status == "" {
+			continue
+		}
+		results[i].Status = branch.Status
+		results[i].Action = branch.ExecutedAction
+		if results[i].Action == "" {
+			results[i].Action = branch.Action
+		}
+		results[i].Path = SagaPathPrimary
+		if step.FallbackAction != "" && results[i].Action == step.FallbackAction {
+			results[i].Path = SagaPathFallback
+		}
+	}
+	return results
+}
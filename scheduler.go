@@ -0,0 +1,403 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Overlap policies for scheduled jobs still running when the next run is due
+const (
+	OverlapSkip    = "skip"    // skip the new run
+	OverlapAllow   = "allow"   // start the new run alongside the old one
+	OverlapReplace = "replace" // cancel the old run and start the new one
+)
+
+// CronSchedule is a parsed five-field cron expression
+// (minute hour day-of-month month day-of-week)
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool
+	every                         time.Duration
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a five-field cron expression. Fields accept "*", lists,
+// ranges and steps ("*/15", "1-5", "0,30"). The descriptors @yearly,
+// @monthly, @weekly, @daily, @hourly and "@every <duration>" are supported.
+func ParseCron(expr string) (*CronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil || every <= 0 {
+			return nil, fmt.Errorf("invalid cron expression %q: bad @every duration", expr)
+		}
+		return &CronSchedule{every: every}, nil
+	}
+	if descriptor, ok := cronDescriptors[expr]; ok {
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields", expr)
+	}
+
+	s := &CronSchedule{}
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid cron minute %q: %w", fields[0], err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid cron hour %q: %w", fields[1], err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid cron day of month %q: %w", fields[2], err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid cron month %q: %w", fields[3], err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid cron day of week %q: %w", fields[4], err)
+	}
+	// 7 is an alias for Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = fields[2] == "*" || fields[2] == "?"
+	s.dowStar = fields[4] == "*" || fields[4] == "?"
+	return s, nil
+}
+
+// parseCronField returns the bitset of values matched by field
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("bad step %q", part[i+1:])
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*" || part == "?":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("bad range %q", part)
+			}
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				return 0, fmt.Errorf("bad range %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("bad value %q", part)
+			}
+			lo = n
+			if step == 1 {
+				hi = n
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value out of range [%d-%d]", min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first activation time strictly after t, or the zero time
+// if the expression never matches within five years
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the cron rule that a restricted day-of-month and
+// day-of-week match if either does
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// ScheduledJob launches one run of a scheduled workflow through client
+type ScheduledJob func(ctx context.Context, client *Client) error
+
+// ScheduleOptions tunes a single scheduled job
+type ScheduleOptions struct {
+	// Jitter delays each run by a random duration up to this value, spreading load
+	Jitter time.Duration
+	// Overlap is one of OverlapSkip (default), OverlapAllow or OverlapReplace
+	Overlap string
+	// Timeout bounds each run; zero means no limit
+	Timeout time.Duration
+}
+
+// SchedulerConfig configures a Scheduler
+type SchedulerConfig struct {
+	// Elector, when set, restricts runs to the instance holding leadership
+	Elector *LeaderElector
+	// Location evaluates cron expressions; defaults to time.Local
+	Location *time.Location
+	// OnError is called when a run returns an error
+	OnError func(name string, err error)
+}
+
+// ScheduleEntry describes a registered job
+type ScheduleEntry struct {
+	Name    string
+	Spec    string
+	Next    time.Time
+	Prev    time.Time
+	Running int
+}
+
+type scheduleEntry struct {
+	name     string
+	spec     string
+	schedule *CronSchedule
+	job      ScheduledJob
+	options  ScheduleOptions
+
+	mu      sync.Mutex
+	next    time.Time
+	prev    time.Time
+	running int
+	cancel  context.CancelFunc
+	stop    chan struct{}
+}
+
+// Scheduler launches registered workflows on cron schedules
+type Scheduler struct {
+	client *Client
+	config SchedulerConfig
+
+	mu      sync.Mutex
+	entries map[string]*scheduleEntry
+	ctx     context.Context
+	wg      sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler issuing transactions through client
+func NewScheduler(client *Client, config *SchedulerConfig) *Scheduler {
+	var cfg SchedulerConfig
+	if config != nil {
+		cfg = *config
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	return &Scheduler{client: client, config: cfg, entries: make(map[string]*scheduleEntry)}
+}
+
+// Register adds a job under name; a running scheduler starts it immediately
+func (s *Scheduler) Register(name, spec string, job ScheduledJob, options *ScheduleOptions) error {
+	if name == "" {
+		return fmt.Errorf("schedule name cannot be empty")
+	}
+	if job == nil {
+		return fmt.Errorf("scheduled job cannot be nil")
+	}
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+
+	var opts ScheduleOptions
+	if options != nil {
+		opts = *options
+	}
+	switch opts.Overlap {
+	case "":
+		opts.Overlap = OverlapSkip
+	case OverlapSkip, OverlapAllow, OverlapReplace:
+	default:
+		return fmt.Errorf("unsupported overlap policy: %s", opts.Overlap)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.entries[name]; exists {
+		return fmt.Errorf("schedule %s already registered", name)
+	}
+	entry := &scheduleEntry{name: name, spec: spec, schedule: schedule, job: job, options: opts, stop: make(chan struct{})}
+	s.entries[name] = entry
+	if s.ctx != nil {
+		s.start(s.ctx, entry)
+	}
+	return nil
+}
+
+// Unregister removes a job; runs already in progress are not cancelled
+func (s *Scheduler) Unregister(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[name]; ok {
+		close(entry.stop)
+		delete(s.entries, name)
+	}
+}
+
+// Entries returns the registered jobs sorted by name
+func (s *Scheduler) Entries() []ScheduleEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]ScheduleEntry, 0, len(s.entries))
+	for _, e := range s.entries {
+		e.mu.Lock()
+		entries = append(entries, ScheduleEntry{Name: e.name, Spec: e.spec, Next: e.next, Prev: e.prev, Running: e.running})
+		e.mu.Unlock()
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// Run starts all jobs and blocks until ctx is done, then waits for runs in progress
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	if s.ctx != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("scheduler is already running")
+	}
+	s.ctx = ctx
+	for _, entry := range s.entries {
+		s.start(ctx, entry)
+	}
+	s.mu.Unlock()
+
+	<-ctx.Done()
+	s.wg.Wait()
+
+	s.mu.Lock()
+	s.ctx = nil
+	s.mu.Unlock()
+	return ctx.Err()
+}
+
+// start runs the timer loop of entry; s.mu must be held
+func (s *Scheduler) start(ctx context.Context, entry *scheduleEntry) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			next := entry.schedule.Next(time.Now().In(s.config.Location))
+			if next.IsZero() {
+				return
+			}
+			if entry.options.Jitter > 0 {
+				next = next.Add(time.Duration(rand.Int63n(int64(entry.options.Jitter))))
+			}
+			entry.mu.Lock()
+			entry.next = next
+			entry.mu.Unlock()
+
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-entry.stop:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			if s.config.Elector != nil && !s.config.Elector.IsLeader() {
+				continue
+			}
+			s.fire(ctx, entry)
+		}
+	}()
+}
+
+// fire launches one run of entry, applying its overlap policy
+func (s *Scheduler) fire(ctx context.Context, entry *scheduleEntry) {
+	entry.mu.Lock()
+	if entry.running > 0 {
+		switch entry.options.Overlap {
+		case OverlapSkip:
+			entry.mu.Unlock()
+			return
+		case OverlapReplace:
+			entry.cancel()
+		}
+	}
+
+	var runCtx context.Context
+	var cancel context.CancelFunc
+	if entry.options.Timeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, entry.options.Timeout)
+	} else {
+		runCtx, cancel = context.WithCancel(ctx)
+	}
+	entry.running++
+	entry.prev = time.Now()
+	entry.cancel = cancel
+	entry.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			cancel()
+			entry.mu.Lock()
+			entry.running--
+			entry.mu.Unlock()
+		}()
+
+		if err := entry.job(runCtx, s.client); err != nil && s.config.OnError != nil {
+			s.config.OnError(entry.name, err)
+		}
+	}()
+}
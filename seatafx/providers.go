@@ -0,0 +1,57 @@
+// Package seatafx provides constructors for wiring the Seata client into
+// dependency-injection frameworks such as google/wire and uber-go/fx.
+//
+// The providers only depend on the seata package, so importing them does not
+// pull a DI framework into the build. With wire they are used directly:
+//
+//	var Set = wire.NewSet(seatafx.ProvideClient, seatafx.ProvideSagaManager, seatafx.ProvideTCCManager)
+//
+// With fx, register them and close the client on stop:
+//
+//	fx.Module("seata",
+//		fx.Provide(seatafx.ProvideFxClient, seatafx.ProvideSagaManager, seatafx.ProvideTCCManager),
+//		fx.Invoke(func(lc fx.Lifecycle, c *seata.Client) { lc.Append(fx.StopHook(c.Close)) }),
+//	)
+package seatafx
+
+import (
+	"fmt"
+
+	seata "github.com/seata-team/seata-go-client"
+)
+
+// ProvideClient creates a client from config, failing on an invalid
+// configuration. A nil config uses the defaults. The returned cleanup closes
+// the client, in the form wire expects.
+func ProvideClient(config *seata.Config) (*seata.Client, func(), error) {
+	client, err := ProvideFxClient(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		if err := client.Close(); err != nil {
+			fmt.Printf("Warning: failed to close seata client: %v\n", err)
+		}
+	}
+	return client, cleanup, nil
+}
+
+// ProvideFxClient is ProvideClient without the cleanup function, for
+// frameworks such as fx that close the client through lifecycle hooks
+func ProvideFxClient(config *seata.Config) (*seata.Client, error) {
+	client, err := seata.NewClientE(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create seata client: %w", err)
+	}
+	return client, nil
+}
+
+// ProvideSagaManager creates a saga manager for client
+func ProvideSagaManager(client *seata.Client) *seata.SagaManager {
+	return seata.NewSagaManager(client)
+}
+
+// ProvideTCCManager creates a TCC manager for client
+func ProvideTCCManager(client *seata.Client) *seata.TCCManager {
+	return seata.NewTCCManager(client)
+}
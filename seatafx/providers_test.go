@@ -0,0 +1,25 @@
+package seatafx_test
+
+import (
+	"testing"
+
+	seata "github.com/seata-team/seata-go-client"
+	"github.com/seata-team/seata-go-client/seatafx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvideClient(t *testing.T) {
+	config := seata.DefaultConfig()
+	config.GrpcEndpoint = ""
+	client, cleanup, err := seatafx.ProvideClient(config)
+	assert.NoError(t, err)
+	assert.NotNil(t, seatafx.ProvideSagaManager(client))
+	assert.NotNil(t, seatafx.ProvideTCCManager(client))
+	cleanup()
+
+	config = seata.DefaultConfig()
+	config.HTTPEndpoint = ""
+	config.GrpcEndpoint = ""
+	_, _, err = seatafx.ProvideClient(config)
+	assert.Error(t, err)
+}
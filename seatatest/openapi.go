@@ -0,0 +1,262 @@
+package seatatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Schema is the subset of an OpenAPI schema object checked by Spec
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Enum                 []interface{}      `json:"enum"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*Schema `json:"properties"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	Example              json.RawMessage    `json:"example"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type operation struct {
+	RequestBody *struct {
+		Content map[string]mediaType `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]mediaType `json:"content"`
+	} `json:"responses"`
+}
+
+// Spec is an OpenAPI 3 document of the TC HTTP API, used to check that the
+// requests the client sends and the structs it decodes match what the TC
+// publishes
+type Spec struct {
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+// LoadSpec reads a JSON OpenAPI document from a file or an http(s) URL
+func LoadSpec(location string) (*Spec, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		var resp *http.Response
+		if resp, err = http.Get(location); err != nil {
+			return nil, fmt.Errorf("failed to fetch spec: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch spec: status %d", resp.StatusCode)
+		}
+		data, err = io.ReadAll(resp.Body)
+	} else {
+		data, err = os.ReadFile(location)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	return &spec, nil
+}
+
+// Schema returns the component schema name, or nil
+func (s *Spec) Schema(name string) *Schema {
+	return s.Components.Schemas[name]
+}
+
+// Properties returns the property names of the component schema name
+func (s *Spec) Properties(name string) []string {
+	schema := s.resolve(s.Schema(name))
+	if schema == nil {
+		return nil
+	}
+	names := make([]string, 0, len(schema.Properties))
+	for property := range schema.Properties {
+		names = append(names, property)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateRequest checks a recorded request against the operation of its
+// method and path; requests to paths the spec does not define fail
+func (s *Spec) ValidateRequest(r Request) error {
+	op, err := s.operation(r.Method, r.Path)
+	if err != nil {
+		return err
+	}
+	if op.RequestBody == nil {
+		return nil
+	}
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok || media.Schema == nil {
+		return nil
+	}
+	return s.ValidateJSON(media.Schema, r.Body, r.Method+" "+r.Path)
+}
+
+// ValidateResponse checks a JSON response body of method and path
+func (s *Spec) ValidateResponse(method, path string, status int, body []byte) error {
+	op, err := s.operation(method, path)
+	if err != nil {
+		return err
+	}
+	response, ok := op.Responses[fmt.Sprint(status)]
+	if !ok {
+		return fmt.Errorf("%s %s: status %d is not in the spec", method, path, status)
+	}
+	media, ok := response.Content["application/json"]
+	if !ok || media.Schema == nil {
+		return nil
+	}
+	return s.ValidateJSON(media.Schema, body, fmt.Sprintf("%s %s %d", method, path, status))
+}
+
+// ValidateJSON checks data against schema; where names the value in errors
+func (s *Spec) ValidateJSON(schema *Schema, data []byte, where string) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("%s: invalid JSON: %w", where, err)
+	}
+	var problems []string
+	s.validate(schema, value, "$", &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %s", where, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// operation finds the operation of method and path, matching {param}
+// segments of templated paths
+func (s *Spec) operation(method, path string) (*operation, error) {
+	segments := strings.Split(path, "/")
+	for template, ops := range s.Paths {
+		parts := strings.Split(template, "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+		match := true
+		for i, part := range parts {
+			if part != segments[i] && !(strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}")) {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		if op, ok := ops[strings.ToLower(method)]; ok {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("%s %s is not in the spec", method, path)
+}
+
+func (s *Spec) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = s.Schema(strings.TrimPrefix(schema.Ref, "#/components/schemas/"))
+	}
+	return schema
+}
+
+func (s *Spec) validate(schema *Schema, value interface{}, at string, problems *[]string) {
+	schema = s.resolve(schema)
+	if schema == nil {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*problems = append(*problems, at+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, allowed := range schema.Enum {
+			if allowed == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("%v is not one of %v", value, schema.Enum)
+		}
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			fail("expected object, got %T", value)
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := object[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		var additional *Schema
+		closed := string(schema.AdditionalProperties) == "false"
+		if len(schema.AdditionalProperties) > 0 && !closed && string(schema.AdditionalProperties) != "true" {
+			additional = &Schema{}
+			_ = json.Unmarshal(schema.AdditionalProperties, additional)
+		}
+		for name, property := range object {
+			switch propertySchema, ok := schema.Properties[name]; {
+			case ok:
+				s.validate(propertySchema, property, at+"."+name, problems)
+			case closed:
+				fail("unknown property %q", name)
+			case additional != nil:
+				s.validate(additional, property, at+"."+name, problems)
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			fail("expected array, got %T", value)
+			return
+		}
+		for i, item := range array {
+			s.validate(schema.Items, item, fmt.Sprintf("%s[%d]", at, i), problems)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			fail("expected string, got %T", value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("expected boolean, got %T", value)
+		}
+	case "integer", "number":
+		number, ok := value.(float64)
+		if !ok {
+			fail("expected %s, got %T", schema.Type, value)
+			return
+		}
+		if schema.Type == "integer" && number != float64(int64(number)) {
+			fail("expected integer, got %v", number)
+		}
+		if schema.Minimum != nil && number < *schema.Minimum {
+			fail("%v is below the minimum %v", number, *schema.Minimum)
+		}
+		if schema.Maximum != nil && number > *schema.Maximum {
+			fail("%v is above the maximum %v", number, *schema.Maximum)
+		}
+	}
+}
@@ -0,0 +1,630 @@
+// Package seatatest provides an in-process fake of the Seata TC HTTP API
+// together with TCC business endpoints, for tests and examples that should
+// run without a real server.
+//
+// The fake TC serves /api/start, /api/branch/*, /api/submit, /api/abort,
+// /api/tx (including batch lookups and labels) and /health. On submit it
+// calls every branch action in order and commits when all of them return
+// 2xx, otherwise it aborts and calls the compensate URLs registered for the
+// branches that succeeded. A failing action with a registered fallback is
+// replaced by its fallback. Branch succeed and fail reports for actions
+// ending in /try call the matching /confirm or /cancel endpoint.
+//
+// POST /api/tx/{gid}/archive moves a finished transaction to the archive
+// served at /api/archive/tx. POST /api/tx/{gid}/keepalive records a new
+// deadline without ever timing transactions out.
+//
+// A second succeed or fail report for a branch answers 409 with the code
+// BRANCH_ALREADY_CONFIRMED or BRANCH_ALREADY_CANCELLED.
+//
+// Business endpoints: /fail answers 500, /tcc/{resource}/{try,confirm,cancel}
+// emulate a TCC participant and every other path, such as /ok, answers 200.
+// TCC endpoints keep barrier state per gid and branch, so an empty
+// compensation (cancel before try) and a hanging try (try after cancel) are
+// answered like a barrier-aware service.
+package seatatest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Transaction statuses reported by the fake TC
+const (
+	StatusSubmitted = "SUBMITTED"
+	StatusCommitted = "COMMITTED"
+	StatusAborted   = "ABORTED"
+)
+
+// Response is a canned reply used by failure sequences
+type Response struct {
+	Status int
+	Body   string
+}
+
+// Request is a recorded request
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Body   []byte
+	Header http.Header
+	Time   time.Time
+}
+
+// Branch is a branch registered with the fake TC
+type Branch struct {
+	BranchID   string `json:"branch_id"`
+	Action     string `json:"action"`
+	Compensate string `json:"compensate,omitempty"`
+	Status     string `json:"status,omitempty"`
+	// Fallback runs when Action fails; ExecutedAction is the one that ran
+	Fallback           string `json:"fallback,omitempty"`
+	FallbackCompensate string `json:"fallback_compensate,omitempty"`
+	ExecutedAction     string `json:"executed_action,omitempty"`
+	// Method and CompensateMethod are the HTTP methods used; empty is POST
+	Method           string `json:"method,omitempty"`
+	CompensateMethod string `json:"compensate_method,omitempty"`
+}
+
+// Transaction is a global transaction held by the fake TC
+type Transaction struct {
+	GID          string            `json:"gid"`
+	Mode         string            `json:"mode"`
+	Status       string            `json:"status"`
+	Payload      []byte            `json:"payload"`
+	Branches     []Branch          `json:"branches"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	ParentGID    string            `json:"parent_gid,omitempty"`
+	DeadlineUnix int64             `json:"deadline_unix,omitempty"`
+	CreatedUnix  int64             `json:"created_unix"`
+	UpdatedUnix  int64             `json:"updated_unix"`
+}
+
+// Server is a fake TC plus business endpoints backed by httptest.Server
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	txs       map[string]*Transaction
+	order     []string
+	archived  map[string]*Transaction
+	requests  []Request
+	sequences map[string][]Response
+	barriers  map[string]string // gid/branch -> last TCC phase
+}
+
+// NewServer starts a fake server; call Close when done
+func NewServer() *Server {
+	s := &Server{
+		txs:       make(map[string]*Transaction),
+		archived:  make(map[string]*Transaction),
+		sequences: make(map[string][]Response),
+		barriers:  make(map[string]string),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// FailNext makes the next calls to path return the given responses in
+// order before falling back to normal handling, e.g.
+//
+//	s.FailNext("/tcc/stock/try", seatatest.Response{Status: 500})
+func (s *Server) FailNext(path string, responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sequences[path] = append(s.sequences[path], responses...)
+}
+
+// Requests returns the recorded requests, optionally only those whose path
+// starts with prefix
+func (s *Server) Requests(prefix string) []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Request
+	for _, r := range s.requests {
+		if strings.HasPrefix(r.Path, prefix) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Transaction returns a copy of a transaction held by the fake TC
+func (s *Server) Transaction(gid string) (Transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, ok := s.txs[gid]
+	if !ok {
+		return Transaction{}, false
+	}
+	copied := *tx
+	copied.Branches = append([]Branch(nil), tx.Branches...)
+	return copied, true
+}
+
+// Reset clears transactions, barrier state, sequences and the request log
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txs = make(map[string]*Transaction)
+	s.order = nil
+	s.archived = make(map[string]*Transaction)
+	s.requests = nil
+	s.sequences = make(map[string][]Response)
+	s.barriers = make(map[string]string)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		// Large requests of clients with a Compressor
+		if zr, err := gzip.NewReader(bytes.NewReader(body)); err == nil {
+			body, _ = io.ReadAll(zr)
+		}
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Body:   body,
+		Header: r.Header.Clone(),
+		Time:   time.Now(),
+	})
+	if seq := s.sequences[r.URL.Path]; len(seq) > 0 {
+		resp := seq[0]
+		s.sequences[r.URL.Path] = seq[1:]
+		s.mu.Unlock()
+		if resp.Status == 0 {
+			resp.Status = http.StatusOK
+		}
+		w.WriteHeader(resp.Status)
+		_, _ = w.Write([]byte(resp.Body))
+		return
+	}
+	s.mu.Unlock()
+
+	path := r.URL.Path
+	switch {
+	case path == "/health":
+		_, _ = w.Write([]byte("ok"))
+	case path == "/fail":
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("FAIL"))
+	case strings.HasPrefix(path, "/tcc/"):
+		s.serveTCC(w, r, body)
+	case strings.HasPrefix(path, "/api/"):
+		s.serveAPI(w, r, body)
+	default:
+		_, _ = w.Write([]byte("OK"))
+	}
+}
+
+// serveAPI emulates the TC API
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req struct {
+		GID        string          `json:"gid"`
+		Mode       string          `json:"mode"`
+		Payload    json.RawMessage `json:"payload"`
+		BranchID   string          `json:"branch_id"`
+		Action     string          `json:"action"`
+		Compensate string          `json:"compensate"`
+		GIDs       []string        `json:"gids"`
+		Fallback   string          `json:"fallback"`
+		FallbackC  string          `json:"fallback_compensate"`
+		ParentGID  string          `json:"parent_gid"`
+		Method     string          `json:"method"`
+		CompMethod string          `json:"compensate_method"`
+	}
+	if len(body) > 0 {
+		_ = json.Unmarshal(body, &req)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().Unix()
+
+	switch path := r.URL.Path; {
+	case path == "/api/start":
+		s.txs[req.GID] = &Transaction{GID: req.GID, Mode: req.Mode, Status: StatusSubmitted, Payload: decodePayload(req.Payload), ParentGID: req.ParentGID, CreatedUnix: now, UpdatedUnix: now}
+		s.order = append(s.order, req.GID)
+		writeJSON(w, map[string]string{"gid": req.GID})
+	case path == "/api/branch/add" || path == "/api/branch/try":
+		tx, ok := s.txs[req.GID]
+		if !ok {
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+		tx.Branches = append(tx.Branches, Branch{BranchID: req.BranchID, Action: req.Action, Compensate: req.Compensate, Fallback: req.Fallback, FallbackCompensate: req.FallbackC, Method: req.Method, CompensateMethod: req.CompMethod})
+		tx.UpdatedUnix = now
+		if path == "/api/branch/try" {
+			// Forward the try to the participant and relay its answer
+			s.mu.Unlock()
+			status, respBody := call(r.Context(), req.Action, body)
+			s.mu.Lock()
+			w.WriteHeader(status)
+			_, _ = w.Write(respBody)
+		}
+	case path == "/api/branch/succeed" || path == "/api/branch/fail":
+		tx, ok := s.txs[req.GID]
+		if !ok {
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+		status, phase := "SUCCEED", "confirm"
+		if path == "/api/branch/fail" {
+			status, phase = "FAILED", "cancel"
+		}
+		action := ""
+		for i := range tx.Branches {
+			if tx.Branches[i].BranchID != req.BranchID {
+				continue
+			}
+			// Duplicate reports are answered like the TC: 409 with the
+			// branch's final state
+			switch tx.Branches[i].Status {
+			case "SUCCEED":
+				writeConflict(w, "BRANCH_ALREADY_CONFIRMED")
+				return
+			case "FAILED":
+				writeConflict(w, "BRANCH_ALREADY_CANCELLED")
+				return
+			}
+			tx.Branches[i].Status = status
+			action = tx.Branches[i].Action
+		}
+		tx.UpdatedUnix = now
+		// Drive the second phase of TCC branches registered with a .../try action
+		if strings.HasSuffix(action, "/try") {
+			s.mu.Unlock()
+			code, respBody := call(r.Context(), strings.TrimSuffix(action, "try")+phase, body)
+			s.mu.Lock()
+			if code < 200 || code > 299 {
+				w.WriteHeader(code)
+				_, _ = w.Write(respBody)
+			}
+		}
+	case path == "/api/branch/report":
+	case path == "/api/submit":
+		tx, ok := s.txs[req.GID]
+		if !ok {
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+		branches := append([]Branch(nil), tx.Branches...)
+		go s.execute(req.GID, branches)
+	case path == "/api/payload":
+		tx, ok := s.txs[req.GID]
+		if !ok {
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+		tx.Payload = decodePayload(req.Payload)
+		tx.UpdatedUnix = now
+	case path == "/api/abort":
+		tx, ok := s.txs[req.GID]
+		if !ok {
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+		tx.Status = StatusAborted
+		tx.UpdatedUnix = now
+	case path == "/api/tx":
+		status, parent := r.URL.Query().Get("status"), r.URL.Query().Get("parent_gid")
+		list := make([]*Transaction, 0, len(s.order))
+		for _, gid := range s.order {
+			if tx := s.txs[gid]; tx != nil && (status == "" || tx.Status == status) && (parent == "" || tx.ParentGID == parent) {
+				list = append(list, tx)
+			}
+		}
+		writeJSON(w, list)
+	case path == "/api/tx/batch" && r.Method == http.MethodPost:
+		list := make([]*Transaction, 0, len(req.GIDs))
+		for _, gid := range req.GIDs {
+			if tx := s.txs[gid]; tx != nil {
+				list = append(list, tx)
+			}
+		}
+		writeJSON(w, list)
+	case strings.HasPrefix(path, "/api/tx/") && strings.HasSuffix(path, "/archive") && r.Method == http.MethodPost:
+		// Only finished transactions move to the archive
+		gid := strings.TrimSuffix(strings.TrimPrefix(path, "/api/tx/"), "/archive")
+		tx, ok := s.txs[gid]
+		if !ok {
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+		if tx.Status != StatusCommitted && tx.Status != StatusAborted {
+			http.Error(w, "transaction not finished", http.StatusConflict)
+			return
+		}
+		s.archived[gid] = tx
+		delete(s.txs, gid)
+	case strings.HasPrefix(path, "/api/tx/") && strings.HasSuffix(path, "/keepalive") && r.Method == http.MethodPost:
+		// Deadlines are recorded, not enforced
+		tx, ok := s.txs[strings.TrimSuffix(strings.TrimPrefix(path, "/api/tx/"), "/keepalive")]
+		if !ok {
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+		if tx.Status != StatusSubmitted {
+			http.Error(w, "transaction finished", http.StatusConflict)
+			return
+		}
+		var keepAlive struct {
+			ExtendMS int64 `json:"extend_ms"`
+		}
+		if err := json.Unmarshal(body, &keepAlive); err != nil || keepAlive.ExtendMS <= 0 {
+			http.Error(w, "extend_ms must be positive", http.StatusBadRequest)
+			return
+		}
+		if deadline := time.Now().Add(time.Duration(keepAlive.ExtendMS) * time.Millisecond).Unix(); deadline > tx.DeadlineUnix {
+			tx.DeadlineUnix = deadline
+		}
+		tx.UpdatedUnix = now
+		writeJSON(w, map[string]int64{"deadline_unix": tx.DeadlineUnix})
+	case path == "/api/archive/tx":
+		status := r.URL.Query().Get("status")
+		list := make([]*Transaction, 0, len(s.archived))
+		for _, gid := range s.order {
+			if tx := s.archived[gid]; tx != nil && (status == "" || tx.Status == status) {
+				list = append(list, tx)
+			}
+		}
+		writeJSON(w, list)
+	case strings.HasPrefix(path, "/api/archive/tx/"):
+		tx, ok := s.archived[strings.TrimPrefix(path, "/api/archive/tx/")]
+		if !ok {
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, tx)
+	case strings.HasPrefix(path, "/api/tx/") && strings.Contains(strings.TrimPrefix(path, "/api/tx/"), "/labels"):
+		gid, key, _ := strings.Cut(strings.TrimPrefix(path, "/api/tx/"), "/labels")
+		tx, ok := s.txs[gid]
+		if !ok {
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPatch:
+			var labels map[string]string
+			if err := json.Unmarshal(body, &labels); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if tx.Labels == nil {
+				tx.Labels = make(map[string]string)
+			}
+			for k, v := range labels {
+				tx.Labels[k] = v
+			}
+			tx.UpdatedUnix = now
+		case r.Method == http.MethodDelete:
+			delete(tx.Labels, strings.TrimPrefix(key, "/"))
+			tx.UpdatedUnix = now
+		default:
+			labels := tx.Labels
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			writeJSON(w, labels)
+		}
+	case strings.HasPrefix(path, "/api/tx/"):
+		tx, ok := s.txs[strings.TrimPrefix(path, "/api/tx/")]
+		if !ok {
+			http.Error(w, "transaction not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, tx)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// execute runs the branch actions of a submitted transaction
+func (s *Server) execute(gid string, branches []Branch) {
+	final := StatusCommitted
+	results := make(map[string]string, len(branches))
+	executed := make(map[string]string, len(branches))
+	responses := make(map[string][]byte, len(branches))
+	for _, b := range branches {
+		executed[b.BranchID] = b.Action
+		status, body := callMethod(context.Background(), b.Method, b.Action, nil)
+		if (status < 200 || status > 299) && b.Fallback != "" {
+			executed[b.BranchID] = b.Fallback
+			status, body = callMethod(context.Background(), b.Method, b.Fallback, nil)
+		}
+		if status >= 200 && status <= 299 {
+			results[b.BranchID] = "SUCCEED"
+			responses[b.BranchID] = body
+			continue
+		}
+		results[b.BranchID] = "FAILED"
+		final = StatusAborted
+		break
+	}
+	// Roll back the branches that succeeded, newest first
+	if final == StatusAborted {
+		for i := len(branches) - 1; i >= 0; i-- {
+			b := branches[i]
+			compensate := b.Compensate
+			if executed[b.BranchID] == b.Fallback && b.FallbackCompensate != "" {
+				compensate = b.FallbackCompensate
+			}
+			if results[b.BranchID] != "SUCCEED" || compensate == "" {
+				continue
+			}
+			target, err := renderCompensate(compensate, responses[b.BranchID], gid, b.BranchID)
+			if err != nil {
+				continue
+			}
+			if status, _ := callMethod(context.Background(), b.CompensateMethod, target, nil); status >= 200 && status <= 299 {
+				results[b.BranchID] = "COMPENSATED"
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx, ok := s.txs[gid]
+	if !ok {
+		return
+	}
+	for i := range tx.Branches {
+		if status, ok := results[tx.Branches[i].BranchID]; ok {
+			tx.Branches[i].Status = status
+			tx.Branches[i].ExecutedAction = executed[tx.Branches[i].BranchID]
+		}
+	}
+	tx.Status = final
+	tx.UpdatedUnix = time.Now().Unix()
+}
+
+// serveTCC emulates a barrier-aware TCC participant at /tcc/{resource}/{phase}
+func (s *Server) serveTCC(w http.ResponseWriter, r *http.Request, body []byte) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/tcc/"), "/")
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	phase := parts[1]
+
+	var req struct {
+		GID      string `json:"gid"`
+		BranchID string `json:"branch_id"`
+	}
+	_ = json.Unmarshal(body, &req)
+	if req.GID == "" {
+		req.GID = r.URL.Query().Get("gid")
+	}
+	if req.BranchID == "" {
+		req.BranchID = r.URL.Query().Get("branch_id")
+	}
+	key := parts[0] + "/" + req.GID + "/" + req.BranchID
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	last := s.barriers[key]
+
+	switch phase {
+	case "try":
+		if last == "cancel" {
+			// Hanging request: cancel already ran, refuse the late try
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte("FAILURE"))
+			return
+		}
+		if last == "" {
+			s.barriers[key] = "try"
+		}
+	case "confirm":
+		if last == "try" {
+			s.barriers[key] = "confirm"
+		}
+	case "cancel":
+		// Empty compensation when try never ran; succeed without side effects
+		if last != "confirm" {
+			s.barriers[key] = "cancel"
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	_, _ = w.Write([]byte("SUCCESS"))
+}
+
+// BarrierState returns the last TCC phase recorded for a resource branch
+func (s *Server) BarrierState(resource, gid, branchID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.barriers[resource+"/"+gid+"/"+branchID]
+}
+
+// call POSTs body to url and returns the status code and response body
+func call(ctx context.Context, url string, body []byte) (int, []byte) {
+	return callMethod(ctx, http.MethodPost, url, body)
+}
+
+// callMethod is call with an HTTP method; empty means POST
+func callMethod(ctx context.Context, method, url string, body []byte) (int, []byte) {
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return http.StatusBadGateway, []byte(err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return http.StatusBadGateway, []byte(err.Error())
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, data
+}
+
+// renderCompensate fills a compensation URL template such as
+// "http://svc/orders/{{.resp.id | path}}" from the action's JSON response
+func renderCompensate(rawURL string, resp []byte, gid, branchID string) (string, error) {
+	if !strings.Contains(rawURL, "{{") {
+		return rawURL, nil
+	}
+	funcs := template.FuncMap{"path": url.PathEscape, "query": url.QueryEscape}
+	tmpl, err := template.New("compensate").Funcs(funcs).Option("missingkey=error").Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	var decoded interface{}
+	if len(bytes.TrimSpace(resp)) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(resp))
+		decoder.UseNumber()
+		if err := decoder.Decode(&decoded); err != nil {
+			return "", err
+		}
+	}
+	var out strings.Builder
+	err = tmpl.Execute(&out, map[string]interface{}{"resp": decoded, "gid": gid, "branch_id": branchID})
+	return out.String(), err
+}
+
+// decodePayload accepts the integer-array form sent by /api/start as well as
+// base64 strings
+func decodePayload(raw json.RawMessage) []byte {
+	var ints []int
+	if err := json.Unmarshal(raw, &ints); err == nil {
+		payload := make([]byte, len(ints))
+		for i, b := range ints {
+			payload[i] = byte(b)
+		}
+		return payload
+	}
+	var payload []byte
+	_ = json.Unmarshal(raw, &payload)
+	return payload
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeConflict answers 409 with a TC error code
+func writeConflict(w http.ResponseWriter, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	_ = json.NewEncoder(w).Encode(map[string]string{"code": code, "error": "branch already finished"})
+}
@@ -0,0 +1,173 @@
+package seatatest_test
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	seata "github.com/seata-team/seata-go-client"
+	"github.com/seata-team/seata-go-client/seatatest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClient(s *seatatest.Server) *seata.Client {
+	config := seata.DefaultConfig()
+	config.HTTPEndpoint = s.URL
+	config.GrpcEndpoint = ""
+	return seata.NewClient(config)
+}
+
+func TestServerSaga(t *testing.T) {
+	s := seatatest.NewServer()
+	defer s.Close()
+	client := newClient(s)
+	defer client.Close()
+	ctx := context.Background()
+
+	tx, err := client.StartTransaction(ctx, seata.ModeSaga, []byte("order"))
+	assert.NoError(t, err)
+	assert.NoError(t, tx.AddBranch(ctx, "b1", s.URL+"/ok"))
+	assert.NoError(t, tx.AddBranch(ctx, "b2", s.URL+"/fail"))
+	assert.NoError(t, tx.Submit(ctx))
+
+	assert.Eventually(t, func() bool {
+		info, err := tx.GetInfo(ctx)
+		return err == nil && info.Status == seata.StatusAborted
+	}, 2*time.Second, 10*time.Millisecond)
+
+	info, _ := tx.GetInfo(ctx)
+	assert.Equal(t, []byte("order"), info.Payload)
+	assert.Len(t, s.Requests("/ok"), 1)
+	assert.Len(t, s.Requests("/fail"), 1)
+}
+
+func TestServerTCCBarrier(t *testing.T) {
+	s := seatatest.NewServer()
+	defer s.Close()
+	client := newClient(s)
+	defer client.Close()
+	ctx := context.Background()
+
+	tx, err := client.StartTransaction(ctx, seata.ModeTCC, nil)
+	assert.NoError(t, err)
+	gid := tx.GetGID()
+
+	// A failure sequence makes the first try fail and the second succeed
+	s.FailNext("/tcc/stock/try", seatatest.Response{Status: 500, Body: "boom"})
+	assert.Error(t, tx.Try(ctx, "b1", s.URL+"/tcc/stock/try", nil))
+	assert.NoError(t, tx.Try(ctx, "b1", s.URL+"/tcc/stock/try", nil))
+	assert.Equal(t, "try", s.BarrierState("stock", gid, "b1"))
+
+	assert.NoError(t, tx.Confirm(ctx, "b1"))
+	assert.Equal(t, "confirm", s.BarrierState("stock", gid, "b1"))
+	assert.Len(t, s.Requests("/tcc/stock/"), 3)
+
+	// Cancel without try is an empty compensation; a later try is refused
+	assert.NoError(t, tx.AddBranch(ctx, "b2", s.URL+"/tcc/pay/try"))
+	assert.NoError(t, tx.Cancel(ctx, "b2"))
+	assert.Equal(t, "cancel", s.BarrierState("pay", gid, "b2"))
+	assert.Error(t, tx.Try(ctx, "b2", s.URL+"/tcc/pay/try", nil))
+
+	s.Reset()
+	assert.Empty(t, s.Requests(""))
+}
+
+// specLocation is the TC OpenAPI document checked by TestOpenAPICompatibility;
+// SEATA_OPENAPI_SPEC points it at the spec a TC publishes
+func specLocation() string {
+	if location := os.Getenv("SEATA_OPENAPI_SPEC"); location != "" {
+		return location
+	}
+	return "../docs/openapi.json"
+}
+
+func TestOpenAPICompatibility(t *testing.T) {
+	spec, err := seatatest.LoadSpec(specLocation())
+	if !assert.NoError(t, err) {
+		return
+	}
+	s := seatatest.NewServer()
+	defer s.Close()
+	client := newClient(s)
+	defer client.Close()
+	ctx := context.Background()
+
+	// Every request the client sends must match the spec
+	tx, err := client.StartTransaction(ctx, seata.ModeSaga, []byte("order"))
+	assert.NoError(t, err)
+	assert.NoError(t, tx.AddBranchWithOptions(ctx, "b1", s.URL+"/ok", &seata.BranchOptions{
+		Compensate: s.URL + "/ok", Timeout: time.Second, MaxRetries: 2, Headers: map[string]string{"X-Tenant": "t1"},
+		Fallback: s.URL + "/ok", FallbackCompensate: s.URL + "/ok",
+	}))
+	assert.NoError(t, tx.ReportBranch(ctx, "b1", seata.BranchStatusPrepared, []byte("id")))
+	assert.NoError(t, tx.Try(ctx, "b2", s.URL+"/tcc/stock/try", []byte("sku")))
+	assert.NoError(t, tx.Confirm(ctx, "b2"))
+	assert.NoError(t, tx.SubmitWithOptions(ctx, &seata.SubmitOptions{MaxParallelBranches: 2, Ordered: true}))
+	aborted, err := client.StartTransaction(ctx, seata.ModeTCC, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, aborted.AbortExcept(ctx, "b1"))
+	_, err = client.GetTransaction(ctx, tx.GetGID())
+	assert.NoError(t, err)
+	_, err = client.ListTransactions(ctx, 10, 0, seata.StatusSubmitted)
+	assert.NoError(t, err)
+	_, err = client.GetTransactions(ctx, []string{tx.GetGID()})
+	assert.NoError(t, err)
+	alive, err := client.StartTransaction(ctx, seata.ModeSaga, nil)
+	assert.NoError(t, err)
+	_, err = alive.KeepAlive(ctx, time.Minute)
+	assert.NoError(t, err)
+
+	requests := s.Requests("/api/")
+	assert.NotEmpty(t, requests)
+	for _, r := range requests {
+		assert.NoError(t, spec.ValidateRequest(r))
+	}
+
+	// Every field the client decodes must exist in the spec
+	for name, value := range map[string]interface{}{"Transaction": seata.TransactionInfo{}, "Branch": seata.Branch{}} {
+		properties := spec.Properties(name)
+		typ := reflect.TypeOf(value)
+		for i := 0; i < typ.NumField(); i++ {
+			tag, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			if tag != "" && tag != "-" {
+				assert.Contains(t, properties, tag, "%s.%s", name, typ.Field(i).Name)
+			}
+		}
+	}
+
+	// The spec's example transaction decodes to the same values
+	example := spec.Schema("Transaction").Example
+	assert.NoError(t, spec.ValidateJSON(spec.Schema("Transaction"), example, "example"))
+	s.FailNext("/api/tx/gid-1", seatatest.Response{Body: string(example)})
+	info, err := client.GetTransaction(ctx, "gid-1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []byte("ok"), info.Payload)
+		assert.Equal(t, []byte("id"), info.Branches[0].ApplicationData)
+	}
+}
+
+func TestSpecValidation(t *testing.T) {
+	spec, err := seatatest.LoadSpec("../docs/openapi.json")
+	if !assert.NoError(t, err) {
+		return
+	}
+	valid := seatatest.Request{Method: "POST", Path: "/api/start", Body: []byte(`{"gid":"g","mode":"saga","payload":[1,2]}`)}
+	assert.NoError(t, spec.ValidateRequest(valid))
+
+	// A base64 payload instead of the integer array is drift
+	drifted := valid
+	drifted.Body = []byte(`{"gid":"g","mode":"saga","payload":"AQI="}`)
+	assert.ErrorContains(t, spec.ValidateRequest(drifted), "$.payload: expected array")
+
+	unknown := valid
+	unknown.Body = []byte(`{"gid":"g","mode":"saga","priority":1}`)
+	assert.ErrorContains(t, spec.ValidateRequest(unknown), `unknown property "priority"`)
+
+	assert.Error(t, spec.ValidateRequest(seatatest.Request{Method: "POST", Path: "/api/unknown"}))
+	assert.NoError(t, spec.ValidateRequest(seatatest.Request{Method: "GET", Path: "/api/tx/g1"}))
+	assert.NoError(t, spec.ValidateResponse("GET", "/api/tx", 200, []byte(`[{"gid":"g","mode":"tcc","status":"COMMITTED"}]`)))
+	assert.Error(t, spec.ValidateResponse("GET", "/api/tx", 200, []byte(`[{"gid":"g"}]`)))
+}
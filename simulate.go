@@ -0,0 +1,329 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Calls recorded by a simulation, besides PhaseTry, PhaseConfirm and
+// PhaseCancel
+const (
+	SimulatedAction     = "action"
+	SimulatedFallback   = "fallback"
+	SimulatedCompensate = "compensate"
+	SimulatedSkip       = "skip"
+)
+
+// Decisions the orchestrator takes after a simulated call
+const (
+	DecisionContinue       = "continue"        // the call succeeded, go on
+	DecisionRetry          = "retry"           // call again after Delay
+	DecisionFallback       = "fallback"        // run the step's FallbackAction
+	DecisionRollback       = "rollback"        // cancel or compensate the workflow
+	DecisionGiveUp         = "give-up"         // a cancel or compensation failed
+	DecisionSkip           = "skip"            // the step's Condition was false
+	DecisionNoCompensation = "no-compensation" // the step has nothing to compensate
+)
+
+// StepScript scripts the result of each attempt of a step's calls as
+// ResultSuccess, ResultFailure or ResultOngoing. Attempts beyond the
+// script repeat its last entry; an empty script always succeeds.
+type StepScript struct {
+	// Saga steps
+	Action     []string
+	Fallback   []string
+	Compensate []string
+	// TCC steps
+	Try     []string
+	Confirm []string
+	Cancel  []string
+}
+
+// SimulationScript holds the script of each step by branch ID
+type SimulationScript map[string]StepScript
+
+// SimulationDecision records one simulated call and what the orchestrator
+// did next
+type SimulationDecision struct {
+	BranchID string
+	Call     string // PhaseTry, PhaseConfirm, PhaseCancel or a Simulated* call
+	Attempt  int    // 1 for the first call
+	Result   string
+	Decision string
+	// Delay is the backoff before the next attempt of a DecisionRetry
+	Delay time.Duration
+}
+
+func (d SimulationDecision) String() string {
+	switch d.Decision {
+	case DecisionSkip:
+		return fmt.Sprintf("%s: skipped by its condition", d.BranchID)
+	case DecisionNoCompensation:
+		return fmt.Sprintf("%s %s: nothing to compensate", d.Call, d.BranchID)
+	}
+	s := fmt.Sprintf("%s %s #%d: %s -> %s", d.Call, d.BranchID, d.Attempt, d.Result, d.Decision)
+	if d.Decision == DecisionRetry {
+		s += " in " + d.Delay.String()
+	}
+	return s
+}
+
+// SimulationResult is the outcome of a simulated workflow execution
+type SimulationResult struct {
+	// Committed is false when the workflow was rolled back
+	Committed bool
+	Decisions []SimulationDecision
+	// Compensated lists the branches cancelled or compensated, in order
+	Compensated []string
+	// Failed lists the branches whose cancel or compensation failed
+	Failed []string
+	// Retries and BudgetExhausted report retry budget consumption as in
+	// ExecutionResult
+	Retries         int
+	BudgetExhausted bool
+}
+
+// Report renders the decisions one per line for review
+func (r *SimulationResult) Report() string {
+	var b strings.Builder
+	for _, d := range r.Decisions {
+		b.WriteString(d.String())
+		b.WriteByte('\n')
+	}
+	outcome := "committed"
+	if !r.Committed {
+		outcome = "rolled back"
+	}
+	fmt.Fprintf(&b, "outcome: %s", outcome)
+	if len(r.Failed) > 0 {
+		fmt.Fprintf(&b, ", compensation failed for %s", strings.Join(r.Failed, ", "))
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// Simulator runs workflows against scripted step results without any
+// network, applying the orchestration rules of SagaManager and TCCManager:
+// conditions, retries of ONGOING results with the execution's retry config
+// and budget, saga fallbacks, and the cancel or compensation of a failed
+// workflow. Steps run one at a time in declared order and backoff delays
+// are recorded without jitter instead of waited for, so a simulation is
+// deterministic.
+type Simulator struct {
+	Script  SimulationScript
+	Options *ExecutionOptions
+}
+
+// NewSimulator creates a simulator; nil options use DefaultExecutionOptions
+func NewSimulator(script SimulationScript, options *ExecutionOptions) *Simulator {
+	if options == nil {
+		options = DefaultExecutionOptions()
+	}
+	return &Simulator{Script: script, Options: options}
+}
+
+// simulationGID is the gid derived branch IDs are computed with
+const simulationGID = "simulation"
+
+// simulation is the state of one run
+type simulation struct {
+	*Simulator
+	result   *SimulationResult
+	budget   *retryBudget
+	attempts map[string]int
+}
+
+func (s *Simulator) start() *simulation {
+	return &simulation{
+		Simulator: s,
+		result:    &SimulationResult{Committed: true},
+		budget:    newRetryBudget(s.Options.RetryBudget),
+		attempts:  make(map[string]int),
+	}
+}
+
+// SimulateSaga simulates a saga. The TC's part is modelled too: an
+// ONGOING action is retried up to the step's BranchOptions.MaxRetries, or
+// the execution's retry config; a FAILURE or exhausted action runs the
+// step's FallbackAction if it has one and otherwise rolls the saga back,
+// compensating the completed steps in reverse order.
+func (s *Simulator) SimulateSaga(ctx context.Context, workflow *SagaWorkflow, payload []byte) (*SimulationResult, error) {
+	if err := workflow.Validate(); err != nil {
+		return nil, err
+	}
+	selected, skipped := selectSagaSteps(ctx, workflow, payload)
+	deriveSagaBranchIDs(workflow, selected.Steps, simulationGID)
+	sim := s.start()
+	sim.recordSkipped(skippedSagaSteps(workflow, skipped, simulationGID))
+
+	var completed []SagaStep
+	fallbacks := make(map[string]bool)
+	for _, step := range selected.Steps {
+		if step.Include != "" && step.child == nil {
+			return nil, fmt.Errorf("step includes saga %s; expand the workflow with WorkflowRegistry.ExpandSaga", step.Include)
+		}
+		maxRetries := sim.maxRetries()
+		if step.Options != nil && step.Options.MaxRetries > 0 {
+			maxRetries = step.Options.MaxRetries
+		}
+		fallback := step.FallbackAction
+		if fallback == "" && step.Options != nil {
+			fallback = step.Options.Fallback
+		}
+
+		ok := sim.call(step.BranchID, SimulatedAction, s.Script[step.BranchID].Action, maxRetries, fallback != "")
+		if !ok && fallback != "" {
+			ok = sim.call(step.BranchID, SimulatedFallback, s.Script[step.BranchID].Fallback, maxRetries, false)
+			fallbacks[step.BranchID] = ok
+		}
+		if !ok {
+			sim.result.Committed = false
+			break
+		}
+		completed = append(completed, step)
+	}
+
+	if !sim.result.Committed {
+		for i := len(completed) - 1; i >= 0; i-- {
+			step := completed[i]
+			compensate := step.Compensate
+			if fallbacks[step.BranchID] && step.FallbackCompensate != "" {
+				compensate = step.FallbackCompensate
+			}
+			if compensate == "" {
+				sim.record(SimulationDecision{BranchID: step.BranchID, Call: SimulatedCompensate, Decision: DecisionNoCompensation})
+				continue
+			}
+			sim.rollback(step.BranchID, SimulatedCompensate, s.Script[step.BranchID].Compensate)
+		}
+	}
+	return sim.finish(), nil
+}
+
+// SimulateTCC simulates a TCC workflow: every Try, retried while ONGOING,
+// then every Confirm; the first failure cancels all steps.
+func (s *Simulator) SimulateTCC(ctx context.Context, workflow *TCCWorkflow, payload []byte) (*SimulationResult, error) {
+	if err := workflow.Validate(); err != nil {
+		return nil, err
+	}
+	selected, skipped := selectTCCSteps(ctx, workflow, payload)
+	deriveTCCBranchIDs(workflow, selected.Steps, simulationGID)
+	sim := s.start()
+	sim.recordSkipped(skippedTCCSteps(workflow, skipped, simulationGID))
+
+	for _, step := range selected.Steps {
+		if !sim.call(step.BranchID, PhaseTry, s.Script[step.BranchID].Try, sim.maxRetries(), false) {
+			sim.result.Committed = false
+			break
+		}
+	}
+	if sim.result.Committed {
+		for _, step := range selected.Steps {
+			if !sim.call(step.BranchID, PhaseConfirm, s.Script[step.BranchID].Confirm, 0, false) {
+				sim.result.Committed = false
+				break
+			}
+		}
+	}
+	if !sim.result.Committed {
+		// Like executeCancelPhase, every step is cancelled
+		for _, step := range selected.Steps {
+			sim.rollback(step.BranchID, PhaseCancel, s.Script[step.BranchID].Cancel)
+		}
+	}
+	return sim.finish(), nil
+}
+
+// call simulates a call with its retries and reports whether it succeeded
+func (sim *simulation) call(branchID, call string, script []string, maxRetries int, hasFallback bool) bool {
+	var delay time.Duration
+	for attempt := 0; ; attempt++ {
+		result := sim.next(branchID, call, script)
+		d := SimulationDecision{BranchID: branchID, Call: call, Attempt: attempt + 1, Result: result}
+		if result == ResultOngoing && attempt < maxRetries {
+			delay = sim.backoff(attempt, delay)
+			if sim.budget.take(delay) {
+				d.Decision, d.Delay = DecisionRetry, delay
+				sim.record(d)
+				continue
+			}
+		}
+		switch {
+		case result == ResultSuccess:
+			d.Decision = DecisionContinue
+		case hasFallback:
+			d.Decision = DecisionFallback
+		default:
+			d.Decision = DecisionRollback
+		}
+		sim.record(d)
+		return result == ResultSuccess
+	}
+}
+
+// rollback simulates a cancel or compensation, which is not retried
+func (sim *simulation) rollback(branchID, call string, script []string) {
+	result := sim.next(branchID, call, script)
+	d := SimulationDecision{BranchID: branchID, Call: call, Attempt: 1, Result: result, Decision: DecisionContinue}
+	if result == ResultSuccess {
+		sim.result.Compensated = append(sim.result.Compensated, branchID)
+	} else {
+		d.Decision = DecisionGiveUp
+		sim.result.Failed = append(sim.result.Failed, branchID)
+	}
+	sim.record(d)
+}
+
+// next returns the scripted result of the next attempt of a call
+func (sim *simulation) next(branchID, call string, script []string) string {
+	key := branchID + "\x00" + call
+	attempt := sim.attempts[key]
+	sim.attempts[key]++
+	if len(script) == 0 {
+		return ResultSuccess
+	}
+	if attempt >= len(script) {
+		attempt = len(script) - 1
+	}
+	return strings.ToUpper(script[attempt])
+}
+
+// backoff is RetryManager.calculateBackoff without jitter
+func (sim *simulation) backoff(attempt int, prev time.Duration) time.Duration {
+	config := sim.Options.RetryConfig
+	if config == nil {
+		config = DefaultRetryConfig()
+	}
+	if config.Backoff != nil {
+		return config.Backoff.Next(attempt, prev)
+	}
+	return time.Duration(float64(config.RetryInterval) * math.Pow(config.BackoffFactor, float64(attempt)))
+}
+
+func (sim *simulation) maxRetries() int {
+	if sim.Options.RetryConfig == nil {
+		return DefaultRetryConfig().MaxRetries
+	}
+	return sim.Options.RetryConfig.MaxRetries
+}
+
+func (sim *simulation) record(d SimulationDecision) {
+	sim.result.Decisions = append(sim.result.Decisions, d)
+}
+
+func (sim *simulation) recordSkipped(branchIDs []string) {
+	for _, branchID := range branchIDs {
+		sim.record(SimulationDecision{BranchID: branchID, Call: SimulatedSkip, Decision: DecisionSkip})
+	}
+}
+
+func (sim *simulation) finish() *SimulationResult {
+	var execution ExecutionResult
+	sim.budget.fill(&execution)
+	sim.result.Retries = execution.Retries
+	sim.result.BudgetExhausted = execution.BudgetExhausted
+	return sim.result
+}
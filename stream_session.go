@@ -0,0 +1,198 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// sessionMethod is the bidirectional stream carrying session commands. Each
+// frame is a google.protobuf.Any: the client sends the unary request
+// messages, the server answers every request in order with the unary
+// response message or a google.rpc.Status.
+const sessionMethod = "/" + grpcServiceName + "/Session"
+
+// statusTypeURL identifies an error reply on the session stream
+const statusTypeURL = "type.googleapis.com/google.rpc.Status"
+
+// sessionMethods are the operations sent over the session stream
+var sessionMethods = map[string]bool{
+	"StartGlobal":   true,
+	"Submit":        true,
+	"Abort":         true,
+	"AddBranch":     true,
+	"BranchTry":     true,
+	"BranchSucceed": true,
+	"BranchFail":    true,
+}
+
+// errSessionUnsupported marks calls to replay unary because the server has
+// no session stream
+var errSessionUnsupported = errors.New("session stream not supported")
+
+type sessionReply struct {
+	frame *anypb.Any
+	err   error
+}
+
+// streamSession multiplexes unary writes over one long-lived bidirectional
+// stream. It is installed as the innermost unary interceptor, so stats and
+// debug interceptors see session calls like unary ones.
+type streamSession struct {
+	mu          sync.Mutex // serializes sends with the order of pending
+	stream      grpc.ClientStream
+	cancel      context.CancelFunc
+	pending     []chan sessionReply
+	replied     bool // the current stream has answered at least once
+	unsupported bool
+	closed      bool
+}
+
+func newStreamSession() *streamSession {
+	return &streamSession{}
+}
+
+// intercept sends session methods over the stream and everything else, or
+// everything once the server turned out not to support sessions, unary
+func (s *streamSession) intercept(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	message, ok := req.(proto.Message)
+	service := "/" + grpcServiceName + "/"
+	if !ok || !strings.HasPrefix(method, service) || !sessionMethods[strings.TrimPrefix(method, service)] {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	wait, err := s.send(cc, message)
+	if errors.Is(err, errSessionUnsupported) {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case r := <-wait:
+		if errors.Is(r.err, errSessionUnsupported) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		if r.err != nil {
+			return r.err
+		}
+		if r.frame.GetTypeUrl() == statusTypeURL {
+			return replyStatus(r.frame)
+		}
+		return r.frame.UnmarshalTo(reply.(proto.Message))
+	}
+}
+
+// send writes req to the stream, opening it first if needed, and returns
+// where its reply will be delivered
+func (s *streamSession) send(cc *grpc.ClientConn, req proto.Message) (<-chan sessionReply, error) {
+	frame, err := anypb.New(req)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode session request: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.unsupported {
+		return nil, errSessionUnsupported
+	}
+	if s.closed {
+		return nil, status.Error(codes.Canceled, "grpc: the client connection is closing")
+	}
+	if s.stream == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := cc.NewStream(ctx, &grpc.StreamDesc{StreamName: "Session", ServerStreams: true, ClientStreams: true}, sessionMethod)
+		if status.Code(err) == codes.Unimplemented {
+			cancel()
+			s.unsupported = true
+			return nil, errSessionUnsupported
+		}
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		s.stream, s.cancel, s.replied = stream, cancel, false
+		go s.receive(stream)
+	}
+	if err := s.stream.SendMsg(frame); err != nil {
+		// The receive loop sees the broken stream and fails the pending calls
+		return nil, err
+	}
+	wait := make(chan sessionReply, 1)
+	s.pending = append(s.pending, wait)
+	return wait, nil
+}
+
+// receive delivers replies to pending calls in order until stream breaks
+func (s *streamSession) receive(stream grpc.ClientStream) {
+	for {
+		frame := new(anypb.Any)
+		err := stream.RecvMsg(frame)
+
+		s.mu.Lock()
+		if err != nil {
+			if status.Code(err) == codes.Unimplemented && !s.replied {
+				// Nothing was processed; the calls are replayed unary
+				s.unsupported = true
+				err = errSessionUnsupported
+			}
+			for _, wait := range s.pending {
+				wait <- sessionReply{err: err}
+			}
+			s.pending = nil
+			if s.stream == stream {
+				s.cancel()
+				s.stream = nil
+			}
+			s.mu.Unlock()
+			return
+		}
+		s.replied = true
+		if len(s.pending) == 0 {
+			s.mu.Unlock()
+			continue
+		}
+		wait := s.pending[0]
+		s.pending = s.pending[1:]
+		s.mu.Unlock()
+		wait <- sessionReply{frame: frame}
+	}
+}
+
+// close ends the stream; calls still waiting fail with Canceled
+func (s *streamSession) close() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.stream != nil {
+		s.cancel()
+	}
+}
+
+// replyStatus converts a google.rpc.Status reply into a gRPC error
+func replyStatus(frame *anypb.Any) error {
+	message, err := frame.UnmarshalNew()
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to decode session error: %v", err)
+	}
+	st, ok := message.(interface {
+		GetCode() int32
+		GetMessage() string
+	})
+	if !ok {
+		return status.Error(codes.Internal, "malformed session error")
+	}
+	return status.Error(codes.Code(st.GetCode()), st.GetMessage())
+}
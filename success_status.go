@@ -0,0 +1,49 @@
+package seata
+
+import "context"
+
+// defaultSuccessStatus is accepted from branch calls when no SuccessStatus is configured
+var defaultSuccessStatus = []StatusRange{{Min: 200, Max: 200}}
+
+// isSuccessStatus reports whether code is in ranges, falling back to the
+// client's SuccessStatus and then to 200 only
+func (c *Client) isSuccessStatus(code int, ranges []StatusRange) bool {
+	if len(ranges) == 0 {
+		ranges = c.config.SuccessStatus
+	}
+	if len(ranges) == 0 {
+		ranges = defaultSuccessStatus
+	}
+	for _, r := range ranges {
+		if code >= r.Min && code <= r.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// stepValidator returns the validator checking the Try response of step
+func stepValidator(step TCCStep) ResponseValidator {
+	if step.Validator == nil && len(step.SuccessStatus) > 0 {
+		return (&SuccessCriteria{StatusRanges: step.SuccessStatus}).Validator()
+	}
+	return step.Validator
+}
+
+// confirmStep confirms step, accepting its own SuccessStatus; nats://
+// steps are confirmed over NATS
+func (tx *Transaction) confirmStep(ctx context.Context, step TCCStep) error {
+	if isNATSURL(step.Confirm) {
+		return tx.finishNATS(ctx, step.BranchID, step.Confirm, "confirm", BranchStatusSucceed)
+	}
+	return tx.finishBranch(ctx, step.BranchID, "/api/branch/succeed", "failed to execute confirm phase", step.SuccessStatus)
+}
+
+// cancelStep cancels step, accepting its own SuccessStatus; nats:// steps
+// are cancelled over NATS
+func (tx *Transaction) cancelStep(ctx context.Context, step TCCStep) error {
+	if isNATSURL(step.Cancel) {
+		return tx.finishNATS(ctx, step.BranchID, step.Cancel, "cancel", BranchStatusFailed)
+	}
+	return tx.finishBranch(ctx, step.BranchID, "/api/branch/fail", "failed to execute cancel phase", step.SuccessStatus)
+}
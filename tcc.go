@@ -2,8 +2,10 @@ package seata
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"sync"
+	"runtime/trace"
+	"time"
 )
 
 // TCCManager provides high-level TCC pattern management
@@ -20,31 +22,94 @@ func NewTCCManager(client *Client) *TCCManager {
 
 // ExecuteTCC executes a complete TCC workflow
 func (tm *TCCManager) ExecuteTCC(ctx context.Context, workflow *TCCWorkflow, payload []byte, options *ExecutionOptions) error {
+	_, err := tm.ExecuteTCCWithResult(ctx, workflow, payload, options)
+	return err
+}
+
+// ExecuteTCCWithResult executes a complete TCC workflow like ExecuteTCC and
+// reports the execution, including retry budget consumption. The result is
+// returned even when the workflow fails after the transaction was started.
+func (tm *TCCManager) ExecuteTCCWithResult(ctx context.Context, workflow *TCCWorkflow, payload []byte, options *ExecutionOptions) (*ExecutionResult, error) {
 	if options == nil {
 		options = DefaultExecutionOptions()
 	}
 
+	ctx, endProfile := startProfile(ctx, options, ModeTCC)
+	defer endProfile()
+	declared := workflow
+	workflow, skipped := selectTCCSteps(ctx, workflow, payload)
+	if err := tm.client.preflight(ctx, options.PreflightCheck, tccActions(workflow.Steps)); err != nil {
+		return nil, err
+	}
+
 	// Start global transaction
+	started := time.Now()
 	tx, err := tm.client.StartTransaction(ctx, ModeTCC, payload)
 	if err != nil {
-		return fmt.Errorf("failed to start TCC transaction: %w", err)
+		return nil, fmt.Errorf("failed to start TCC transaction: %w", err)
+	}
+	ctx = profileGID(ctx, options, tx.gid)
+	defer tx.StartHeartbeat(ctx, options.Heartbeat)()
+	tx.budget = newRetryBudget(options.RetryBudget)
+	tx.tcc = newTCCRecorder()
+	tx.timings = newTimingRecorder()
+	tx.timings.record(PhaseStart, "", started, nil)
+	deriveTCCBranchIDs(declared, workflow.Steps, tx.gid)
+	result := &ExecutionResult{GID: tx.gid, Skipped: skippedTCCSteps(declared, skipped, tx.gid)}
+	defer tx.budget.fill(result)
+	defer tx.timings.fill(result)
+
+	// Persist the execution before any branch reserves resources
+	tx.progress = newWorkflowProgress(options.Store, tx.gid)
+	if err := tx.progress.start(ctx, tx, options.WorkflowName, declared.Version, workflow.Steps); err != nil {
+		if abortErr := tx.Abort(ctx); abortErr != nil {
+			return result, errors.Join(err, abortErr)
+		}
+		return result, err
 	}
 
 	// Execute try phase for all branches
 	if err := tm.executeTryPhase(ctx, tx, workflow, payload, options); err != nil {
-		// Try phase failed, execute cancel phase for all branches
-		tm.executeCancelPhase(ctx, tx, workflow)
-		return fmt.Errorf("TCC try phase failed: %w", err)
+		// Try phase failed, execute cancel phase for all branches unless a
+		// rejected batch left nothing reserved
+		var batchErr *BatchTryError
+		if errors.As(err, &batchErr) {
+			tx.progress.finish(ctx, WorkflowStatusFailed)
+			tccErr := tx.tcc.fail(fmt.Errorf("%w: %w", ErrTryPhaseFailed, err))
+			result.TCC = tccErr.Result
+			return result, tccErr
+		}
+		return tm.rollback(ctx, tx, workflow, options, result, fmt.Errorf("%w: %w", ErrTryPhaseFailed, err))
 	}
 
-	// Try phase succeeded, execute confirm phase
+	// Try phase succeeded; an execution that cannot record it is cancelled,
+	// since resuming it would cancel the confirmed branches
+	if err := tx.progress.setStatus(ctx, WorkflowStatusConfirming); err != nil {
+		return tm.rollback(ctx, tx, workflow, options, result, fmt.Errorf("%w: %w", ErrConfirmPhaseFailed, err))
+	}
+
+	// Execute confirm phase
 	if err := tm.executeConfirmPhase(ctx, tx, workflow, options); err != nil {
 		// Confirm phase failed, execute cancel phase
-		tm.executeCancelPhase(ctx, tx, workflow)
-		return fmt.Errorf("TCC confirm phase failed: %w", err)
+		return tm.rollback(ctx, tx, workflow, options, result, fmt.Errorf("%w: %w", ErrConfirmPhaseFailed, err))
 	}
 
-	return nil
+	tx.progress.finish(ctx, WorkflowStatusCompleted)
+	tm.client.emit(EventTransactionCommitted, tx, "")
+	return result, nil
+}
+
+// rollback runs the cancel phase of a failed execution and returns its
+// result with the *TCCError for cause
+func (tm *TCCManager) rollback(ctx context.Context, tx *Transaction, workflow *TCCWorkflow, options *ExecutionOptions, result *ExecutionResult, cause error) (*ExecutionResult, error) {
+	if err := tx.progress.setStatus(ctx, WorkflowStatusCancelling); err != nil {
+		fmt.Printf("Warning: workflow %s: %v\n", tx.gid, err)
+	}
+	tm.executeCancelPhase(ctx, tx, workflow, options)
+	tx.progress.finish(ctx, WorkflowStatusFailed)
+	tccErr := tx.tcc.fail(cause)
+	result.TCC = tccErr.Result
+	return result, tccErr
 }
 
 // ExecuteTCCWithBarrier executes TCC with barrier pattern for idempotency
@@ -52,30 +117,56 @@ func (tm *TCCManager) ExecuteTCCWithBarrier(ctx context.Context, workflow *TCCWo
 	if options == nil {
 		options = DefaultExecutionOptions()
 	}
+	declared := workflow
+	workflow, _ = selectTCCSteps(ctx, workflow, payload)
+	if err := tm.client.preflight(ctx, options.PreflightCheck, tccActions(workflow.Steps)); err != nil {
+		return err
+	}
 
 	// Start global transaction
 	tx, err := tm.client.StartTransaction(ctx, ModeTCC, payload)
 	if err != nil {
 		return fmt.Errorf("failed to start TCC transaction: %w", err)
 	}
+	deriveTCCBranchIDs(declared, workflow.Steps, tx.gid)
+	defer tx.StartHeartbeat(ctx, options.Heartbeat)()
+	tx.tcc = newTCCRecorder()
 
 	// Execute try phase with barrier
 	if err := tm.executeTryPhaseWithBarrier(ctx, tx, workflow, payload, barrierID, options); err != nil {
-		tm.executeCancelPhase(ctx, tx, workflow)
-		return fmt.Errorf("TCC try phase with barrier failed: %w", err)
+		tm.executeCancelPhase(ctx, tx, workflow, options)
+		return tx.tcc.fail(fmt.Errorf("%w with barrier: %w", ErrTryPhaseFailed, err))
 	}
 
 	// Execute confirm phase with barrier
 	if err := tm.executeConfirmPhaseWithBarrier(ctx, tx, workflow, barrierID, options); err != nil {
-		tm.executeCancelPhase(ctx, tx, workflow)
-		return fmt.Errorf("TCC confirm phase with barrier failed: %w", err)
+		tm.executeCancelPhase(ctx, tx, workflow, options)
+		return tx.tcc.fail(fmt.Errorf("%w with barrier: %w", ErrConfirmPhaseFailed, err))
 	}
 
+	tm.client.emit(EventTransactionCommitted, tx, "")
 	return nil
 }
 
 // executeTryPhase executes the try phase for all branches
 func (tm *TCCManager) executeTryPhase(ctx context.Context, tx *Transaction, workflow *TCCWorkflow, payload []byte, options *ExecutionOptions) error {
+	defer trace.StartRegion(ctx, "seata.try").End()
+	if options.BatchTry {
+		items := make([]TryItem, len(workflow.Steps))
+		for i, step := range workflow.Steps {
+			items[i] = TryItem{BranchID: step.BranchID, Action: step.Try, Payload: payload}
+		}
+		_, err := tx.TryAll(ctx, items)
+		var batchErr *BatchTryError
+		if errors.As(err, &batchErr) {
+			for _, r := range batchErr.Results {
+				if r.Result != ResultSuccess {
+					tx.tcc.tryFailed(r.BranchID, fmt.Errorf("%w: %s", ErrBranchFailure, r.Error))
+				}
+			}
+		}
+		return err
+	}
 	if options.ParallelBranches {
 		return tm.executeTryPhaseParallel(ctx, tx, workflow, payload, options)
 	}
@@ -84,45 +175,143 @@ func (tm *TCCManager) executeTryPhase(ctx context.Context, tx *Transaction, work
 
 // executeTryPhaseParallel executes try phase in parallel
 func (tm *TCCManager) executeTryPhaseParallel(ctx context.Context, tx *Transaction, workflow *TCCWorkflow, payload []byte, options *ExecutionOptions) error {
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(workflow.Steps))
-	semaphore := make(chan struct{}, options.MaxConcurrency)
+	return forEachStep(len(workflow.Steps), options.MaxConcurrency, func(i int) error {
+		step := workflow.Steps[i]
+		if err := tm.tryBranch(ctx, tx, step, payload, options); err != nil {
+			tx.tcc.tryFailed(step.BranchID, err)
+			return fmt.Errorf("try phase failed for branch %s: %w", step.BranchID, err)
+		}
+		return nil
+	})
+}
 
+// executeTryPhaseSequential executes try phase sequentially
+func (tm *TCCManager) executeTryPhaseSequential(ctx context.Context, tx *Transaction, workflow *TCCWorkflow, payload []byte, options *ExecutionOptions) error {
 	for _, step := range workflow.Steps {
-		wg.Add(1)
-		go func(step TCCStep) {
-			defer wg.Done()
+		if err := tm.tryBranch(ctx, tx, step, payload, options); err != nil {
+			tx.tcc.tryFailed(step.BranchID, err)
+			return fmt.Errorf("try phase failed for branch %s: %w", step.BranchID, err)
+		}
+	}
+	return nil
+}
 
-			semaphore <- struct{}{}        // Acquire semaphore
-			defer func() { <-semaphore }() // Release semaphore
+// tryBranch runs the try phase of one step through the breaker of its
+// participant, failing fast with ErrCircuitOpen while the breaker is open
+func (tm *TCCManager) tryBranch(ctx context.Context, tx *Transaction, step TCCStep, payload []byte, options *ExecutionOptions) error {
+	if err := tm.client.allowParticipant(step.Try, options.CircuitBreaker); err != nil {
+		return err
+	}
+	started := time.Now()
+	err := tm.tryBranchWithTimeout(ctx, tx, step, payload, options)
+	tx.timings.record(PhaseTry, step.BranchID, started, err)
+	tx.progress.tried(ctx, step.BranchID, err)
+	tm.client.recordParticipant(step.Try, options.CircuitBreaker, err)
+	return err
+}
 
-			if err := tx.Try(ctx, step.BranchID, step.Try, payload); err != nil {
-				errChan <- fmt.Errorf("try phase failed for branch %s: %w", step.BranchID, err)
-			}
-		}(step)
+// tryBranchWithTimeout bounds the try of one step by its Timeout. A try
+// that exceeds it fails with ErrStepTimeout; the cancel phase that follows
+// fails the branch, so the TC does not keep it PREPARED.
+func (tm *TCCManager) tryBranchWithTimeout(ctx context.Context, tx *Transaction, step TCCStep, payload []byte, options *ExecutionOptions) error {
+	if step.Timeout <= 0 {
+		return tm.tryBranchWithRetry(ctx, tx, step, payload, options)
+	}
+	stepCtx, cancel := context.WithTimeout(ctx, step.Timeout)
+	defer cancel()
+	err := tm.tryBranchWithRetry(stepCtx, tx, step, payload, options)
+	if err == nil || !errors.Is(stepCtx.Err(), context.DeadlineExceeded) || ctx.Err() != nil {
+		return err
 	}
+	tx.tcc.timedOut(step.BranchID)
+	return fmt.Errorf("branch %s try exceeded %s: %w", step.BranchID, step.Timeout, ErrStepTimeout)
+}
 
-	wg.Wait()
-	close(errChan)
+// tryBranchWithRetry runs the try of one step. An ONGOING result is retried
+// with backoff, or polled when the participant returned a poll URL; a
+// FAILURE result is not retried and the cancel phase fails the branch.
+func (tm *TCCManager) tryBranchWithRetry(ctx context.Context, tx *Transaction, step TCCStep, payload []byte, options *ExecutionOptions) error {
+	retry := NewRetryManager(options.RetryConfig)
+	var delay time.Duration
+	for attempt := 0; ; attempt++ {
+		err := tm.client.withBranchSlot(ctx, step.Try, func() error {
+			return tx.TryWithValidator(ctx, step.BranchID, step.Try, payload, stepValidator(step))
+		})
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, ErrBranchFailure):
+			return err
+		case !errors.Is(err, ErrBranchOngoing):
+			return tm.reconcileTry(ctx, tx, step, err)
+		}
 
-	// Check for errors
-	for err := range errChan {
-		if err != nil {
+		var ongoing *OngoingError
+		if errors.As(err, &ongoing) && ongoing.PollURL != "" {
+			return tm.pollBranch(ctx, tx, step, ongoing.PollURL, options)
+		}
+		if !errors.Is(err, ErrBranchOngoing) || attempt >= retry.config.MaxRetries {
 			return err
 		}
-	}
 
-	return nil
+		delay = retry.calculateBackoff(attempt, delay)
+		if !tx.budget.take(delay) {
+			return fmt.Errorf("%w: %v", ErrRetryBudgetExhausted, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
 }
 
-// executeTryPhaseSequential executes try phase sequentially
-func (tm *TCCManager) executeTryPhaseSequential(ctx context.Context, tx *Transaction, workflow *TCCWorkflow, payload []byte, options *ExecutionOptions) error {
-	for _, step := range workflow.Steps {
-		if err := tx.Try(ctx, step.BranchID, step.Try, payload); err != nil {
-			return fmt.Errorf("try phase failed for branch %s: %w", step.BranchID, err)
+// pollBranch polls an asynchronous participant until it reports success or
+// failure, backing off between polls, or until the step's poll timeout.
+// Polls go to the participant directly, not through the TC client.
+func (tm *TCCManager) pollBranch(ctx context.Context, tx *Transaction, step TCCStep, pollURL string, options *ExecutionOptions) error {
+	timeout := step.PollTimeout
+	if timeout <= 0 {
+		timeout = options.Timeout
+	}
+	pollCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	validator := step.Validator
+	if validator == nil {
+		validator = DTMResultValidator()
+	}
+	retry := NewRetryManager(options.RetryConfig)
+	var delay time.Duration
+
+	for attempt := 0; ; attempt++ {
+		err := tm.client.withBranchSlot(pollCtx, pollURL, func() error {
+			req := tm.client.participants.R().SetContext(pollCtx)
+			HeadersFromContext(tx.BranchContext(pollCtx, step.BranchID), func(name, value string) { req.SetHeader(name, value) })
+			resp, err := req.Get(pollURL)
+			if err != nil {
+				return err
+			}
+			return validator(resp.StatusCode(), resp.Body())
+		})
+		if err == nil || errors.Is(err, ErrBranchFailure) {
+			return err
+		}
+
+		delay = retry.calculateBackoff(attempt, delay)
+		delay = min(max(delay, minPollInterval), maxPollInterval)
+		if !tx.budget.take(delay) {
+			return fmt.Errorf("branch %s still ONGOING: %w", step.BranchID, ErrRetryBudgetExhausted)
+		}
+		select {
+		case <-pollCtx.Done():
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("branch %s still ONGOING after %s: %w", step.BranchID, timeout, ErrBranchOngoing)
+		case <-time.After(delay):
 		}
 	}
-	return nil
 }
 
 // executeTryPhaseWithBarrier executes try phase with barrier pattern
@@ -130,14 +319,12 @@ func (tm *TCCManager) executeTryPhaseWithBarrier(ctx context.Context, tx *Transa
 	// Add barrier ID to payload
 	barrierPayload := append(payload, []byte(barrierID)...)
 
-	if options.ParallelBranches {
-		return tm.executeTryPhaseParallel(ctx, tx, workflow, barrierPayload, options)
-	}
-	return tm.executeTryPhaseSequential(ctx, tx, workflow, barrierPayload, options)
+	return tm.executeTryPhase(ctx, tx, workflow, barrierPayload, options)
 }
 
 // executeConfirmPhase executes the confirm phase for all branches
 func (tm *TCCManager) executeConfirmPhase(ctx context.Context, tx *Transaction, workflow *TCCWorkflow, options *ExecutionOptions) error {
+	defer trace.StartRegion(ctx, "seata.confirm").End()
 	if options.ParallelBranches {
 		return tm.executeConfirmPhaseParallel(ctx, tx, workflow, options)
 	}
@@ -146,41 +333,27 @@ func (tm *TCCManager) executeConfirmPhase(ctx context.Context, tx *Transaction,
 
 // executeConfirmPhaseParallel executes confirm phase in parallel
 func (tm *TCCManager) executeConfirmPhaseParallel(ctx context.Context, tx *Transaction, workflow *TCCWorkflow, options *ExecutionOptions) error {
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(workflow.Steps))
-	semaphore := make(chan struct{}, options.MaxConcurrency)
-
-	for _, step := range workflow.Steps {
-		wg.Add(1)
-		go func(step TCCStep) {
-			defer wg.Done()
-
-			semaphore <- struct{}{}        // Acquire semaphore
-			defer func() { <-semaphore }() // Release semaphore
-
-			if err := tx.Confirm(ctx, step.BranchID); err != nil {
-				errChan <- fmt.Errorf("confirm phase failed for branch %s: %w", step.BranchID, err)
-			}
-		}(step)
-	}
-
-	wg.Wait()
-	close(errChan)
-
-	// Check for errors
-	for err := range errChan {
+	return forEachStep(len(workflow.Steps), options.MaxConcurrency, func(i int) error {
+		step := workflow.Steps[i]
+		started := time.Now()
+		err := tm.confirmBranch(ctx, tx, step)
+		tx.timings.record(PhaseConfirm, step.BranchID, started, err)
 		if err != nil {
-			return err
+			tx.tcc.confirmFailed(step.BranchID, err)
+			return fmt.Errorf("confirm phase failed for branch %s: %w", step.BranchID, err)
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
 
 // executeConfirmPhaseSequential executes confirm phase sequentially
 func (tm *TCCManager) executeConfirmPhaseSequential(ctx context.Context, tx *Transaction, workflow *TCCWorkflow, options *ExecutionOptions) error {
 	for _, step := range workflow.Steps {
-		if err := tx.Confirm(ctx, step.BranchID); err != nil {
+		started := time.Now()
+		err := tm.confirmBranch(ctx, tx, step)
+		tx.timings.record(PhaseConfirm, step.BranchID, started, err)
+		if err != nil {
+			tx.tcc.confirmFailed(step.BranchID, err)
 			return fmt.Errorf("confirm phase failed for branch %s: %w", step.BranchID, err)
 		}
 	}
@@ -195,19 +368,17 @@ func (tm *TCCManager) executeConfirmPhaseWithBarrier(ctx context.Context, tx *Tr
 }
 
 // executeCancelPhase executes the cancel phase for all branches
-func (tm *TCCManager) executeCancelPhase(ctx context.Context, tx *Transaction, workflow *TCCWorkflow) {
-	var wg sync.WaitGroup
-
-	for _, step := range workflow.Steps {
-		wg.Add(1)
-		go func(step TCCStep) {
-			defer wg.Done()
-			// Execute cancel phase (ignore errors for cleanup)
-			tx.Cancel(ctx, step.BranchID)
-		}(step)
-	}
-
-	wg.Wait()
+func (tm *TCCManager) executeCancelPhase(ctx context.Context, tx *Transaction, workflow *TCCWorkflow, options *ExecutionOptions) {
+	defer trace.StartRegion(ctx, "seata.cancel").End()
+	forEachStep(len(workflow.Steps), options.MaxConcurrency, func(i int) error {
+		// Cancel every branch; failures are reported, not retried here
+		step := workflow.Steps[i]
+		started := time.Now()
+		err := tm.client.withBranchSlot(ctx, step.Cancel, func() error { return tx.cancelStep(ctx, step) })
+		tx.timings.record(PhaseCancel, step.BranchID, started, err)
+		tx.tcc.cancelled(step.BranchID, err)
+		return nil
+	})
 }
 
 // CreateTCCWorkflow creates a new TCC workflow
@@ -236,19 +407,21 @@ func (tw *TCCWorkflow) Validate() error {
 
 	seen := make(map[string]bool)
 	for _, step := range tw.Steps {
-		if step.BranchID == "" {
+		if step.BranchID == "" && !tw.DeriveBranchIDs {
 			return fmt.Errorf("branch ID cannot be empty")
 		}
-		if step.Try == "" {
-			return fmt.Errorf("try action cannot be empty")
-		}
-		if step.Confirm == "" {
-			return fmt.Errorf("confirm action cannot be empty")
-		}
-		if step.Cancel == "" {
-			return fmt.Errorf("cancel action cannot be empty")
+		if step.Include == "" {
+			if step.Try == "" {
+				return fmt.Errorf("try action cannot be empty")
+			}
+			if step.Confirm == "" {
+				return fmt.Errorf("confirm action cannot be empty")
+			}
+			if step.Cancel == "" {
+				return fmt.Errorf("cancel action cannot be empty")
+			}
 		}
-		if seen[step.BranchID] {
+		if step.BranchID != "" && seen[step.BranchID] {
 			return fmt.Errorf("duplicate branch ID: %s", step.BranchID)
 		}
 		seen[step.BranchID] = true
@@ -0,0 +1,124 @@
+package seata
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TCCResult reports what happened to the branches of a TCC execution that
+// failed, so callers can tell whether the cleanup completed
+type TCCResult struct {
+	// TryFailures and ConfirmFailures hold the error of each branch whose
+	// try or confirm failed
+	TryFailures     map[string]error
+	ConfirmFailures map[string]error
+	// TimedOut lists the branches whose try exceeded TCCStep.Timeout
+	TimedOut []string
+	// Cancelled lists the branches whose cancel succeeded
+	Cancelled []string
+	// CancelFailures holds the error of each branch whose cancel failed;
+	// those branches may still hold reservations
+	CancelFailures map[string]error
+}
+
+// CleanupComplete reports whether every branch was cancelled successfully
+func (r *TCCResult) CleanupComplete() bool {
+	return len(r.CancelFailures) == 0
+}
+
+// TCCError is returned by TCC executions that failed after the transaction
+// was started. Err is the try or confirm error; Result has the per-branch
+// outcomes including the cancel phase.
+type TCCError struct {
+	Err    error
+	Result *TCCResult
+}
+
+func (e *TCCError) Error() string {
+	if len(e.Result.CancelFailures) == 0 {
+		return e.Err.Error()
+	}
+	failed := make([]string, 0, len(e.Result.CancelFailures))
+	for branchID := range e.Result.CancelFailures {
+		failed = append(failed, branchID)
+	}
+	sort.Strings(failed)
+	return fmt.Sprintf("%v (cancel failed for %s)", e.Err, strings.Join(failed, ", "))
+}
+
+func (e *TCCError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrCompensationFailed when a cancel failed
+func (e *TCCError) Is(target error) bool {
+	return target == ErrCompensationFailed && len(e.Result.CancelFailures) > 0
+}
+
+// tccRecorder collects branch outcomes of one TCC execution
+type tccRecorder struct {
+	mu     sync.Mutex
+	result TCCResult
+}
+
+func newTCCRecorder() *tccRecorder {
+	return &tccRecorder{result: TCCResult{
+		TryFailures:     make(map[string]error),
+		ConfirmFailures: make(map[string]error),
+		CancelFailures:  make(map[string]error),
+	}}
+}
+
+func (r *tccRecorder) tryFailed(branchID string, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.TryFailures[branchID] = err
+}
+
+func (r *tccRecorder) timedOut(branchID string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.TimedOut = append(r.result.TimedOut, branchID)
+}
+
+func (r *tccRecorder) confirmFailed(branchID string, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.ConfirmFailures[branchID] = err
+}
+
+func (r *tccRecorder) cancelled(branchID string, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.result.CancelFailures[branchID] = err
+		return
+	}
+	r.result.Cancelled = append(r.result.Cancelled, branchID)
+}
+
+// fail wraps err with a copy of the recorded outcomes
+func (r *tccRecorder) fail(err error) *TCCError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := r.result
+	result.Cancelled = append([]string(nil), r.result.Cancelled...)
+	sort.Strings(result.Cancelled)
+	result.TimedOut = append([]string(nil), r.result.TimedOut...)
+	sort.Strings(result.TimedOut)
+	return &TCCError{Err: err, Result: &result}
+}
@@ -0,0 +1,121 @@
+package seata
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Phases of an execution reported in ExecutionResult.Timings
+const (
+	PhaseStart      = "start"
+	PhaseBranchAdd  = "branch_add"
+	PhaseSubmit     = "submit"
+	PhaseCompletion = "completion"
+	PhaseTry        = "try"
+	PhaseConfirm    = "confirm"
+	PhaseCancel     = "cancel"
+)
+
+// PhaseTiming is the duration of one phase of an execution; per-branch
+// phases carry the branch ID
+type PhaseTiming struct {
+	Phase    string
+	BranchID string
+	Start    time.Time
+	Duration time.Duration
+	// Failed is set when the phase returned an error
+	Failed bool
+}
+
+// SpanEvent is a phase timing shaped for an OpenTelemetry span event, e.g.
+// span.AddEvent(e.Name, trace.WithTimestamp(e.Time), trace.WithAttributes(...))
+type SpanEvent struct {
+	Name       string
+	Time       time.Time
+	Attributes map[string]string
+}
+
+// PhaseDuration returns the total time spent in phase, summed over branches
+func (r *ExecutionResult) PhaseDuration(phase string) time.Duration {
+	var total time.Duration
+	for _, timing := range r.Timings {
+		if timing.Phase == phase {
+			total += timing.Duration
+		}
+	}
+	return total
+}
+
+// ObserveTimings passes every timing to observe in seconds, e.g. to a
+// Prometheus histogram:
+//
+//	result.ObserveTimings(func(phase, branchID string, seconds float64) {
+//		phaseSeconds.WithLabelValues(phase).Observe(seconds)
+//	})
+func (r *ExecutionResult) ObserveTimings(observe func(phase, branchID string, seconds float64)) {
+	for _, timing := range r.Timings {
+		observe(timing.Phase, timing.BranchID, timing.Duration.Seconds())
+	}
+}
+
+// SpanEvents returns one event per timing, stamped with the end of the
+// phase, for attaching to the span of the execution
+func (r *ExecutionResult) SpanEvents() []SpanEvent {
+	events := make([]SpanEvent, len(r.Timings))
+	for i, timing := range r.Timings {
+		attributes := map[string]string{
+			"seata.gid":         r.GID,
+			"seata.phase":       timing.Phase,
+			"seata.duration_ms": formatMillis(timing.Duration),
+		}
+		if timing.BranchID != "" {
+			attributes["seata.branch_id"] = timing.BranchID
+		}
+		if timing.Failed {
+			attributes["seata.failed"] = "true"
+		}
+		events[i] = SpanEvent{Name: "seata." + timing.Phase, Time: timing.Start.Add(timing.Duration), Attributes: attributes}
+	}
+	return events
+}
+
+// timingRecorder collects the phase timings of one execution
+type timingRecorder struct {
+	mu      sync.Mutex
+	timings []PhaseTiming
+}
+
+func newTimingRecorder() *timingRecorder {
+	return &timingRecorder{}
+}
+
+// record adds a phase that started at start and ends now. A nil recorder
+// records nothing.
+func (t *timingRecorder) record(phase, branchID string, start time.Time, err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timings = append(t.timings, PhaseTiming{
+		Phase:    phase,
+		BranchID: branchID,
+		Start:    start,
+		Duration: time.Since(start),
+		Failed:   err != nil,
+	})
+}
+
+// fill copies the timings into result in start order
+func (t *timingRecorder) fill(result *ExecutionResult) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result.Timings = append([]PhaseTiming(nil), t.timings...)
+	sort.SliceStable(result.Timings, func(i, j int) bool {
+		return result.Timings[i].Start.Before(result.Timings[j].Start)
+	})
+}
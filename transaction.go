@@ -3,9 +3,16 @@ package seata
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 )
 
+// ErrDuplicateBranch is returned when a branch ID is added again with a
+// different action or compensation
+var ErrDuplicateBranch = errors.New("duplicate branch ID")
+
 // Transaction represents a global transaction
 type Transaction struct {
 	client   *Client
@@ -13,71 +20,141 @@ type Transaction struct {
 	mode     string
 	payload  []byte
 	branches []*Branch
+	// endpoints pinned at start when sticky sessions are enabled
+	httpBase string
+	grpc     *GrpcClient
+	// retry budget of the workflow execution driving this transaction
+	budget *retryBudget
+	// branch outcomes of the TCC execution driving this transaction
+	tcc *tccRecorder
+	// phase timings of the workflow execution driving this transaction
+	timings *timingRecorder
+	// persisted progress of the TCC execution driving this transaction
+	progress *workflowProgress
+	// steps of that execution skipped by their Condition
+	skipped []string
+	// compensate URLs of saga steps the orchestrator calls itself instead
+	// of registering them with the TC, by branch ID
+	compensations map[string]string
+	// correlation ID sent with every call of the transaction
+	correlationID string
+	// set once Submit succeeded; the payload cannot change afterwards
+	submitted bool
+	// gid of the transaction this one was forked from
+	parentGID string
+	// children started with Fork
+	forkMu   sync.Mutex
+	children []forkedChild
 }
 
 // Branch represents a branch transaction
 type Branch struct {
-	BranchID string `json:"branch_id"`
-	Action   string `json:"action"`
-	Status   string `json:"status,omitempty"`
+	BranchID        string `json:"branch_id"`
+	Action          string `json:"action"`
+	Compensate      string `json:"compensate,omitempty"`
+	Status          string `json:"status,omitempty"`
+	ApplicationData []byte `json:"application_data,omitempty"`
+	// ExecutedAction is the action the TC ran: Action, or its fallback
+	ExecutedAction string `json:"executed_action,omitempty"`
 }
 
 // TransactionInfo represents detailed transaction information
 type TransactionInfo struct {
-	GID         string   `json:"gid"`
-	Mode        string   `json:"mode"`
-	Status      string   `json:"status"`
-	Payload     []byte   `json:"payload"`
-	Branches    []Branch `json:"branches"`
-	UpdatedUnix int64    `json:"updated_unix"`
-	CreatedUnix int64    `json:"created_unix"`
+	GID             string   `json:"gid"`
+	Mode            string   `json:"mode"`
+	Status          string   `json:"status"`
+	Payload         []byte   `json:"payload"`
+	Branches        []Branch `json:"branches"`
+	BusinessKey     string   `json:"business_key,omitempty"`
+	ParentGID       string   `json:"parent_gid,omitempty"`
+	SkippedBranches []string `json:"skip_compensation,omitempty"`
+	// DeadlineUnix is when the TC times the transaction out; keep-alives
+	// move it forward
+	DeadlineUnix int64 `json:"deadline_unix,omitempty"`
+	UpdatedUnix  int64 `json:"updated_unix"`
+	CreatedUnix  int64 `json:"created_unix"`
+	// Labels are free-form annotations set with SetLabels
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
-// AddBranch adds a branch transaction to the global transaction
+// AddBranch adds a branch transaction to the global transaction. Adding a
+// branch ID again is a no-op when the action and compensation are the same,
+// and fails with ErrDuplicateBranch otherwise.
 func (tx *Transaction) AddBranch(ctx context.Context, branchID, action string) error {
-	// Use gRPC if available, otherwise fall back to HTTP
-	if tx.client.grpcClient != nil && tx.client.grpcClient.client != nil {
-		return tx.addBranchGRPC(ctx, branchID, action)
+	if added, err := tx.branchAdded(branchID, action, ""); added || err != nil {
+		return err
 	}
 
-	return tx.addBranchHTTP(ctx, branchID, action)
+	// Use gRPC if available, otherwise fall back to HTTP
+	gc := tx.grpcClient()
+	return tx.client.withFailover(gc, true, func() error {
+		return tx.addBranchGRPC(ctx, gc, branchID, action)
+	}, func() error {
+		return tx.addBranchHTTP(ctx, branchID, action, nil)
+	})
+}
+
+// AddBranchWithCompensation adds a saga branch together with its
+// compensation URL, so the TC calls it when rolling the saga back. The gRPC
+// API has no compensation field, so the branch is added over HTTP.
+func (tx *Transaction) AddBranchWithCompensation(ctx context.Context, branchID, action, compensate string) error {
+	return tx.AddBranchWithOptions(ctx, branchID, action, &BranchOptions{Compensate: compensate})
+}
+
+// branchAdded reports whether branchID was already added with the same
+// action and compensation
+func (tx *Transaction) branchAdded(branchID, action, compensate string) (bool, error) {
+	for _, branch := range tx.branches {
+		if branch.BranchID != branchID {
+			continue
+		}
+		if branch.Action != action || branch.Compensate != compensate {
+			return false, fmt.Errorf("%w: %s is already registered with action %s", ErrDuplicateBranch, branchID, branch.Action)
+		}
+		return true, nil
+	}
+	return false, nil
 }
 
 // addBranchHTTP adds a branch via HTTP
-func (tx *Transaction) addBranchHTTP(ctx context.Context, branchID, action string) error {
+func (tx *Transaction) addBranchHTTP(ctx context.Context, branchID, action string, options *BranchOptions) error {
 	req := map[string]interface{}{
 		"gid":       tx.gid,
 		"branch_id": branchID,
 		"action":    action,
 	}
+	options.apply(req)
 
 	resp, err := tx.client.httpClient.R().
-		SetContext(ctx).
+		SetContext(tx.BranchContext(ctx, branchID)).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
-		Post("/api/branch/add")
+		Post(tx.url("/api/branch/add"))
 
 	if err != nil {
 		return fmt.Errorf("failed to add branch: %w", err)
 	}
 
 	if resp.StatusCode() != 200 {
-		return fmt.Errorf("failed to add branch: status %d, body: %s", resp.StatusCode(), resp.String())
+		return tx.client.statusError("failed to add branch", resp)
 	}
 
 	// Add branch to local list
 	tx.branches = append(tx.branches, &Branch{
-		BranchID: branchID,
-		Action:   action,
+		BranchID:   branchID,
+		Action:     action,
+		Compensate: compensateOf(options),
 	})
+	tx.client.emit(EventBranchRegistered, tx, branchID)
 
 	return nil
 }
 
 // addBranchGRPC adds a branch via gRPC
-func (tx *Transaction) addBranchGRPC(ctx context.Context, branchID, action string) error {
-	_, err := tx.client.grpcClient.AddBranch(ctx, tx.gid, branchID, action)
+func (tx *Transaction) addBranchGRPC(ctx context.Context, gc *GrpcClient, branchID, action string) error {
+	_, err := gc.AddBranch(tx.BranchContext(ctx, branchID), tx.gid, branchID, action)
 	if err != nil {
+		tx.client.reportGrpcFailure(gc, err)
 		return fmt.Errorf("failed to add branch via gRPC: %w", err)
 	}
 
@@ -86,71 +163,218 @@ func (tx *Transaction) addBranchGRPC(ctx context.Context, branchID, action strin
 		BranchID: branchID,
 		Action:   action,
 	})
+	tx.client.emit(EventBranchRegistered, tx, branchID)
 
 	return nil
 }
 
+// SubmitOptions control how the TC executes the branches of a submitted transaction
+type SubmitOptions struct {
+	// MaxParallelBranches limits branches the TC runs concurrently; zero uses the server default
+	MaxParallelBranches int
+	// Ordered makes the TC run branches one by one in the order they were added
+	Ordered bool
+	// BranchTimeout bounds each branch call made by the TC; zero uses the server default
+	BranchTimeout time.Duration
+}
+
 // Submit submits the global transaction for execution
 func (tx *Transaction) Submit(ctx context.Context) error {
-	// Use gRPC if available, otherwise fall back to HTTP
-	if tx.client.grpcClient != nil && tx.client.grpcClient.client != nil {
-		return tx.submitGRPC(ctx)
+	return tx.SubmitWithOptions(ctx, nil)
+}
+
+// SubmitWithOptions submits the global transaction with execution options.
+// Options are only understood by the HTTP API, so gRPC is used only when
+// options is nil. A configured PreSubmitValidator can veto the submission
+// with ErrSubmitVetoed, and an Authorizer deny it with ErrUnauthorized.
+func (tx *Transaction) SubmitWithOptions(ctx context.Context, options *SubmitOptions) error {
+	if err := tx.validateSubmit(ctx); err != nil {
+		return err
+	}
+	if err := tx.client.authorize(ctx, AuthorizeSubmit, tx.mode, tx.gid, tx.payload); err != nil {
+		return err
+	}
+	if options != nil {
+		if options.MaxParallelBranches < 0 || options.BranchTimeout < 0 {
+			return fmt.Errorf("invalid submit options: limits cannot be negative")
+		}
+		return tx.submitHTTP(ctx, options)
 	}
 
-	return tx.submitHTTP(ctx)
+	// Use gRPC if available, otherwise fall back to HTTP
+	gc := tx.grpcClient()
+	return tx.client.withFailover(gc, true, func() error {
+		return tx.submitGRPC(ctx, gc)
+	}, func() error {
+		return tx.submitHTTP(ctx, nil)
+	})
 }
 
 // submitHTTP submits a transaction via HTTP
-func (tx *Transaction) submitHTTP(ctx context.Context) error {
+func (tx *Transaction) submitHTTP(ctx context.Context, options *SubmitOptions) error {
 	req := map[string]interface{}{
 		"gid": tx.gid,
 	}
+	if options != nil {
+		if options.MaxParallelBranches > 0 {
+			req["max_parallel_branches"] = options.MaxParallelBranches
+		}
+		if options.Ordered {
+			req["ordered"] = true
+		}
+		if options.BranchTimeout > 0 {
+			req["branch_timeout_ms"] = options.BranchTimeout.Milliseconds()
+		}
+	}
 
 	resp, err := tx.client.httpClient.R().
-		SetContext(ctx).
+		SetContext(tx.Context(ctx)).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
-		Post("/api/submit")
+		Post(tx.url("/api/submit"))
 
 	if err != nil {
 		return fmt.Errorf("failed to submit transaction: %w", err)
 	}
 
 	if resp.StatusCode() != 200 {
-		return fmt.Errorf("failed to submit transaction: status %d, body: %s", resp.StatusCode(), resp.String())
+		return tx.client.statusError("failed to submit transaction", resp)
 	}
 
+	tx.submitted = true
+	tx.client.emit(EventTransactionSubmitted, tx, "")
 	return nil
 }
 
 // submitGRPC submits a transaction via gRPC
-func (tx *Transaction) submitGRPC(ctx context.Context) error {
-	_, err := tx.client.grpcClient.Submit(ctx, tx.gid)
+func (tx *Transaction) submitGRPC(ctx context.Context, gc *GrpcClient) error {
+	_, err := gc.Submit(tx.Context(ctx), tx.gid)
 	if err != nil {
+		tx.client.reportGrpcFailure(gc, err)
 		return fmt.Errorf("failed to submit transaction via gRPC: %w", err)
 	}
 
+	tx.submitted = true
+	tx.client.emit(EventTransactionSubmitted, tx, "")
 	return nil
 }
 
 // Abort aborts the global transaction
 func (tx *Transaction) Abort(ctx context.Context) error {
+	return tx.AbortExcept(ctx)
+}
+
+// AbortExcept aborts the global transaction without compensating the
+// listed branches, e.g. notification branches that cannot be undone.
+// The TC reports them in TransactionInfo.SkippedBranches. Skipping is only
+// understood by the HTTP API, so gRPC is used only when no branch is listed.
+//
+// A configured Authorizer can deny the abort with ErrUnauthorized.
+// With Config.AbortEscalation set, an abort that failed because the TC was
+// unreachable or answered with a server error is retried in the background,
+// and the returned error also matches ErrAbortQueued. Children forked with
+// ForkOptions.CascadeAbort are aborted after the parent.
+func (tx *Transaction) AbortExcept(ctx context.Context, skipBranchIDs ...string) error {
+	if err := tx.client.authorize(ctx, AuthorizeAbort, tx.mode, tx.gid, tx.payload); err != nil {
+		return err
+	}
+	err := tx.abort(ctx, skipBranchIDs)
+	if err != nil && tx.client.abortEscalator != nil && abortRetryable(err) {
+		tx.client.abortEscalator.enqueue(context.WithoutCancel(ctx), tx, skipBranchIDs, false, err)
+		return fmt.Errorf("%w (%w)", err, ErrAbortQueued)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.abortChildren(ctx)
+}
+
+// abort aborts the transaction once over the available transports
+func (tx *Transaction) abort(ctx context.Context, skipBranchIDs []string) error {
+	var err error
+	if len(skipBranchIDs) > 0 {
+		err = tx.abortHTTP(ctx, skipBranchIDs)
+	} else {
+		gc := tx.grpcClient()
+		err = tx.client.withFailover(gc, true, func() error {
+			return tx.abortGRPC(ctx, gc)
+		}, func() error {
+			return tx.abortHTTP(ctx, nil)
+		})
+	}
+	if err == nil {
+		tx.client.emit(EventTransactionAborted, tx, "")
+	}
+	return err
+}
+
+// CancelSaga asks the TC to stop executing the remaining branches of the
+// submitted saga and compensate the completed ones right away. Unlike
+// Abort, which is meant for transactions not yet submitted, it applies to
+// sagas already running; the transaction moves to CANCELLING and ends
+// ABORTED. The gRPC API has no cancellation, so it is sent over HTTP.
+//
+// Like AbortExcept, a configured Authorizer can deny it with
+// ErrUnauthorized, a failed cancellation is retried in the background with
+// Config.AbortEscalation, and children forked with CascadeAbort are aborted
+// afterwards.
+func (tx *Transaction) CancelSaga(ctx context.Context) error {
+	if tx.mode != ModeSaga {
+		return fmt.Errorf("cannot cancel a %s transaction: only sagas can be cancelled", tx.mode)
+	}
+	if err := tx.client.authorize(ctx, AuthorizeAbort, tx.mode, tx.gid, tx.payload); err != nil {
+		return err
+	}
+	err := tx.cancelSaga(ctx)
+	if err != nil && tx.client.abortEscalator != nil && abortRetryable(err) {
+		tx.client.abortEscalator.enqueue(context.WithoutCancel(ctx), tx, nil, true, err)
+		return fmt.Errorf("%w (%w)", err, ErrAbortQueued)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.abortChildren(ctx)
+}
+
+// cancelSaga cancels the saga once
+func (tx *Transaction) cancelSaga(ctx context.Context) error {
+	return tx.client.withFailover(nil, true, nil, func() error {
+		resp, err := tx.client.httpClient.R().
+			SetContext(tx.Context(ctx)).
+			SetHeader("Content-Type", "application/json").
+			SetBody(map[string]interface{}{"gid": tx.gid}).
+			Post(tx.url("/api/cancel"))
+		if err != nil {
+			return fmt.Errorf("failed to cancel saga: %w", err)
+		}
+		if resp.StatusCode() != 200 {
+			return tx.client.statusError("failed to cancel saga", resp)
+		}
+		return nil
+	})
+}
+
+// abortHTTP aborts a transaction via HTTP
+func (tx *Transaction) abortHTTP(ctx context.Context, skipBranchIDs []string) error {
 	req := map[string]interface{}{
 		"gid": tx.gid,
 	}
+	if len(skipBranchIDs) > 0 {
+		req["skip_compensation"] = skipBranchIDs
+	}
 
 	resp, err := tx.client.httpClient.R().
-		SetContext(ctx).
+		SetContext(tx.Context(ctx)).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
-		Post("/api/abort")
+		Post(tx.url("/api/abort"))
 
 	if err != nil {
 		return fmt.Errorf("failed to abort transaction: %w", err)
 	}
 
 	if resp.StatusCode() != 200 {
-		return fmt.Errorf("failed to abort transaction: status %d, body: %s", resp.StatusCode(), resp.String())
+		return tx.client.statusError("failed to abort transaction", resp)
 	}
 
 	return nil
@@ -175,6 +399,17 @@ func (tx *Transaction) GetBranches() []*Branch {
 
 // Try executes the try phase of a TCC branch
 func (tx *Transaction) Try(ctx context.Context, branchID, action string, payload []byte) error {
+	return tx.TryWithValidator(ctx, branchID, action, payload, nil)
+}
+
+// TryWithValidator executes the try phase of a TCC branch and checks the
+// response with validator. A nil validator falls back to the client's
+// ResponseValidator, or to requiring a SuccessStatus when none is configured.
+// nats:// actions are published over NATS instead (see NATSConfig).
+func (tx *Transaction) TryWithValidator(ctx context.Context, branchID, action string, payload []byte, validator ResponseValidator) error {
+	if isNATSURL(action) {
+		return tx.tryNATS(ctx, branchID, action, payload)
+	}
 	encodedPayload := base64.StdEncoding.EncodeToString(payload)
 
 	req := map[string]interface{}{
@@ -185,17 +420,27 @@ func (tx *Transaction) Try(ctx context.Context, branchID, action string, payload
 	}
 
 	resp, err := tx.client.httpClient.R().
-		SetContext(ctx).
+		SetContext(tx.BranchContext(ctx, branchID)).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
-		Post("/api/branch/try")
+		Post(tx.url("/api/branch/try"))
 
 	if err != nil {
 		return fmt.Errorf("failed to execute try phase: %w", err)
 	}
 
-	if resp.StatusCode() != 200 {
-		return fmt.Errorf("failed to execute try phase: status %d, body: %s", resp.StatusCode(), resp.String())
+	if validator == nil {
+		validator = tx.client.config.ResponseValidator
+	}
+	if validator != nil {
+		if err := validator(resp.StatusCode(), resp.Body()); err != nil {
+			return fmt.Errorf("failed to execute try phase: %w", err)
+		}
+		return nil
+	}
+
+	if !tx.client.isSuccessStatus(resp.StatusCode(), nil) {
+		return tx.client.statusError("failed to execute try phase", resp)
 	}
 
 	return nil
@@ -203,95 +448,100 @@ func (tx *Transaction) Try(ctx context.Context, branchID, action string, payload
 
 // Confirm executes the confirm phase of a TCC branch
 func (tx *Transaction) Confirm(ctx context.Context, branchID string) error {
-	req := map[string]interface{}{
-		"gid":       tx.gid,
-		"branch_id": branchID,
-	}
-
-	resp, err := tx.client.httpClient.R().
-		SetContext(ctx).
-		SetHeader("Content-Type", "application/json").
-		SetBody(req).
-		Post("/api/branch/succeed")
-
-	if err != nil {
-		return fmt.Errorf("failed to execute confirm phase: %w", err)
-	}
-
-	if resp.StatusCode() != 200 {
-		return fmt.Errorf("failed to execute confirm phase: status %d, body: %s", resp.StatusCode(), resp.String())
-	}
-
-	return nil
+	return tx.finishBranch(ctx, branchID, "/api/branch/succeed", "failed to execute confirm phase", nil)
 }
 
 // Cancel executes the cancel phase of a TCC branch
 func (tx *Transaction) Cancel(ctx context.Context, branchID string) error {
-	req := map[string]interface{}{
-		"gid":       tx.gid,
-		"branch_id": branchID,
-	}
-
-	resp, err := tx.client.httpClient.R().
-		SetContext(ctx).
-		SetHeader("Content-Type", "application/json").
-		SetBody(req).
-		Post("/api/branch/fail")
-
-	if err != nil {
-		return fmt.Errorf("failed to execute cancel phase: %w", err)
-	}
-
-	if resp.StatusCode() != 200 {
-		return fmt.Errorf("failed to execute cancel phase: status %d, body: %s", resp.StatusCode(), resp.String())
-	}
-
-	return nil
+	return tx.finishBranch(ctx, branchID, "/api/branch/fail", "failed to execute cancel phase", nil)
 }
 
 // BranchSucceed marks a branch as successful
 func (tx *Transaction) BranchSucceed(ctx context.Context, branchID string) error {
+	return tx.finishBranch(ctx, branchID, "/api/branch/succeed", "failed to mark branch as successful", nil)
+}
+
+// BranchFail marks a branch as failed
+func (tx *Transaction) BranchFail(ctx context.Context, branchID string) error {
+	return tx.finishBranch(ctx, branchID, "/api/branch/fail", "failed to mark branch as failed", nil)
+}
+
+// finishBranch confirms or cancels a branch. A status in successStatus (the
+// client's SuccessStatus when nil) is a success. A branch already in the
+// requested state is a success too unless Config.StrictBranchFinish is set;
+// one in the opposite state is an ErrBranchFinishConflict.
+func (tx *Transaction) finishBranch(ctx context.Context, branchID, path, msg string, successStatus []StatusRange) error {
 	req := map[string]interface{}{
 		"gid":       tx.gid,
 		"branch_id": branchID,
 	}
 
 	resp, err := tx.client.httpClient.R().
-		SetContext(ctx).
+		SetContext(tx.BranchContext(ctx, branchID)).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
-		Post("/api/branch/succeed")
+		Post(tx.url(path))
 
 	if err != nil {
-		return fmt.Errorf("failed to mark branch as successful: %w", err)
+		return fmt.Errorf("%s: %w", msg, err)
 	}
 
-	if resp.StatusCode() != 200 {
-		return fmt.Errorf("failed to mark branch as successful: status %d, body: %s", resp.StatusCode(), resp.String())
+	if tx.client.isSuccessStatus(resp.StatusCode(), successStatus) {
+		if path == "/api/branch/succeed" {
+			tx.client.emit(EventBranchSucceeded, tx, branchID)
+		} else {
+			tx.client.emit(EventBranchFailed, tx, branchID)
+		}
+		return nil
 	}
-
-	return nil
+	switch same, opposite := finishOutcome(path, resp); {
+	case opposite:
+		return fmt.Errorf("%w (%w)", tx.client.statusError(msg, resp), ErrBranchFinishConflict)
+	case same && tx.client.config.StrictBranchFinish:
+		return fmt.Errorf("%w (%w)", tx.client.statusError(msg, resp), ErrBranchAlreadyFinished)
+	case same:
+		return nil
+	}
+	return tx.client.statusError(msg, resp)
 }
 
-// BranchFail marks a branch as failed
-func (tx *Transaction) BranchFail(ctx context.Context, branchID string) error {
+// ReportBranch reports the phase one result of a branch together with
+// application data (e.g. reserved stock ids). The TC stores the data and
+// forwards it to the branch's confirm/cancel handlers.
+func (tx *Transaction) ReportBranch(ctx context.Context, branchID, status string, applicationData []byte) error {
+	switch status {
+	case BranchStatusPrepared, BranchStatusSucceed, BranchStatusFailed:
+	default:
+		return fmt.Errorf("invalid branch status: %s", status)
+	}
+
 	req := map[string]interface{}{
-		"gid":       tx.gid,
-		"branch_id": branchID,
+		"gid":              tx.gid,
+		"branch_id":        branchID,
+		"status":           status,
+		"application_data": base64.StdEncoding.EncodeToString(applicationData),
 	}
 
 	resp, err := tx.client.httpClient.R().
-		SetContext(ctx).
+		SetContext(tx.BranchContext(ctx, branchID)).
 		SetHeader("Content-Type", "application/json").
 		SetBody(req).
-		Post("/api/branch/fail")
+		Post(tx.url("/api/branch/report"))
 
 	if err != nil {
-		return fmt.Errorf("failed to mark branch as failed: %w", err)
+		return fmt.Errorf("failed to report branch: %w", err)
 	}
 
-	if resp.StatusCode() != 200 {
-		return fmt.Errorf("failed to mark branch as failed: status %d, body: %s", resp.StatusCode(), resp.String())
+	if !tx.client.isSuccessStatus(resp.StatusCode(), nil) {
+		return tx.client.statusError("failed to report branch", resp)
+	}
+
+	// Keep the local branch list in sync
+	for _, branch := range tx.branches {
+		if branch.BranchID == branchID {
+			branch.Status = status
+			branch.ApplicationData = applicationData
+		}
 	}
 
 	return nil
@@ -299,5 +549,19 @@ func (tx *Transaction) BranchFail(ctx context.Context, branchID string) error {
 
 // GetInfo retrieves the current transaction information
 func (tx *Transaction) GetInfo(ctx context.Context) (*TransactionInfo, error) {
-	return tx.client.GetTransaction(ctx, tx.gid)
+	return tx.client.GetTransaction(tx.Context(ctx), tx.gid)
+}
+
+// url resolves an API path against the pinned HTTP endpoint, if any
+func (tx *Transaction) url(path string) string {
+	return joinURL(tx.httpBase, path)
+}
+
+// grpcClient returns the pinned gRPC client, or the client's current one
+func (tx *Transaction) grpcClient() *GrpcClient {
+	if tx.grpc != nil {
+		return tx.grpc
+	}
+	_, gc := tx.client.currentTargets()
+	return gc
 }
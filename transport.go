@@ -0,0 +1,169 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Transport modes for Config.Transport
+const (
+	// TransportAuto uses both transports with failover
+	TransportAuto = ""
+	// TransportGRPCOnly never talks to the TC over HTTP; operations the gRPC
+	// API does not offer fail with ErrTransportDisabled
+	TransportGRPCOnly = "grpc-only"
+	// TransportHTTPOnly never dials the TC over gRPC
+	TransportHTTPOnly = "http-only"
+	// TransportConnect reaches the TC's gRPC API with the Connect protocol
+	// over HTTP at GrpcEndpoint (e.g. https://tc.example.com), for TCs behind
+	// Connect or gRPC-Web proxies. HTTP API failover works as in auto mode.
+	TransportConnect = "connect"
+)
+
+// ErrTransportDisabled is returned when an operation needs a transport that
+// Config.Transport rules out
+var ErrTransportDisabled = errors.New("transport disabled by Config.Transport")
+
+// Transport preferences for Config.PreferredTransport
+const (
+	// TransportPreferGRPC sends every operation the gRPC API supports over gRPC
+	TransportPreferGRPC = "grpc"
+	// TransportPreferHTTP sends every operation over HTTP first
+	TransportPreferHTTP = "http"
+)
+
+// transportUnavailable reports whether err means the transport could not be
+// reached, so the call was not processed and may be sent over the other one
+func transportUnavailable(err error) bool {
+	if s, ok := status.FromError(err); ok && s.Code() == codes.Unavailable {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// preferGRPC reports whether an operation goes over gRPC first. Writes use
+// gRPC unless HTTP is preferred; reads use HTTP unless gRPC is preferred.
+func (c *Client) preferGRPC(write bool) bool {
+	switch c.config.PreferredTransport {
+	case TransportPreferGRPC:
+		return true
+	case TransportPreferHTTP:
+		return false
+	}
+	return write
+}
+
+// withFailover runs an operation over the preferred transport and, when that
+// transport is unreachable, over the other one. Without a connected gRPC
+// client only viaHTTP runs; Config.Transport can restrict it to one side.
+func (c *Client) withFailover(gc *GrpcClient, write bool, viaGRPC, viaHTTP func() error) error {
+	switch c.config.Transport {
+	case TransportHTTPOnly:
+		return viaHTTP()
+	case TransportGRPCOnly:
+		if gc == nil || gc.client == nil {
+			return fmt.Errorf("%w: HTTP is not allowed and the gRPC client is not connected", ErrTransportDisabled)
+		}
+		if err := viaGRPC(); err != nil {
+			if transportUnavailable(err) {
+				return fmt.Errorf("gRPC is unavailable and HTTP fallback is disabled in gRPC-only mode: %w", err)
+			}
+			return err
+		}
+		return nil
+	}
+	if gc == nil || gc.client == nil {
+		return viaHTTP()
+	}
+	first, second := viaHTTP, viaGRPC
+	if c.preferGRPC(write) {
+		first, second = viaGRPC, viaHTTP
+	}
+	err := first()
+	if err == nil || !transportUnavailable(err) {
+		return err
+	}
+	if fallbackErr := second(); fallbackErr != nil {
+		return errors.Join(err, fallbackErr)
+	}
+	return nil
+}
+
+// installTransportGuard rejects HTTP requests to the TC in gRPC-only mode.
+// Calls to participant URLs are not affected.
+func (c *Client) installTransportGuard() {
+	if c.config.Transport != TransportGRPCOnly {
+		return
+	}
+	c.httpClient.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+		if c.isTCURL(req.URL) {
+			return fmt.Errorf("%w: %s %s needs HTTP, which is not allowed in gRPC-only mode", ErrTransportDisabled, req.Method, req.URL)
+		}
+		return nil
+	})
+}
+
+// isTCURL reports whether rawURL addresses the TC HTTP API, including its
+// configured or discovered read replicas
+func (c *Client) isTCURL(rawURL string) bool {
+	if strings.HasPrefix(rawURL, "/") || (c.config.HTTPEndpoint != "" && strings.HasPrefix(rawURL, c.config.HTTPEndpoint)) {
+		return true
+	}
+	if c.replicas.contains(rawURL) {
+		return true
+	}
+	c.lbMu.RLock()
+	defer c.lbMu.RUnlock()
+	for _, addr := range c.httpAddrs {
+		if strings.HasPrefix(rawURL, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// getTransactionGRPC retrieves a transaction via gRPC
+func (c *Client) getTransactionGRPC(ctx context.Context, gc *GrpcClient, gid string) (*TransactionInfo, error) {
+	txInfo, err := gc.Get(ctx, gid)
+	if err != nil {
+		c.reportGrpcFailure(gc, err)
+		return nil, fmt.Errorf("failed to get transaction via gRPC: %w", err)
+	}
+	if err := c.decodeTransactionInfo(txInfo); err != nil {
+		return nil, err
+	}
+	return txInfo, nil
+}
+
+// listTransactionsGRPC lists transactions via gRPC
+func (c *Client) listTransactionsGRPC(ctx context.Context, gc *GrpcClient, limit, offset int, status string) ([]*TransactionInfo, error) {
+	transactions, err := gc.List(ctx, limit, offset, status)
+	if err != nil {
+		c.reportGrpcFailure(gc, err)
+		return nil, fmt.Errorf("failed to list transactions via gRPC: %w", err)
+	}
+	for _, txInfo := range transactions {
+		if err := c.decodeTransactionInfo(txInfo); err != nil {
+			return nil, err
+		}
+	}
+	return transactions, nil
+}
+
+// abortGRPC aborts a transaction via gRPC
+func (tx *Transaction) abortGRPC(ctx context.Context, gc *GrpcClient) error {
+	_, err := gc.Abort(tx.Context(ctx), tx.gid)
+	if err != nil {
+		tx.client.reportGrpcFailure(gc, err)
+		return fmt.Errorf("failed to abort transaction via gRPC: %w", err)
+	}
+	return nil
+}
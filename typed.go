@@ -0,0 +1,108 @@
+package seata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// TypedSagaStep is a saga step whose output is merged into a typed result
+type TypedSagaStep[TOut any] struct {
+	BranchID   string
+	Action     string
+	Compensate string
+	// OnResult merges the application data reported by the branch into out.
+	// When nil, non-empty data is decoded as JSON directly into out.
+	OnResult func(out *TOut, data []byte) error
+}
+
+// TypedSagaWorkflow is a saga taking a TIn payload and producing a TOut result
+type TypedSagaWorkflow[TIn, TOut any] struct {
+	Steps []TypedSagaStep[TOut]
+}
+
+// AddStep adds a step to the typed workflow
+func (tw *TypedSagaWorkflow[TIn, TOut]) AddStep(branchID, action, compensate string, onResult func(out *TOut, data []byte) error) {
+	tw.Steps = append(tw.Steps, TypedSagaStep[TOut]{
+		BranchID:   branchID,
+		Action:     action,
+		Compensate: compensate,
+		OnResult:   onResult,
+	})
+}
+
+// untyped returns the equivalent SagaWorkflow
+func (tw *TypedSagaWorkflow[TIn, TOut]) untyped() *SagaWorkflow {
+	workflow := &SagaWorkflow{}
+	for _, step := range tw.Steps {
+		workflow.AddStep(step.BranchID, step.Action, step.Compensate)
+	}
+	return workflow
+}
+
+// validated returns the validated untyped workflow. Steps is exported and
+// may change between executions, so it is validated every time.
+func (tw *TypedSagaWorkflow[TIn, TOut]) validated() (*SagaWorkflow, error) {
+	workflow := tw.untyped()
+	if err := workflow.Validate(); err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// ExecuteSagaT runs a typed saga: input is marshaled to JSON as the
+// transaction payload and, once the saga commits, the application data of
+// each branch is folded into the returned TOut in step order.
+//
+// It is a function rather than a SagaManager method because Go methods
+// cannot declare type parameters.
+func ExecuteSagaT[TIn, TOut any](ctx context.Context, sm *SagaManager, workflow *TypedSagaWorkflow[TIn, TOut], input TIn, options *ExecutionOptions) (TOut, error) {
+	var out TOut
+
+	untyped, err := workflow.validated()
+	if err != nil {
+		return out, err
+	}
+
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return out, fmt.Errorf("failed to encode saga payload: %w", err)
+	}
+
+	tx, err := sm.executeSaga(ctx, untyped, payload, options)
+	if err != nil {
+		return out, err
+	}
+
+	info, err := tx.GetInfo(ctx)
+	if err != nil {
+		return out, fmt.Errorf("failed to get transaction info: %w", err)
+	}
+	data := make(map[string][]byte, len(info.Branches))
+	for _, branch := range info.Branches {
+		data[branch.BranchID] = branch.ApplicationData
+	}
+
+	for _, step := range workflow.Steps {
+		stepData := data[step.BranchID]
+		switch {
+		case step.OnResult != nil:
+			err = step.OnResult(&out, stepData)
+		case len(stepData) > 0:
+			err = json.Unmarshal(stepData, &out)
+		}
+		if err != nil {
+			return out, fmt.Errorf("failed to decode result of branch %s: %w", step.BranchID, err)
+		}
+	}
+	return out, nil
+}
+
+// DecodePayload decodes the JSON payload of a transaction into T
+func DecodePayload[T any](info *TransactionInfo) (T, error) {
+	var v T
+	if err := json.Unmarshal(info.Payload, &v); err != nil {
+		return v, fmt.Errorf("failed to decode payload of transaction %s: %w", info.GID, err)
+	}
+	return v, nil
+}
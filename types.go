@@ -14,13 +14,29 @@ const (
 	ModeTCC  = "tcc"
 )
 
-// Transaction statuses
+// Transaction statuses. SUBMITTED through SUSPENDED are intermediate;
+// COMMITTED, ABORTED and TIMEOUT are final.
 const (
-	StatusSubmitted = "SUBMITTED"
-	StatusCommitted = "COMMITTED"
-	StatusAborted   = "ABORTED"
+	StatusSubmitted   = "SUBMITTED"
+	StatusCommitting  = "COMMITTING"
+	StatusRollbacking = "ROLLBACKING"
+	StatusCancelling  = "CANCELLING" // after CancelSaga, while completed branches are compensated
+	StatusSuspended   = "SUSPENDED"  // needs operator action, e.g. a compensation kept failing
+	StatusCommitted   = "COMMITTED"
+	StatusAborted     = "ABORTED"
+	StatusTimeout     = "TIMEOUT" // rolled back by the TC after the transaction deadline
 )
 
+// IsTerminal reports whether a transaction status is final. Statuses not
+// known to this client are treated as intermediate.
+func IsTerminal(status string) bool {
+	switch status {
+	case StatusCommitted, StatusAborted, StatusTimeout:
+		return true
+	}
+	return false
+}
+
 // Branch statuses
 const (
 	BranchStatusPrepared = "PREPARED"
@@ -47,6 +63,9 @@ const (
 	ErrCodeServerError         = "SERVER_ERROR"
 	ErrCodeTimeout             = "TIMEOUT"
 	ErrCodeNetworkError        = "NETWORK_ERROR"
+	// Returned with 409 when a branch is confirmed or cancelled again
+	ErrCodeBranchAlreadyConfirmed = "BRANCH_ALREADY_CONFIRMED"
+	ErrCodeBranchAlreadyCancelled = "BRANCH_ALREADY_CANCELLED"
 )
 
 // Saga workflow helper types
@@ -54,10 +73,37 @@ type SagaStep struct {
 	BranchID   string
 	Action     string
 	Compensate string
+	// Options are sent to the TC with the branch when set
+	Options *BranchOptions
+	// FallbackAction is run by the TC once Action has exhausted its
+	// retries, e.g. an alternate payment gateway, and is used in place of
+	// Action while the breaker of Action's participant is open.
+	// FallbackCompensate rolls the fallback back; empty uses Compensate.
+	FallbackAction     string
+	FallbackCompensate string
+	// Condition skips the step when it returns false; nil always runs it
+	Condition StepCondition
+	// Include names a saga registered in a WorkflowRegistry that takes the
+	// place of this step, which then has no Action; see
+	// WorkflowRegistry.ExpandSaga. IncludeAsChild runs it as a child
+	// transaction instead of inlining its steps.
+	Include        string
+	IncludeAsChild bool
+
+	// child is the included saga resolved by ExpandSaga
+	child *SagaWorkflow
+	// index is the position of the step in its declared workflow
+	index int
 }
 
 type SagaWorkflow struct {
 	Steps []SagaStep
+	// Version identifies the workflow definition; persisted states record it
+	// so a WorkflowMigrator can upgrade in-flight instances
+	Version int
+	// DeriveBranchIDs lets steps omit BranchID; each gets
+	// DeriveBranchID(gid, step index, Action) when the transaction starts
+	DeriveBranchIDs bool
 }
 
 // TCC workflow helper types
@@ -66,10 +112,38 @@ type TCCStep struct {
 	Try      string
 	Confirm  string
 	Cancel   string
+	// Validator checks the Try response; nil uses the client default
+	Validator ResponseValidator
+	// PollTimeout bounds polling of an ONGOING Try that returned a poll URL;
+	// zero uses the execution timeout
+	PollTimeout time.Duration
+	// Timeout bounds the whole Try including retries and polling. A Try
+	// that exceeds it reports the branch failed to the TC and fails the
+	// try phase with ErrStepTimeout; zero means no limit.
+	Timeout time.Duration
+	// Query is an optional participant endpoint reporting the branch's real
+	// state (see QueryBranch), used to reconcile lost Try/Confirm responses
+	Query string
+	// SuccessStatus overrides the client's SuccessStatus for this step's
+	// Try (when no Validator is set), Confirm and Cancel calls
+	SuccessStatus []StatusRange
+	// Condition skips the step when it returns false; nil always runs it
+	Condition StepCondition
+	// Include names a TCC workflow registered in a WorkflowRegistry whose
+	// steps are inlined in place of this one; see WorkflowRegistry.ExpandTCC
+	Include string
+
+	// index is the position of the step in its declared workflow
+	index int
 }
 
 type TCCWorkflow struct {
 	Steps []TCCStep
+	// Version identifies the workflow definition (see SagaWorkflow.Version)
+	Version int
+	// DeriveBranchIDs lets steps omit BranchID; each gets
+	// DeriveBranchID(gid, step index, Try) when the transaction starts
+	DeriveBranchIDs bool
 }
 
 // Retry configuration
@@ -77,6 +151,9 @@ type RetryConfig struct {
 	MaxRetries    int
 	RetryInterval time.Duration
 	BackoffFactor float64
+	// Backoff overrides the default exponential backoff derived from
+	// RetryInterval and BackoffFactor
+	Backoff BackoffStrategy
 }
 
 // Default retry configuration
@@ -135,6 +212,35 @@ type ExecutionOptions struct {
 	CircuitBreaker   *CircuitBreakerConfig
 	ParallelBranches bool
 	MaxConcurrency   int
+	// Submit passes execution options to the TC when a saga is submitted
+	Submit *SubmitOptions
+	// BatchTry sends the TCC try phase as one all-or-nothing request (see
+	// Transaction.TryAll); step validators and ONGOING handling do not apply
+	BatchTry bool
+	// RetryBudget caps retries across all steps; consumption is reported in
+	// ExecutionResult
+	RetryBudget *RetryBudget
+	// RegisterCompensations sends each saga step's Compensate URL with its
+	// branch, so the TC itself calls it on rollback
+	RegisterCompensations bool
+	// Profile labels the execution's goroutines for pprof (see
+	// ProfileLabelGID) and groups its runtime/trace regions into one task
+	Profile bool
+	// WorkflowName is the workflow label used when Profile is set, and the
+	// name a persisted execution is resumed under
+	WorkflowName string
+	// Store persists the progress of TCC executions so ResumeTCC can finish
+	// them after the orchestrator restarts
+	Store WorkflowStore
+	// Migrator upgrades executions ResumeTCC finds persisted under an older
+	// workflow Version
+	Migrator *WorkflowMigrator
+	// Heartbeat keeps the transaction alive on the TC while the execution
+	// runs, for workflows slower than the TC's transaction timeout
+	Heartbeat *HeartbeatConfig
+	// PreflightCheck probes every participant before the transaction starts
+	// and fails with ErrParticipantsUnreachable if any is down
+	PreflightCheck *PreflightConfig
 }
 
 // Default execution options
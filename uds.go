@@ -0,0 +1,78 @@
+package seata
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Unix domain socket endpoint schemes
+const (
+	schemeHTTPUnix = "http+unix://"
+	schemeUnix     = "unix://"
+)
+
+// unixSocketBaseURL is the placeholder base URL used for requests over a socket
+const unixSocketBaseURL = "http://unix"
+
+// parseUnixHTTPEndpoint recognizes Unix domain socket HTTP endpoints.
+// Supported forms:
+//
+//	unix:///var/run/seata.sock
+//	http+unix:///var/run/seata.sock
+//	http+unix://%2Fvar%2Frun%2Fseata.sock/prefix
+//
+// It returns the socket path and the base URL requests should use.
+func parseUnixHTTPEndpoint(endpoint string) (socketPath, baseURL string, ok bool) {
+	var rest string
+	switch {
+	case strings.HasPrefix(endpoint, schemeHTTPUnix):
+		rest = strings.TrimPrefix(endpoint, schemeHTTPUnix)
+	case strings.HasPrefix(endpoint, schemeUnix):
+		rest = strings.TrimPrefix(endpoint, schemeUnix)
+	default:
+		return "", "", false
+	}
+
+	// Absolute socket path without a URL path component
+	if strings.HasPrefix(rest, "/") {
+		return rest, unixSocketBaseURL, true
+	}
+
+	// Percent-encoded socket path as host, followed by an optional base path
+	host, path := rest, ""
+	if idx := strings.Index(rest, "/"); idx >= 0 {
+		host, path = rest[:idx], rest[idx:]
+	}
+	socketPath, err := url.PathUnescape(host)
+	if err != nil || socketPath == "" {
+		return "", "", false
+	}
+	return socketPath, unixSocketBaseURL + path, true
+}
+
+// useUnixSocket makes the transport dial socketPath for every request
+func useUnixSocket(transport *http.Transport, socketPath string) {
+	dialer := &net.Dialer{}
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", socketPath)
+	}
+}
+
+// grpcTarget converts a configured endpoint into a gRPC dial target.
+// gRPC resolves unix:// and unix: targets natively; http+unix:// is mapped onto them.
+func grpcTarget(endpoint string) string {
+	switch {
+	case strings.HasPrefix(endpoint, "grpc://"):
+		return strings.TrimPrefix(endpoint, "grpc://")
+	case strings.HasPrefix(endpoint, schemeGrpcs):
+		return strings.TrimPrefix(endpoint, schemeGrpcs)
+	case strings.HasPrefix(endpoint, schemeHTTPUnix):
+		if socketPath, _, ok := parseUnixHTTPEndpoint(endpoint); ok {
+			return "unix://" + socketPath
+		}
+	}
+	return endpoint
+}
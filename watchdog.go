@@ -0,0 +1,194 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WatchdogConfig enables alerting on transactions started by this client
+// that stay non-terminal for longer than SLA
+type WatchdogConfig struct {
+	SLA           time.Duration // required
+	CheckInterval time.Duration // zero means SLA/4, at least 1s
+	// OnStuck is called once per transaction when it exceeds the SLA
+	OnStuck func(StuckTransaction)
+	// MaxFailures is the number of consecutive failed status lookups, e.g.
+	// of a purged transaction, after which it is no longer watched; zero
+	// means 5
+	MaxFailures int
+	// Grace is how long a transaction is still watched after its TC
+	// deadline, or after the SLA when it has none; zero means SLA
+	Grace time.Duration
+}
+
+// StuckTransaction describes a transaction that exceeded the watchdog SLA
+type StuckTransaction struct {
+	GID       string
+	Mode      string
+	Status    string
+	StartedAt time.Time
+	Age       time.Duration
+}
+
+// Watchdog tracks locally started transactions and reports stuck ones
+type Watchdog struct {
+	client *Client
+	config WatchdogConfig
+	stop   chan struct{}
+	done   chan struct{}
+	alerts int64
+
+	mu      sync.Mutex
+	tracked map[string]*watchedTx
+}
+
+type watchedTx struct {
+	tx        *Transaction
+	startedAt time.Time
+	alerted   bool
+	failures  int
+	// deadline is the TC deadline, once a lookup reported one
+	deadline time.Time
+}
+
+func newWatchdog(client *Client, config *WatchdogConfig) *Watchdog {
+	copied := *config
+	if copied.CheckInterval <= 0 {
+		copied.CheckInterval = copied.SLA / 4
+		if copied.CheckInterval < time.Second {
+			copied.CheckInterval = time.Second
+		}
+	}
+	if copied.MaxFailures <= 0 {
+		copied.MaxFailures = 5
+	}
+	if copied.Grace <= 0 {
+		copied.Grace = copied.SLA
+	}
+	return &Watchdog{
+		client:  client,
+		config:  copied,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		tracked: make(map[string]*watchedTx),
+	}
+}
+
+// Watchdog returns the client's watchdog, or nil when Config.Watchdog is unset
+func (c *Client) Watchdog() *Watchdog {
+	return c.watchdog
+}
+
+// Track starts watching tx; transactions started through the client are
+// tracked automatically
+func (w *Watchdog) Track(tx *Transaction) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.tracked[tx.gid]; !ok {
+		w.tracked[tx.gid] = &watchedTx{tx: tx, startedAt: time.Now()}
+	}
+}
+
+// Tracked returns the number of transactions being watched
+func (w *Watchdog) Tracked() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.tracked)
+}
+
+// Alerts returns how many stuck transactions have been reported
+func (w *Watchdog) Alerts() int64 {
+	return atomic.LoadInt64(&w.alerts)
+}
+
+func (w *Watchdog) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.check(context.Background())
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+func (w *Watchdog) close() {
+	close(w.stop)
+	<-w.done
+}
+
+// check queries every transaction older than the SLA, forgets terminal ones
+// and reports the rest once. Transactions past their deadline plus Grace, or
+// whose status could not be read MaxFailures times in a row, are forgotten
+// too, so the watchdog does not grow without bound.
+func (w *Watchdog) check(ctx context.Context) {
+	now := time.Now()
+	w.mu.Lock()
+	var due []*watchedTx
+	for _, entry := range w.tracked {
+		if now.Sub(entry.startedAt) >= w.config.SLA {
+			due = append(due, entry)
+		}
+	}
+	w.mu.Unlock()
+
+	for _, entry := range due {
+		info, err := entry.tx.GetInfo(ctx)
+		if err != nil {
+			if entry.failures++; entry.failures >= w.config.MaxFailures || w.expired(entry, now) {
+				fmt.Printf("Warning: watchdog stopped tracking transaction %s: %v\n", entry.tx.gid, err)
+				w.forget(entry)
+			}
+			continue
+		}
+		entry.failures = 0
+		if info.DeadlineUnix > 0 {
+			entry.deadline = time.Unix(info.DeadlineUnix, 0)
+		}
+		if IsTerminal(info.Status) {
+			w.forget(entry)
+			continue
+		}
+		if entry.alerted {
+			if w.expired(entry, now) {
+				w.forget(entry)
+			}
+			continue
+		}
+		entry.alerted = true
+		atomic.AddInt64(&w.alerts, 1)
+
+		stuck := StuckTransaction{
+			GID:       entry.tx.gid,
+			Mode:      entry.tx.mode,
+			Status:    info.Status,
+			StartedAt: entry.startedAt,
+			Age:       now.Sub(entry.startedAt),
+		}
+		fmt.Printf("Warning: transaction %s is still %s after %v (SLA %v)\n", stuck.GID, stuck.Status, stuck.Age.Round(time.Second), w.config.SLA)
+		if w.config.OnStuck != nil {
+			w.config.OnStuck(stuck)
+		}
+	}
+}
+
+// expired reports whether entry is past its deadline plus Grace
+func (w *Watchdog) expired(entry *watchedTx, now time.Time) bool {
+	deadline := entry.deadline
+	if deadline.IsZero() {
+		deadline = entry.startedAt.Add(w.config.SLA)
+	}
+	return now.After(deadline.Add(w.config.Grace))
+}
+
+func (w *Watchdog) forget(entry *watchedTx) {
+	w.mu.Lock()
+	delete(w.tracked, entry.tx.gid)
+	w.mu.Unlock()
+}
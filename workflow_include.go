@@ -0,0 +1,144 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ExpandSaga returns the registered saga name with its includes resolved.
+// Inlined steps are prefixed with the branch ID of the step including them
+// ("charge" in a step "billing" becomes "billing.charge"), so a fragment can
+// be included more than once; steps left to DeriveBranchIDs stay unnamed.
+// A step with IncludeAsChild keeps its place
+// and runs the included saga as a child transaction, forked from the parent
+// and completed before the parent is submitted.
+func (r *WorkflowRegistry) ExpandSaga(name string) (*SagaWorkflow, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.expandSaga(name, "", nil)
+}
+
+func (r *WorkflowRegistry) expandSaga(name, prefix string, stack []string) (*SagaWorkflow, error) {
+	workflow, ok := r.sagas[name]
+	if !ok {
+		return nil, fmt.Errorf("saga workflow %s is not registered", name)
+	}
+	if err := checkIncludeCycle(name, stack); err != nil {
+		return nil, err
+	}
+	stack = append(stack, name)
+
+	expanded := &SagaWorkflow{Version: workflow.Version, DeriveBranchIDs: workflow.DeriveBranchIDs}
+	for _, step := range workflow.Steps {
+		if step.Include == "" {
+			if step.BranchID != "" {
+				step.BranchID = prefix + step.BranchID
+			}
+			expanded.Steps = append(expanded.Steps, step)
+			continue
+		}
+		if step.IncludeAsChild {
+			child, err := r.expandSaga(step.Include, "", stack)
+			if err != nil {
+				return nil, err
+			}
+			step.BranchID = prefix + step.BranchID
+			step.child = child
+			expanded.Steps = append(expanded.Steps, step)
+			continue
+		}
+		inlined, err := r.expandSaga(step.Include, prefix+step.BranchID+".", stack)
+		if err != nil {
+			return nil, err
+		}
+		expanded.DeriveBranchIDs = expanded.DeriveBranchIDs || inlined.DeriveBranchIDs
+		for _, inner := range inlined.Steps {
+			if step.Condition != nil && inner.Condition == nil {
+				inner.Condition = step.Condition
+			}
+			expanded.Steps = append(expanded.Steps, inner)
+		}
+	}
+	return expanded, nil
+}
+
+// ExpandTCC returns the registered TCC workflow name with its includes
+// inlined, prefixing their branch IDs like ExpandSaga
+func (r *WorkflowRegistry) ExpandTCC(name string) (*TCCWorkflow, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.expandTCC(name, "", nil)
+}
+
+func (r *WorkflowRegistry) expandTCC(name, prefix string, stack []string) (*TCCWorkflow, error) {
+	workflow, ok := r.tccs[name]
+	if !ok {
+		return nil, fmt.Errorf("TCC workflow %s is not registered", name)
+	}
+	if err := checkIncludeCycle(name, stack); err != nil {
+		return nil, err
+	}
+	stack = append(stack, name)
+
+	expanded := &TCCWorkflow{Version: workflow.Version, DeriveBranchIDs: workflow.DeriveBranchIDs}
+	for _, step := range workflow.Steps {
+		if step.Include == "" {
+			if step.BranchID != "" {
+				step.BranchID = prefix + step.BranchID
+			}
+			expanded.Steps = append(expanded.Steps, step)
+			continue
+		}
+		inlined, err := r.expandTCC(step.Include, prefix+step.BranchID+".", stack)
+		if err != nil {
+			return nil, err
+		}
+		expanded.DeriveBranchIDs = expanded.DeriveBranchIDs || inlined.DeriveBranchIDs
+		for _, inner := range inlined.Steps {
+			if step.Condition != nil && inner.Condition == nil {
+				inner.Condition = step.Condition
+			}
+			expanded.Steps = append(expanded.Steps, inner)
+		}
+	}
+	return expanded, nil
+}
+
+// checkIncludeCycle fails when name is already being expanded
+func checkIncludeCycle(name string, stack []string) error {
+	for _, including := range stack {
+		if including == name {
+			return fmt.Errorf("workflow include cycle: %s -> %s", strings.Join(stack, " -> "), name)
+		}
+	}
+	return nil
+}
+
+// runChildSaga runs the saga included by step as a child of tx and waits for
+// it to commit
+func (sm *SagaManager) runChildSaga(ctx context.Context, tx *Transaction, step SagaStep, options *ExecutionOptions) error {
+	workflow, _ := selectSagaSteps(ctx, step.child, tx.payload)
+	steps, err := sm.routeSagaSteps(workflow, options)
+	if err != nil {
+		return err
+	}
+	child, err := tx.ForkWithOptions(ctx, ModeSaga, tx.payload, &ForkOptions{CascadeAbort: true})
+	if err != nil {
+		return err
+	}
+	deriveSagaBranchIDs(step.child, steps, child.gid)
+	for _, childStep := range steps {
+		if err := sm.addBranch(ctx, child, childStep, options); err != nil {
+			child.Abort(ctx)
+			return fmt.Errorf("failed to add branch %s of child saga %s: %w", childStep.BranchID, step.Include, err)
+		}
+	}
+	if err := child.SubmitWithOptions(ctx, options.Submit); err != nil {
+		return fmt.Errorf("failed to submit child saga %s: %w", step.Include, err)
+	}
+	if err := sm.waitForCompletion(ctx, child, workflow, options); err != nil {
+		return fmt.Errorf("child saga %s (%s): %w", step.Include, child.gid, err)
+	}
+	return nil
+}
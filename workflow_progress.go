@@ -0,0 +1,193 @@
+package seata
+
+import (
+	"context"
+	"fmt"
+)
+
+// workflowProgress persists the progress of one TCC execution to
+// ExecutionOptions.Store. A nil progress persists nothing.
+//
+// The workflow status says how a resumed execution continues: RUNNING and
+// CANCELLING executions are cancelled, since an interrupted try may have
+// reserved resources without being recorded, and CONFIRMING ones are
+// confirmed. Step statuses record each try for inspection.
+type workflowProgress struct {
+	store WorkflowStore
+	id    string
+}
+
+func newWorkflowProgress(store WorkflowStore, id string) *workflowProgress {
+	if store == nil {
+		return nil
+	}
+	return &workflowProgress{store: store, id: id}
+}
+
+// start saves a new execution with its steps pending
+func (p *workflowProgress) start(ctx context.Context, tx *Transaction, name string, version int, steps []TCCStep) error {
+	if p == nil {
+		return nil
+	}
+	state := &WorkflowState{
+		ID:      p.id,
+		Name:    name,
+		Mode:    tx.mode,
+		Version: version,
+		GID:     tx.gid,
+		Status:  WorkflowStatusRunning,
+		Payload: tx.payload,
+	}
+	for _, step := range steps {
+		state.Steps = append(state.Steps, WorkflowStepState{BranchID: step.BranchID, Status: StepStatusPending})
+	}
+	if err := p.store.Save(ctx, state); err != nil {
+		return fmt.Errorf("failed to persist workflow: %w", err)
+	}
+	return nil
+}
+
+// tried records the outcome of a step's try. Resuming does not depend on
+// step statuses, so a failure to record one is only logged.
+func (p *workflowProgress) tried(ctx context.Context, branchID string, tryErr error) {
+	if p == nil {
+		return
+	}
+	status := StepStatusSucceeded
+	if tryErr != nil {
+		status = StepStatusFailed
+	}
+	if err := p.store.MarkStep(context.WithoutCancel(ctx), p.id, branchID, status, tryErr); err != nil {
+		fmt.Printf("Warning: failed to record step %s of workflow %s: %v\n", branchID, p.id, err)
+	}
+}
+
+// setStatus moves the execution to status
+func (p *workflowProgress) setStatus(ctx context.Context, status string) error {
+	if p == nil {
+		return nil
+	}
+	ctx = context.WithoutCancel(ctx)
+	state, err := p.store.Load(ctx, p.id)
+	if err != nil {
+		return fmt.Errorf("failed to persist workflow status %s: %w", status, err)
+	}
+	state.Status = status
+	if err := p.store.Save(ctx, state); err != nil {
+		return fmt.Errorf("failed to persist workflow status %s: %w", status, err)
+	}
+	return nil
+}
+
+// finish records the terminal status; a failure is only logged, as resuming
+// the execution again repeats idempotent confirms or cancels
+func (p *workflowProgress) finish(ctx context.Context, status string) {
+	if err := p.setStatus(ctx, status); err != nil {
+		fmt.Printf("Warning: workflow %s: %v\n", p.id, err)
+	}
+}
+
+// ResumeTCC finishes a TCC execution persisted in options.Store by an
+// orchestrator that stopped before it ended. An execution interrupted
+// before all its tries succeeded is cancelled and fails with
+// ErrTryPhaseFailed; one interrupted while confirming is confirmed. workflow
+// is the current definition of the execution; a state persisted under
+// another Version is first upgraded by options.Migrator. Steps the state
+// does not list, or lists as skipped, are left alone.
+func (tm *TCCManager) ResumeTCC(ctx context.Context, state *WorkflowState, workflow *TCCWorkflow, options *ExecutionOptions) (*ExecutionResult, error) {
+	if options == nil {
+		options = DefaultExecutionOptions()
+	}
+	if state.Mode != ModeTCC {
+		return nil, fmt.Errorf("workflow %s is not a TCC execution: %s", state.ID, state.Mode)
+	}
+	if state.GID == "" {
+		return nil, fmt.Errorf("workflow %s has no gid", state.ID)
+	}
+	if state.IsFinished() {
+		return &ExecutionResult{GID: state.GID}, nil
+	}
+
+	// The steps of the execution, with the branch IDs it derived
+	steps := append([]TCCStep(nil), workflow.Steps...)
+	for i := range steps {
+		steps[i].index = i
+	}
+	deriveTCCBranchIDs(workflow, steps, state.GID)
+	if state.Version != workflow.Version {
+		if err := migrateTCCState(ctx, state, workflow.Version, steps, options); err != nil {
+			return nil, err
+		}
+	}
+	persisted := make(map[string]bool, len(state.Steps))
+	for _, step := range state.Steps {
+		if step.Status != StepStatusSkipped {
+			persisted[step.BranchID] = true
+		}
+	}
+	resumed := *workflow
+	resumed.Steps = nil
+	for _, step := range steps {
+		if persisted[step.BranchID] {
+			resumed.Steps = append(resumed.Steps, step)
+		}
+	}
+
+	tx := &Transaction{
+		client:   tm.client.ForGID(state.GID),
+		gid:      state.GID,
+		mode:     ModeTCC,
+		payload:  state.Payload,
+		branches: make([]*Branch, 0),
+	}
+	tx.budget = newRetryBudget(options.RetryBudget)
+	tx.tcc = newTCCRecorder()
+	tx.timings = newTimingRecorder()
+	tx.progress = newWorkflowProgress(options.Store, state.ID)
+	result := &ExecutionResult{GID: tx.gid}
+	defer tx.budget.fill(result)
+	defer tx.timings.fill(result)
+
+	if state.Status == WorkflowStatusConfirming {
+		if err := tm.executeConfirmPhase(ctx, tx, &resumed, options); err != nil {
+			return tm.rollback(ctx, tx, &resumed, options, result, fmt.Errorf("%w: %w", ErrConfirmPhaseFailed, err))
+		}
+		tx.progress.finish(ctx, WorkflowStatusCompleted)
+		tm.client.emit(EventTransactionCommitted, tx, "")
+		return result, nil
+	}
+	return tm.rollback(ctx, tx, &resumed, options, result, fmt.Errorf("%w: execution %s was interrupted before its tries completed", ErrTryPhaseFailed, state.ID))
+}
+
+// migrateTCCState upgrades state to version with options.Migrator, aligning
+// it with steps, and persists the result. Steps the migration adds to a
+// confirming execution were never tried, so they are skipped.
+func migrateTCCState(ctx context.Context, state *WorkflowState, version int, steps []TCCStep, options *ExecutionOptions) error {
+	if options.Migrator == nil {
+		return fmt.Errorf("workflow %s was persisted under version %d and needs ExecutionOptions.Migrator to resume with version %d", state.ID, state.Version, version)
+	}
+	known := make(map[string]bool, len(state.Steps))
+	for _, step := range state.Steps {
+		known[step.BranchID] = true
+	}
+	branchIDs := make([]string, len(steps))
+	for i, step := range steps {
+		branchIDs[i] = step.BranchID
+	}
+	if err := options.Migrator.Migrate(state, version, branchIDs); err != nil {
+		return err
+	}
+	if state.Status == WorkflowStatusConfirming {
+		for i := range state.Steps {
+			if !known[state.Steps[i].BranchID] && state.Steps[i].Status == StepStatusPending {
+				state.Steps[i].Status = StepStatusSkipped
+			}
+		}
+	}
+	if options.Store != nil {
+		if err := options.Store.Save(ctx, state); err != nil {
+			return fmt.Errorf("failed to persist migrated workflow %s: %w", state.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package seata
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// WorkflowRegistry holds workflows that were validated and had their step
+// URLs parsed once at registration, so executing them does no validation or
+// parsing on the hot path. Registered workflows are copies; changing the
+// original afterwards has no effect.
+type WorkflowRegistry struct {
+	mu    sync.RWMutex
+	sagas map[string]*SagaWorkflow
+	tccs  map[string]*TCCWorkflow
+}
+
+// NewWorkflowRegistry creates an empty registry
+func NewWorkflowRegistry() *WorkflowRegistry {
+	return &WorkflowRegistry{
+		sagas: make(map[string]*SagaWorkflow),
+		tccs:  make(map[string]*TCCWorkflow),
+	}
+}
+
+// RegisterSaga validates workflow and stores a copy under name
+func (r *WorkflowRegistry) RegisterSaga(name string, workflow *SagaWorkflow) error {
+	if err := workflow.Validate(); err != nil {
+		return fmt.Errorf("invalid saga workflow %s: %w", name, err)
+	}
+	for _, step := range workflow.Steps {
+		if err := checkStepURL(step.Action, step.Compensate); err != nil {
+			return fmt.Errorf("invalid saga workflow %s, step %s: %w", name, step.BranchID, err)
+		}
+	}
+
+	copied := &SagaWorkflow{Steps: append([]SagaStep(nil), workflow.Steps...), Version: workflow.Version, DeriveBranchIDs: workflow.DeriveBranchIDs}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sagas[name] = copied
+	return nil
+}
+
+// RegisterTCC validates workflow and stores a copy under name
+func (r *WorkflowRegistry) RegisterTCC(name string, workflow *TCCWorkflow) error {
+	if err := workflow.Validate(); err != nil {
+		return fmt.Errorf("invalid TCC workflow %s: %w", name, err)
+	}
+	for _, step := range workflow.Steps {
+		if err := checkStepURL(step.Try, step.Confirm, step.Cancel, step.Query); err != nil {
+			return fmt.Errorf("invalid TCC workflow %s, step %s: %w", name, step.BranchID, err)
+		}
+	}
+
+	copied := &TCCWorkflow{Steps: append([]TCCStep(nil), workflow.Steps...), Version: workflow.Version, DeriveBranchIDs: workflow.DeriveBranchIDs}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tccs[name] = copied
+	return nil
+}
+
+// Saga returns the registered saga workflow; it is shared and must not be modified
+func (r *WorkflowRegistry) Saga(name string) (*SagaWorkflow, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	workflow, ok := r.sagas[name]
+	return workflow, ok
+}
+
+// TCC returns the registered TCC workflow; it is shared and must not be modified
+func (r *WorkflowRegistry) TCC(name string) (*TCCWorkflow, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	workflow, ok := r.tccs[name]
+	return workflow, ok
+}
+
+// checkStepURL verifies that every non-empty rawURL is an absolute URL
+func checkStepURL(rawURLs ...string) error {
+	for _, rawURL := range rawURLs {
+		if rawURL == "" {
+			continue
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return fmt.Errorf("invalid URL %q: %w", rawURL, err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("URL %q must be absolute", rawURL)
+		}
+	}
+	return nil
+}
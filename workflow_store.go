@@ -0,0 +1,177 @@
+package seata
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Workflow statuses
+const (
+	WorkflowStatusPending   = "PENDING"
+	WorkflowStatusRunning   = "RUNNING"
+	WorkflowStatusCompleted = "COMPLETED"
+	WorkflowStatusFailed    = "FAILED"
+	// A TCC execution whose tries all succeeded, or that is being rolled back
+	WorkflowStatusConfirming = "CONFIRMING"
+	WorkflowStatusCancelling = "CANCELLING"
+)
+
+// Workflow step statuses
+const (
+	StepStatusPending   = "PENDING"
+	StepStatusSucceeded = "SUCCEEDED"
+	StepStatusFailed    = "FAILED"
+	StepStatusSkipped   = "SKIPPED"
+)
+
+// ErrWorkflowNotFound is returned when a workflow is not in the store
+var ErrWorkflowNotFound = errors.New("workflow not found")
+
+// WorkflowState is the persisted progress of a multi-step orchestration
+type WorkflowState struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name,omitempty"` // ExecutionOptions.WorkflowName
+	Mode        string              `json:"mode"`
+	Version     int                 `json:"version,omitempty"` // workflow definition version
+	GID         string              `json:"gid,omitempty"`
+	Status      string              `json:"status"`
+	Payload     []byte              `json:"payload,omitempty"`
+	Steps       []WorkflowStepState `json:"steps"`
+	CreatedUnix int64               `json:"created_unix"`
+	UpdatedUnix int64               `json:"updated_unix"`
+}
+
+// WorkflowStepState is the persisted progress of one workflow step
+type WorkflowStepState struct {
+	BranchID    string `json:"branch_id"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	UpdatedUnix int64  `json:"updated_unix"`
+}
+
+// IsFinished reports whether the workflow reached a terminal status
+func (ws *WorkflowState) IsFinished() bool {
+	return ws.Status == WorkflowStatusCompleted || ws.Status == WorkflowStatusFailed
+}
+
+// NextPendingStep returns the index of the first step not yet succeeded or skipped, or -1
+func (ws *WorkflowState) NextPendingStep() int {
+	for i, step := range ws.Steps {
+		if step.Status != StepStatusSucceeded && step.Status != StepStatusSkipped {
+			return i
+		}
+	}
+	return -1
+}
+
+// WorkflowStore persists workflow progress so interrupted orchestrations can be resumed
+type WorkflowStore interface {
+	// Save creates or replaces a workflow
+	Save(ctx context.Context, state *WorkflowState) error
+	// Load returns a workflow or ErrWorkflowNotFound
+	Load(ctx context.Context, id string) (*WorkflowState, error)
+	// ListPending returns all workflows that have not finished
+	ListPending(ctx context.Context) ([]*WorkflowState, error)
+	// MarkStep records the status of one step
+	MarkStep(ctx context.Context, id, branchID, status string, stepErr error) error
+}
+
+// applyStepStatus updates the step of branchID in state, adding it if missing
+func applyStepStatus(state *WorkflowState, branchID, status string, stepErr error) {
+	now := time.Now().Unix()
+	errMsg := ""
+	if stepErr != nil {
+		errMsg = stepErr.Error()
+	}
+
+	state.UpdatedUnix = now
+	for i := range state.Steps {
+		if state.Steps[i].BranchID == branchID {
+			state.Steps[i].Status = status
+			state.Steps[i].Error = errMsg
+			state.Steps[i].UpdatedUnix = now
+			return
+		}
+	}
+	state.Steps = append(state.Steps, WorkflowStepState{BranchID: branchID, Status: status, Error: errMsg, UpdatedUnix: now})
+}
+
+// touchWorkflow fills in timestamps before a workflow is saved
+func touchWorkflow(state *WorkflowState) {
+	now := time.Now().Unix()
+	if state.CreatedUnix == 0 {
+		state.CreatedUnix = now
+	}
+	state.UpdatedUnix = now
+	if state.Status == "" {
+		state.Status = WorkflowStatusPending
+	}
+}
+
+// copyWorkflowState returns a deep copy of state
+func copyWorkflowState(state *WorkflowState) *WorkflowState {
+	copied := *state
+	copied.Payload = append([]byte(nil), state.Payload...)
+	copied.Steps = append([]WorkflowStepState(nil), state.Steps...)
+	return &copied
+}
+
+// MemoryWorkflowStore keeps workflows in process memory; state is lost on restart
+type MemoryWorkflowStore struct {
+	mu        sync.RWMutex
+	workflows map[string]*WorkflowState
+}
+
+// NewMemoryWorkflowStore creates an empty in-memory workflow store
+func NewMemoryWorkflowStore() *MemoryWorkflowStore {
+	return &MemoryWorkflowStore{workflows: make(map[string]*WorkflowState)}
+}
+
+// Save creates or replaces a workflow
+func (s *MemoryWorkflowStore) Save(ctx context.Context, state *WorkflowState) error {
+	touchWorkflow(state)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workflows[state.ID] = copyWorkflowState(state)
+	return nil
+}
+
+// Load returns a workflow or ErrWorkflowNotFound
+func (s *MemoryWorkflowStore) Load(ctx context.Context, id string) (*WorkflowState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state, ok := s.workflows[id]
+	if !ok {
+		return nil, ErrWorkflowNotFound
+	}
+	return copyWorkflowState(state), nil
+}
+
+// ListPending returns all unfinished workflows ordered by creation time
+func (s *MemoryWorkflowStore) ListPending(ctx context.Context) ([]*WorkflowState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var pending []*WorkflowState
+	for _, state := range s.workflows {
+		if !state.IsFinished() {
+			pending = append(pending, copyWorkflowState(state))
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedUnix < pending[j].CreatedUnix })
+	return pending, nil
+}
+
+// MarkStep records the status of one step
+func (s *MemoryWorkflowStore) MarkStep(ctx context.Context, id, branchID, status string, stepErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.workflows[id]
+	if !ok {
+		return ErrWorkflowNotFound
+	}
+	applyStepStatus(state, branchID, status, stepErr)
+	return nil
+}
@@ -0,0 +1,112 @@
+package seata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// EtcdWorkflowStore persists workflows as JSON documents under an etcd prefix
+type EtcdWorkflowStore struct {
+	cli    *clientv3.Client
+	prefix string
+}
+
+// NewEtcdWorkflowStore creates a store keeping workflows under prefix
+// (default "/seata/workflows/")
+func NewEtcdWorkflowStore(cli *clientv3.Client, prefix string) *EtcdWorkflowStore {
+	if prefix == "" {
+		prefix = "/seata/workflows/"
+	}
+	return &EtcdWorkflowStore{cli: cli, prefix: prefix}
+}
+
+// Save creates or replaces a workflow
+func (s *EtcdWorkflowStore) Save(ctx context.Context, state *WorkflowState) error {
+	touchWorkflow(state)
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode workflow %s: %w", state.ID, err)
+	}
+	if _, err := s.cli.Put(ctx, s.prefix+state.ID, string(data)); err != nil {
+		return fmt.Errorf("failed to save workflow %s: %w", state.ID, err)
+	}
+	return nil
+}
+
+// Load returns a workflow or ErrWorkflowNotFound
+func (s *EtcdWorkflowStore) Load(ctx context.Context, id string) (*WorkflowState, error) {
+	state, _, err := s.load(ctx, id)
+	return state, err
+}
+
+// ListPending returns all unfinished workflows ordered by creation time
+func (s *EtcdWorkflowStore) ListPending(ctx context.Context) ([]*WorkflowState, error) {
+	resp, err := s.cli.Get(ctx, s.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflows: %w", err)
+	}
+
+	var pending []*WorkflowState
+	for _, kv := range resp.Kvs {
+		var state WorkflowState
+		if err := json.Unmarshal(kv.Value, &state); err != nil {
+			return nil, fmt.Errorf("failed to decode workflow %s: %w", string(kv.Key), err)
+		}
+		if !state.IsFinished() {
+			pending = append(pending, &state)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedUnix < pending[j].CreatedUnix })
+	return pending, nil
+}
+
+// MarkStep records the status of one step. The update is applied with a
+// compare-and-swap on the key revision and retried on conflicts.
+func (s *EtcdWorkflowStore) MarkStep(ctx context.Context, id, branchID, status string, stepErr error) error {
+	for attempt := 0; attempt < 5; attempt++ {
+		state, revision, err := s.load(ctx, id)
+		if err != nil {
+			return err
+		}
+		applyStepStatus(state, branchID, status, stepErr)
+
+		data, err := json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("failed to encode workflow %s: %w", id, err)
+		}
+
+		key := s.prefix + id
+		resp, err := s.cli.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "=", revision)).
+			Then(clientv3.OpPut(key, string(data))).
+			Commit()
+		if err != nil {
+			return fmt.Errorf("failed to mark step %s of workflow %s: %w", branchID, id, err)
+		}
+		if resp.Succeeded {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to mark step %s of workflow %s: too many concurrent updates", branchID, id)
+}
+
+// load returns a workflow and the revision it was read at
+func (s *EtcdWorkflowStore) load(ctx context.Context, id string) (*WorkflowState, int64, error) {
+	resp, err := s.cli.Get(ctx, s.prefix+id)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load workflow %s: %w", id, err)
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, 0, ErrWorkflowNotFound
+	}
+
+	var state WorkflowState
+	if err := json.Unmarshal(resp.Kvs[0].Value, &state); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode workflow %s: %w", id, err)
+	}
+	return &state, resp.Kvs[0].ModRevision, nil
+}
@@ -0,0 +1,122 @@
+package seata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// RedisCommands is the subset of Redis commands used by RedisWorkflowStore.
+// It keeps this package free of a Redis driver dependency; wrapping a
+// go-redis client takes a few lines, e.g. Get maps redis.Nil to found=false.
+type RedisCommands interface {
+	Get(ctx context.Context, key string) (value string, found bool, err error)
+	Set(ctx context.Context, key, value string) error
+	SAdd(ctx context.Context, key, member string) error
+	SRem(ctx context.Context, key, member string) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+}
+
+// RedisWorkflowStore persists workflows as JSON strings, tracking unfinished
+// workflow ids in a set. MarkStep serializes updates within this process
+// only; run a single writer per workflow across replicas.
+type RedisWorkflowStore struct {
+	redis  RedisCommands
+	prefix string
+	mu     sync.Mutex
+}
+
+// NewRedisWorkflowStore creates a store using keys under prefix (default "seata:workflow:")
+func NewRedisWorkflowStore(redis RedisCommands, prefix string) *RedisWorkflowStore {
+	if prefix == "" {
+		prefix = "seata:workflow:"
+	}
+	return &RedisWorkflowStore{redis: redis, prefix: prefix}
+}
+
+// pendingKey is the set of unfinished workflow ids
+func (s *RedisWorkflowStore) pendingKey() string {
+	return s.prefix + "pending"
+}
+
+// Save creates or replaces a workflow
+func (s *RedisWorkflowStore) Save(ctx context.Context, state *WorkflowState) error {
+	touchWorkflow(state)
+	return s.put(ctx, state)
+}
+
+// Load returns a workflow or ErrWorkflowNotFound
+func (s *RedisWorkflowStore) Load(ctx context.Context, id string) (*WorkflowState, error) {
+	value, found, err := s.redis.Get(ctx, s.prefix+id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow %s: %w", id, err)
+	}
+	if !found {
+		return nil, ErrWorkflowNotFound
+	}
+	var state WorkflowState
+	if err := json.Unmarshal([]byte(value), &state); err != nil {
+		return nil, fmt.Errorf("failed to decode workflow %s: %w", id, err)
+	}
+	return &state, nil
+}
+
+// ListPending returns all unfinished workflows ordered by creation time
+func (s *RedisWorkflowStore) ListPending(ctx context.Context) ([]*WorkflowState, error) {
+	ids, err := s.redis.SMembers(ctx, s.pendingKey())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflows: %w", err)
+	}
+
+	pending := make([]*WorkflowState, 0, len(ids))
+	for _, id := range ids {
+		state, err := s.Load(ctx, id)
+		if err == ErrWorkflowNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !state.IsFinished() {
+			pending = append(pending, state)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].CreatedUnix < pending[j].CreatedUnix })
+	return pending, nil
+}
+
+// MarkStep records the status of one step
+func (s *RedisWorkflowStore) MarkStep(ctx context.Context, id, branchID, status string, stepErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.Load(ctx, id)
+	if err != nil {
+		return err
+	}
+	applyStepStatus(state, branchID, status, stepErr)
+	return s.put(ctx, state)
+}
+
+// put writes state and maintains the pending set
+func (s *RedisWorkflowStore) put(ctx context.Context, state *WorkflowState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode workflow %s: %w", state.ID, err)
+	}
+	if err := s.redis.Set(ctx, s.prefix+state.ID, string(data)); err != nil {
+		return fmt.Errorf("failed to save workflow %s: %w", state.ID, err)
+	}
+
+	if state.IsFinished() {
+		err = s.redis.SRem(ctx, s.pendingKey(), state.ID)
+	} else {
+		err = s.redis.SAdd(ctx, s.pendingKey(), state.ID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update pending workflows: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package seata
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SQLWorkflowStore persists workflows in a SQL table, e.g.:
+//
+//	CREATE TABLE seata_workflow (
+//	  id VARCHAR(128) PRIMARY KEY,
+//	  status VARCHAR(32) NOT NULL,
+//	  data TEXT NOT NULL,
+//	  created_unix BIGINT NOT NULL,
+//	  updated_unix BIGINT NOT NULL
+//	);
+type SQLWorkflowStore struct {
+	db      *sql.DB
+	table   string
+	dialect string
+}
+
+// NewSQLWorkflowStore creates a workflow store using table (default "seata_workflow")
+func NewSQLWorkflowStore(db *sql.DB, dialect, table string) (*SQLWorkflowStore, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database cannot be nil")
+	}
+	if dialect != SQLDialectMySQL && dialect != SQLDialectPostgres {
+		return nil, fmt.Errorf("unsupported SQL dialect: %s", dialect)
+	}
+	if table == "" {
+		table = "seata_workflow"
+	}
+	return &SQLWorkflowStore{db: db, table: table, dialect: dialect}, nil
+}
+
+// Save creates or replaces a workflow
+func (s *SQLWorkflowStore) Save(ctx context.Context, state *WorkflowState) error {
+	touchWorkflow(state)
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode workflow %s: %w", state.ID, err)
+	}
+
+	var query string
+	if s.dialect == SQLDialectPostgres {
+		query = fmt.Sprintf(`INSERT INTO %s (id, status, data, created_unix, updated_unix) VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET status = EXCLUDED.status, data = EXCLUDED.data, updated_unix = EXCLUDED.updated_unix`, s.table)
+	} else {
+		query = fmt.Sprintf(`INSERT INTO %s (id, status, data, created_unix, updated_unix) VALUES (?, ?, ?, ?, ?)
+ON DUPLICATE KEY UPDATE status = VALUES(status), data = VALUES(data), updated_unix = VALUES(updated_unix)`, s.table)
+	}
+
+	if _, err := s.db.ExecContext(ctx, s.rebind(query), state.ID, state.Status, string(data), state.CreatedUnix, state.UpdatedUnix); err != nil {
+		return fmt.Errorf("failed to save workflow %s: %w", state.ID, err)
+	}
+	return nil
+}
+
+// Load returns a workflow or ErrWorkflowNotFound
+func (s *SQLWorkflowStore) Load(ctx context.Context, id string) (*WorkflowState, error) {
+	query := fmt.Sprintf("SELECT data FROM %s WHERE id = ?", s.table)
+	return s.scanOne(s.db.QueryRowContext(ctx, s.rebind(query), id), id)
+}
+
+// ListPending returns all unfinished workflows ordered by creation time
+func (s *SQLWorkflowStore) ListPending(ctx context.Context) ([]*WorkflowState, error) {
+	query := fmt.Sprintf("SELECT data FROM %s WHERE status NOT IN (?, ?) ORDER BY created_unix", s.table)
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), WorkflowStatusCompleted, WorkflowStatusFailed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflows: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []*WorkflowState
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to list workflows: %w", err)
+		}
+		var state WorkflowState
+		if err := json.Unmarshal([]byte(data), &state); err != nil {
+			return nil, fmt.Errorf("failed to decode workflow: %w", err)
+		}
+		pending = append(pending, &state)
+	}
+	return pending, rows.Err()
+}
+
+// MarkStep records the status of one step inside a row-locking transaction
+func (s *SQLWorkflowStore) MarkStep(ctx context.Context, id, branchID, status string, stepErr error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to mark step %s of workflow %s: %w", branchID, id, err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf("SELECT data FROM %s WHERE id = ? FOR UPDATE", s.table)
+	state, err := s.scanOne(tx.QueryRowContext(ctx, s.rebind(query), id), id)
+	if err != nil {
+		return err
+	}
+	applyStepStatus(state, branchID, status, stepErr)
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode workflow %s: %w", id, err)
+	}
+	update := fmt.Sprintf("UPDATE %s SET data = ?, updated_unix = ? WHERE id = ?", s.table)
+	if _, err := tx.ExecContext(ctx, s.rebind(update), string(data), state.UpdatedUnix, id); err != nil {
+		return fmt.Errorf("failed to mark step %s of workflow %s: %w", branchID, id, err)
+	}
+	return tx.Commit()
+}
+
+// scanOne decodes the workflow in row
+func (s *SQLWorkflowStore) scanOne(row *sql.Row, id string) (*WorkflowState, error) {
+	var data string
+	if err := row.Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWorkflowNotFound
+		}
+		return nil, fmt.Errorf("failed to load workflow %s: %w", id, err)
+	}
+	var state WorkflowState
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		return nil, fmt.Errorf("failed to decode workflow %s: %w", id, err)
+	}
+	return &state, nil
+}
+
+// rebind converts ? placeholders into the dialect's placeholder syntax
+func (s *SQLWorkflowStore) rebind(query string) string {
+	return rebindSQL(s.dialect, query)
+}
+
+// rebindSQL converts ? placeholders into $n for PostgreSQL
+func rebindSQL(dialect, query string) string {
+	if dialect != SQLDialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,149 @@
+package seata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WorkflowMigration upgrades persisted workflow state from one workflow
+// version to the next
+type WorkflowMigration struct {
+	From, To int
+	// StepMap renames step branch IDs, old -> new
+	StepMap map[string]string
+	// Removed lists old branch IDs that no longer exist; unfinished ones are
+	// marked skipped
+	Removed []string
+	// Migrate is an optional hook run after StepMap and Removed, e.g. to
+	// rewrite the payload
+	Migrate func(state *WorkflowState) error
+}
+
+// WorkflowMigrator lets an orchestrator running a new workflow version drive
+// instances persisted under older versions
+type WorkflowMigrator struct {
+	mu         sync.RWMutex
+	migrations map[int]WorkflowMigration // keyed by From
+}
+
+// NewWorkflowMigrator creates an empty migrator
+func NewWorkflowMigrator() *WorkflowMigrator {
+	return &WorkflowMigrator{migrations: make(map[int]WorkflowMigration)}
+}
+
+// Register adds a migration; only one migration may start at each version
+func (m *WorkflowMigrator) Register(migration WorkflowMigration) error {
+	if migration.To <= migration.From {
+		return fmt.Errorf("migration must move to a newer version, got %d -> %d", migration.From, migration.To)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.migrations[migration.From]; ok {
+		return fmt.Errorf("migration from version %d already registered", migration.From)
+	}
+	m.migrations[migration.From] = migration
+	return nil
+}
+
+// Migrate upgrades state in place to version, then aligns its steps with
+// branchIDs, the step order of the current workflow: missing steps are added
+// as pending and steps no longer present are marked skipped unless they
+// already succeeded. State already at version is only aligned.
+func (m *WorkflowMigrator) Migrate(state *WorkflowState, version int, branchIDs []string) error {
+	if state.Version > version {
+		return fmt.Errorf("workflow %s has version %d, newer than %d", state.ID, state.Version, version)
+	}
+
+	m.mu.RLock()
+	for state.Version < version {
+		migration, ok := m.migrations[state.Version]
+		if !ok || migration.To > version {
+			m.mu.RUnlock()
+			return fmt.Errorf("no migration path for workflow %s from version %d to %d", state.ID, state.Version, version)
+		}
+		if err := applyMigration(state, migration); err != nil {
+			m.mu.RUnlock()
+			return fmt.Errorf("failed to migrate workflow %s from version %d: %w", state.ID, migration.From, err)
+		}
+		state.Version = migration.To
+	}
+	m.mu.RUnlock()
+
+	alignSteps(state, branchIDs)
+	state.UpdatedUnix = time.Now().Unix()
+	return nil
+}
+
+// applyMigration renames and removes steps and runs the migration hook
+func applyMigration(state *WorkflowState, migration WorkflowMigration) error {
+	removed := make(map[string]bool, len(migration.Removed))
+	for _, id := range migration.Removed {
+		removed[id] = true
+	}
+	for i := range state.Steps {
+		step := &state.Steps[i]
+		if newID, ok := migration.StepMap[step.BranchID]; ok {
+			step.BranchID = newID
+		} else if removed[step.BranchID] && step.Status != StepStatusSucceeded {
+			step.Status = StepStatusSkipped
+		}
+	}
+	if migration.Migrate != nil {
+		return migration.Migrate(state)
+	}
+	return nil
+}
+
+// alignSteps orders state steps like branchIDs, adding missing ones as
+// pending; steps not in branchIDs move to the end and are skipped unless
+// they already succeeded
+func alignSteps(state *WorkflowState, branchIDs []string) {
+	if branchIDs == nil {
+		return
+	}
+	existing := make(map[string]WorkflowStepState, len(state.Steps))
+	for _, step := range state.Steps {
+		existing[step.BranchID] = step
+	}
+
+	now := time.Now().Unix()
+	aligned := make([]WorkflowStepState, 0, len(state.Steps)+len(branchIDs))
+	wanted := make(map[string]bool, len(branchIDs))
+	for _, id := range branchIDs {
+		wanted[id] = true
+		if step, ok := existing[id]; ok {
+			aligned = append(aligned, step)
+		} else {
+			aligned = append(aligned, WorkflowStepState{BranchID: id, Status: StepStatusPending, UpdatedUnix: now})
+		}
+	}
+	for _, step := range state.Steps {
+		if wanted[step.BranchID] {
+			continue
+		}
+		if step.Status != StepStatusSucceeded {
+			step.Status = StepStatusSkipped
+		}
+		aligned = append(aligned, step)
+	}
+	state.Steps = aligned
+}
+
+// BranchIDs returns the branch IDs of the workflow steps in order
+func (sw *SagaWorkflow) BranchIDs() []string {
+	ids := make([]string, len(sw.Steps))
+	for i, step := range sw.Steps {
+		ids[i] = step.BranchID
+	}
+	return ids
+}
+
+// BranchIDs returns the branch IDs of the workflow steps in order
+func (tw *TCCWorkflow) BranchIDs() []string {
+	ids := make([]string, len(tw.Steps))
+	for i, step := range tw.Steps {
+		ids[i] = step.BranchID
+	}
+	return ids
+}